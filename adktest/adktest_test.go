@@ -0,0 +1,297 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adktest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/adktest"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+func TestModel(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name      string
+		response  adktest.Response
+		stream    bool
+		wantTexts []string
+		wantErr   error
+	}{
+		{
+			name:      "text",
+			response:  adktest.Text("hello"),
+			wantTexts: []string{"hello"},
+		},
+		{
+			name:      "stream",
+			response:  adktest.Stream("hel", "lo"),
+			stream:    true,
+			wantTexts: []string{"hel", "lo", "hello"},
+		},
+		{
+			name:      "stream without streaming mode",
+			response:  adktest.Stream("hel", "lo"),
+			wantTexts: []string{"hello"},
+		},
+		{
+			name:     "error",
+			response: adktest.Error(errFailed),
+			wantErr:  errFailed,
+		},
+		{
+			name: "error after chunks",
+			response: adktest.Response{
+				Responses: adktest.Stream("hel").Responses[:1],
+				Err:       errFailed,
+			},
+			stream:    true,
+			wantTexts: []string{"hel"},
+			wantErr:   errFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := adktest.NewModel(tt.response)
+			req := &model.LLMRequest{Contents: genai.Text("hi")}
+
+			var texts []string
+			var err error
+			for resp, respErr := range llm.GenerateContent(t.Context(), req, tt.stream) {
+				if respErr != nil {
+					err = respErr
+					break
+				}
+				texts = append(texts, resp.Content.Parts[0].Text)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GenerateContent() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantTexts, texts); diff != "" {
+				t.Errorf("GenerateContent() texts mismatch (-want +got):\n%s", diff)
+			}
+			if got := llm.Requests(); len(got) != 1 || got[0] != req {
+				t.Errorf("Requests() = %v, want the request", got)
+			}
+		})
+	}
+}
+
+func TestModel_NoResponse(t *testing.T) {
+	llm := adktest.NewModel(adktest.Text("hello"))
+	for range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+	}
+	if got := llm.Remaining(); got != 0 {
+		t.Errorf("Remaining() = %d, want 0", got)
+	}
+	for _, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if !errors.Is(err, adktest.ErrNoResponse) {
+			t.Errorf("GenerateContent() error = %v, want %v", err, adktest.ErrNoResponse)
+		}
+	}
+	if got := len(llm.Requests()); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestRunner_MultiAgent(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	getWeather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		if err := ctx.State().Set("last_city", args.City); err != nil {
+			return nil, err
+		}
+		return map[string]any{"weather": "sunny"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weatherModel := adktest.NewModel(
+		adktest.FunctionCall("get_weather", map[string]any{"city": "Paris"}),
+		adktest.Text("It is sunny in Paris."),
+	)
+	weather, err := llmagent.New(llmagent.Config{
+		Name:        "weather",
+		Description: "answers the questions about the weather",
+		Model:       weatherModel,
+		Tools:       []tool.Tool{getWeather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootModel := adktest.NewModel(adktest.TransferTo("weather"))
+	root, err := llmagent.New(llmagent.Config{
+		Name:      "root",
+		Model:     rootModel,
+		SubAgents: []agent.Agent{weather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := adktest.NewRunner(t, root)
+	r.CreateSession(t, "session", map[string]any{"user:name": "Ada"})
+	events := r.Run(t, "session", "What is the weather in Paris?")
+
+	adktest.AssertTrajectory(t, events,
+		adktest.ToolCall{Agent: "root", Name: "transfer_to_agent"},
+		adktest.ToolCall{Agent: "weather", Name: "get_weather", Args: map[string]any{"city": "Paris"}},
+	)
+	adktest.AssertStateDelta(t, events, map[string]any{"last_city": "Paris"})
+	if diff := cmp.Diff([]string{"It is sunny in Paris."}, adktest.Texts(events)); diff != "" {
+		t.Errorf("Texts() mismatch (-want +got):\n%s", diff)
+	}
+	if got := weatherModel.Remaining(); got != 0 {
+		t.Errorf("weather model has %d responses left, want 0", got)
+	}
+	// The second request of the weather agent has the function response.
+	requests := weatherModel.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d weather model requests, want 2", len(requests))
+	}
+	last := requests[1].Contents[len(requests[1].Contents)-1]
+	if len(last.Parts) != 1 || last.Parts[0].FunctionResponse == nil || last.Parts[0].FunctionResponse.Name != "get_weather" {
+		t.Errorf("last content of the request = %v, want the get_weather response", last)
+	}
+
+	s := r.Session(t, "session")
+	for key, want := range map[string]any{"last_city": "Paris", "user:name": "Ada"} {
+		if got, err := s.State().Get(key); err != nil || got != want {
+			t.Errorf("state %q = %v, %v, want %v", key, got, err, want)
+		}
+	}
+}
+
+func TestRunner_Error(t *testing.T) {
+	errFailed := errors.New("failed")
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: adktest.NewModel(adktest.Error(errFailed))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := adktest.NewRunner(t, a)
+	_, err = r.RunContent(t, "session", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{})
+	if !errors.Is(err, errFailed) {
+		t.Errorf("RunContent() error = %v, want %v", err, errFailed)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	events := []*session.Event{
+		{
+			Author: "agent",
+			LLMResponse: model.LLMResponse{
+				Content: genai.NewContentFromFunctionCall("search", map[string]any{"query": "adk"}, genai.RoleModel),
+			},
+		},
+		{
+			Author:  "agent",
+			Actions: session.EventActions{StateDelta: map[string]any{"query": "adk", "count": 1}},
+		},
+		{
+			Author:  "agent",
+			Actions: session.EventActions{StateDelta: map[string]any{"count": 2}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		assert     func(t testing.TB)
+		wantFailed bool
+	}{
+		{
+			name: "trajectory",
+			assert: func(t testing.TB) {
+				adktest.AssertTrajectory(t, events, adktest.ToolCall{Name: "search"})
+			},
+		},
+		{
+			name: "trajectory with args",
+			assert: func(t testing.TB) {
+				adktest.AssertTrajectory(t, events, adktest.ToolCall{Agent: "agent", Name: "search", Args: map[string]any{"query": "adk"}})
+			},
+		},
+		{
+			name: "wrong args",
+			assert: func(t testing.TB) {
+				adktest.AssertTrajectory(t, events, adktest.ToolCall{Name: "search", Args: map[string]any{"query": "go"}})
+			},
+			wantFailed: true,
+		},
+		{
+			name: "missing call",
+			assert: func(t testing.TB) {
+				adktest.AssertTrajectory(t, events, adktest.ToolCall{Name: "search"}, adktest.ToolCall{Name: "fetch"})
+			},
+			wantFailed: true,
+		},
+		{
+			name: "state delta",
+			assert: func(t testing.TB) {
+				adktest.AssertStateDelta(t, events, map[string]any{"count": 2})
+			},
+		},
+		{
+			name: "overridden state delta",
+			assert: func(t testing.TB) {
+				adktest.AssertStateDelta(t, events, map[string]any{"count": 1})
+			},
+			wantFailed: true,
+		},
+		{
+			name: "missing state delta",
+			assert: func(t testing.TB) {
+				adktest.AssertStateDelta(t, events, map[string]any{"other": "value"})
+			},
+			wantFailed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{TB: t}
+			tt.assert(rec)
+			if rec.failed != tt.wantFailed {
+				t.Errorf("assertion failed = %v, want %v: %s", rec.failed, tt.wantFailed, rec.msg)
+			}
+		})
+	}
+}
+
+// recorder records the failures of an assertion instead of failing the test.
+type recorder struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+	r.msg = fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adktest
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// ToolCall is a function call made by an agent.
+type ToolCall struct {
+	// Agent is the agent calling the function. It is not compared by
+	// [AssertTrajectory] if empty.
+	Agent string
+	Name  string
+	// Args are the arguments of the call. They are not compared by
+	// [AssertTrajectory] if nil.
+	Args map[string]any
+}
+
+// Trajectory returns the function calls of the events in order, including
+// the transfers to other agents.
+func Trajectory(events []*session.Event) []ToolCall {
+	var calls []ToolCall
+	for _, ev := range events {
+		if ev.Partial || ev.Content == nil {
+			continue
+		}
+		for _, part := range ev.Content.Parts {
+			if fc := part.FunctionCall; fc != nil {
+				calls = append(calls, ToolCall{Agent: ev.Author, Name: fc.Name, Args: fc.Args})
+			}
+		}
+	}
+	return calls
+}
+
+// AssertTrajectory reports an error if the function calls of the events are
+// not the wanted ones, in order.
+func AssertTrajectory(t testing.TB, events []*session.Event, want ...ToolCall) {
+	t.Helper()
+	got := Trajectory(events)
+	for i := range min(len(got), len(want)) {
+		if want[i].Agent == "" {
+			got[i].Agent = ""
+		}
+		if want[i].Args == nil {
+			got[i].Args = nil
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("trajectory mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// StateDelta returns the state changes of the events. The later changes of
+// a key override the earlier ones.
+func StateDelta(events []*session.Event) map[string]any {
+	delta := make(map[string]any)
+	for _, ev := range events {
+		if ev.Partial {
+			continue
+		}
+		maps.Copy(delta, ev.Actions.StateDelta)
+	}
+	return delta
+}
+
+// AssertStateDelta reports an error if the events did not change the state
+// keys to the wanted values. The changes of the other keys are ignored.
+func AssertStateDelta(t testing.TB, events []*session.Event, want map[string]any) {
+	t.Helper()
+	delta := StateDelta(events)
+	got := make(map[string]any)
+	for k := range want {
+		if v, ok := delta[k]; ok {
+			got[k] = v
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("state delta mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// Texts returns the texts of the complete model responses, excluding the
+// thoughts.
+func Texts(events []*session.Event) []string {
+	var texts []string
+	for _, ev := range events {
+		if ev.Partial || ev.Content == nil || ev.Content.Role == genai.RoleUser {
+			continue
+		}
+		for _, part := range ev.Content.Parts {
+			if part.Text != "" && !part.Thought {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+	return texts
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httprr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mismatch describes why the request is not in the replay log, showing the
+// differences with the closest recorded request.
+func (rr *RecordReplay) mismatch(reqWire string) string {
+	requestLine, _, _ := strings.Cut(reqWire, "\r\n")
+	var closest string
+	closestDiff := -1
+	for _, recorded := range slices.Sorted(maps.Keys(rr.replay)) {
+		recordedLine, _, _ := strings.Cut(recorded, "\r\n")
+		if recordedLine != requestLine {
+			continue
+		}
+		if n := diffSize(wireLines(recorded), wireLines(reqWire)); closestDiff < 0 || n < closestDiff {
+			closest, closestDiff = recorded, n
+		}
+	}
+	if closestDiff < 0 {
+		return fmt.Sprintf("no request to %s recorded in %s, got:\n%s", requestLine, rr.file, reqWire)
+	}
+	return fmt.Sprintf("request not recorded in %s, diff with the closest recorded request (-recorded +got):\n%s",
+		rr.file, cmp.Diff(wireLines(closest), wireLines(reqWire)))
+}
+
+// wireLines returns the lines of the wire format of a request, with the JSON
+// body indented so that the differences show field by field.
+func wireLines(wire string) []string {
+	header, body, _ := strings.Cut(wire, "\r\n\r\n")
+	lines := strings.Split(header, "\r\n")
+	if body == "" {
+		return lines
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(body), "", "  "); err == nil {
+		body = buf.String()
+	}
+	return append(append(lines, ""), strings.Split(body, "\n")...)
+}
+
+// diffSize returns the number of lines of a and b not in their longest
+// common subsequence.
+func diffSize(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return len(a) + len(b) - 2*prev[len(b)]
+}
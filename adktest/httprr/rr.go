@@ -8,6 +8,17 @@
 // is controlled by the -httprecord flag, which is defined by this package
 // only in test programs (built by “go test”).
 // See the [Open] documentation for more details.
+//
+// The credentials of the requests are removed from the log by default, see
+// [RedactSecrets]. Non-deterministic parts of the requests are canonicalized
+// with request scrubbers, e.g. [CompactJSON] or [ScrubJSON], and a request
+// missing from the log is reported with its differences from the closest
+// recorded request.
+//
+// The scrubbers needed by the ADK models and tools are installed by
+// [google.golang.org/adk/model/gemini.NewRecordReplayClientConfig],
+// [google.golang.org/adk/model/anthropic.NewRecordReplayConfig] and
+// [google.golang.org/adk/tool/mcptoolset.NewRecordReplayTransport].
 package httprr
 
 import (
@@ -104,15 +115,25 @@ func (rr *RecordReplay) Recording() bool {
 // the file as a new log. In that mode, [RecordReplay.RoundTrip]
 // makes actual HTTP requests using rt but then logs the requests and
 // responses to the file for replaying in a future run.
+//
+// In both modes, [RedactSecrets] is registered as the first request
+// scrubber.
 func Open(file string, rt http.RoundTripper) (*RecordReplay, error) {
 	record, err := Recording(file)
 	if err != nil {
 		return nil, err
 	}
+	var rr *RecordReplay
 	if record {
-		return create(file, rt)
+		rr, err = create(file, rt)
+	} else {
+		rr, err = open(file, rt)
 	}
-	return open(file, rt)
+	if err != nil {
+		return nil, err
+	}
+	rr.ScrubReq(RedactSecrets)
+	return rr, nil
 }
 
 // Recording reports whether the "-httprecord" flag is set
@@ -334,7 +355,7 @@ func (rr *RecordReplay) respWire(resp *http.Response) (string, error) {
 func (rr *RecordReplay) replayRoundTrip(req *http.Request, reqLog string) (*http.Response, error) {
 	respLog, ok := rr.replay[reqLog]
 	if !ok {
-		return nil, fmt.Errorf("cached HTTP response not found for:\n%s", reqLog)
+		return nil, fmt.Errorf("cached HTTP response not found: %s", rr.mismatch(reqLog))
 	}
 	resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(respLog)), req)
 	if err != nil {
@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httprr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// secretHeaders are the request headers holding credentials, removed by
+// [RedactSecrets].
+var secretHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"X-Goog-Api-Key",
+	"X-Api-Key",
+	"Api-Key",
+}
+
+// secretParams are the URL query parameters holding credentials, removed by
+// [RedactSecrets].
+var secretParams = []string{"key", "api_key", "access_token"}
+
+// RedactSecrets is a request scrubber removing the credentials from the
+// request: the authorization, cookie and API key headers, and the API key
+// query parameters. It is registered by [Open] before the other scrubbers,
+// so that the secrets are never written to the traces, and the traces are
+// replayed whatever the credentials of the client.
+func RedactSecrets(req *http.Request) error {
+	for _, name := range secretHeaders {
+		req.Header.Del(name)
+		// Some clients, e.g. genai, don't canonicalize the header names.
+		delete(req.Header, strings.ToLower(name))
+	}
+	if req.URL.RawQuery != "" {
+		query := req.URL.Query()
+		for _, name := range secretParams {
+			query.Del(name)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	return nil
+}
+
+// ScrubHeaders returns a request scrubber removing the headers, e.g. the
+// headers including version numbers or request IDs.
+func ScrubHeaders(names ...string) func(*http.Request) error {
+	return func(req *http.Request) error {
+		for _, name := range names {
+			req.Header.Del(name)
+			delete(req.Header, strings.ToLower(name))
+		}
+		return nil
+	}
+}
+
+// CompactJSON is a request scrubber compacting the JSON request bodies, so
+// that the traces don't depend on the spacing of the JSON encoders.
+func CompactJSON(req *http.Request) error {
+	body, ok := jsonBody(req)
+	if !ok {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body.Data); err == nil {
+		body.Data = buf.Bytes()
+	}
+	return nil
+}
+
+// ScrubJSON returns a request scrubber removing the fields of the JSON
+// request bodies at the paths, so that the requests match whatever the
+// values of these fields, e.g. generated IDs or timestamps. A path is a dot
+// separated list of field names, where "*" matches all the elements of an
+// array or all the fields of an object, e.g. "contents.*.parts.*.functionCall.id".
+// The body is compacted.
+func ScrubJSON(paths ...string) func(*http.Request) error {
+	return func(req *http.Request) error {
+		body, ok := jsonBody(req)
+		if !ok {
+			return nil
+		}
+		var v any
+		if err := json.Unmarshal(body.Data, &v); err != nil {
+			// Not JSON after all, leave it.
+			return nil
+		}
+		for _, path := range paths {
+			removeJSONField(v, strings.Split(path, "."))
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		body.Data = data
+		return nil
+	}
+}
+
+func removeJSONField(v any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			if path[0] == "*" {
+				clear(v)
+			} else {
+				delete(v, path[0])
+			}
+			return
+		}
+		if path[0] == "*" {
+			for _, child := range v {
+				removeJSONField(child, path[1:])
+			}
+			return
+		}
+		removeJSONField(v[path[0]], path[1:])
+	case []any:
+		if path[0] != "*" {
+			return
+		}
+		for _, child := range v {
+			removeJSONField(child, path[1:])
+		}
+	}
+}
+
+// jsonBody returns the body of the request if it is JSON.
+func jsonBody(req *http.Request) (*Body, bool) {
+	ctype := req.Header.Get("Content-Type")
+	if ctype != "application/json" && !strings.HasPrefix(ctype, "application/json;") {
+		return nil, false
+	}
+	body, ok := req.Body.(*Body)
+	return body, ok
+}
@@ -0,0 +1,255 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httprr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rr")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" || r.URL.Query().Get("key") == "" {
+			http.Error(w, "missing credentials", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	do := func(rr *RecordReplay, secret string) {
+		t.Helper()
+		req, err := http.NewRequest("GET", srv.URL+"/models?key="+secret+"&page=2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+secret)
+		req.Header["x-goog-api-key"] = []string{secret}
+		resp, err := rr.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("got status %s, want 200 OK", resp.Status)
+		}
+	}
+
+	*record = "."
+	rr, err := Open(file, http.DefaultTransport)
+	*record = ""
+	if err != nil {
+		t.Fatal(err)
+	}
+	do(rr, "recording-secret")
+	if err := rr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("trace contains the secret:\n%s", data)
+	}
+	if !strings.Contains(string(data), "page=2") {
+		t.Errorf("trace lost the query parameters:\n%s", data)
+	}
+
+	// The trace is replayed whatever the credentials.
+	rr, err = Open(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	do(rr, "replaying-secret")
+}
+
+func TestScrubJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		body  string
+		want  string
+	}{
+		{
+			name:  "field",
+			paths: []string{"id"},
+			body:  `{"id": "123", "text": "hi"}`,
+			want:  `{"text":"hi"}`,
+		},
+		{
+			name:  "nested field",
+			paths: []string{"meta.time"},
+			body:  `{"meta": {"time": 1, "user": "u"}}`,
+			want:  `{"meta":{"user":"u"}}`,
+		},
+		{
+			name:  "array elements",
+			paths: []string{"contents.*.parts.*.functionCall.id"},
+			body:  `{"contents": [{"parts": [{"functionCall": {"id": "a", "name": "f"}}, {"text": "t"}]}, {"parts": [{"functionCall": {"id": "b", "name": "g"}}]}]}`,
+			want:  `{"contents":[{"parts":[{"functionCall":{"name":"f"}},{"text":"t"}]},{"parts":[{"functionCall":{"name":"g"}}]}]}`,
+		},
+		{
+			name:  "object fields",
+			paths: []string{"labels.*"},
+			body:  `{"labels": {"a": 1, "b": 2}}`,
+			want:  `{"labels":{}}`,
+		},
+		{
+			name:  "missing path",
+			paths: []string{"missing.id", "text.id"},
+			body:  `{"text": "hi"}`,
+			want:  `{"text":"hi"}`,
+		},
+		{
+			name:  "not json",
+			paths: []string{"id"},
+			body:  `id=123`,
+			want:  `id=123`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "http://example.com", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			body := &Body{Data: []byte(tt.body)}
+			req.Body = body
+
+			if err := ScrubJSON(tt.paths...)(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(body.Data); got != tt.want {
+				t.Errorf("got body %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rr")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	post := func(rr *RecordReplay, path, body string) error {
+		t.Helper()
+		req, err := http.NewRequest("POST", srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := rr.Client().Do(req)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.Body.Close()
+	}
+
+	rr, err := create(file, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr.ScrubReq(ScrubJSON("requestId"))
+	for _, body := range []string{
+		`{"model": "m", "requestId": "1", "text": "hello"}`,
+		`{"model": "m", "requestId": "2", "text": "goodbye", "temperature": 1}`,
+	} {
+		if err := post(rr, "/generate", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err = open(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr.ScrubReq(ScrubJSON("requestId"))
+
+	// The scrubbed fields don't matter.
+	if err := post(rr, "/generate", `{"model": "m", "requestId": "3", "text": "hello"}`); err != nil {
+		t.Fatalf("replaying request with another requestId: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "closest body",
+			path:     "/generate",
+			body:     `{"model": "m", "text": "goodbye", "temperature": 0}`,
+			want:     []string{`"temperature": 1,`, `"temperature": 0,`},
+			dontWant: []string{`"text": "hello"`},
+		},
+		{
+			name: "unknown request line",
+			path: "/other",
+			body: `{}`,
+			want: []string{"no request to POST " + srv.URL + "/other HTTP/1.1 recorded"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := post(rr, tt.path, tt.body)
+			if err == nil {
+				t.Fatal("replaying unrecorded request succeeded, want error")
+			}
+			msg := err.Error()
+			for _, want := range tt.want {
+				if !strings.Contains(msg, want) {
+					t.Errorf("error does not contain %q:\n%s", want, msg)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(msg, dontWant) {
+					t.Errorf("error contains %q:\n%s", dontWant, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestWireLines(t *testing.T) {
+	wire := "POST http://example.com/ HTTP/1.1\r\nContent-Type: application/json\r\n\r\n{\"a\":[1,2]}"
+	want := []string{
+		"POST http://example.com/ HTTP/1.1",
+		"Content-Type: application/json",
+		"",
+		"{",
+		`  "a": [`,
+		"    1,",
+		"    2",
+		"  ]",
+		"}",
+	}
+	if diff := cmp.Diff(want, wireLines(wire)); diff != "" {
+		t.Errorf("wireLines() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adktest provides utilities for testing agents deterministically:
+// a scripted [model.LLM], a runner with in-memory services, and assertions on
+// the resulting events.
+//
+// A test scripts the replies of the model, runs the agent and checks the
+// tool calls and state changes:
+//
+//	llm := adktest.NewModel(
+//		adktest.FunctionCall("get_weather", map[string]any{"city": "Paris"}),
+//		adktest.Text("It is sunny in Paris."),
+//	)
+//	a, _ := llmagent.New(llmagent.Config{Name: "weather", Model: llm, Tools: tools})
+//	r := adktest.NewRunner(t, a)
+//	events := r.Run(t, "session", "What is the weather in Paris?")
+//	adktest.AssertTrajectory(t, events, adktest.ToolCall{Name: "get_weather"})
+package adktest
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// ErrNoResponse is the error of the calls of a [Model] which has no scripted
+// response left.
+var ErrNoResponse = errors.New("adktest: no scripted response left")
+
+// Response is the scripted reply of a [Model] to one call.
+type Response struct {
+	// Responses are the responses yielded in order. The partial responses
+	// are only yielded in streaming mode.
+	Responses []*model.LLMResponse
+	// Err, if not nil, is yielded after the responses.
+	Err error
+}
+
+// Text returns a response replying with the text.
+func Text(text string) Response {
+	return Reply(&model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), TurnComplete: true})
+}
+
+// FunctionCall returns a response calling the function with the arguments.
+func FunctionCall(name string, args map[string]any) Response {
+	return FunctionCalls(&genai.FunctionCall{Name: name, Args: args})
+}
+
+// FunctionCalls returns a response calling the functions in parallel.
+func FunctionCalls(calls ...*genai.FunctionCall) Response {
+	content := &genai.Content{Role: genai.RoleModel}
+	for _, call := range calls {
+		content.Parts = append(content.Parts, &genai.Part{FunctionCall: call})
+	}
+	return Reply(&model.LLMResponse{Content: content, TurnComplete: true})
+}
+
+// TransferTo returns a response transferring the conversation to the agent.
+func TransferTo(agentName string) Response {
+	return FunctionCall("transfer_to_agent", map[string]any{"agent_name": agentName})
+}
+
+// Stream returns a response streaming the text chunks. In streaming mode, the
+// chunks are yielded as partial responses followed by the whole text. Else
+// only the whole text is yielded.
+func Stream(chunks ...string) Response {
+	var resp Response
+	for _, chunk := range chunks {
+		resp.Responses = append(resp.Responses, &model.LLMResponse{Content: genai.NewContentFromText(chunk, genai.RoleModel), Partial: true})
+	}
+	resp.Responses = append(resp.Responses, Text(strings.Join(chunks, "")).Responses...)
+	return resp
+}
+
+// Error returns a response failing with the error.
+func Error(err error) Response {
+	return Response{Err: err}
+}
+
+// Reply returns a response yielding the responses as they are.
+func Reply(responses ...*model.LLMResponse) Response {
+	return Response{Responses: responses}
+}
+
+// Model is a [model.LLM] replying with scripted responses, one per call, and
+// recording the requests. It is safe for concurrent use.
+type Model struct {
+	name string
+
+	mu        sync.Mutex
+	responses []Response
+	requests  []*model.LLMRequest
+}
+
+// NewModel returns a model replying with the responses in order.
+func NewModel(responses ...Response) *Model {
+	return &Model{name: "adktest-model", responses: responses}
+}
+
+// Name returns the name of the model.
+func (m *Model) Name() string {
+	return m.name
+}
+
+// Add adds responses to the script.
+func (m *Model) Add(responses ...Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses = append(m.responses, responses...)
+}
+
+// Requests returns the requests received by the model.
+func (m *Model) Requests() []*model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*model.LLMRequest(nil), m.requests...)
+}
+
+// Remaining returns the number of scripted responses not used yet.
+func (m *Model) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.responses)
+}
+
+// GenerateContent records the request and yields the next scripted
+// response, or [ErrNoResponse] if there is none.
+func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.mu.Lock()
+		m.requests = append(m.requests, req)
+		if len(m.responses) == 0 {
+			m.mu.Unlock()
+			yield(nil, ErrNoResponse)
+			return
+		}
+		resp := m.responses[0]
+		m.responses = m.responses[1:]
+		m.mu.Unlock()
+
+		for _, r := range resp.Responses {
+			if r.Partial && !stream {
+				continue
+			}
+			if !yield(r, nil) {
+				return
+			}
+		}
+		if resp.Err != nil {
+			yield(nil, resp.Err)
+		}
+	}
+}
+
+var _ model.LLM = (*Model)(nil)
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adktest
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Runner runs an agent with in-memory session, artifact and memory services.
+// The sessions are created on their first run.
+type Runner struct {
+	AppName string
+	UserID  string
+
+	SessionService  session.Service
+	ArtifactService artifact.Service
+	MemoryService   memory.Service
+
+	runner *runner.Runner
+}
+
+// NewRunner returns a runner of the root agent. The test fails if the runner
+// cannot be created, e.g. if the agent tree is invalid.
+func NewRunner(t testing.TB, root agent.Agent) *Runner {
+	t.Helper()
+	r := &Runner{
+		AppName:         "test_app",
+		UserID:          "test_user",
+		SessionService:  session.InMemoryService(),
+		ArtifactService: artifact.InMemoryService(),
+		MemoryService:   memory.InMemoryService(),
+	}
+	var err error
+	r.runner, err = runner.New(runner.Config{
+		AppName:         r.AppName,
+		Agent:           root,
+		SessionService:  r.SessionService,
+		ArtifactService: r.ArtifactService,
+		MemoryService:   r.MemoryService,
+	})
+	if err != nil {
+		t.Fatalf("failed to create runner: %v", err)
+	}
+	return r
+}
+
+// CreateSession creates the session with the initial state.
+func (r *Runner) CreateSession(t testing.TB, sessionID string, state map[string]any) session.Session {
+	t.Helper()
+	resp, err := r.SessionService.Create(t.Context(), &session.CreateRequest{
+		AppName:   r.AppName,
+		UserID:    r.UserID,
+		SessionID: sessionID,
+		State:     state,
+	})
+	if err != nil {
+		t.Fatalf("failed to create session %q: %v", sessionID, err)
+	}
+	return resp.Session
+}
+
+// Session returns the session with its current state and events.
+func (r *Runner) Session(t testing.TB, sessionID string) session.Session {
+	t.Helper()
+	resp, err := r.SessionService.Get(t.Context(), &session.GetRequest{
+		AppName:   r.AppName,
+		UserID:    r.UserID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		t.Fatalf("failed to get session %q: %v", sessionID, err)
+	}
+	return resp.Session
+}
+
+// Run runs the agent with the user message in the session and returns the
+// events. The test fails if the run fails.
+func (r *Runner) Run(t testing.TB, sessionID, message string) []*session.Event {
+	t.Helper()
+	events, err := r.RunContent(t, sessionID, genai.NewContentFromText(message, genai.RoleUser), agent.RunConfig{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	return events
+}
+
+// RunContent runs the agent with the user content in the session and
+// returns the events until the run completes or fails, and the error.
+func (r *Runner) RunContent(t testing.TB, sessionID string, content *genai.Content, cfg agent.RunConfig) ([]*session.Event, error) {
+	t.Helper()
+	_, err := r.SessionService.Get(t.Context(), &session.GetRequest{
+		AppName:   r.AppName,
+		UserID:    r.UserID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		r.CreateSession(t, sessionID, nil)
+	}
+
+	var events []*session.Event
+	for ev, err := range r.runner.Run(t.Context(), r.UserID, sessionID, content, cfg) {
+		if err != nil {
+			return events, err
+		}
+		if ev == nil {
+			return events, errors.New("adktest: nil event")
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
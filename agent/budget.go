@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import "google.golang.org/genai"
+
+// UsageStateKey is the session state key of the [Usage] totals of the
+// session, recorded when the run has a [Budget].
+const UsageStateKey = "adk_usage"
+
+// ErrorCodeBudgetExceeded is the error code of the event ending an
+// invocation which exceeded its [Budget].
+const ErrorCodeBudgetExceeded = "BUDGET_EXCEEDED"
+
+// Budget limits the usage of the LLM calls of the invocations and sessions.
+//
+// The usage is tracked from the usage metadata of the model responses. An
+// LLM call is not started once a limit is reached, and the invocation ends
+// with an event with the [ErrorCodeBudgetExceeded] error code. The call
+// exceeding a token or cost limit is completed, so the usage may end up
+// above the limits.
+//
+// The usage totals are attached to the model response events, in the custom
+// metadata under the "usage" key, and the session totals are kept in the
+// session state under [UsageStateKey]. An empty budget tracks the usage
+// without limits.
+type Budget struct {
+	// MaxInvocation is the maximum usage of each invocation. Zero fields
+	// mean no limit.
+	MaxInvocation Usage
+	// MaxSession is the maximum cumulative usage of the invocations of the
+	// session. Zero fields mean no limit.
+	MaxSession Usage
+	// Cost returns the estimated cost of an LLM call, e.g. in USD, from the
+	// usage metadata of its response. Costs are zero if not set.
+	Cost func(modelName string, usage *genai.GenerateContentResponseUsageMetadata) float64
+}
+
+// Usage is the usage of LLM calls.
+type Usage struct {
+	PromptTokens int64   `json:"prompt_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	LLMCalls     int64   `json:"llm_calls"`
+	Cost         float64 `json:"cost"`
+}
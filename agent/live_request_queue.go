@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// LiveRequest is a request sent to the model during a live, bidirectional
+// streaming run.
+//
+// Exactly one of the fields should be set.
+type LiveRequest struct {
+	// Content is sent to the model as a complete turn of the user.
+	Content *genai.Content
+	// RealtimeInput is streamed to the model in realtime, e.g. audio or
+	// video chunks and activity signals.
+	RealtimeInput *genai.LiveRealtimeInput
+	// Close ends the live run.
+	Close bool
+}
+
+// LiveRequestQueue is the queue of the requests sent by the client to the
+// model during a live run (see Runner.RunLive in the runner package).
+//
+// Sending never blocks. The queue is safe for concurrent use.
+type LiveRequestQueue struct {
+	mu       sync.Mutex
+	requests []*LiveRequest
+	// ready has a value when requests is not empty.
+	ready chan struct{}
+}
+
+// NewLiveRequestQueue returns an empty LiveRequestQueue.
+func NewLiveRequestQueue() *LiveRequestQueue {
+	return &LiveRequestQueue{ready: make(chan struct{}, 1)}
+}
+
+// Send adds the request to the queue.
+func (q *LiveRequestQueue) Send(req *LiveRequest) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.requests = append(q.requests, req)
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// SendContent sends a complete turn of the user.
+func (q *LiveRequestQueue) SendContent(content *genai.Content) {
+	q.Send(&LiveRequest{Content: content})
+}
+
+// SendRealtime streams a media chunk, e.g. audio or video.
+func (q *LiveRequestQueue) SendRealtime(blob *genai.Blob) {
+	q.Send(&LiveRequest{RealtimeInput: &genai.LiveRealtimeInput{Media: blob}})
+}
+
+// SendActivityStart signals that the user started an activity, e.g.
+// speaking. The model is interrupted if it is generating a response.
+func (q *LiveRequestQueue) SendActivityStart() {
+	q.Send(&LiveRequest{RealtimeInput: &genai.LiveRealtimeInput{ActivityStart: &genai.ActivityStart{}}})
+}
+
+// SendActivityEnd signals that the user ended the activity.
+func (q *LiveRequestQueue) SendActivityEnd() {
+	q.Send(&LiveRequest{RealtimeInput: &genai.LiveRealtimeInput{ActivityEnd: &genai.ActivityEnd{}}})
+}
+
+// Close ends the live run, once the requests sent before are processed.
+func (q *LiveRequestQueue) Close() {
+	q.Send(&LiveRequest{Close: true})
+}
+
+// Receive removes and returns the first request of the queue, waiting until
+// there is one or the context is done.
+func (q *LiveRequestQueue) Receive(ctx context.Context) (*LiveRequest, error) {
+	return q.next(ctx, true)
+}
+
+// Peek returns the first request of the queue without removing it, waiting
+// until there is one or the context is done.
+//
+// A consumer that may stop before handling the request, e.g. when the
+// control is transferred to another agent, peeks the request and removes it
+// with Receive once it is handed over, so that the request is left to the
+// next consumer otherwise.
+func (q *LiveRequestQueue) Peek(ctx context.Context) (*LiveRequest, error) {
+	return q.next(ctx, false)
+}
+
+// next returns the first request of the queue, removing it if remove is set.
+func (q *LiveRequestQueue) next(ctx context.Context, remove bool) (*LiveRequest, error) {
+	for {
+		q.mu.Lock()
+		if len(q.requests) > 0 {
+			req := q.requests[0]
+			if remove {
+				q.requests = q.requests[1:]
+			}
+			if len(q.requests) > 0 {
+				select {
+				case q.ready <- struct{}{}:
+				default:
+				}
+			}
+			q.mu.Unlock()
+			return req, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+func TestLiveRequestQueue(t *testing.T) {
+	t.Parallel()
+
+	q := NewLiveRequestQueue()
+	audio := &genai.Blob{MIMEType: "audio/pcm", Data: []byte("data")}
+	q.SendContent(genai.NewContentFromText("hello", genai.RoleUser))
+	q.SendActivityStart()
+	q.SendRealtime(audio)
+	q.SendActivityEnd()
+	q.Close()
+
+	want := []*LiveRequest{
+		{Content: genai.NewContentFromText("hello", genai.RoleUser)},
+		{RealtimeInput: &genai.LiveRealtimeInput{ActivityStart: &genai.ActivityStart{}}},
+		{RealtimeInput: &genai.LiveRealtimeInput{Media: audio}},
+		{RealtimeInput: &genai.LiveRealtimeInput{ActivityEnd: &genai.ActivityEnd{}}},
+		{Close: true},
+	}
+	var got []*LiveRequest
+	for range want {
+		req, err := q.Receive(t.Context())
+		if err != nil {
+			t.Fatalf("Receive failed: %v", err)
+		}
+		got = append(got, req)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+func TestLiveRequestQueue_ReceiveWaits(t *testing.T) {
+	t.Parallel()
+
+	q := NewLiveRequestQueue()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Receive(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Receive on an empty queue returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go q.SendContent(genai.NewContentFromText("hello", genai.RoleUser))
+	req, err := q.Receive(t.Context())
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if req.Content == nil || req.Content.Parts[0].Text != "hello" {
+		t.Errorf("unexpected request %v", req)
+	}
+}
+
+func TestLiveRequestQueue_Peek(t *testing.T) {
+	t.Parallel()
+
+	q := NewLiveRequestQueue()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Peek(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Peek on an empty queue returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go q.SendContent(genai.NewContentFromText("hello", genai.RoleUser))
+	want := &LiveRequest{Content: genai.NewContentFromText("hello", genai.RoleUser)}
+	for range 2 {
+		req, err := q.Peek(t.Context())
+		if err != nil {
+			t.Fatalf("Peek failed: %v", err)
+		}
+		if diff := cmp.Diff(want, req); diff != "" {
+			t.Errorf("unexpected peeked request (-want +got):\n%s", diff)
+		}
+	}
+	q.Close()
+	req, err := q.Receive(t.Context())
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if diff := cmp.Diff(want, req); diff != "" {
+		t.Errorf("unexpected received request (-want +got):\n%s", diff)
+	}
+	if req, err := q.Peek(t.Context()); err != nil || !req.Close {
+		t.Errorf("Peek after Receive = %v, %v, want the close request", req, err)
+	}
+}
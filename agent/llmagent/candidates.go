@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Keys of the custom metadata of the model responses selected among several
+// candidates, see [Config.CandidateSelector].
+const (
+	// SelectedCandidateMetadataKey is the key of the index of the selected
+	// candidate.
+	SelectedCandidateMetadataKey = llminternal.SelectedCandidateMetadataKey
+	// CandidatesMetadataKey is the key of the candidates which were not
+	// selected, for evaluation. Each one is a map with the "index",
+	// "content", "finish_reason" and "avg_logprobs" keys.
+	CandidatesMetadataKey = llminternal.CandidatesMetadataKey
+)
+
+// CandidateSelector picks the winning candidate of a model response with
+// several candidates, see [Config.CandidateSelector]. It returns the index of
+// the winner in candidates.
+type CandidateSelector func(ctx agent.CallbackContext, candidates []*model.LLMResponse) (int, error)
+
+// SelectByScore returns the selector picking the candidate with the highest
+// score. The first one wins the ties.
+func SelectByScore(score func(*model.LLMResponse) float64) CandidateSelector {
+	return func(ctx agent.CallbackContext, candidates []*model.LLMResponse) (int, error) {
+		best, bestScore := 0, 0.0
+		for i, candidate := range candidates {
+			if s := score(candidate); i == 0 || s > bestScore {
+				best, bestScore = i, s
+			}
+		}
+		return best, nil
+	}
+}
+
+// SelectByAvgLogprobs returns the selector picking the candidate with the
+// highest average log probability, i.e. the one the model is the most
+// confident about. The model must return the log probabilities, e.g. with
+// the ResponseLogprobs of the GenerateContentConfig.
+func SelectByAvgLogprobs() CandidateSelector {
+	return SelectByScore(func(candidate *model.LLMResponse) float64 {
+		return candidate.AvgLogprobs
+	})
+}
+
+// SelectByJudge returns the selector asking the judge model to pick the best
+// candidate according to the criteria, e.g. "the most accurate and concise
+// answer to the user question". The usage of the judge is not counted in the
+// budget of the invocation.
+func SelectByJudge(judge model.LLM, criteria string) CandidateSelector {
+	return func(ctx agent.CallbackContext, candidates []*model.LLMResponse) (int, error) {
+		var prompt strings.Builder
+		fmt.Fprintf(&prompt, "Pick the best of the following %d candidate responses according to these criteria: %s\n", len(candidates), criteria)
+		for i, candidate := range candidates {
+			fmt.Fprintf(&prompt, "\n<candidate %d>\n%s\n</candidate %d>\n", i+1, candidateText(candidate), i+1)
+		}
+		prompt.WriteString("\nReply with the number of the best candidate only.")
+
+		req := &model.LLMRequest{
+			Contents: []*genai.Content{genai.NewContentFromText(prompt.String(), genai.RoleUser)},
+			Config:   &genai.GenerateContentConfig{},
+		}
+		var reply string
+		for resp, err := range judge.GenerateContent(ctx, req, false) {
+			if err != nil {
+				return 0, fmt.Errorf("judge %q failed: %w", judge.Name(), err)
+			}
+			if !resp.Partial && resp.Content != nil {
+				reply = candidateText(resp)
+			}
+		}
+		number, err := strconv.Atoi(judgeNumberRegex.FindString(reply))
+		if err != nil || number < 1 || number > len(candidates) {
+			return 0, fmt.Errorf("judge %q replied %q, want a candidate number between 1 and %d", judge.Name(), reply, len(candidates))
+		}
+		return number - 1, nil
+	}
+}
+
+var judgeNumberRegex = regexp.MustCompile(`\d+`)
+
+// candidateText returns the text of the response, with its function calls
+// as JSON.
+func candidateText(resp *model.LLMResponse) string {
+	if resp.Content == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range resp.Content.Parts {
+		switch {
+		case part.Thought:
+		case part.Text != "":
+			text.WriteString(part.Text)
+		case part.FunctionCall != nil:
+			call, err := json.Marshal(map[string]any{"function_call": part.FunctionCall.Name, "args": part.FunctionCall.Args})
+			if err == nil {
+				text.Write(call)
+			}
+		}
+	}
+	return text.String()
+}
@@ -18,12 +18,17 @@ import (
 	"fmt"
 	"iter"
 	"strings"
+	"sync"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/genai"
@@ -31,6 +36,30 @@ import (
 
 // New is a constructor for LLMAgent.
 func New(cfg Config) (agent.Agent, error) {
+	if cfg.InputSchema != nil && cfg.InputJSONSchema != nil {
+		return nil, fmt.Errorf("InputSchema and InputJSONSchema are mutually exclusive")
+	}
+	if cfg.OutputSchema != nil && cfg.OutputJSONSchema != nil {
+		return nil, fmt.Errorf("OutputSchema and OutputJSONSchema are mutually exclusive")
+	}
+	if cfg.OutputRetries < 0 {
+		return nil, fmt.Errorf("OutputRetries must not be negative, got %d", cfg.OutputRetries)
+	}
+	if cfg.MaxContinuations < 0 {
+		return nil, fmt.Errorf("MaxContinuations must not be negative, got %d", cfg.MaxContinuations)
+	}
+	if cfg.Model != nil && cfg.ModelName != "" {
+		return nil, fmt.Errorf("Model and ModelName are mutually exclusive")
+	}
+	inputJSONSchema, err := resolveSchema(cfg.InputJSONSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid InputJSONSchema: %w", err)
+	}
+	outputJSONSchema, err := resolveSchema(cfg.OutputJSONSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OutputJSONSchema: %w", err)
+	}
+
 	beforeModelCallbacks := make([]llminternal.BeforeModelCallback, 0, len(cfg.BeforeModelCallbacks))
 	for _, c := range cfg.BeforeModelCallbacks {
 		beforeModelCallbacks = append(beforeModelCallbacks, llminternal.BeforeModelCallback(c))
@@ -52,24 +81,31 @@ func New(cfg Config) (agent.Agent, error) {
 	}
 
 	a := &llmAgent{
-		beforeModelCallbacks: beforeModelCallbacks,
-		model:                cfg.Model,
-		afterModelCallbacks:  afterModelCallbacks,
-		beforeToolCallbacks:  beforeToolCallbacks,
-		afterToolCallbacks:   afterToolCallbacks,
-		instruction:          cfg.Instruction,
-		inputSchema:          cfg.InputSchema,
-		outputSchema:         cfg.OutputSchema,
+		beforeModelCallbacks:   beforeModelCallbacks,
+		model:                  cfg.Model,
+		modelName:              cfg.ModelName,
+		afterModelCallbacks:    afterModelCallbacks,
+		beforeToolCallbacks:    beforeToolCallbacks,
+		afterToolCallbacks:     afterToolCallbacks,
+		maxConcurrentToolCalls: cfg.MaxConcurrentToolCalls,
+		maxContinuations:       cfg.MaxContinuations,
+		candidateSelector:      llminternal.CandidateSelector(cfg.CandidateSelector),
+		instruction:            cfg.Instruction,
+		inputSchema:            cfg.InputSchema,
+		outputSchema:           cfg.OutputSchema,
 
 		State: llminternal.State{
 			Model:                    cfg.Model,
 			GenerateContentConfig:    cfg.GenerateContentConfig,
+			ContextCache:             cfg.ContextCache,
 			Tools:                    cfg.Tools,
 			Toolsets:                 cfg.Toolsets,
 			DisallowTransferToParent: cfg.DisallowTransferToParent,
 			DisallowTransferToPeers:  cfg.DisallowTransferToPeers,
 			InputSchema:              cfg.InputSchema,
 			OutputSchema:             cfg.OutputSchema,
+			InputJSONSchema:          inputJSONSchema,
+			OutputJSONSchema:         outputJSONSchema,
 			// TODO: internal type for includeContents
 			IncludeContents:           string(cfg.IncludeContents),
 			Instruction:               cfg.Instruction,
@@ -77,6 +113,9 @@ func New(cfg Config) (agent.Agent, error) {
 			GlobalInstruction:         cfg.GlobalInstruction,
 			GlobalInstructionProvider: llminternal.InstructionProvider(cfg.GlobalInstructionProvider),
 			OutputKey:                 cfg.OutputKey,
+			OutputRetries:             cfg.OutputRetries,
+			CodeExecutor:              cfg.CodeExecutor,
+			Planner:                   cfg.Planner,
 		},
 	}
 
@@ -149,6 +188,39 @@ type Config struct {
 	BeforeModelCallbacks []BeforeModelCallback
 	// Model that is used by the agent.
 	Model model.LLM
+	// ModelName is the name of the model used by the agent when Model is not
+	// set, e.g. "gemini-2.5-flash" or "openai/gpt-4o". It is resolved with
+	// [model.Resolve] when the agent first runs, so the provider must be
+	// registered by then, e.g. by importing its package.
+	ModelName string
+	// ContextCache enables the context caching of the model, e.g. the
+	// Gemini cached contents, for the long instructions and tools which are
+	// sent with every request. It overrides the context caching of the
+	// runner.
+	ContextCache *model.ContextCacheConfig
+	// MaxContinuations is the number of continuation requests sent to the
+	// model when its response is truncated, e.g. when it reaches the maximum
+	// number of output tokens or the stream ends early. The responses are
+	// stitched into one final response. Zero means the truncated responses
+	// are not continued.
+	//
+	// A response still truncated is the final event of the agent, with the
+	// [ErrorCodeTruncated] error code and the finish reason of the model.
+	// Its function calls, which may have cut-off arguments, are dropped
+	// rather than run.
+	MaxContinuations int
+	// CandidateSelector picks the winning candidate of the model responses
+	// with several candidates, e.g. when the CandidateCount of the
+	// GenerateContentConfig is more than one. See [SelectByScore],
+	// [SelectByAvgLogprobs] and [SelectByJudge]. The first candidate wins if
+	// nil.
+	//
+	// The winner replaces the model response before the after model
+	// callbacks. The other candidates are kept in the custom metadata of the
+	// response event under [CandidatesMetadataKey]. The candidates are only
+	// selected among the final responses, not the partial ones of the
+	// streaming mode.
+	CandidateSelector CandidateSelector
 	// AfterModelCallbacks will be called in the order they are provided until
 	// there's a callback that returns a non-nil LLMResponse or error. Then
 	// actual LLM response is replaced with the returned response/error.
@@ -213,14 +285,30 @@ type Config struct {
 	// Whether to include contents (conversation history) in the model request.
 	IncludeContents IncludeContents
 
-	// TODO(ngeorgy): consider to switch to jsonschema for input and output schema.
 	// The input schema when agent is used as a tool.
 	InputSchema *genai.Schema
+	// InputJSONSchema is the input schema when agent is used as a tool, as a
+	// JSON schema. It is an alternative to InputSchema.
+	InputJSONSchema *jsonschema.Schema
 	// The output schema when agent replies.
 	//
-	// NOTE: when this is set, agent can only reply and cannot use any tools,
-	// such as function tools, RAGs, agent transfer, etc.
+	// The final reply of the agent is validated against the schema. If
+	// OutputKey is set, the parsed JSON value is saved in the state.
+	//
+	// When the agent has tools, the model is not constrained by the response
+	// schema. It gets a set_model_response tool taking the output schema as
+	// parameters instead, and is instructed to call it with its final reply.
+	// The tool arguments are then the final reply of the agent.
 	OutputSchema *genai.Schema
+	// OutputJSONSchema is the output schema when agent replies, as a JSON
+	// schema. It is an alternative to OutputSchema.
+	OutputJSONSchema *jsonschema.Schema
+	// OutputRetries is the number of times the model is asked again to reply
+	// when its reply does not match the output schema. The rejected reply is
+	// not yielded: it is quoted, with the validation error, by a user message
+	// asking the model to fix it. If the reply still does not match, it is
+	// yielded and the agent run fails.
+	OutputRetries int
 
 	// Callbacks are executed in the order they are provided.
 	// The execution of the callback chain stops at the first callback that returns a non-nil
@@ -234,6 +322,16 @@ type Config struct {
 	BeforeToolCallbacks []BeforeToolCallback
 	// Tools available to the agent.
 	Tools []tool.Tool
+	// MaxConcurrentToolCalls limits the number of function calls running
+	// concurrently when the model requests several function calls in one
+	// response. Zero, the default, runs them sequentially in order. A
+	// negative value means no limit.
+	//
+	// Each function call has its own [tool.Context]. When calls run
+	// concurrently, the tools and the tool callbacks must be safe for
+	// concurrent use, and parallel function calls must not set the same
+	// state key to different values.
+	MaxConcurrentToolCalls int
 	// Callbacks are executed in the order they are provided.
 	// The execution of the callback chain stops at the first callback that returns a non-nil
 	// response.
@@ -250,10 +348,25 @@ type Config struct {
 
 	// OutputKey is an optional parameter to specify the key in session state for the agent output.
 	//
+	// The output is saved as a string, or as the parsed JSON value if the
+	// agent has an output schema. Use [TypedOutput] to read it.
+	//
 	// Typical uses cases are:
 	// - Extracts agent reply for later use, such as in tools, callbacks, etc.
 	// - Connects agents to coordinate with each other.
 	OutputKey string
+
+	// CodeExecutor executes the code blocks in the model responses.
+	//
+	// If set, the first code block of a model response is executed and the
+	// result is sent back to the model, which continues the generation.
+	// See the codeexecutor package for the recognized code block formats.
+	CodeExecutor codeexecutor.CodeExecutor
+
+	// Planner instructs the agent to make a plan and execute it step by step.
+	//
+	// See the planner package for the available planners.
+	Planner planner.Planner
 }
 
 // BeforeModelCallback that is called before sending a request to the model.
@@ -297,6 +410,10 @@ const (
 	IncludeContentsDefault IncludeContents = "default"
 )
 
+// ErrorCodeTruncated is the error code of the final event of a truncated
+// model response, see [Config.MaxContinuations].
+const ErrorCodeTruncated = llminternal.ErrorCodeTruncated
+
 type llmAgent struct {
 	agent.Agent
 	llminternal.State
@@ -305,10 +422,17 @@ type llmAgent struct {
 	beforeModelCallbacks []llminternal.BeforeModelCallback
 	model                model.LLM
 	afterModelCallbacks  []llminternal.AfterModelCallback
-	instruction          string
 
-	beforeToolCallbacks []llminternal.BeforeToolCallback
-	afterToolCallbacks  []llminternal.AfterToolCallback
+	// modelName is resolved to model on the first run, guarded by modelMu.
+	modelName   string
+	modelMu     sync.Mutex
+	instruction string
+
+	beforeToolCallbacks    []llminternal.BeforeToolCallback
+	afterToolCallbacks     []llminternal.AfterToolCallback
+	maxConcurrentToolCalls int
+	maxContinuations       int
+	candidateSelector      llminternal.CandidateSelector
 
 	inputSchema  *genai.Schema
 	outputSchema *genai.Schema
@@ -319,70 +443,164 @@ type agentState = agentinternal.State
 func (a *llmAgent) run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	// TODO: branch context?
 	ctx = icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
-		Artifacts:   ctx.Artifacts(),
-		Memory:      ctx.Memory(),
-		Session:     ctx.Session(),
-		Branch:      ctx.Branch(),
-		Agent:       a,
-		UserContent: ctx.UserContent(),
-		RunConfig:   ctx.RunConfig(),
+		InvocationID: ctx.InvocationID(),
+		Artifacts:    ctx.Artifacts(),
+		Memory:       ctx.Memory(),
+		Session:      ctx.Session(),
+		Branch:       ctx.Branch(),
+		Agent:        a,
+		UserContent:  ctx.UserContent(),
+		RunConfig:    ctx.RunConfig(),
 	})
 
+	llm, err := a.resolveModel(ctx)
+	if err != nil {
+		return func(yield func(*session.Event, error) bool) {
+			yield(nil, err)
+		}
+	}
+
 	f := &llminternal.Flow{
-		Model:                a.model,
+		Model:                llm,
 		RequestProcessors:    llminternal.DefaultRequestProcessors,
 		ResponseProcessors:   llminternal.DefaultResponseProcessors,
 		BeforeModelCallbacks: a.beforeModelCallbacks,
 		AfterModelCallbacks:  a.afterModelCallbacks,
 		BeforeToolCallbacks:  a.beforeToolCallbacks,
 		AfterToolCallbacks:   a.afterToolCallbacks,
+
+		MaxConcurrentToolCalls: a.maxConcurrentToolCalls,
+		MaxContinuations:       a.maxContinuations,
+		SelectCandidate:        a.candidateSelector,
 	}
 
 	return func(yield func(*session.Event, error) bool) {
-		for ev, err := range f.Run(ctx) {
-			a.maybeSaveOutputToState(ev)
-			if !yield(ev, err) {
+		for retries := 0; ; retries++ {
+			var (
+				rejected  *session.Event
+				outputErr error
+			)
+			for ev, err := range f.Run(ctx) {
+				if err == nil {
+					if err := a.maybeSaveOutputToState(ev); err != nil {
+						// The rejected reply is only yielded if it is not
+						// retried.
+						rejected, outputErr = ev, err
+						continue
+					}
+				}
+				if !yield(ev, err) {
+					return
+				}
+			}
+			if outputErr == nil {
 				return
 			}
+			if retries >= a.OutputRetries {
+				if yield(rejected, nil) {
+					yield(nil, fmt.Errorf("agent %q reply does not match the output schema: %w", a.Name(), outputErr))
+				}
+				return
+			}
+			// Ask the model to fix its reply, quoted since it is not in the
+			// session.
+			ev := session.NewEvent(ctx.InvocationID())
+			ev.Author = "user"
+			ev.Branch = ctx.Branch()
+			ev.LLMResponse = model.LLMResponse{
+				Content: genai.NewContentFromText(fmt.Sprintf("Your reply %q does not match the output schema: %v. Reply again with JSON matching the schema.", replyText(rejected.Content), outputErr), genai.RoleUser),
+			}
+			if !yield(ev, nil) {
+				return
+			}
+		}
+	}
+}
+
+// replyText returns the text of the reply, without its thoughts.
+func replyText(content *genai.Content) string {
+	var sb strings.Builder
+	for _, part := range content.Parts {
+		if part.Text != "" && !part.Thought {
+			sb.WriteString(part.Text)
 		}
 	}
+	return sb.String()
 }
 
-// maybeSaveOutputToState saves the model output to state if needed. skip if the event
-// was authored by some other agent (e.g. current agent transferred to another agent)
-func (a *llmAgent) maybeSaveOutputToState(event *session.Event) {
+// resolveModel returns the model of the agent, unless the runner overrides
+// it. The model name is resolved once.
+func (a *llmAgent) resolveModel(ctx agent.InvocationContext) (model.LLM, error) {
+	if cfg := runconfig.FromContext(ctx); cfg != nil && cfg.Model != nil {
+		return cfg.Model, nil
+	}
+	a.modelMu.Lock()
+	defer a.modelMu.Unlock()
+	if a.model == nil && a.modelName != "" {
+		llm, err := model.Resolve(ctx, a.modelName)
+		if err != nil {
+			return nil, fmt.Errorf("agent %q: %w", a.Name(), err)
+		}
+		a.model = llm
+	}
+	return a.model, nil
+}
+
+// maybeSaveOutputToState validates the model output against the output schema
+// and saves it to state if needed. skip if the event was authored by some
+// other agent (e.g. current agent transferred to another agent).
+//
+// It returns an error if the output does not match the output schema.
+func (a *llmAgent) maybeSaveOutputToState(event *session.Event) error {
 	if event == nil {
-		return
+		return nil
 	}
 	if event.Author != a.Name() {
 		// TODO: log "Skipping output save for agent %s: event authored by %s"
-		return
+		return nil
 	}
-	if a.OutputKey != "" && !event.Partial && event.Content != nil && len(event.Content.Parts) > 0 {
-		var sb strings.Builder
-		for _, part := range event.Content.Parts {
-			if part.Text != "" && !part.Thought {
-				sb.WriteString(part.Text)
-			}
+	if event.Partial || event.Content == nil || event.Content.Role == genai.RoleUser || len(event.Content.Parts) == 0 {
+		return nil
+	}
+	if a.OutputKey == "" && !a.HasOutputSchema() {
+		return nil
+	}
+
+	text := replyText(event.Content)
+	var result any = text
+
+	if a.HasOutputSchema() {
+		// If the result from the final chunk is just whitespace or empty,
+		// it means this is an empty final chunk of a stream, or a function
+		// call. Do not attempt to parse it as JSON. The text sent along
+		// with tool calls is not the reply either.
+		if strings.TrimSpace(text) == "" || !event.IsFinalResponse() {
+			return nil
 		}
-		result := sb.String()
-
-		// TODO: add output schema validation and unmarshalling
-		if a.OutputSchema != nil {
-			// If the result from the final chunk is just whitespace or empty,
-			// it means this is an empty final chunk of a stream.
-			// Do not attempt to parse it as JSON.
-			if strings.TrimSpace(result) == "" {
-				return
-			}
+		parsed, err := a.ParseOutput(text)
+		if err != nil {
+			return err
 		}
+		result = parsed
+	}
+	if a.OutputKey == "" {
+		return nil
+	}
 
-		if event.Actions.StateDelta == nil {
-			event.Actions.StateDelta = make(map[string]any)
-		}
+	if event.Actions.StateDelta == nil {
+		event.Actions.StateDelta = make(map[string]any)
+	}
+
+	event.Actions.StateDelta[a.OutputKey] = result
+	return nil
+}
 
-		event.Actions.StateDelta[a.OutputKey] = result
+// resolveSchema resolves the JSON schema, if not nil.
+func resolveSchema(schema *jsonschema.Schema) (*jsonschema.Resolved, error) {
+	if schema == nil {
+		return nil, nil
 	}
+	return schema.Resolve(nil)
 }
 
 // InstructionProvider allows to create instructions dynamically. It is called
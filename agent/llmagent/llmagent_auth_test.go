@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+func TestToolAuthentication(t *testing.T) {
+	oauthServer := testutil.NewFakeOAuthServer(t)
+	authConfig := &auth.Config{
+		RawCredential: &auth.Credential{
+			Type: auth.CredentialTypeOAuth2,
+			OAuth2: &auth.OAuth2{
+				ClientID:         oauthServer.ClientID,
+				ClientSecret:     oauthServer.ClientSecret,
+				AuthorizationURL: oauthServer.AuthorizationURL(),
+				TokenURL:         oauthServer.TokenURL(),
+				RedirectURI:      "http://localhost/callback",
+			},
+		},
+	}
+
+	var toolCalls int
+	type Args struct{}
+	calendar, err := functiontool.New(functiontool.Config{
+		Name:        "list_events",
+		Description: "lists calendar events",
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		toolCalls++
+		cred, err := tool.LoadCredential(ctx, authConfig)
+		if err != nil {
+			return nil, err
+		}
+		if cred == nil {
+			if err := tool.RequestCredential(ctx, authConfig); err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": "pending authorization"}, nil
+		}
+		if !oauthServer.ValidAccessToken(cred.OAuth2.AccessToken) {
+			return map[string]any{"status": "invalid token"}, nil
+		}
+		return map[string]any{"events": "standup"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("list_events", map[string]any{}, genai.RoleModel),
+			genai.NewContentFromText("You have a standup.", genai.RoleModel),
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "assistant",
+		Model: model,
+		Tools: []tool.Tool{calendar},
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	// The first invocation pauses on the credential request.
+	events, err := testutil.CollectEvents(runner.Run(t, "session", "what's on my calendar?"))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %v", len(events), events)
+	}
+	if got := events[1].LLMResponse.Content.Parts[0].FunctionResponse.Response["status"]; got != "pending authorization" {
+		t.Errorf("unexpected first tool response status %v", got)
+	}
+	authEvent := events[2]
+	if !authEvent.IsFinalResponse() {
+		t.Errorf("credential request event is not final: %v", authEvent)
+	}
+	authCall := authEvent.LLMResponse.Content.Parts[0].FunctionCall
+	if authCall == nil || authCall.Name != "adk_request_credential" {
+		t.Fatalf("unexpected credential request event content: %v", authEvent.LLMResponse.Content)
+	}
+	if diff := cmp.Diff([]string{authCall.ID}, authEvent.LongRunningToolIDs); diff != "" {
+		t.Errorf("unexpected long running tool IDs (-want +got):\n%s", diff)
+	}
+	toolCallID := events[0].LLMResponse.Content.Parts[0].FunctionCall.ID
+	if got := authCall.Args["functionCallId"]; got != toolCallID {
+		t.Errorf("credential request functionCallId = %v, want %v", got, toolCallID)
+	}
+
+	// The client only gets the consent page URL, never the client secret.
+	authResponse := authCall.Args["authConfig"].(map[string]any)
+	if raw := authResponse["rawAuthCredential"]; raw != nil {
+		t.Errorf("credential request sent the raw credential to the client: %v", raw)
+	}
+	if got := authEvent.Actions.RequestedAuthConfigs; len(got) > 0 {
+		t.Errorf("credential request event has requested auth configs %v", got)
+	}
+	if got := events[1].Actions.RequestedAuthConfigs[toolCallID]; got == nil || got.RawCredential != nil {
+		t.Errorf("function response event requested auth config = %+v, want one without raw credential", got)
+	}
+
+	// The client completes the authorization and sends back the auth config.
+	oauth2Resp := authResponse["exchangedAuthCredential"].(map[string]any)["oauth2"].(map[string]any)
+	oauth2Resp["authResponseUri"] = oauthServer.Authorize(t, oauth2Resp["authUri"].(string))
+
+	fnResponse := &genai.FunctionResponse{ID: authCall.ID, Name: "adk_request_credential", Response: authResponse}
+	events, err = testutil.CollectEvents(runner.RunContent(t, "session", &genai.Content{
+		Role:  genai.RoleUser,
+		Parts: []*genai.Part{{FunctionResponse: fnResponse}},
+	}))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %v", len(events), events)
+	}
+	wantResponse := &genai.FunctionResponse{ID: toolCallID, Name: "list_events", Response: map[string]any{"events": "standup"}}
+	if diff := cmp.Diff(wantResponse, events[0].LLMResponse.Content.Parts[0].FunctionResponse); diff != "" {
+		t.Errorf("unexpected resumed tool response (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(genai.NewContentFromText("You have a standup.", genai.RoleModel), events[1].LLMResponse.Content); diff != "" {
+		t.Errorf("unexpected final response (-want +got):\n%s", diff)
+	}
+	if toolCalls != 2 {
+		t.Errorf("tool was called %d times, want 2", toolCalls)
+	}
+
+	// The model sees the tool call with the final response only.
+	if len(model.Requests) != 2 {
+		t.Fatalf("got %d model requests, want 2", len(model.Requests))
+	}
+	wantContents := []*genai.Content{
+		genai.NewContentFromText("what's on my calendar?", genai.RoleUser),
+		genai.NewContentFromFunctionCall("list_events", map[string]any{}, genai.RoleModel),
+		genai.NewContentFromFunctionResponse("list_events", map[string]any{"events": "standup"}, genai.RoleUser),
+	}
+	if diff := cmp.Diff(wantContents, model.Requests[1].Contents); diff != "" {
+		t.Errorf("unexpected contents of the second model request (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+func TestBudget(t *testing.T) {
+	costPerCall := func(modelName string, usage *genai.GenerateContentResponseUsageMetadata) float64 {
+		return 0.25
+	}
+
+	tests := []struct {
+		name string
+		// budget is the budget of every run.
+		budget agent.Budget
+		runs   int
+		// wantCalls is the number of model calls of each run.
+		wantCalls []int
+		wantUsage agent.Usage
+	}{
+		{
+			name:      "llm calls",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{LLMCalls: 3}},
+			runs:      1,
+			wantCalls: []int{3},
+			wantUsage: agent.Usage{PromptTokens: 300, OutputTokens: 30, LLMCalls: 3},
+		},
+		{
+			name:      "prompt tokens",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{PromptTokens: 150}},
+			runs:      1,
+			wantCalls: []int{2},
+			wantUsage: agent.Usage{PromptTokens: 200, OutputTokens: 20, LLMCalls: 2},
+		},
+		{
+			name:      "cost",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{Cost: 0.5}, Cost: costPerCall},
+			runs:      1,
+			wantCalls: []int{2},
+			wantUsage: agent.Usage{PromptTokens: 200, OutputTokens: 20, LLMCalls: 2, Cost: 0.5},
+		},
+		{
+			name:      "session",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{LLMCalls: 3}, MaxSession: agent.Usage{LLMCalls: 4}},
+			runs:      3,
+			wantCalls: []int{3, 1, 0},
+			wantUsage: agent.Usage{PromptTokens: 400, OutputTokens: 40, LLMCalls: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type Args struct{}
+			again, err := functiontool.New(functiontool.Config{
+				Name:        "again",
+				Description: "asks to be called again",
+			}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				return map[string]any{"result": "call me again"}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			llm := &loopingModel{}
+			a, err := llmagent.New(llmagent.Config{Name: "looper", Model: llm, Tools: []tool.Tool{again}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+			created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for run := range tt.runs {
+				calls := llm.calls
+				var events []*session.Event
+				for ev, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("loop", genai.RoleUser), agent.RunConfig{Budget: &tt.budget}) {
+					if err != nil {
+						t.Fatal(err)
+					}
+					events = append(events, ev)
+				}
+				if got := llm.calls - calls; got != tt.wantCalls[run] {
+					t.Errorf("run %d: got %d model calls, want %d", run, got, tt.wantCalls[run])
+				}
+				last := events[len(events)-1]
+				if last.ErrorCode != agent.ErrorCodeBudgetExceeded || last.Author != "looper" {
+					t.Errorf("run %d: last event = %+v, want a budget exceeded error from looper", run, last)
+				}
+			}
+
+			resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := resp.Session.State().Get(agent.UsageStateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]any{
+				"prompt_tokens": tt.wantUsage.PromptTokens,
+				"output_tokens": tt.wantUsage.OutputTokens,
+				"llm_calls":     tt.wantUsage.LLMCalls,
+				"cost":          tt.wantUsage.Cost,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("session usage mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBudget_UsageMetadata(t *testing.T) {
+	llm := &loopingModel{}
+	a, err := llmagent.New(llmagent.Config{Name: "looper", Model: llm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []any
+	for range 2 {
+		// The model replies with a function call to an unknown tool, the
+		// run fails after the model response.
+		for ev, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("loop", genai.RoleUser), agent.RunConfig{Budget: &agent.Budget{}}) {
+			if err != nil {
+				break
+			}
+			got = append(got, ev.CustomMetadata["usage"])
+		}
+	}
+
+	usage := func(calls int64) map[string]any {
+		return map[string]any{"prompt_tokens": 100 * calls, "output_tokens": 10 * calls, "llm_calls": calls, "cost": 0.0}
+	}
+	want := []any{
+		map[string]any{"invocation": usage(1), "session": usage(1)},
+		map[string]any{"invocation": usage(1), "session": usage(2)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("usage metadata mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// loopingModel always asks to call the again tool, using 100 prompt tokens
+// and 10 output tokens.
+type loopingModel struct {
+	calls int
+}
+
+func (m *loopingModel) Name() string {
+	return "looping-model"
+}
+
+func (m *loopingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.calls++
+		yield(&model.LLMResponse{
+			Content:       genai.NewContentFromFunctionCall("again", map[string]any{}, genai.RoleModel),
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 100, CandidatesTokenCount: 10},
+		}, nil)
+	}
+}
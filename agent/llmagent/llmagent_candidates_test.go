@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/adktest"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestCandidateSelector(t *testing.T) {
+	candidate := func(text string, avgLogprobs float64) *model.LLMResponse {
+		return &model.LLMResponse{
+			Content:      genai.NewContentFromText(text, genai.RoleModel),
+			FinishReason: genai.FinishReasonStop,
+			AvgLogprobs:  avgLogprobs,
+		}
+	}
+	candidates := []*model.LLMResponse{
+		candidate("short", -0.5),
+		candidate("the longest one", -0.9),
+		candidate("medium one", -0.1),
+	}
+	response := *candidates[0]
+	response.Candidates = candidates
+	response.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: 42}
+
+	tests := []struct {
+		name         string
+		selector     func(judge model.LLM) llmagent.CandidateSelector
+		judgeReply   string
+		want         string
+		wantLosers   []int
+		wantJudgeReq bool
+		wantErr      bool
+	}{
+		{
+			name:       "first by default",
+			want:       "short",
+			wantLosers: []int{1, 2},
+		},
+		{
+			name: "score",
+			selector: func(model.LLM) llmagent.CandidateSelector {
+				return llmagent.SelectByScore(func(resp *model.LLMResponse) float64 {
+					return float64(len(resp.Content.Parts[0].Text))
+				})
+			},
+			want:       "the longest one",
+			wantLosers: []int{0, 2},
+		},
+		{
+			name: "avg logprobs",
+			selector: func(model.LLM) llmagent.CandidateSelector {
+				return llmagent.SelectByAvgLogprobs()
+			},
+			want:       "medium one",
+			wantLosers: []int{0, 1},
+		},
+		{
+			name: "judge",
+			selector: func(judge model.LLM) llmagent.CandidateSelector {
+				return llmagent.SelectByJudge(judge, "the most detailed answer")
+			},
+			judgeReply:   "Candidate 2 is the best.",
+			want:         "the longest one",
+			wantLosers:   []int{0, 2},
+			wantJudgeReq: true,
+		},
+		{
+			name: "judge invalid reply",
+			selector: func(judge model.LLM) llmagent.CandidateSelector {
+				return llmagent.SelectByJudge(judge, "the most detailed answer")
+			},
+			judgeReply:   "none of them",
+			wantJudgeReq: true,
+			wantErr:      true,
+		},
+		{
+			name: "out of range",
+			selector: func(model.LLM) llmagent.CandidateSelector {
+				return func(agent.CallbackContext, []*model.LLMResponse) (int, error) {
+					return 3, nil
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "selector error",
+			selector: func(model.LLM) llmagent.CandidateSelector {
+				return func(agent.CallbackContext, []*model.LLMResponse) (int, error) {
+					return 0, errors.New("no winner")
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			judge := adktest.NewModel(adktest.Text(tt.judgeReply))
+			cfg := llmagent.Config{Name: "assistant", Model: adktest.NewModel(adktest.Reply(&response))}
+			if tt.selector != nil {
+				cfg.CandidateSelector = tt.selector(judge)
+			}
+			a, err := llmagent.New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := adktest.NewRunner(t, a)
+			events, err := r.RunContent(t, "session", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if judgeReqs := judge.Requests(); tt.wantJudgeReq != (len(judgeReqs) == 1) {
+				t.Errorf("got %d judge requests, want judge request %v", len(judgeReqs), tt.wantJudgeReq)
+			} else if tt.wantJudgeReq {
+				prompt := judgeReqs[0].Contents[0].Parts[0].Text
+				for _, c := range candidates {
+					if !strings.Contains(prompt, c.Content.Parts[0].Text) {
+						t.Errorf("judge prompt does not contain candidate %q:\n%s", c.Content.Parts[0].Text, prompt)
+					}
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			ev := events[0]
+			if got := ev.Content.Parts[0].Text; got != tt.want {
+				t.Errorf("got reply %q, want %q", got, tt.want)
+			}
+			if ev.Candidates != nil {
+				t.Errorf("event has %d candidates, want none", len(ev.Candidates))
+			}
+			if ev.UsageMetadata != response.UsageMetadata {
+				t.Errorf("event usage = %v, want the usage of the response", ev.UsageMetadata)
+			}
+			var gotLosers []int
+			losers, _ := ev.CustomMetadata[llmagent.CandidatesMetadataKey].([]any)
+			for _, loser := range losers {
+				loser := loser.(map[string]any)
+				i := loser["index"].(int)
+				gotLosers = append(gotLosers, i)
+				if diff := cmp.Diff(candidates[i].Content, loser["content"]); diff != "" {
+					t.Errorf("losing candidate %d content mismatch (-want +got):\n%s", i, diff)
+				}
+			}
+			if diff := cmp.Diff(tt.wantLosers, gotLosers); diff != "" {
+				t.Errorf("losing candidates mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCandidateSelector_Streaming(t *testing.T) {
+	response := func(partial bool, texts ...string) *model.LLMResponse {
+		resp := &model.LLMResponse{Partial: partial, TurnComplete: !partial}
+		for _, text := range texts {
+			resp.Candidates = append(resp.Candidates, &model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel)})
+		}
+		resp.Content = resp.Candidates[0].Content
+		return resp
+	}
+	selections := 0
+	a, err := llmagent.New(llmagent.Config{
+		Name: "assistant",
+		Model: adktest.NewModel(adktest.Reply(
+			response(true, "Hel", "Bon"),
+			response(true, "lo", "jour"),
+			response(false, "Hello", "Bonjour"),
+		)),
+		CandidateSelector: func(ctx agent.CallbackContext, candidates []*model.LLMResponse) (int, error) {
+			selections++
+			return 1, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := adktest.NewRunner(t, a)
+	events, err := r.RunContent(t, "session", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if selections != 1 {
+		t.Errorf("the selector ran %d times, want once for the final response", selections)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	for _, ev := range events[:2] {
+		if !ev.Partial {
+			t.Errorf("got non partial event %v, want partial", ev)
+		}
+	}
+	final := events[2]
+	if got := final.Content.Parts[0].Text; got != "Bonjour" {
+		t.Errorf("got reply %q, want %q", got, "Bonjour")
+	}
+	if final.Partial || !final.TurnComplete {
+		t.Errorf("final event has Partial %v and TurnComplete %v, want false and true", final.Partial, final.TurnComplete)
+	}
+	if got := final.CustomMetadata[llmagent.SelectedCandidateMetadataKey]; got != 1 {
+		t.Errorf("got selected candidate %v, want 1", got)
+	}
+}
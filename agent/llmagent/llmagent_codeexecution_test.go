@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/codeexecutor"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/genai"
+)
+
+type fakeCodeExecutor struct {
+	codes     []string
+	responses []*codeexecutor.ExecuteResponse
+}
+
+func (e *fakeCodeExecutor) Execute(ctx context.Context, req *codeexecutor.ExecuteRequest) (*codeexecutor.ExecuteResponse, error) {
+	e.codes = append(e.codes, req.Code)
+	resp := e.responses[0]
+	e.responses = e.responses[1:]
+	return resp, nil
+}
+
+func TestCodeExecution(t *testing.T) {
+	executor := &fakeCodeExecutor{
+		responses: []*codeexecutor.ExecuteResponse{{Stdout: "2\n"}},
+	}
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromText("Let me compute.\n```python\nprint(1 + 1)\n```\nDone.", genai.RoleModel),
+			genai.NewContentFromText("The answer is 2.", genai.RoleModel),
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:         "calculator",
+		Model:        model,
+		CodeExecutor: executor,
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, a)
+	parts, err := testutil.CollectParts(runner.Run(t, "session", "what is 1 + 1?"))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+
+	wantParts := []*genai.Part{
+		genai.NewPartFromText("Let me compute.\n"),
+		genai.NewPartFromExecutableCode("print(1 + 1)", genai.LanguagePython),
+		genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "Code execution result:\n2\n"),
+		genai.NewPartFromText("The answer is 2."),
+	}
+	if diff := cmp.Diff(wantParts, parts); diff != "" {
+		t.Errorf("unexpected parts (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"print(1 + 1)"}, executor.codes); diff != "" {
+		t.Errorf("unexpected executed code (-want +got):\n%s", diff)
+	}
+
+	if len(model.Requests) != 2 {
+		t.Fatalf("got %d model requests, want 2", len(model.Requests))
+	}
+	wantContents := []*genai.Content{
+		genai.NewContentFromText("what is 1 + 1?", genai.RoleUser),
+		genai.NewContentFromParts([]*genai.Part{
+			genai.NewPartFromText("Let me compute.\n"),
+			genai.NewPartFromText("```tool_code\nprint(1 + 1)\n```"),
+		}, genai.RoleModel),
+		genai.NewContentFromText("```tool_output\nCode execution result:\n2\n\n```", genai.RoleUser),
+	}
+	if diff := cmp.Diff(wantContents, model.Requests[1].Contents); diff != "" {
+		t.Errorf("unexpected contents of the second model request (-want +got):\n%s", diff)
+	}
+}
+
+func TestCodeExecution_ErrorRetries(t *testing.T) {
+	executor := &fakeCodeExecutor{
+		responses: []*codeexecutor.ExecuteResponse{{Stderr: "error 1"}, {Stderr: "error 2"}},
+	}
+	failingCode := func() *genai.Content {
+		return genai.NewContentFromText("```python\nfail()\n```", genai.RoleModel)
+	}
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{failingCode(), failingCode(), failingCode()},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:         "calculator",
+		Model:        model,
+		CodeExecutor: executor,
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, a)
+	events, err := testutil.CollectEvents(runner.Run(t, "session", "run it"))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+
+	// After two failures in a row, the third code block is returned as is.
+	if got, want := len(executor.codes), 2; got != want {
+		t.Errorf("code was executed %d times, want %d", got, want)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if !events[2].IsFinalResponse() {
+		t.Errorf("last event is not a final response: %v", events[2])
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/compaction"
+	"google.golang.org/genai"
+)
+
+func TestCompaction(t *testing.T) {
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromText("a1", genai.RoleModel),
+			genai.NewContentFromText("a2", genai.RoleModel),
+			genai.NewContentFromText("a3", genai.RoleModel),
+		},
+	}
+	summaryModel := &testutil.MockModel{
+		Responses: []*genai.Content{genai.NewContentFromText("summary", genai.RoleModel)},
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+		Compaction: &compaction.Config{
+			Summarizer: compaction.LLMSummarizer(summaryModel, ""),
+			Interval:   2,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"q1", "q2", "q3"} {
+		if _, err := testutil.CollectEvents(r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{})); err != nil {
+			t.Fatalf("agent run failed: %v", err)
+		}
+		r.Wait()
+	}
+
+	if len(summaryModel.Requests) != 1 {
+		t.Fatalf("got %d summary requests, want 1", len(summaryModel.Requests))
+	}
+	want := []*genai.Content{
+		genai.NewContentFromText("summary", genai.RoleModel),
+		genai.NewContentFromText("q3", genai.RoleUser),
+	}
+	if diff := cmp.Diff(want, model.Requests[2].Contents); diff != "" {
+		t.Errorf("unexpected contents of the request after the compaction (-want +got):\n%s", diff)
+	}
+}
+
+type summarizerFunc func(ctx context.Context, events []*session.Event) (*genai.Content, error)
+
+func (f summarizerFunc) Summarize(ctx context.Context, events []*session.Event) (*genai.Content, error) {
+	return f(ctx, events)
+}
+
+func TestCompaction_SessionsRunConcurrently(t *testing.T) {
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromText("a1", genai.RoleModel),
+			genai.NewContentFromText("a2", genai.RoleModel),
+		},
+	}
+	// The summary of the slow session waits until the other session is
+	// compacted.
+	slowStarted := make(chan struct{})
+	release := make(chan struct{})
+	fastSummarized := make(chan struct{})
+	summarizer := summarizerFunc(func(ctx context.Context, events []*session.Event) (*genai.Content, error) {
+		if events[0].LLMResponse.Content.Parts[0].Text == "slow" {
+			close(slowStarted)
+			<-release
+		} else {
+			close(fastSummarized)
+		}
+		return genai.NewContentFromText("summary", genai.RoleModel), nil
+	})
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+		Compaction:     &compaction.Config{Summarizer: summarizer, Interval: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Wait()
+	defer close(release)
+
+	for _, msg := range []string{"slow", "fast"} {
+		created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := testutil.CollectEvents(r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{})); err != nil {
+			t.Fatalf("agent run failed: %v", err)
+		}
+		if msg == "slow" {
+			<-slowStarted
+		}
+	}
+	select {
+	case <-fastSummarized:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the compaction of a session waits for the compaction of another session")
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// liveEvent is the summary of an event of a live run.
+type liveEvent struct {
+	Author       string
+	Text         string
+	FunctionCall string
+	Response     map[string]any
+	Partial      bool
+	TurnComplete bool
+	Interrupted  bool
+}
+
+func summarizeLiveEvent(ev *session.Event) liveEvent {
+	got := liveEvent{
+		Author:       ev.Author,
+		Partial:      ev.LLMResponse.Partial,
+		TurnComplete: ev.LLMResponse.TurnComplete,
+		Interrupted:  ev.LLMResponse.Interrupted,
+	}
+	if c := ev.LLMResponse.Content; c != nil {
+		for _, p := range c.Parts {
+			got.Text += p.Text
+			if p.FunctionCall != nil {
+				got.FunctionCall = p.FunctionCall.Name
+			}
+			if p.FunctionResponse != nil {
+				got.Response = p.FunctionResponse.Response
+			}
+		}
+	}
+	return got
+}
+
+// runLive runs the agent in bidi streaming mode, calling onEvent for every
+// event, and returns the summaries of the events.
+func runLive(t *testing.T, runner *testutil.TestAgentRunner, queue *agent.LiveRequestQueue, onEvent func(*session.Event)) []liveEvent {
+	t.Helper()
+	var got []liveEvent
+	for ev, err := range runner.RunLive(t, "session", queue, agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("RunLive failed: %v", err)
+		}
+		got = append(got, summarizeLiveEvent(ev))
+		onEvent(ev)
+	}
+	return got
+}
+
+func TestRunLive(t *testing.T) {
+	liveModel := &testutil.MockLiveModel{
+		Turns: [][]*model.LLMResponse{
+			{
+				{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("lo", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("Hello", genai.RoleModel)},
+				{TurnComplete: true},
+			},
+			{
+				{Content: genai.NewContentFromText("Bye", genai.RoleModel)},
+				{TurnComplete: true},
+			},
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "assistant",
+		Model:       liveModel,
+		Instruction: "Be nice.",
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	queue := agent.NewLiveRequestQueue()
+	queue.SendContent(genai.NewContentFromText("Hi", genai.RoleUser))
+	got := runLive(t, runner, queue, func(ev *session.Event) {
+		if ev.LLMResponse.TurnComplete {
+			queue.Close()
+		}
+	})
+	want := []liveEvent{
+		{Author: "user", Text: "Hi"},
+		{Author: "assistant", Text: "Hel", Partial: true},
+		{Author: "assistant", Text: "lo", Partial: true},
+		{Author: "assistant", Text: "Hello"},
+		{Author: "assistant", TurnComplete: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	// The next run continues the conversation saved in the session.
+	queue = agent.NewLiveRequestQueue()
+	queue.SendContent(genai.NewContentFromText("Bye", genai.RoleUser))
+	runLive(t, runner, queue, func(ev *session.Event) {
+		if ev.LLMResponse.TurnComplete {
+			queue.Close()
+		}
+	})
+	if len(liveModel.Requests) != 2 {
+		t.Fatalf("got %d connections, want 2", len(liveModel.Requests))
+	}
+	if got := liveModel.Requests[0].Config.SystemInstruction; got == nil || got.Parts[0].Text != "Be nice." {
+		t.Errorf("unexpected system instruction %v", got)
+	}
+	if liveModel.Requests[0].LiveConnectConfig == nil {
+		t.Errorf("live connect config is not set")
+	}
+	wantHistory := []*genai.Content{
+		genai.NewContentFromText("Hi", genai.RoleUser),
+		genai.NewContentFromText("Hello", genai.RoleModel),
+	}
+	if diff := cmp.Diff(wantHistory, liveModel.History); diff != "" {
+		t.Errorf("unexpected history sent to the model (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunLive_FunctionCall(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return map[string]any{"weather": "sunny in " + args.City}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	liveModel := &testutil.MockLiveModel{
+		Turns: [][]*model.LLMResponse{
+			{
+				{Content: genai.NewContentFromText("Let me check.", genai.RoleModel)},
+				{Content: genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel)},
+			},
+			// Started by the function response.
+			{
+				{Content: genai.NewContentFromText("It is sunny.", genai.RoleModel)},
+				{TurnComplete: true},
+			},
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "assistant",
+		Model: liveModel,
+		Tools: []tool.Tool{weather},
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	queue := agent.NewLiveRequestQueue()
+	queue.SendContent(genai.NewContentFromText("How is the weather in Paris?", genai.RoleUser))
+	got := runLive(t, runner, queue, func(ev *session.Event) {
+		if ev.LLMResponse.TurnComplete {
+			queue.Close()
+		}
+	})
+	want := []liveEvent{
+		{Author: "user", Text: "How is the weather in Paris?"},
+		{Author: "assistant", Text: "Let me check."},
+		{Author: "assistant", FunctionCall: "get_weather"},
+		{Author: "assistant", Response: map[string]any{"weather": "sunny in Paris"}},
+		{Author: "assistant", Text: "It is sunny."},
+		{Author: "assistant", TurnComplete: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	if len(liveModel.Contents) != 2 {
+		t.Fatalf("got %d contents sent to the model, want 2", len(liveModel.Contents))
+	}
+	fnResponse := liveModel.Contents[1].Parts[0].FunctionResponse
+	if fnResponse == nil || fnResponse.Name != "get_weather" {
+		t.Errorf("unexpected content sent to the model after the function call: %v", liveModel.Contents[1])
+	}
+}
+
+func TestRunLive_Interruption(t *testing.T) {
+	liveModel := &testutil.MockLiveModel{
+		Turns: [][]*model.LLMResponse{
+			{
+				{Content: genai.NewContentFromText("Let me think", genai.RoleModel), Partial: true},
+			},
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "assistant",
+		Model: liveModel,
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	queue := agent.NewLiveRequestQueue()
+	audio := &genai.Blob{MIMEType: "audio/pcm", Data: []byte("hello")}
+	queue.SendActivityStart()
+	queue.SendRealtime(audio)
+	queue.SendActivityEnd()
+	got := runLive(t, runner, queue, func(ev *session.Event) {
+		switch {
+		case ev.LLMResponse.Partial:
+			// The user talks again while the model is responding.
+			queue.SendActivityStart()
+		case ev.LLMResponse.Interrupted:
+			queue.Close()
+		}
+	})
+	want := []liveEvent{
+		{Author: "assistant", Text: "Let me think", Partial: true},
+		{Author: "assistant", Interrupted: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	wantInputs := []*genai.LiveRealtimeInput{
+		{ActivityStart: &genai.ActivityStart{}},
+		{Media: audio},
+		{ActivityEnd: &genai.ActivityEnd{}},
+		{ActivityStart: &genai.ActivityStart{}},
+	}
+	if diff := cmp.Diff(wantInputs, liveModel.RealtimeInputs); diff != "" {
+		t.Errorf("unexpected realtime inputs sent to the model (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunLive_Transfer(t *testing.T) {
+	rootModel := &testutil.MockLiveModel{
+		Turns: [][]*model.LLMResponse{
+			{
+				{Content: genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "helper"}, genai.RoleModel)},
+			},
+		},
+	}
+	helperModel := &testutil.MockLiveModel{
+		Turns: [][]*model.LLMResponse{
+			{
+				{Content: genai.NewContentFromText("Helper here.", genai.RoleModel)},
+				{TurnComplete: true},
+			},
+		},
+	}
+	helper, err := llmagent.New(llmagent.Config{
+		Name:        "helper",
+		Description: "helps",
+		Model:       helperModel,
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	root, err := llmagent.New(llmagent.Config{
+		Name:      "root",
+		Model:     rootModel,
+		SubAgents: []agent.Agent{helper},
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	runner := testutil.NewTestAgentRunner(t, root)
+
+	queue := agent.NewLiveRequestQueue()
+	queue.SendContent(genai.NewContentFromText("I need help", genai.RoleUser))
+	got := runLive(t, runner, queue, func(ev *session.Event) {
+		if ev.LLMResponse.TurnComplete {
+			queue.Close()
+		}
+	})
+	want := []liveEvent{
+		{Author: "user", Text: "I need help"},
+		{Author: "root", FunctionCall: "transfer_to_agent"},
+		{Author: "root", Response: map[string]any{}},
+		{Author: "helper", Text: "Helper here."},
+		{Author: "helper", TurnComplete: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+	if len(helperModel.Requests) != 1 {
+		t.Errorf("got %d connections to the helper model, want 1", len(helperModel.Requests))
+	}
+}
+
+func TestRunLive_TransferKeepsQueuedRequests(t *testing.T) {
+	rootModel := &testutil.MockLiveModel{
+		Turns: [][]*model.LLMResponse{
+			{
+				{Content: genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "helper"}, genai.RoleModel)},
+			},
+		},
+	}
+	helperModel := &testutil.MockLiveModel{}
+	helper, err := llmagent.New(llmagent.Config{
+		Name:        "helper",
+		Description: "helps",
+		Model:       helperModel,
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	root, err := llmagent.New(llmagent.Config{
+		Name:      "root",
+		Model:     rootModel,
+		SubAgents: []agent.Agent{helper},
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	runner := testutil.NewTestAgentRunner(t, root)
+
+	// The requests sent while the root agent transfers are received by the
+	// helper.
+	queue := agent.NewLiveRequestQueue()
+	queue.SendContent(genai.NewContentFromText("I need help", genai.RoleUser))
+	got := runLive(t, runner, queue, func(ev *session.Event) {
+		if ev.Author == "root" && ev.LLMResponse.Content != nil && ev.LLMResponse.Content.Parts[0].FunctionCall != nil {
+			queue.SendContent(genai.NewContentFromText("thanks", genai.RoleUser))
+			queue.Close()
+		}
+	})
+	if !slices.ContainsFunc(got, func(ev liveEvent) bool { return cmp.Equal(ev, liveEvent{Author: "user", Text: "thanks"}) }) {
+		t.Errorf("the queued request is missing from the events: %v", got)
+	}
+	if diff := cmp.Diff([]*genai.Content{genai.NewContentFromText("thanks", genai.RoleUser)}, helperModel.Contents); diff != "" {
+		t.Errorf("unexpected contents sent to the helper model (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunLive_ModelWithoutBidiStreaming(t *testing.T) {
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "assistant",
+		Model: &testutil.MockModel{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	queue := agent.NewLiveRequestQueue()
+	queue.Close()
+	_, err = testutil.CollectEvents(runner.RunLive(t, "session", queue, agent.RunConfig{}))
+	if err == nil {
+		t.Fatal("RunLive succeeded, want error")
+	}
+}
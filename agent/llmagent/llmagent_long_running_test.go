@@ -0,0 +1,218 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+func TestLongRunningTool(t *testing.T) {
+	type Args struct {
+		Amount int `json:"amount"`
+	}
+	approval, err := functiontool.New(functiontool.Config{
+		Name:          "ask_for_approval",
+		Description:   "asks the manager to approve the reimbursement",
+		IsLongRunning: true,
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return map[string]any{"status": "pending", "ticket": "t-1"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	approverModel := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("ask_for_approval", map[string]any{"amount": 500}, genai.RoleModel),
+			genai.NewContentFromText("Waiting for the approval of your manager.", genai.RoleModel),
+			genai.NewContentFromText("Your reimbursement is approved.", genai.RoleModel),
+		},
+	}
+	approver, err := llmagent.New(llmagent.Config{
+		Name:                     "approver",
+		Description:              "handles the reimbursements",
+		Model:                    approverModel,
+		Tools:                    []tool.Tool{approval},
+		DisallowTransferToParent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootModel := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "approver"}, genai.RoleModel),
+		},
+	}
+	root, err := llmagent.New(llmagent.Config{
+		Name:      "root",
+		Model:     rootModel,
+		SubAgents: []agent.Agent{approver},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, root)
+
+	// The invocation ends with the pending response of the tool.
+	events, err := testutil.CollectEvents(runner.Run(t, "session", "reimburse $500"))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5: %v", len(events), events)
+	}
+	callEvent := events[2]
+	fnCall := callEvent.LLMResponse.Content.Parts[0].FunctionCall
+	if callEvent.Author != "approver" || fnCall == nil || fnCall.Name != "ask_for_approval" {
+		t.Fatalf("unexpected function call event: %v", callEvent)
+	}
+	if diff := cmp.Diff([]string{fnCall.ID}, callEvent.LongRunningToolIDs); diff != "" {
+		t.Errorf("unexpected long running tool IDs (-want +got):\n%s", diff)
+	}
+	if got := events[3].LLMResponse.Content.Parts[0].FunctionResponse.Response["status"]; got != "pending" {
+		t.Errorf("unexpected tool response status %v", got)
+	}
+	if len(approverModel.Requests) != 2 {
+		t.Fatalf("got %d approver model requests, want 2", len(approverModel.Requests))
+	}
+
+	// Progress updates are saved, without running the agents.
+	events, err = testutil.CollectEvents(runner.RunContent(t, "session", &genai.Content{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+			ID:           fnCall.ID,
+			Name:         fnCall.Name,
+			Response:     map[string]any{"status": "waiting for the manager"},
+			WillContinue: genai.Ptr(true),
+		}}},
+	}))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(events) != 0 || len(approverModel.Requests) != 2 || len(rootModel.Requests) != 1 {
+		t.Errorf("progress update ran the agents: %v", events)
+	}
+
+	// The final response resumes the agent that issued the call.
+	events, err = testutil.CollectEvents(runner.RunContent(t, "session", &genai.Content{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+			ID:       fnCall.ID,
+			Name:     fnCall.Name,
+			Response: map[string]any{"status": "approved"},
+		}}},
+	}))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Author != "approver" {
+		t.Fatalf("unexpected events of the resumed invocation: %v", events)
+	}
+	if diff := cmp.Diff(genai.NewContentFromText("Your reimbursement is approved.", genai.RoleModel), events[0].LLMResponse.Content); diff != "" {
+		t.Errorf("unexpected final response (-want +got):\n%s", diff)
+	}
+	if len(rootModel.Requests) != 1 {
+		t.Errorf("got %d root model requests, want 1", len(rootModel.Requests))
+	}
+
+	// The model sees the function call with the final response only.
+	if len(approverModel.Requests) != 3 {
+		t.Fatalf("got %d approver model requests, want 3", len(approverModel.Requests))
+	}
+	contents := approverModel.Requests[2].Contents
+	wantContents := []*genai.Content{
+		genai.NewContentFromFunctionCall("ask_for_approval", map[string]any{"amount": 500}, genai.RoleModel),
+		genai.NewContentFromFunctionResponse("ask_for_approval", map[string]any{"status": "approved"}, genai.RoleUser),
+	}
+	if len(contents) < 2 {
+		t.Fatalf("got %d contents, want at least 2", len(contents))
+	}
+	if diff := cmp.Diff(wantContents, contents[len(contents)-2:]); diff != "" {
+		t.Errorf("unexpected last contents of the resumed request (-want +got):\n%s", diff)
+	}
+}
+
+func TestLongRunningTool_NoResponse(t *testing.T) {
+	type Args struct{}
+	approval, err := functiontool.New(functiontool.Config{
+		Name:          "ask_for_approval",
+		Description:   "asks the manager to approve the reimbursement",
+		IsLongRunning: true,
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("ask_for_approval", map[string]any{}, genai.RoleModel),
+			genai.NewContentFromText("Your reimbursement is approved.", genai.RoleModel),
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "approver",
+		Model: model,
+		Tools: []tool.Tool{approval},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	// The function call ends the invocation.
+	events, err := testutil.CollectEvents(runner.Run(t, "session", "reimburse $500"))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(events) != 1 || len(model.Requests) != 1 {
+		t.Fatalf("got %d events and %d model requests, want 1 and 1: %v", len(events), len(model.Requests), events)
+	}
+	fnCall := events[0].LLMResponse.Content.Parts[0].FunctionCall
+	if !events[0].IsFinalResponse() {
+		t.Errorf("function call event is not final: %v", events[0])
+	}
+
+	events, err = testutil.CollectEvents(runner.RunContent(t, "session", &genai.Content{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+			ID:       fnCall.ID,
+			Name:     fnCall.Name,
+			Response: map[string]any{"status": "approved"},
+		}}},
+	}))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(events) != 1 || len(model.Requests) != 2 {
+		t.Fatalf("got %d events and %d model requests, want 1 and 2: %v", len(events), len(model.Requests), events)
+	}
+	wantContents := []*genai.Content{
+		genai.NewContentFromText("reimburse $500", genai.RoleUser),
+		genai.NewContentFromFunctionCall("ask_for_approval", map[string]any{}, genai.RoleModel),
+		genai.NewContentFromFunctionResponse("ask_for_approval", map[string]any{"status": "approved"}, genai.RoleUser),
+	}
+	if diff := cmp.Diff(wantContents, model.Requests[1].Contents); diff != "" {
+		t.Errorf("unexpected contents of the resumed request (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestModelName(t *testing.T) {
+	resolved := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromText("from the resolved model", genai.RoleModel),
+	}}
+	if err := model.Register("llmagent-test-*", func(ctx context.Context, name string) (model.LLM, error) {
+		return resolved, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	override := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromText("from the override", genai.RoleModel),
+	}}
+
+	tests := []struct {
+		name      string
+		modelName string
+		override  model.LLM
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "resolved",
+			modelName: "llmagent-test-model",
+			want:      "from the resolved model",
+		},
+		{
+			name:      "overridden",
+			modelName: "llmagent-test-model",
+			override:  override,
+			want:      "from the override",
+		},
+		{
+			name:      "unknown",
+			modelName: "unknown-model",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := llmagent.New(llmagent.Config{Name: "assistant", ModelName: tt.modelName})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService, Model: tt.override})
+			if err != nil {
+				t.Fatal(err)
+			}
+			created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := testutil.CollectTextParts(r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("agent run error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("got replies %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelName_MutuallyExclusive(t *testing.T) {
+	_, err := llmagent.New(llmagent.Config{Name: "assistant", Model: &testutil.MockModel{}, ModelName: "gemini-2.5-flash"})
+	if err == nil {
+		t.Errorf("llmagent.New() with Model and ModelName succeeded, want error")
+	}
+}
+
+func TestContextCache(t *testing.T) {
+	agentCache := &model.ContextCacheConfig{MaxUses: 1}
+	runnerCache := &model.ContextCacheConfig{MaxUses: 2}
+
+	tests := []struct {
+		name        string
+		agentCache  *model.ContextCacheConfig
+		runnerCache *model.ContextCacheConfig
+		want        *model.ContextCacheConfig
+	}{
+		{name: "disabled"},
+		{name: "agent", agentCache: agentCache, want: agentCache},
+		{name: "runner", runnerCache: runnerCache, want: runnerCache},
+		{name: "agent overrides runner", agentCache: agentCache, runnerCache: runnerCache, want: agentCache},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("hello", genai.RoleModel)}}
+			a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, ContextCache: tt.agentCache})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessionService := session.InMemoryService()
+			r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService, ContextCache: tt.runnerCache})
+			if err != nil {
+				t.Fatal(err)
+			}
+			created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := testutil.CollectEvents(r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{})); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(llm.Requests) != 1 {
+				t.Fatalf("got %d model requests, want 1", len(llm.Requests))
+			}
+			if got := llm.Requests[0].CacheConfig; got != tt.want {
+				t.Errorf("request CacheConfig = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
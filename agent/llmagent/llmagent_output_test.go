@@ -0,0 +1,324 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+type capital struct {
+	Country string `json:"country"`
+	City    string `json:"city"`
+}
+
+func TestOutputSchema(t *testing.T) {
+	outputSchema, err := jsonschema.For[capital](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		outputRetries int
+		responses     []*genai.Content
+		want          capital
+		wantErr       bool
+		// wantAuthors are the authors of the events, the rejected replies
+		// are only yielded when they are not retried.
+		wantAuthors []string
+	}{
+		{
+			name: "valid reply",
+			responses: []*genai.Content{
+				genai.NewContentFromText(`{"country": "France", "city": "Paris"}`, genai.RoleModel),
+			},
+			want:        capital{Country: "France", City: "Paris"},
+			wantAuthors: []string{"geographer"},
+		},
+		{
+			name:          "valid reply after retry",
+			outputRetries: 1,
+			responses: []*genai.Content{
+				genai.NewContentFromText("The capital of France is Paris.", genai.RoleModel),
+				genai.NewContentFromText(`{"country": "France", "city": "Paris"}`, genai.RoleModel),
+			},
+			want:        capital{Country: "France", City: "Paris"},
+			wantAuthors: []string{"user", "geographer"},
+		},
+		{
+			name:          "retries exhausted",
+			outputRetries: 1,
+			responses: []*genai.Content{
+				genai.NewContentFromText("The capital of France is Paris.", genai.RoleModel),
+				genai.NewContentFromText(`{"country": "France"}`, genai.RoleModel),
+			},
+			wantErr:     true,
+			wantAuthors: []string{"user", "geographer"},
+		},
+		{
+			name: "invalid reply without retries",
+			responses: []*genai.Content{
+				genai.NewContentFromText("Paris", genai.RoleModel),
+			},
+			wantErr:     true,
+			wantAuthors: []string{"geographer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &testutil.MockModel{Responses: tt.responses}
+			a, err := llmagent.New(llmagent.Config{
+				Name:             "geographer",
+				Model:            model,
+				OutputJSONSchema: outputSchema,
+				OutputKey:        "capital",
+				OutputRetries:    tt.outputRetries,
+			})
+			if err != nil {
+				t.Fatalf("failed to create llm agent: %v", err)
+			}
+			sessionService := session.InMemoryService()
+			r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+			created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events, err := testutil.CollectEvents(r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("Capital of France?", genai.RoleUser), agent.RunConfig{}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("agent run error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var gotAuthors []string
+			for _, ev := range events {
+				gotAuthors = append(gotAuthors, ev.Author)
+			}
+			if diff := cmp.Diff(tt.wantAuthors, gotAuthors); diff != "" {
+				t.Errorf("event authors mismatch (-want +got):\n%s", diff)
+			}
+			if len(model.Requests) != len(tt.responses) {
+				t.Errorf("got %d model requests, want %d", len(model.Requests), len(tt.responses))
+			}
+			if got := model.Requests[0].Config.ResponseJsonSchema; got == nil {
+				t.Errorf("response JSON schema is not set in the request")
+			}
+			if len(model.Requests) > 1 {
+				contents := model.Requests[1].Contents
+				feedback := contents[len(contents)-1]
+				if feedback.Role != genai.RoleUser || !strings.Contains(feedback.Parts[0].Text, "does not match the output schema") || !strings.Contains(feedback.Parts[0].Text, tt.responses[0].Parts[0].Text) {
+					t.Errorf("unexpected last content of the retry request: %v", feedback)
+				}
+				if prev := contents[len(contents)-2]; prev.Role != genai.RoleUser {
+					t.Errorf("the rejected reply is in the retry request: %v", prev)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := llmagent.TypedOutput[capital](resp.Session.State(), "capital")
+			if err != nil {
+				t.Fatalf("TypedOutput failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTypedOutput_String(t *testing.T) {
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{
+		AppName: "app",
+		UserID:  "user",
+		State: map[string]any{
+			"text": "Paris",
+			"json": `{"country": "France", "city": "Paris"}`,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := created.Session.State()
+
+	text, err := llmagent.TypedOutput[string](state, "text")
+	if err != nil || text != "Paris" {
+		t.Errorf("TypedOutput[string]() = %q, %v, want %q", text, err, "Paris")
+	}
+	got, err := llmagent.TypedOutput[capital](state, "json")
+	if err != nil || got != (capital{Country: "France", City: "Paris"}) {
+		t.Errorf("TypedOutput[capital]() = %v, %v", got, err)
+	}
+	if _, err := llmagent.TypedOutput[capital](state, "missing"); err == nil {
+		t.Errorf("TypedOutput() for a missing key succeeded, want error")
+	}
+}
+
+func TestOutputSchema_WithTools(t *testing.T) {
+	outputSchema, err := jsonschema.For[capital](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type Args struct {
+		Country string `json:"country"`
+	}
+	lookup, err := functiontool.New(functiontool.Config{
+		Name:        "lookup_capital",
+		Description: "looks up the capital of a country",
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return map[string]any{"capital": "Paris"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("lookup_capital", map[string]any{"country": "France"}, genai.RoleModel),
+			// The reply does not match the schema, the model is told by the
+			// tool response.
+			genai.NewContentFromFunctionCall("set_model_response", map[string]any{"country": "France", "city": 1}, genai.RoleModel),
+			genai.NewContentFromFunctionCall("set_model_response", map[string]any{"country": "France", "city": "Paris"}, genai.RoleModel),
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:             "geographer",
+		Model:            model,
+		Tools:            []tool.Tool{lookup},
+		OutputJSONSchema: outputSchema,
+		OutputKey:        "capital",
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := testutil.CollectEvents(r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("Capital of France?", genai.RoleUser), agent.RunConfig{}))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(model.Requests) != 3 {
+		t.Fatalf("got %d model requests, want 3", len(model.Requests))
+	}
+	req := model.Requests[0]
+	if req.Config.ResponseJsonSchema != nil || req.Config.ResponseMIMEType != "" {
+		t.Errorf("response schema is set in the request: %v, %q", req.Config.ResponseJsonSchema, req.Config.ResponseMIMEType)
+	}
+	for _, name := range []string{"lookup_capital", "set_model_response"} {
+		if _, ok := req.Tools[name]; !ok {
+			t.Errorf("tool %q is missing in the request", name)
+		}
+	}
+	if !strings.Contains(req.Config.SystemInstruction.Parts[0].Text, "set_model_response") {
+		t.Errorf("system instruction does not mention set_model_response: %v", req.Config.SystemInstruction)
+	}
+	contents := model.Requests[2].Contents
+	if got := contents[len(contents)-1].Parts[0].FunctionResponse.Response; got["error"] == nil {
+		t.Errorf("invalid set_model_response call got response %v, want error", got)
+	}
+
+	last := events[len(events)-1]
+	if !last.IsFinalResponse() {
+		t.Errorf("last event is not final: %v", last)
+	}
+	if got := last.LLMResponse.Content; got.Role != genai.RoleModel || got.Parts[0].Text == "" {
+		t.Errorf("unexpected final reply: %v", got)
+	}
+	resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := llmagent.TypedOutput[capital](resp.Session.State(), "capital")
+	if err != nil {
+		t.Fatalf("TypedOutput failed: %v", err)
+	}
+	if diff := cmp.Diff(capital{Country: "France", City: "Paris"}, got); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestOutputSchema_WithToolsErrorField(t *testing.T) {
+	// The reply of an output schema with an error field is not mistaken for
+	// a failed set_model_response call.
+	type lookupResult struct {
+		Error string `json:"error"`
+	}
+	outputSchema, err := jsonschema.For[lookupResult](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup, err := functiontool.New(functiontool.Config{
+		Name:        "lookup_capital",
+		Description: "looks up the capital of a country",
+	}, func(ctx tool.Context, args struct{}) (map[string]any, error) {
+		return map[string]any{"capital": "unknown"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("set_model_response", map[string]any{"error": "no such country"}, genai.RoleModel),
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:             "geographer",
+		Model:            model,
+		Tools:            []tool.Tool{lookup},
+		OutputJSONSchema: outputSchema,
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "Capital of Atlantis?"))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(model.Requests) != 1 {
+		t.Errorf("got %d model requests, want 1", len(model.Requests))
+	}
+	last := events[len(events)-1]
+	if diff := cmp.Diff(genai.NewContentFromText(`{"error":"no such country"}`, genai.RoleModel), last.LLMResponse.Content); diff != "" {
+		t.Errorf("unexpected final reply (-want +got):\n%s", diff)
+	}
+}
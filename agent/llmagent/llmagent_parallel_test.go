@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+func TestParallelFunctionCalls(t *testing.T) {
+	tests := []struct {
+		name                   string
+		maxConcurrentToolCalls int
+		wantMaxRunning         int
+		wantStartOrder         []string
+	}{
+		{name: "sequential by default", wantMaxRunning: 1, wantStartOrder: []string{"1", "2", "3"}},
+		{name: "limit", maxConcurrentToolCalls: 2, wantMaxRunning: 2},
+		{name: "no limit", maxConcurrentToolCalls: -1, wantMaxRunning: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning atomic.Int32
+			var mu sync.Mutex
+			var callbackCalls []string
+			// Every call reports its start, and waits to be released.
+			started := make(chan string)
+			release := make(chan struct{})
+
+			type Args struct {
+				Value int `json:"value"`
+			}
+			block, err := functiontool.New(functiontool.Config{
+				Name:        "block",
+				Description: "blocks until released",
+			}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				n := running.Add(1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				started <- ctx.FunctionCallID()
+				<-release
+				running.Add(-1)
+				if err := ctx.State().Set("value_"+ctx.FunctionCallID(), args.Value); err != nil {
+					return nil, err
+				}
+				return map[string]any{"value": args.Value}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fnCall := func(id string, value int) *genai.Part {
+				return &genai.Part{FunctionCall: &genai.FunctionCall{ID: id, Name: "block", Args: map[string]any{"value": value}}}
+			}
+			model := &testutil.MockModel{
+				Responses: []*genai.Content{
+					genai.NewContentFromParts([]*genai.Part{fnCall("1", 1), fnCall("2", 2), fnCall("3", 3)}, genai.RoleModel),
+					genai.NewContentFromText("done", genai.RoleModel),
+				},
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name:                   "blocker",
+				Model:                  model,
+				Tools:                  []tool.Tool{block},
+				MaxConcurrentToolCalls: tt.maxConcurrentToolCalls,
+				AfterToolCallbacks: []llmagent.AfterToolCallback{
+					func(ctx tool.Context, tool tool.Tool, args, result map[string]any, err error) (map[string]any, error) {
+						mu.Lock()
+						defer mu.Unlock()
+						callbackCalls = append(callbackCalls, ctx.FunctionCallID())
+						return nil, nil
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to create llm agent: %v", err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+
+			type result struct {
+				events []*session.Event
+				err    error
+			}
+			done := make(chan result)
+			go func() {
+				events, err := testutil.CollectEvents(runner.Run(t, "session", "block"))
+				done <- result{events, err}
+			}()
+
+			// Wait for as many calls as allowed to run at the same time
+			// before releasing one.
+			var startOrder []string
+			for finished, pending := 0, 0; finished < 3; finished++ {
+				for pending < min(tt.wantMaxRunning, 3-finished) {
+					startOrder = append(startOrder, <-started)
+					pending++
+				}
+				release <- struct{}{}
+				pending--
+			}
+			res := <-done
+			if res.err != nil {
+				t.Fatalf("agent run failed: %v", res.err)
+			}
+			events := res.events
+
+			if got := int(maxRunning.Load()); got != tt.wantMaxRunning {
+				t.Errorf("got %d concurrent function calls, want %d", got, tt.wantMaxRunning)
+			}
+			if tt.wantStartOrder != nil {
+				if diff := cmp.Diff(tt.wantStartOrder, startOrder); diff != "" {
+					t.Errorf("unexpected function call order (-want +got):\n%s", diff)
+				}
+			}
+			if len(callbackCalls) != 3 {
+				t.Errorf("after tool callback was called %d times, want 3", len(callbackCalls))
+			}
+
+			// The function responses are in the order of the function calls.
+			if len(events) != 3 {
+				t.Fatalf("got %d events, want 3: %v", len(events), events)
+			}
+			var gotIDs []string
+			for _, fnResponse := range utils.FunctionResponses(events[1].LLMResponse.Content) {
+				gotIDs = append(gotIDs, fnResponse.ID)
+			}
+			if diff := cmp.Diff([]string{"1", "2", "3"}, gotIDs); diff != "" {
+				t.Errorf("unexpected function response order (-want +got):\n%s", diff)
+			}
+			wantDelta := map[string]any{"value_1": 1, "value_2": 2, "value_3": 3}
+			if diff := cmp.Diff(wantDelta, events[1].Actions.StateDelta); diff != "" {
+				t.Errorf("unexpected state delta (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
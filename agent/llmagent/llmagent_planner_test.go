@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/adktest"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/planner"
+	"google.golang.org/genai"
+)
+
+func TestPlanner(t *testing.T) {
+	model := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromText(planner.PlanningTag+"1. greet"+planner.FinalAnswerTag+"Hello!", genai.RoleModel),
+			genai.NewContentFromText("Bye!", genai.RoleModel),
+		},
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:    "greeter",
+		Model:   model,
+		Planner: planner.NewPlanReAct(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create llm agent: %v", err)
+	}
+
+	runner := testutil.NewTestAgentRunner(t, a)
+	parts, err := testutil.CollectParts(runner.Run(t, "session", "hi"))
+	if err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	wantParts := []*genai.Part{
+		{Text: planner.PlanningTag + "1. greet" + planner.FinalAnswerTag, Thought: true},
+		genai.NewPartFromText("Hello!"),
+	}
+	if diff := cmp.Diff(wantParts, parts); diff != "" {
+		t.Errorf("unexpected parts (-want +got):\n%s", diff)
+	}
+
+	if _, err := testutil.CollectParts(runner.Run(t, "session", "bye")); err != nil {
+		t.Fatalf("agent run failed: %v", err)
+	}
+	if len(model.Requests) != 2 {
+		t.Fatalf("got %d model requests, want 2", len(model.Requests))
+	}
+	req := model.Requests[1]
+	if req.Config == nil || req.Config.SystemInstruction == nil || !strings.Contains(req.Config.SystemInstruction.Parts[0].Text, planner.FinalAnswerTag) {
+		t.Errorf("system instruction does not contain the planning instruction: %v", req.Config)
+	}
+	// The thoughts of the previous response are sent back as regular text.
+	wantContents := []*genai.Content{
+		genai.NewContentFromText("hi", genai.RoleUser),
+		genai.NewContentFromParts([]*genai.Part{
+			genai.NewPartFromText(planner.PlanningTag + "1. greet" + planner.FinalAnswerTag),
+			genai.NewPartFromText("Hello!"),
+		}, genai.RoleModel),
+		genai.NewContentFromText("bye", genai.RoleUser),
+	}
+	if diff := cmp.Diff(wantContents, req.Contents); diff != "" {
+		t.Errorf("unexpected contents of the second model request (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlanner_SessionKeepsThoughts(t *testing.T) {
+	llm := adktest.NewModel(
+		adktest.Text(planner.PlanningTag+"1. greet"+planner.FinalAnswerTag+"Hello!"),
+		adktest.Text("Bye!"),
+	)
+	a, err := llmagent.New(llmagent.Config{
+		Name:    "greeter",
+		Model:   llm,
+		Planner: planner.NewPlanReAct(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := adktest.NewRunner(t, a)
+	r.Run(t, "session", "hi")
+	// The second request sends the thoughts back as regular text.
+	r.Run(t, "session", "bye")
+
+	var thoughts []string
+	for ev := range r.Session(t, "session").Events().All() {
+		if ev.Content == nil {
+			continue
+		}
+		for _, part := range ev.Content.Parts {
+			if part.Thought {
+				thoughts = append(thoughts, part.Text)
+			}
+		}
+	}
+	want := []string{planner.PlanningTag + "1. greet" + planner.FinalAnswerTag}
+	if diff := cmp.Diff(want, thoughts); diff != "" {
+		t.Errorf("thoughts of the session events mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/workflowagents/loopagent"
+	"google.golang.org/adk/agent/workflowagents/sequentialagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// resumeTest runs the agent in streaming mode until crash returns true for an
+// event, and then resumes the invocation. It returns the texts of the resumed
+// invocation.
+func resumeTest(t *testing.T, a agent.Agent, crash func(*session.Event) bool) []string {
+	t.Helper()
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService, Resumable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := created.Session.ID()
+
+	cfg := agent.RunConfig{StreamingMode: agent.StreamingModeSSE}
+	var invocationID string
+	for ev, err := range r.Run(t.Context(), "user", sessionID, genai.NewContentFromText("go", genai.RoleUser), cfg) {
+		if err != nil {
+			t.Fatalf("agent run failed: %v", err)
+		}
+		invocationID = ev.InvocationID
+		if crash(ev) {
+			break
+		}
+	}
+
+	var texts []string
+	streamed := false
+	for ev, err := range r.Resume(t.Context(), "user", sessionID, invocationID, cfg) {
+		if err != nil {
+			t.Fatalf("resume failed: %v", err)
+		}
+		if ev.InvocationID != invocationID {
+			t.Errorf("got event of invocation %q, want %q", ev.InvocationID, invocationID)
+		}
+		streamed = streamed || ev.LLMResponse.Partial
+		if c := ev.LLMResponse.Content; c != nil && !ev.LLMResponse.Partial {
+			for _, p := range c.Parts {
+				if p.Text != "" {
+					texts = append(texts, ev.Author+": "+p.Text)
+				}
+			}
+		}
+	}
+	// The resumed invocation keeps the streaming mode.
+	if len(texts) > 0 && !streamed {
+		t.Errorf("resumed invocation did not stream the model responses")
+	}
+	return texts
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name      string
+		crash     func(*session.Event) bool
+		wantCalls int
+		want      []string
+	}{
+		{
+			name: "pending function call",
+			crash: func(ev *session.Event) bool {
+				return len(ev.LLMResponse.Content.Parts) > 0 && ev.LLMResponse.Content.Parts[0].FunctionCall != nil
+			},
+			wantCalls: 1,
+			want:      []string{"writer: draft", "reviewer: approved"},
+		},
+		{
+			name: "completed sub-agent",
+			crash: func(ev *session.Event) bool {
+				return ev.Author == "writer" && ev.IsFinalResponse()
+			},
+			wantCalls: 1,
+			want:      []string{"reviewer: approved"},
+		},
+		{
+			name: "completed invocation",
+			crash: func(ev *session.Event) bool {
+				return false
+			},
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			type Args struct{}
+			research, err := functiontool.New(functiontool.Config{
+				Name:        "research",
+				Description: "researches the topic",
+			}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				calls++
+				return map[string]any{"result": "facts"}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer, err := llmagent.New(llmagent.Config{
+				Name: "writer",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("research", map[string]any{}, genai.RoleModel),
+					genai.NewContentFromText("draft", genai.RoleModel),
+				}},
+				Tools: []tool.Tool{research},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			reviewer, err := llmagent.New(llmagent.Config{
+				Name: "reviewer",
+				Model: &testutil.MockModel{Responses: []*genai.Content{
+					genai.NewContentFromText("approved", genai.RoleModel),
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := sequentialagent.New(sequentialagent.Config{
+				AgentConfig: agent.Config{Name: "root", SubAgents: []agent.Agent{writer, reviewer}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := resumeTest(t, root, tt.crash)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected texts of the resumed invocation (-want +got):\n%s", diff)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls of the tool, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestResume_Loop(t *testing.T) {
+	counter, err := llmagent.New(llmagent.Config{
+		Name: "counter",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			genai.NewContentFromText("1", genai.RoleModel),
+			genai.NewContentFromText("2", genai.RoleModel),
+			genai.NewContentFromText("3", genai.RoleModel),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := loopagent.New(loopagent.Config{
+		AgentConfig:   agent.Config{Name: "root", SubAgents: []agent.Agent{counter}},
+		MaxIterations: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The invocation stops after the checkpoint of the second iteration.
+	iterations := 0
+	got := resumeTest(t, root, func(ev *session.Event) bool {
+		if ev.Author == "root" {
+			iterations++
+		}
+		return iterations == 2
+	})
+	if diff := cmp.Diff([]string{"counter: 3"}, got); diff != "" {
+		t.Errorf("unexpected texts of the resumed invocation (-want +got):\n%s", diff)
+	}
+}
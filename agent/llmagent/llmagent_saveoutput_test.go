@@ -18,6 +18,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
@@ -28,6 +29,25 @@ type MockOutputSchema struct {
 	Confidence float64 `json:"confidence"`
 }
 
+var (
+	mockOutputSchema = &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"message":    {Type: genai.TypeString},
+			"confidence": {Type: genai.TypeNumber},
+		},
+		Required: []string{"message"},
+	}
+	mockOutputJSONSchema = must(jsonschema.For[MockOutputSchema](nil))
+)
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 // createTestEvent is a helper to build events for tests.
 func createTestEvent(author, contentText string, isFinal bool) *session.Event {
 	var parts []*genai.Part
@@ -55,6 +75,7 @@ func TestLlmAgent_MaybeSaveOutputToState(t *testing.T) {
 		agentConfig      Config
 		event            *session.Event
 		wantStateDelta   map[string]any
+		wantErr          bool
 		customEventParts []*genai.Part // For multi-part test
 	}{
 		{
@@ -104,7 +125,45 @@ func TestLlmAgent_MaybeSaveOutputToState(t *testing.T) {
 			event:          createTestEvent("testagent", "Test response", true),
 			wantStateDelta: map[string]any{},
 		},
-		//TODO tests with OutputSchema
+		{
+			name:           "saves parsed output matching the output schema",
+			agentConfig:    Config{Name: "test_agent", OutputKey: "result", OutputSchema: mockOutputSchema},
+			event:          createTestEvent("test_agent", `{"message": "hi", "confidence": 0.5}`, true),
+			wantStateDelta: map[string]any{"result": map[string]any{"message": "hi", "confidence": 0.5}},
+		},
+		{
+			name:           "saves parsed output matching the output JSON schema",
+			agentConfig:    Config{Name: "test_agent", OutputKey: "result", OutputJSONSchema: mockOutputJSONSchema},
+			event:          createTestEvent("test_agent", "```json\n{\"message\": \"hi\", \"confidence\": 0.5}\n```", true),
+			wantStateDelta: map[string]any{"result": map[string]any{"message": "hi", "confidence": 0.5}},
+		},
+		{
+			name:           "fails on invalid JSON with output schema",
+			agentConfig:    Config{Name: "test_agent", OutputKey: "result", OutputSchema: mockOutputSchema},
+			event:          createTestEvent("test_agent", "Hi!", true),
+			wantStateDelta: map[string]any{},
+			wantErr:        true,
+		},
+		{
+			name:           "fails on output not matching the output JSON schema",
+			agentConfig:    Config{Name: "test_agent", OutputKey: "result", OutputJSONSchema: mockOutputJSONSchema},
+			event:          createTestEvent("test_agent", `{"confidence": 0.5}`, true),
+			wantStateDelta: map[string]any{},
+			wantErr:        true,
+		},
+		{
+			name:           "validates output without output_key",
+			agentConfig:    Config{Name: "test_agent", OutputSchema: mockOutputSchema},
+			event:          createTestEvent("test_agent", `{"message": 1}`, true),
+			wantStateDelta: map[string]any{},
+			wantErr:        true,
+		},
+		{
+			name:           "skips empty final chunk with output schema",
+			agentConfig:    Config{Name: "test_agent", OutputKey: "result", OutputSchema: mockOutputSchema},
+			event:          createTestEvent("test_agent", "  ", true),
+			wantStateDelta: map[string]any{},
+		},
 	}
 
 	// Iterate over the test cases
@@ -125,7 +184,10 @@ func TestLlmAgent_MaybeSaveOutputToState(t *testing.T) {
 			if !ok {
 				t.Fatal("failed to create agent: %w", err)
 			}
-			createdLlmAgent.maybeSaveOutputToState(tc.event)
+			err = createdLlmAgent.maybeSaveOutputToState(tc.event)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("maybeSaveOutputToState() error = %v, wantErr %v", err, tc.wantErr)
+			}
 
 			// --- Assertion ---
 			gotStateDelta := tc.event.Actions.StateDelta
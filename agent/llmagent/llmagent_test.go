@@ -24,9 +24,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/adktest/httprr"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
-	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/tool/functiontool"
 
@@ -933,35 +933,27 @@ func TestAgentTransfer(t *testing.T) {
 }
 
 func newGeminiModel(t *testing.T, modelName string, transport http.RoundTripper) model.LLM {
-	apiKey := "fakeKey"
+	cfg := &genai.ClientConfig{
+		HTTPClient: &http.Client{Transport: transport},
+		APIKey:     "fakeKey",
+	}
 	if transport == nil { // use httprr
 		trace := filepath.Join("testdata", strings.ReplaceAll(t.Name()+".httprr", "/", "_"))
-		recording := false
-		transport, recording = newGeminiTestClientConfig(t, trace)
-		if recording { // if we are recording httprr trace, don't use the fakeKey.
-			apiKey = ""
+		var rr *httprr.RecordReplay
+		var err error
+		cfg, rr, err = gemini.NewRecordReplayClientConfig(trace, nil)
+		if err != nil {
+			t.Fatal(err)
 		}
+		t.Cleanup(func() { rr.Close() })
 	}
-	model, err := gemini.NewModel(t.Context(), modelName, &genai.ClientConfig{
-		HTTPClient: &http.Client{Transport: transport},
-		APIKey:     apiKey,
-	})
+	model, err := gemini.NewModel(t.Context(), modelName, cfg)
 	if err != nil {
 		t.Fatalf("failed to create model: %v", err)
 	}
 	return model
 }
 
-func newGeminiTestClientConfig(t *testing.T, rrfile string) (http.RoundTripper, bool) {
-	t.Helper()
-	rr, err := testutil.NewGeminiTransport(rrfile)
-	if err != nil {
-		t.Fatal(err)
-	}
-	recording, _ := httprr.Recording(rrfile)
-	return rr, recording
-}
-
 type roundTripperFunc func(*http.Request) (*http.Response, error)
 
 // RoundTrip implements http.RoundTripper.
@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/adktest"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+func TestTruncation(t *testing.T) {
+	chunk := func(text string) *model.LLMResponse {
+		return &model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), Partial: true}
+	}
+	final := func(text string, reason genai.FinishReason, promptTokens, outputTokens int32) *model.LLMResponse {
+		return &model.LLMResponse{
+			Content:      genai.NewContentFromText(text, genai.RoleModel),
+			FinishReason: reason,
+			TurnComplete: true,
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+				PromptTokenCount:     promptTokens,
+				CandidatesTokenCount: outputTokens,
+				TotalTokenCount:      promptTokens + outputTokens,
+			},
+		}
+	}
+
+	type finalEvent struct {
+		Text         string
+		ErrorCode    string
+		FinishReason genai.FinishReason
+		OutputTokens int32
+	}
+	tests := []struct {
+		name             string
+		maxContinuations int
+		responses        []adktest.Response
+		wantFinal        finalEvent
+		wantPartials     int
+		wantRequests     int
+	}{
+		{
+			name: "complete",
+			responses: []adktest.Response{
+				adktest.Reply(chunk("Hel"), chunk("lo"), final("Hello", genai.FinishReasonStop, 10, 2)),
+			},
+			wantFinal:    finalEvent{Text: "Hello", FinishReason: genai.FinishReasonStop, OutputTokens: 2},
+			wantPartials: 2,
+			wantRequests: 1,
+		},
+		{
+			name: "max tokens not continued",
+			responses: []adktest.Response{
+				adktest.Reply(chunk("Hel"), chunk("lo"), final("Hello", genai.FinishReasonMaxTokens, 10, 2)),
+			},
+			wantFinal:    finalEvent{Text: "Hello", ErrorCode: llmagent.ErrorCodeTruncated, FinishReason: genai.FinishReasonMaxTokens, OutputTokens: 2},
+			wantPartials: 2,
+			wantRequests: 1,
+		},
+		{
+			name:             "max tokens continued",
+			maxContinuations: 2,
+			responses: []adktest.Response{
+				adktest.Reply(chunk("Hel"), chunk("lo"), final("Hello", genai.FinishReasonMaxTokens, 10, 2)),
+				adktest.Reply(chunk(" wor"), chunk("ld"), final(" world", genai.FinishReasonStop, 20, 3)),
+			},
+			wantFinal:    finalEvent{Text: "Hello world", FinishReason: genai.FinishReasonStop, OutputTokens: 5},
+			wantPartials: 4,
+			wantRequests: 2,
+		},
+		{
+			name:             "still truncated",
+			maxContinuations: 1,
+			responses: []adktest.Response{
+				adktest.Reply(chunk("Hello"), final("Hello", genai.FinishReasonMaxTokens, 10, 2)),
+				adktest.Reply(chunk(" world"), final(" world", genai.FinishReasonMaxTokens, 20, 3)),
+			},
+			wantFinal:    finalEvent{Text: "Hello world", ErrorCode: llmagent.ErrorCodeTruncated, FinishReason: genai.FinishReasonMaxTokens, OutputTokens: 5},
+			wantPartials: 2,
+			wantRequests: 2,
+		},
+		{
+			name: "stream ended early",
+			responses: []adktest.Response{
+				adktest.Reply(chunk("Hel"), chunk("lo")),
+			},
+			wantFinal:    finalEvent{Text: "Hello", ErrorCode: llmagent.ErrorCodeTruncated},
+			wantPartials: 2,
+			wantRequests: 1,
+		},
+		{
+			name:             "stream ended early continued",
+			maxContinuations: 1,
+			responses: []adktest.Response{
+				adktest.Reply(chunk("Hel"), chunk("lo")),
+				adktest.Reply(chunk("!"), final("!", genai.FinishReasonStop, 20, 1)),
+			},
+			wantFinal:    finalEvent{Text: "Hello!", FinishReason: genai.FinishReasonStop, OutputTokens: 1},
+			wantPartials: 3,
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := adktest.NewModel(tt.responses...)
+			a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, MaxContinuations: tt.maxContinuations})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := adktest.NewRunner(t, a)
+			events, err := r.RunContent(t, "session", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{StreamingMode: agent.StreamingModeSSE})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var finals []*session.Event
+			partials := 0
+			for _, ev := range events {
+				if ev.Partial {
+					partials++
+				} else if ev.Author == "assistant" {
+					finals = append(finals, ev)
+				}
+			}
+			if len(finals) != 1 {
+				t.Fatalf("got %d final events, want 1", len(finals))
+			}
+			ev := finals[0]
+			got := finalEvent{Text: eventText(ev), ErrorCode: ev.ErrorCode, FinishReason: ev.FinishReason}
+			if ev.UsageMetadata != nil {
+				got.OutputTokens = ev.UsageMetadata.CandidatesTokenCount
+			}
+			if diff := cmp.Diff(tt.wantFinal, got); diff != "" {
+				t.Errorf("final event mismatch (-want +got):\n%s", diff)
+			}
+			if partials != tt.wantPartials {
+				t.Errorf("got %d partial events, want %d", partials, tt.wantPartials)
+			}
+
+			requests := llm.Requests()
+			if len(requests) != tt.wantRequests {
+				t.Fatalf("got %d model requests, want %d", len(requests), tt.wantRequests)
+			}
+			// The continuation requests include the truncated response.
+			if len(requests) > 1 {
+				contents := requests[1].Contents
+				if len(contents) < 2 {
+					t.Fatalf("got %d contents in the continuation request, want at least 2", len(contents))
+				}
+				truncated := contents[len(contents)-2]
+				if truncated.Role != genai.RoleModel || !strings.HasPrefix(tt.wantFinal.Text, truncated.Parts[0].Text) {
+					t.Errorf("continuation request has truncated content %+v, want the model response so far", truncated)
+				}
+				if role := contents[len(contents)-1].Role; role != genai.RoleUser {
+					t.Errorf("continuation request ends with a %q content, want user", role)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncation_Persisted(t *testing.T) {
+	llm := adktest.NewModel(
+		adktest.Reply(&model.LLMResponse{Content: genai.NewContentFromText("Hel", genai.RoleModel), FinishReason: genai.FinishReasonMaxTokens}),
+		adktest.Reply(&model.LLMResponse{Content: genai.NewContentFromText("lo", genai.RoleModel), FinishReason: genai.FinishReasonStop}),
+	)
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, MaxContinuations: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := adktest.NewRunner(t, a)
+	r.Run(t, "session", "hi")
+
+	// Only the stitched response is saved in the session.
+	var texts []string
+	for ev := range r.Session(t, "session").Events().All() {
+		if ev.Author == "assistant" {
+			texts = append(texts, eventText(ev))
+		}
+	}
+	if diff := cmp.Diff([]string{"Hello"}, texts); diff != "" {
+		t.Errorf("session events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTruncation_FunctionCallsNotRun(t *testing.T) {
+	runs := 0
+	lookup, err := functiontool.New(functiontool.Config{
+		Name:        "lookup",
+		Description: "looks up a query",
+	}, func(ctx tool.Context, args map[string]any) (map[string]any, error) {
+		runs++
+		return map[string]any{"result": "found"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := adktest.NewModel(adktest.Reply(&model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			genai.NewPartFromText("Let me look it up."),
+			genai.NewPartFromFunctionCall("lookup", map[string]any{"query": "wea"}),
+		}},
+		FinishReason: genai.FinishReasonMaxTokens,
+	}))
+	// The continuations are not requested for responses with function calls.
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, MaxContinuations: 1, Tools: []tool.Tool{lookup}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := adktest.NewRunner(t, a)
+	events := r.Run(t, "session", "hi")
+
+	if runs != 0 {
+		t.Errorf("the tool ran %d times, want 0", runs)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1: %v", len(events), events)
+	}
+	ev := events[0]
+	if ev.ErrorCode != llmagent.ErrorCodeTruncated {
+		t.Errorf("got error code %q, want %q", ev.ErrorCode, llmagent.ErrorCodeTruncated)
+	}
+	if diff := cmp.Diff("Let me look it up.", eventText(ev)); diff != "" {
+		t.Errorf("event text mismatch (-want +got):\n%s", diff)
+	}
+	if calls := ev.Content.Parts; len(calls) != 1 {
+		t.Errorf("got %d parts, want only the text part", len(calls))
+	}
+	if got := len(llm.Requests()); got != 1 {
+		t.Errorf("got %d model requests, want 1", got)
+	}
+}
+
+func TestTruncation_InvalidConfig(t *testing.T) {
+	if _, err := llmagent.New(llmagent.Config{Name: "assistant", MaxContinuations: -1}); err == nil {
+		t.Errorf("llmagent.New() with negative MaxContinuations succeeded, want error")
+	}
+}
+
+func eventText(ev *session.Event) string {
+	if ev.Content == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range ev.Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/adk/internal/typeutil"
+	"google.golang.org/adk/session"
+)
+
+// TypedOutput returns the output of an agent saved in the state under the
+// output key (see Config.OutputKey), converted to T.
+//
+// The output of an agent with an output schema is converted from its parsed
+// JSON value, e.g. to a struct with the matching JSON fields. Outputs saved
+// as strings are parsed as JSON, unless T is string.
+//
+// It returns an error wrapping session.ErrStateKeyNotExist if there is no
+// output in the state.
+func TypedOutput[T any](state session.ReadonlyState, outputKey string) (T, error) {
+	var zero T
+	v, err := state.Get(outputKey)
+	if err != nil {
+		return zero, err
+	}
+	if s, ok := v.(string); ok {
+		if typed, ok := any(s).(T); ok {
+			return typed, nil
+		}
+		var typed T
+		if err := json.Unmarshal([]byte(s), &typed); err != nil {
+			return zero, fmt.Errorf("failed to parse output %q: %w", outputKey, err)
+		}
+		return typed, nil
+	}
+	typed, err := typeutil.ConvertToWithJSONSchema[any, T](v, nil)
+	if err != nil {
+		return zero, fmt.Errorf("failed to convert output %q to %T: %w", outputKey, zero, err)
+	}
+	return typed, nil
+}
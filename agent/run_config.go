@@ -14,6 +14,8 @@
 
 package agent
 
+import "google.golang.org/genai"
+
 // StreamingMode defines the streaming mode for agent execution.
 type StreamingMode string
 
@@ -23,6 +25,10 @@ const (
 	// StreamingModeSSE enables server-sent events streaming, one-way, where
 	// LLM response parts are streamed immediately as they are generated.
 	StreamingModeSSE StreamingMode = "sse"
+	// StreamingModeBidi enables bidirectional streaming, where the client
+	// and the model talk over a persistent connection. It is used by
+	// Runner.RunLive in the runner package.
+	StreamingModeBidi StreamingMode = "bidi"
 )
 
 // RunConfig controls runtime behavior of an agent.
@@ -32,4 +38,18 @@ type RunConfig struct {
 	// If true, ADK runner will save each part of the user input that is a blob
 	// (e.g., images, files) as an artifact.
 	SaveInputBlobsAsArtifacts bool
+	// Budget limits the usage of the LLM calls of the invocation and the
+	// session. No limit applies if nil.
+	Budget *Budget
+
+	// The following fields are only used in bidirectional streaming mode.
+
+	// ResponseModalities are the output modalities of the model, e.g.
+	// audio or text.
+	ResponseModalities []genai.Modality
+	// SpeechConfig configures the speech generation of the model.
+	SpeechConfig *genai.SpeechConfig
+	// RealtimeInputConfig configures the handling of the realtime input,
+	// e.g. the voice activity detection.
+	RealtimeInputConfig *genai.RealtimeInputConfig
 }
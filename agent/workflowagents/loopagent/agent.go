@@ -19,9 +19,11 @@ package loopagent
 import (
 	"fmt"
 	"iter"
+	"slices"
 
 	"google.golang.org/adk/agent"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/agent/checkpoint"
 	"google.golang.org/adk/session"
 )
 
@@ -72,34 +74,77 @@ type loopAgent struct {
 	maxIterations uint
 }
 
-func (a *loopAgent) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
-	count := a.maxIterations
+// loopState is the checkpoint of the loop agent.
+type loopState struct {
+	// CurrentSubAgent is the name of the next sub-agent to run.
+	CurrentSubAgent string `json:"current_sub_agent,omitempty"`
+	// TimesLooped is the number of completed iterations.
+	TimesLooped uint `json:"times_looped"`
+	// Done is set when the loop completed, or a sub-agent escalated.
+	Done bool `json:"done,omitempty"`
+}
 
+func (a *loopAgent) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
-		for {
-			shouldExit := false
-			for _, subAgent := range ctx.Agent().SubAgents() {
-				for event, err := range subAgent.Run(ctx) {
+		subAgents := ctx.Agent().SubAgents()
+
+		// A resumed invocation continues from the checkpoint. Only the
+		// current sub-agent resumes; the next ones start afresh.
+		var state loopState
+		runCtx := ctx
+		if checkpoint.Resuming(ctx) {
+			if _, err := checkpoint.Load(ctx, &state); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+		if state.Done {
+			return
+		}
+		start := slices.IndexFunc(subAgents, func(a agent.Agent) bool { return a.Name() == state.CurrentSubAgent })
+		start = max(start, 0)
+
+		for iterations := state.TimesLooped; a.maxIterations == 0 || iterations < a.maxIterations; iterations++ {
+			for i := start; i < len(subAgents); i++ {
+				shouldExit := false
+				for event, err := range subAgents[i].Run(runCtx) {
 					// TODO: ensure consistency -- if there's an error, return and close iterator, verify everywhere in ADK.
 					if !yield(event, err) {
 						return
 					}
 
-					if event.Actions.Escalate {
+					if event != nil && event.Actions.Escalate {
 						shouldExit = true
 					}
 				}
-				if shouldExit {
+
+				if checkpoint.Resuming(runCtx) {
+					runCtx = checkpoint.WithResuming(ctx, false)
+				}
+				if !checkpoint.Enabled(ctx) {
+					if shouldExit {
+						return
+					}
+					continue
+				}
+				// Record that the sub-agent completed.
+				next := loopState{TimesLooped: iterations, Done: shouldExit}
+				if i+1 < len(subAgents) {
+					next.CurrentSubAgent = subAgents[i+1].Name()
+				} else {
+					next.TimesLooped++
+					next.Done = next.Done || next.TimesLooped == a.maxIterations
+				}
+				ev, err := checkpoint.NewEvent(ctx, next)
+				if err != nil {
+					yield(nil, err)
 					return
 				}
-			}
-
-			if count > 0 {
-				count--
-				if count == 0 {
+				if !yield(ev, nil) || next.Done {
 					return
 				}
 			}
+			start = 0
 		}
 	}
 }
@@ -18,10 +18,13 @@ package parallelagent
 import (
 	"fmt"
 	"iter"
+	"slices"
+	"sync"
 
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/adk/agent"
 	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/agent/checkpoint"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/session"
 )
@@ -63,6 +66,12 @@ func New(cfg Config) (agent.Agent, error) {
 	return parallelAgent, nil
 }
 
+// parallelState is the checkpoint of the parallel agent.
+type parallelState struct {
+	// FinishedSubAgents are the names of the sub-agents that completed.
+	FinishedSubAgents []string `json:"finished_sub_agents"`
+}
+
 func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	curAgent := ctx.Agent()
 
@@ -72,7 +81,21 @@ func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 		resultsChan           = make(chan result)
 	)
 
+	// A resumed invocation skips the sub-agents that completed.
+	var state parallelState
+	if checkpoint.Resuming(ctx) {
+		if _, err := checkpoint.Load(ctx, &state); err != nil {
+			return func(yield func(*session.Event, error) bool) {
+				yield(nil, err)
+			}
+		}
+	}
+	var mu sync.Mutex // guards state
+
 	for _, sa := range ctx.Agent().SubAgents() {
+		if slices.Contains(state.FinishedSubAgents, sa.Name()) {
+			continue
+		}
 		branch := fmt.Sprintf("%s.%s", curAgent.Name(), sa.Name())
 		if ctx.Branch() != "" {
 			branch = fmt.Sprintf("%s.%s", ctx.Branch(), branch)
@@ -80,19 +103,38 @@ func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 		subAgent := sa
 		errGroup.Go(func() error {
 			subCtx := icontext.NewInvocationContext(errGroupCtx, icontext.InvocationContextParams{
-				Artifacts:   ctx.Artifacts(),
-				Memory:      ctx.Memory(),
-				Session:     ctx.Session(),
-				Branch:      branch,
-				Agent:       subAgent,
-				UserContent: ctx.UserContent(),
-				RunConfig:   ctx.RunConfig(),
+				InvocationID: ctx.InvocationID(),
+				Artifacts:    ctx.Artifacts(),
+				Memory:       ctx.Memory(),
+				Session:      ctx.Session(),
+				Branch:       branch,
+				Agent:        subAgent,
+				UserContent:  ctx.UserContent(),
+				RunConfig:    ctx.RunConfig(),
 			})
 
 			if err := runSubAgent(subCtx, subAgent, resultsChan, doneChan); err != nil {
 				return fmt.Errorf("failed to run sub-agent %q: %w", subAgent.Name(), err)
 			}
+			if !checkpoint.Enabled(ctx) {
+				return nil
+			}
 
+			// Record that the sub-agent completed. The checkpoints are sent
+			// in order, so that the last one has all the sub-agents.
+			mu.Lock()
+			defer mu.Unlock()
+			state.FinishedSubAgents = append(state.FinishedSubAgents, subAgent.Name())
+			ev, err := checkpoint.NewEvent(ctx, state)
+			if err != nil {
+				return err
+			}
+			select {
+			case <-doneChan:
+			case <-errGroupCtx.Done():
+				return errGroupCtx.Err()
+			case resultsChan <- result{event: ev}:
+			}
 			return nil
 		})
 	}
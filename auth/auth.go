@@ -0,0 +1,239 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth provides the credentials used by tools to authenticate to
+// external services.
+//
+// A tool describes the credential it needs with a [Config] and obtains it with
+// tool.LoadCredential. Credentials that don't need user interaction,
+// such as API keys, OAuth2 client credentials and service accounts, are
+// exchanged for access tokens directly. For OAuth2 authorization code
+// credentials the tool calls tool.RequestCredential: the agent then
+// emits an "adk_request_credential" function call event with the consent page
+// URL, which pauses the invocation until the client sends back the
+// authorization response as the function response. The raw credential never
+// leaves the server: the pending request is kept in the [CredentialService]
+// and the response is matched to it by credential key and state. The original
+// tool call is then resumed with the exchanged credential.
+//
+// Exchanged credentials are kept in a [CredentialService], configured in
+// runner.Config.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// CredentialType is the type of a [Credential].
+type CredentialType string
+
+const (
+	// CredentialTypeAPIKey is an API key sent with every request.
+	CredentialTypeAPIKey CredentialType = "apiKey"
+	// CredentialTypeOAuth2 is an OAuth2 credential obtained with the
+	// authorization code flow. It requires the user to grant the access.
+	CredentialTypeOAuth2 CredentialType = "oauth2"
+	// CredentialTypeClientCredentials is an OAuth2 credential obtained with
+	// the client credentials flow.
+	CredentialTypeClientCredentials CredentialType = "clientCredentials"
+	// CredentialTypeServiceAccount is a service account that obtains access
+	// tokens with the OAuth2 JWT bearer flow.
+	CredentialTypeServiceAccount CredentialType = "serviceAccount"
+)
+
+// Credential holds the information needed to authenticate to a service.
+type Credential struct {
+	Type CredentialType `json:"authType"`
+
+	// APIKey is set for CredentialTypeAPIKey.
+	APIKey *APIKey `json:"apiKey,omitempty"`
+	// OAuth2 holds the client configuration and the tokens of the OAuth2
+	// credentials. For service accounts it holds the exchanged access token.
+	OAuth2 *OAuth2 `json:"oauth2,omitempty"`
+	// ServiceAccount is set for CredentialTypeServiceAccount.
+	ServiceAccount *ServiceAccount `json:"serviceAccount,omitempty"`
+}
+
+// APIKey is an API key and the location where it is sent.
+type APIKey struct {
+	Key string `json:"key"`
+	// Name of the header or query parameter. Defaults to "X-API-Key".
+	Name string `json:"name,omitempty"`
+	// In is either "header" (the default) or "query".
+	In string `json:"in,omitempty"`
+}
+
+// OAuth2 is the OAuth2 client configuration and the state of the
+// authorization.
+type OAuth2 struct {
+	ClientID         string   `json:"clientId,omitempty"`
+	ClientSecret     string   `json:"clientSecret,omitempty"`
+	AuthorizationURL string   `json:"authorizationUrl,omitempty"`
+	TokenURL         string   `json:"tokenUrl,omitempty"`
+	Scopes           []string `json:"scopes,omitempty"`
+	RedirectURI      string   `json:"redirectUri,omitempty"`
+
+	// AuthURI is the URL of the consent page the user visits to grant the
+	// access, generated together with State when the credential is
+	// requested.
+	AuthURI string `json:"authUri,omitempty"`
+	State   string `json:"state,omitempty"`
+
+	// AuthResponseURI is the redirect URI, including the authorization code
+	// and the state, the user was sent to after granting the access. It is
+	// set by the client. Alternatively, the client sets AuthCode.
+	AuthResponseURI string `json:"authResponseUri,omitempty"`
+	AuthCode        string `json:"authCode,omitempty"`
+
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// ExpiresAt is the expiration time of the access token in seconds since
+	// the Unix epoch. Zero means the token does not expire.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
+
+// ServiceAccount is a service account key.
+type ServiceAccount struct {
+	ClientEmail string `json:"clientEmail"`
+	// PrivateKey is the PEM encoded RSA private key.
+	PrivateKey   string   `json:"privateKey"`
+	PrivateKeyID string   `json:"privateKeyId,omitempty"`
+	TokenURL     string   `json:"tokenUrl"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// Token returns the OAuth2 access token of the credential, or nil if the
+// credential has no access token.
+func (c *Credential) Token() *oauth2.Token {
+	if c == nil || c.OAuth2 == nil || c.OAuth2.AccessToken == "" {
+		return nil
+	}
+	tok := &oauth2.Token{
+		AccessToken:  c.OAuth2.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: c.OAuth2.RefreshToken,
+	}
+	if c.OAuth2.ExpiresAt > 0 {
+		tok.Expiry = time.Unix(c.OAuth2.ExpiresAt, 0)
+	}
+	return tok
+}
+
+// Expired reports whether the access token of the credential has expired.
+// Credentials without an expiring access token never expire.
+func (c *Credential) Expired() bool {
+	if c == nil || c.OAuth2 == nil || c.OAuth2.ExpiresAt == 0 {
+		return false
+	}
+	return !time.Now().Add(expiryDelta).Before(time.Unix(c.OAuth2.ExpiresAt, 0))
+}
+
+// expiryDelta is how long before the expiration an access token is
+// considered expired, to avoid using it while it expires.
+const expiryDelta = 10 * time.Second
+
+// Config describes the credential required by a tool.
+type Config struct {
+	// RawCredential is the credential provided by the tool, e.g. the OAuth2
+	// client configuration.
+	RawCredential *Credential `json:"rawAuthCredential"`
+	// ExchangedCredential is the credential obtained from the raw credential,
+	// e.g. with the OAuth2 access token. When the credential is requested
+	// from the user, it holds the authorization request and response.
+	ExchangedCredential *Credential `json:"exchangedAuthCredential,omitempty"`
+	// CredentialKey identifies the credential in the [CredentialService].
+	// If empty, a key derived from the raw credential is used.
+	CredentialKey string `json:"credentialKey,omitempty"`
+}
+
+// Key returns the key of the credential in the [CredentialService].
+func (c *Config) Key() string {
+	if c.CredentialKey != "" {
+		return c.CredentialKey
+	}
+	if c.RawCredential == nil {
+		return ""
+	}
+	data, err := json.Marshal(c.RawCredential)
+	if err != nil {
+		// Credentials consist of plain values and always marshal.
+		panic(fmt.Sprintf("failed to marshal credential: %v", err))
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("adk_%s_%s", c.RawCredential.Type, hex.EncodeToString(sum[:8]))
+}
+
+// Validate checks that the config describes a supported credential.
+func (c *Config) Validate() error {
+	if c == nil || c.RawCredential == nil {
+		return fmt.Errorf("invalid auth config: raw credential is required")
+	}
+	cred := c.RawCredential
+	switch cred.Type {
+	case CredentialTypeAPIKey:
+		if cred.APIKey == nil || cred.APIKey.Key == "" {
+			return fmt.Errorf("invalid auth config: API key is required")
+		}
+	case CredentialTypeOAuth2:
+		if cred.OAuth2 == nil || cred.OAuth2.ClientID == "" || cred.OAuth2.AuthorizationURL == "" || cred.OAuth2.TokenURL == "" {
+			return fmt.Errorf("invalid auth config: OAuth2 client ID, authorization URL and token URL are required")
+		}
+	case CredentialTypeClientCredentials:
+		if cred.OAuth2 == nil || cred.OAuth2.ClientID == "" || cred.OAuth2.TokenURL == "" {
+			return fmt.Errorf("invalid auth config: OAuth2 client ID and token URL are required")
+		}
+	case CredentialTypeServiceAccount:
+		if cred.ServiceAccount == nil || cred.ServiceAccount.ClientEmail == "" || cred.ServiceAccount.PrivateKey == "" || cred.ServiceAccount.TokenURL == "" {
+			return fmt.Errorf("invalid auth config: service account client email, private key and token URL are required")
+		}
+	default:
+		return fmt.Errorf("invalid auth config: unsupported credential type %q", cred.Type)
+	}
+	return nil
+}
+
+// RequiresUserAuth reports whether the credential needs the user to grant the
+// access before it can be used.
+func (c *Credential) RequiresUserAuth() bool {
+	return c != nil && c.Type == CredentialTypeOAuth2
+}
+
+// clone returns a deep copy of the credential.
+func (c *Credential) clone() *Credential {
+	if c == nil {
+		return nil
+	}
+	cred := *c
+	if c.APIKey != nil {
+		apiKey := *c.APIKey
+		cred.APIKey = &apiKey
+	}
+	if c.OAuth2 != nil {
+		o := *c.OAuth2
+		o.Scopes = append([]string(nil), c.OAuth2.Scopes...)
+		cred.OAuth2 = &o
+	}
+	if c.ServiceAccount != nil {
+		sa := *c.ServiceAccount
+		sa.Scopes = append([]string(nil), c.ServiceAccount.Scopes...)
+		cred.ServiceAccount = &sa
+	}
+	return &cred
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/jwt"
+)
+
+// GenerateAuthRequest returns a copy of the config with the authorization
+// request the user has to complete in the exchanged credential.
+//
+// For OAuth2 credentials it generates the consent page URL and the state,
+// unless the exchanged credential already has them: the state is then
+// required, the authorization responses without state are rejected.
+func GenerateAuthRequest(cfg *Config) (*Config, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	req := *cfg
+	req.RawCredential = cfg.RawCredential.clone()
+	req.ExchangedCredential = cfg.ExchangedCredential.clone()
+	if req.CredentialKey == "" {
+		req.CredentialKey = cfg.Key()
+	}
+	if !req.RawCredential.RequiresUserAuth() {
+		return &req, nil
+	}
+	if req.ExchangedCredential != nil && req.ExchangedCredential.OAuth2 != nil && req.ExchangedCredential.OAuth2.AuthURI != "" {
+		return &req, nil
+	}
+
+	cred := req.RawCredential.clone()
+	cred.OAuth2.State = oauth2.GenerateVerifier()
+	cred.OAuth2.AuthURI = oauth2Config(cred.OAuth2).AuthCodeURL(cred.OAuth2.State, oauth2.AccessTypeOffline)
+	req.ExchangedCredential = cred
+	return &req, nil
+}
+
+// Exchange exchanges the credential for an access token and returns the
+// credential with the token.
+//
+// OAuth2 authorization code credentials must have the authorization response
+// set by the client. API keys are returned as is.
+func Exchange(ctx context.Context, cred *Credential) (*Credential, error) {
+	if cred == nil {
+		return nil, fmt.Errorf("credential is nil")
+	}
+	switch cred.Type {
+	case CredentialTypeAPIKey:
+		return cred.clone(), nil
+	case CredentialTypeOAuth2:
+		if cred.OAuth2 == nil {
+			return nil, fmt.Errorf("OAuth2 configuration is missing")
+		}
+		code, err := authCode(cred.OAuth2)
+		if err != nil {
+			return nil, err
+		}
+		tok, err := oauth2Config(cred.OAuth2).Exchange(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+		}
+		return withToken(cred, tok), nil
+	case CredentialTypeClientCredentials:
+		if cred.OAuth2 == nil {
+			return nil, fmt.Errorf("OAuth2 configuration is missing")
+		}
+		cfg := &clientcredentials.Config{
+			ClientID:     cred.OAuth2.ClientID,
+			ClientSecret: cred.OAuth2.ClientSecret,
+			TokenURL:     cred.OAuth2.TokenURL,
+			Scopes:       cred.OAuth2.Scopes,
+		}
+		tok, err := cfg.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain client credentials token: %w", err)
+		}
+		return withToken(cred, tok), nil
+	case CredentialTypeServiceAccount:
+		if cred.ServiceAccount == nil {
+			return nil, fmt.Errorf("service account is missing")
+		}
+		cfg := &jwt.Config{
+			Email:        cred.ServiceAccount.ClientEmail,
+			PrivateKey:   []byte(cred.ServiceAccount.PrivateKey),
+			PrivateKeyID: cred.ServiceAccount.PrivateKeyID,
+			Scopes:       cred.ServiceAccount.Scopes,
+			TokenURL:     cred.ServiceAccount.TokenURL,
+		}
+		tok, err := cfg.TokenSource(ctx).Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain service account token: %w", err)
+		}
+		return withToken(cred, tok), nil
+	default:
+		return nil, fmt.Errorf("unsupported credential type %q", cred.Type)
+	}
+}
+
+// Refresh obtains a new access token for an exchanged credential.
+//
+// OAuth2 authorization code credentials are refreshed with the refresh token,
+// other credentials are exchanged again.
+func Refresh(ctx context.Context, cred *Credential) (*Credential, error) {
+	if cred == nil {
+		return nil, fmt.Errorf("credential is nil")
+	}
+	if cred.Type != CredentialTypeOAuth2 {
+		return Exchange(ctx, cred)
+	}
+	tok := cred.Token()
+	if tok == nil || tok.RefreshToken == "" {
+		return nil, fmt.Errorf("credential has no refresh token")
+	}
+	// Force the refresh, the token may not be expired yet by the oauth2
+	// package standards.
+	tok.AccessToken = ""
+	newTok, err := oauth2Config(cred.OAuth2).TokenSource(ctx, tok).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	return withToken(cred, newTok), nil
+}
+
+func oauth2Config(o *OAuth2) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  o.AuthorizationURL,
+			TokenURL: o.TokenURL,
+		},
+		RedirectURL: o.RedirectURI,
+		Scopes:      o.Scopes,
+	}
+}
+
+// authCode returns the authorization code of the authorization response,
+// verifying its state.
+func authCode(o *OAuth2) (string, error) {
+	if o.AuthCode != "" {
+		return o.AuthCode, nil
+	}
+	if o.AuthResponseURI == "" {
+		return "", fmt.Errorf("authorization response is missing")
+	}
+	u, err := url.Parse(o.AuthResponseURI)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization response URI: %w", err)
+	}
+	query := u.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		return "", fmt.Errorf("authorization failed: %s", errCode)
+	}
+	if o.State != "" && query.Get("state") != o.State {
+		return "", fmt.Errorf("authorization response state does not match the request")
+	}
+	code := query.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("authorization response has no code")
+	}
+	return code, nil
+}
+
+// withToken returns a copy of the credential with the token and without the
+// completed authorization request.
+func withToken(cred *Credential, tok *oauth2.Token) *Credential {
+	c := cred.clone()
+	if c.OAuth2 == nil {
+		c.OAuth2 = &OAuth2{}
+	}
+	c.OAuth2.AuthURI = ""
+	c.OAuth2.State = ""
+	c.OAuth2.AuthResponseURI = ""
+	c.OAuth2.AuthCode = ""
+	c.OAuth2.AccessToken = tok.AccessToken
+	if tok.RefreshToken != "" {
+		c.OAuth2.RefreshToken = tok.RefreshToken
+	}
+	c.OAuth2.ExpiresAt = 0
+	if !tok.Expiry.IsZero() {
+		c.OAuth2.ExpiresAt = tok.Expiry.Unix()
+	}
+	return c
+}
@@ -0,0 +1,291 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/testutil"
+)
+
+func oauth2Config(s *testutil.FakeOAuthServer) *auth.Config {
+	return &auth.Config{
+		RawCredential: &auth.Credential{
+			Type: auth.CredentialTypeOAuth2,
+			OAuth2: &auth.OAuth2{
+				ClientID:         s.ClientID,
+				ClientSecret:     s.ClientSecret,
+				AuthorizationURL: s.AuthorizationURL(),
+				TokenURL:         s.TokenURL(),
+				Scopes:           []string{"read"},
+				RedirectURI:      "http://localhost/callback",
+			},
+		},
+	}
+}
+
+func TestGenerateAuthRequest(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	cfg := oauth2Config(s)
+
+	req, err := auth.GenerateAuthRequest(cfg)
+	if err != nil {
+		t.Fatalf("GenerateAuthRequest() error = %v", err)
+	}
+	if req.CredentialKey != cfg.Key() {
+		t.Errorf("CredentialKey = %q, want %q", req.CredentialKey, cfg.Key())
+	}
+	if cfg.ExchangedCredential != nil {
+		t.Errorf("GenerateAuthRequest() modified the config")
+	}
+	o := req.ExchangedCredential.OAuth2
+	if o.State == "" {
+		t.Fatal("state is empty")
+	}
+	authURI, err := url.Parse(o.AuthURI)
+	if err != nil {
+		t.Fatalf("invalid auth URI %q: %v", o.AuthURI, err)
+	}
+	q := authURI.Query()
+	for param, want := range map[string]string{
+		"client_id":     s.ClientID,
+		"redirect_uri":  "http://localhost/callback",
+		"response_type": "code",
+		"scope":         "read",
+		"state":         o.State,
+	} {
+		if got := q.Get(param); got != want {
+			t.Errorf("auth URI parameter %q = %q, want %q", param, got, want)
+		}
+	}
+
+	// The pending request is kept.
+	again, err := auth.GenerateAuthRequest(req)
+	if err != nil {
+		t.Fatalf("GenerateAuthRequest() error = %v", err)
+	}
+	if diff := cmp.Diff(req, again); diff != "" {
+		t.Errorf("GenerateAuthRequest() of a pending request mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExchange_AuthorizationCode(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	req, err := auth.GenerateAuthRequest(oauth2Config(s))
+	if err != nil {
+		t.Fatalf("GenerateAuthRequest() error = %v", err)
+	}
+	cred := req.ExchangedCredential
+	cred.OAuth2.AuthResponseURI = s.Authorize(t, cred.OAuth2.AuthURI)
+
+	got, err := auth.Exchange(t.Context(), cred)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if !s.ValidAccessToken(got.OAuth2.AccessToken) {
+		t.Errorf("Exchange() returned an invalid access token %q", got.OAuth2.AccessToken)
+	}
+	if got.OAuth2.RefreshToken == "" || got.OAuth2.ExpiresAt == 0 {
+		t.Errorf("Exchange() returned no refresh token or expiry: %+v", got.OAuth2)
+	}
+	if got.OAuth2.AuthURI != "" || got.OAuth2.AuthResponseURI != "" || got.OAuth2.State != "" {
+		t.Errorf("Exchange() kept the authorization request: %+v", got.OAuth2)
+	}
+
+	refreshed, err := auth.Refresh(t.Context(), got)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if refreshed.OAuth2.AccessToken == got.OAuth2.AccessToken || !s.ValidAccessToken(refreshed.OAuth2.AccessToken) {
+		t.Errorf("Refresh() returned access token %q, want a new valid token", refreshed.OAuth2.AccessToken)
+	}
+	want := []string{"authorization_code", "refresh_token"}
+	if diff := cmp.Diff(want, s.GrantTypes()); diff != "" {
+		t.Errorf("unexpected grant types (-want +got):\n%s", diff)
+	}
+}
+
+func TestExchange_AuthorizationCodeErrors(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	for _, tc := range []struct {
+		name            string
+		authResponseURI func(authURI string) string
+		wantErr         string
+	}{
+		{
+			name:            "missing response",
+			authResponseURI: func(string) string { return "" },
+			wantErr:         "authorization response is missing",
+		},
+		{
+			name: "state mismatch",
+			authResponseURI: func(authURI string) string {
+				return strings.Replace(s.Authorize(t, authURI), "state=", "state=x", 1)
+			},
+			wantErr: "state does not match",
+		},
+		{
+			name:            "access denied",
+			authResponseURI: func(string) string { return "http://localhost/callback?error=access_denied" },
+			wantErr:         "access_denied",
+		},
+		{
+			name:            "invalid code",
+			authResponseURI: func(authURI string) string { return strings.Replace(s.Authorize(t, authURI), "code=", "code=x", 1) },
+			wantErr:         "invalid_grant",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := auth.GenerateAuthRequest(oauth2Config(s))
+			if err != nil {
+				t.Fatalf("GenerateAuthRequest() error = %v", err)
+			}
+			cred := req.ExchangedCredential
+			cred.OAuth2.AuthResponseURI = tc.authResponseURI(cred.OAuth2.AuthURI)
+			_, err = auth.Exchange(t.Context(), cred)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Exchange() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestExchange_ClientCredentials(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	cred := &auth.Credential{
+		Type: auth.CredentialTypeClientCredentials,
+		OAuth2: &auth.OAuth2{
+			ClientID:     s.ClientID,
+			ClientSecret: s.ClientSecret,
+			TokenURL:     s.TokenURL(),
+		},
+	}
+
+	got, err := auth.Exchange(t.Context(), cred)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if !s.ValidAccessToken(got.OAuth2.AccessToken) {
+		t.Errorf("Exchange() returned an invalid access token %q", got.OAuth2.AccessToken)
+	}
+
+	cred.OAuth2.ClientSecret = "wrong"
+	if _, err := auth.Exchange(t.Context(), cred); err == nil {
+		t.Error("Exchange() with a wrong client secret succeeded, want error")
+	}
+}
+
+func TestExchange_ServiceAccount(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := &auth.Credential{
+		Type: auth.CredentialTypeServiceAccount,
+		ServiceAccount: &auth.ServiceAccount{
+			ClientEmail: "agent@example.com",
+			PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+			TokenURL:    s.TokenURL(),
+			Scopes:      []string{"read"},
+		},
+	}
+
+	got, err := auth.Exchange(t.Context(), cred)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if !s.ValidAccessToken(got.Token().AccessToken) {
+		t.Errorf("Exchange() returned an invalid access token %q", got.Token().AccessToken)
+	}
+	if diff := cmp.Diff(cred.ServiceAccount, got.ServiceAccount); diff != "" {
+		t.Errorf("Exchange() changed the service account (-want +got):\n%s", diff)
+	}
+}
+
+func TestExchange_APIKey(t *testing.T) {
+	cred := &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: &auth.APIKey{Key: "secret"}}
+	got, err := auth.Exchange(t.Context(), cred)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if diff := cmp.Diff(cred, got); diff != "" {
+		t.Errorf("Exchange() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cfg     *auth.Config
+		wantErr bool
+	}{
+		{name: "nil", cfg: nil, wantErr: true},
+		{name: "no raw credential", cfg: &auth.Config{}, wantErr: true},
+		{
+			name: "api key",
+			cfg:  &auth.Config{RawCredential: &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: &auth.APIKey{Key: "k"}}},
+		},
+		{
+			name:    "empty api key",
+			cfg:     &auth.Config{RawCredential: &auth.Credential{Type: auth.CredentialTypeAPIKey}},
+			wantErr: true,
+		},
+		{
+			name:    "oauth2 without token URL",
+			cfg:     &auth.Config{RawCredential: &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2{ClientID: "c", AuthorizationURL: "a"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			cfg:     &auth.Config{RawCredential: &auth.Credential{Type: "http"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.cfg.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Key(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	a, b := oauth2Config(s), oauth2Config(s)
+	if a.Key() != b.Key() {
+		t.Errorf("keys of equal configs differ: %q != %q", a.Key(), b.Key())
+	}
+	b.RawCredential.OAuth2.Scopes = []string{"write"}
+	if a.Key() == b.Key() {
+		t.Errorf("keys of different configs are equal: %q", a.Key())
+	}
+	b.CredentialKey = "custom"
+	if got := b.Key(); got != "custom" {
+		t.Errorf("Key() = %q, want %q", got, "custom")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// CredentialService stores the exchanged credentials of the users.
+//
+// Credentials are identified by the app name, the user ID and the credential
+// key (see [Config.Key]).
+type CredentialService interface {
+	// Load loads a credential. It returns an error wrapping fs.ErrNotExist
+	// if the credential does not exist.
+	Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error)
+	// Save saves a credential, replacing the existing one.
+	Save(ctx context.Context, req *SaveRequest) error
+	// Delete deletes a credential. Deleting a non-existing credential is
+	// not an error.
+	Delete(ctx context.Context, req *DeleteRequest) error
+}
+
+// LoadRequest is the parameter for [CredentialService.Load].
+type LoadRequest struct {
+	AppName, UserID, Key string
+}
+
+// LoadResponse is the return type of [CredentialService.Load].
+type LoadResponse struct {
+	Credential *Credential
+}
+
+// SaveRequest is the parameter for [CredentialService.Save].
+type SaveRequest struct {
+	AppName, UserID, Key string
+	Credential           *Credential
+}
+
+// DeleteRequest is the parameter for [CredentialService.Delete].
+type DeleteRequest struct {
+	AppName, UserID, Key string
+}
+
+func validateKey(op, appName, userID, key string) error {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"AppName", appName},
+		{"UserID", userID},
+		{"Key", key},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invalid %s request: missing required fields: %s", op, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// InMemoryCredentialService returns an in-memory implementation of the
+// credential service. The credentials are lost when the process exits.
+func InMemoryCredentialService() CredentialService {
+	return &inMemoryService{credentials: make(map[credentialID]*Credential)}
+}
+
+type credentialID struct {
+	appName, userID, key string
+}
+
+type inMemoryService struct {
+	mu          sync.RWMutex
+	credentials map[credentialID]*Credential
+}
+
+func (s *inMemoryService) Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error) {
+	if err := validateKey("load", req.AppName, req.UserID, req.Key); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cred, ok := s.credentials[credentialID{req.AppName, req.UserID, req.Key}]
+	if !ok {
+		return nil, fmt.Errorf("credential %q not found: %w", req.Key, fs.ErrNotExist)
+	}
+	return &LoadResponse{Credential: cred.clone()}, nil
+}
+
+func (s *inMemoryService) Save(ctx context.Context, req *SaveRequest) error {
+	if err := validateKey("save", req.AppName, req.UserID, req.Key); err != nil {
+		return err
+	}
+	if req.Credential == nil {
+		return fmt.Errorf("invalid save request: missing required fields: Credential")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credentials[credentialID{req.AppName, req.UserID, req.Key}] = req.Credential.clone()
+	return nil
+}
+
+func (s *inMemoryService) Delete(ctx context.Context, req *DeleteRequest) error {
+	if err := validateKey("delete", req.AppName, req.UserID, req.Key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.credentials, credentialID{req.AppName, req.UserID, req.Key})
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+)
+
+func TestInMemoryCredentialService(t *testing.T) {
+	ctx := t.Context()
+	s := auth.InMemoryCredentialService()
+	cred := &auth.Credential{
+		Type:   auth.CredentialTypeOAuth2,
+		OAuth2: &auth.OAuth2{ClientID: "client", AccessToken: "token", Scopes: []string{"read"}},
+	}
+
+	if _, err := s.Load(ctx, &auth.LoadRequest{AppName: "app", UserID: "user", Key: "key"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Load() of a missing credential error = %v, want fs.ErrNotExist", err)
+	}
+	if err := s.Save(ctx, &auth.SaveRequest{AppName: "app", UserID: "user", Key: "key", Credential: cred}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// The stored credential is not affected by later changes.
+	cred.OAuth2.Scopes[0] = "write"
+
+	resp, err := s.Load(ctx, &auth.LoadRequest{AppName: "app", UserID: "user", Key: "key"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := &auth.Credential{
+		Type:   auth.CredentialTypeOAuth2,
+		OAuth2: &auth.OAuth2{ClientID: "client", AccessToken: "token", Scopes: []string{"read"}},
+	}
+	if diff := cmp.Diff(want, resp.Credential); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Credentials are scoped to the user.
+	if _, err := s.Load(ctx, &auth.LoadRequest{AppName: "app", UserID: "other", Key: "key"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() for another user error = %v, want fs.ErrNotExist", err)
+	}
+
+	if err := s.Delete(ctx, &auth.DeleteRequest{AppName: "app", UserID: "user", Key: "key"}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Load(ctx, &auth.LoadRequest{AppName: "app", UserID: "user", Key: "key"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() after Delete() error = %v, want fs.ErrNotExist", err)
+	}
+	if err := s.Delete(ctx, &auth.DeleteRequest{AppName: "app", UserID: "user", Key: "key"}); err != nil {
+		t.Errorf("Delete() of a missing credential error = %v", err)
+	}
+}
+
+func TestInMemoryCredentialService_InvalidRequests(t *testing.T) {
+	ctx := t.Context()
+	s := auth.InMemoryCredentialService()
+	if _, err := s.Load(ctx, &auth.LoadRequest{AppName: "app"}); err == nil {
+		t.Error("Load() without user and key succeeded, want error")
+	}
+	if err := s.Save(ctx, &auth.SaveRequest{AppName: "app", UserID: "user", Key: "key"}); err == nil {
+		t.Error("Save() without credential succeeded, want error")
+	}
+	if err := s.Delete(ctx, &auth.DeleteRequest{UserID: "user", Key: "key"}); err == nil {
+		t.Error("Delete() without app succeeded, want error")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+// Apply authenticates the HTTP request with the credential.
+//
+// API keys are set in the configured header or query parameter, access
+// tokens are set as bearer tokens in the Authorization header.
+func (c *Credential) Apply(req *http.Request) {
+	if c == nil {
+		return
+	}
+	if c.Type == CredentialTypeAPIKey {
+		if c.APIKey == nil {
+			return
+		}
+		name := c.APIKey.Name
+		if name == "" {
+			name = "X-API-Key"
+		}
+		if c.APIKey.In == "query" {
+			q := req.URL.Query()
+			q.Set(name, c.APIKey.Key)
+			req.URL.RawQuery = q.Encode()
+			return
+		}
+		req.Header.Set(name, c.APIKey.Key)
+		return
+	}
+	if tok := c.Token(); tok != nil {
+		tok.SetAuthHeader(req)
+	}
+}
+
+type credentialCtxKey struct{}
+
+// NewContext returns a context carrying the credential, used by [Transport]
+// to authenticate the requests.
+func NewContext(ctx context.Context, cred *Credential) context.Context {
+	return context.WithValue(ctx, credentialCtxKey{}, cred)
+}
+
+// FromContext returns the credential carried by the context, if any.
+func FromContext(ctx context.Context) (*Credential, bool) {
+	cred, ok := ctx.Value(credentialCtxKey{}).(*Credential)
+	return cred, ok
+}
+
+// Transport is an http.RoundTripper that authenticates the requests with the
+// credential carried by the request context (see [NewContext]). Requests
+// without a credential are sent unchanged.
+type Transport struct {
+	// Base is the underlying transport. If nil, http.DefaultTransport is
+	// used.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cred, ok := FromContext(req.Context())
+	if !ok || cred == nil {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	cred.Apply(req)
+	return base.RoundTrip(req)
+}
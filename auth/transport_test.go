@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/adk/auth"
+)
+
+func TestCredential_Apply(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		cred       *auth.Credential
+		wantHeader string
+		wantValue  string
+		wantQuery  string
+	}{
+		{
+			name:       "api key header",
+			cred:       &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: &auth.APIKey{Key: "secret"}},
+			wantHeader: "X-API-Key",
+			wantValue:  "secret",
+		},
+		{
+			name:       "api key custom header",
+			cred:       &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: &auth.APIKey{Key: "secret", Name: "X-Token"}},
+			wantHeader: "X-Token",
+			wantValue:  "secret",
+		},
+		{
+			name:      "api key query",
+			cred:      &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: &auth.APIKey{Key: "secret", Name: "key", In: "query"}},
+			wantQuery: "a=b&key=secret",
+		},
+		{
+			name:       "access token",
+			cred:       &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2{AccessToken: "token"}},
+			wantHeader: "Authorization",
+			wantValue:  "Bearer token",
+		},
+		{
+			name:      "no access token",
+			cred:      &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2{ClientID: "client"}},
+			wantQuery: "a=b",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/path?a=b", nil)
+			tc.cred.Apply(req)
+			if tc.wantHeader != "" {
+				if got := req.Header.Get(tc.wantHeader); got != tc.wantValue {
+					t.Errorf("header %q = %q, want %q", tc.wantHeader, got, tc.wantValue)
+				}
+			}
+			if tc.wantQuery != "" {
+				if got := req.URL.RawQuery; got != tc.wantQuery {
+					t.Errorf("query = %q, want %q", got, tc.wantQuery)
+				}
+				if len(req.Header) != 0 {
+					t.Errorf("unexpected headers %v", req.Header)
+				}
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var gotAuth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &auth.Transport{}}
+
+	cred := &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2{AccessToken: "token"}}
+	for _, ctx := range []context.Context{t.Context(), auth.NewContext(t.Context(), cred)} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if req.Header.Get("Authorization") != "" {
+			t.Error("Transport modified the request")
+		}
+	}
+
+	want := []string{"", "Bearer token"}
+	if len(gotAuth) != len(want) || gotAuth[0] != want[0] || gotAuth[1] != want[1] {
+		t.Errorf("Authorization headers = %q, want %q", gotAuth, want)
+	}
+}
@@ -18,6 +18,7 @@ package main
 import (
 	_ "google.golang.org/adk/cmd/adkgo/internal/deploy/cloudrun"
 	"google.golang.org/adk/cmd/adkgo/internal/root"
+	_ "google.golang.org/adk/cmd/adkgo/internal/sessions"
 )
 
 func main() {
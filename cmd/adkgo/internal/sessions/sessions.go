@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessions handles the commands exporting, importing and migrating
+// the sessions of the session services.
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/adk/cmd/adkgo/internal/root"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/database"
+	"google.golang.org/adk/session/filestore"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// serviceSyntax describes the session services of the flags.
+const serviceSyntax = "sqlite:<file>, postgres:<dsn> or mysql:<dsn> for a database, dir:<directory> for a file service"
+
+// dialectors open the databases of the session services, by kind. The DSNs
+// are the ones of the GORM drivers.
+var dialectors = map[string]func(dsn string) gorm.Dialector{
+	"sqlite":   sqlite.Open,
+	"postgres": postgres.Open,
+	"mysql":    mysql.Open,
+}
+
+type sessionsFlags struct {
+	from         string
+	to           string
+	appName      string
+	userID       string
+	file         string
+	skipExisting bool
+}
+
+var flags sessionsFlags
+
+// sessionsCmd represents the sessions command.
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Exports, imports and migrates sessions",
+	Long: `The sessions are exported in a versioned JSONL format holding their events and their app, user and session scoped states.
+The sessions are streamed one at a time.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+		return nil
+	},
+}
+
+// exportCmd represents the sessions export command.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the sessions of an app to a file.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := openService(flags.from)
+		if err != nil {
+			return err
+		}
+		w := cmd.OutOrStdout()
+		if flags.file != "" {
+			f, err := os.Create(flags.file)
+			if err != nil {
+				return fmt.Errorf("cannot create export file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		resp, err := session.Export(cmd.Context(), from, w, &session.ExportRequest{AppName: flags.appName, UserID: flags.userID})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d sessions, %d events.\n", resp.Sessions, resp.Events)
+		return nil
+	},
+}
+
+// importCmd represents the sessions import command.
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Imports the sessions of an export file.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, err := openService(flags.to)
+		if err != nil {
+			return err
+		}
+		r := cmd.InOrStdin()
+		if flags.file != "" {
+			f, err := os.Open(flags.file)
+			if err != nil {
+				return fmt.Errorf("cannot open export file: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		resp, err := session.Import(cmd.Context(), to, r, &session.ImportRequest{SkipExisting: flags.skipExisting})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Imported %d sessions, %d events.%s\n", resp.Sessions, resp.Events, skipped(resp))
+		return nil
+	},
+}
+
+// migrateCmd represents the sessions migrate command.
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copies the sessions of an app from a session service to another.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := openService(flags.from)
+		if err != nil {
+			return err
+		}
+		to, err := openService(flags.to)
+		if err != nil {
+			return err
+		}
+		resp, err := migrate(cmd.Context(), from, to,
+			&session.ExportRequest{AppName: flags.appName, UserID: flags.userID},
+			&session.ImportRequest{SkipExisting: flags.skipExisting})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Migrated %d sessions, %d events.%s\n", resp.Sessions, resp.Events, skipped(resp))
+		return nil
+	},
+}
+
+// init creates flags and adds subcommands to parent
+func init() {
+	root.RootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(exportCmd, importCmd, migrateCmd)
+
+	for _, cmd := range []*cobra.Command{exportCmd, migrateCmd} {
+		cmd.Flags().StringVar(&flags.from, "from", "", "Session service to read: "+serviceSyntax)
+		cmd.Flags().StringVar(&flags.appName, "app", "", "App of the sessions")
+		cmd.Flags().StringVar(&flags.userID, "user", "", "User of the sessions, all the users if not specified")
+		_ = cmd.MarkFlagRequired("from")
+		_ = cmd.MarkFlagRequired("app")
+	}
+	for _, cmd := range []*cobra.Command{importCmd, migrateCmd} {
+		cmd.Flags().StringVar(&flags.to, "to", "", "Session service to write: "+serviceSyntax)
+		cmd.Flags().BoolVar(&flags.skipExisting, "skip-existing", false, "Keep the existing sessions and only add their missing events, e.g. to resume an interrupted import")
+		_ = cmd.MarkFlagRequired("to")
+	}
+	exportCmd.Flags().StringVarP(&flags.file, "out", "o", "", "Export file, stdout if not specified")
+	importCmd.Flags().StringVarP(&flags.file, "in", "i", "", "Export file, stdin if not specified")
+}
+
+// openService opens the session service of the spec, see serviceSyntax.
+func openService(spec string) (session.Service, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	if path == "" {
+		return nil, fmt.Errorf("invalid session service %q, want %s", spec, serviceSyntax)
+	}
+	if kind == "dir" {
+		return filestore.NewSessionService(path)
+	}
+	open, ok := dialectors[kind]
+	if !ok {
+		return nil, fmt.Errorf("invalid session service %q, want %s", spec, serviceSyntax)
+	}
+	// The errors are returned, the logs of the lookups of the missing app
+	// and user states would only be noise.
+	service, err := database.NewSessionService(open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	if err := database.AutoMigrate(service); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// skipped describes the sessions skipped by an import.
+func skipped(resp *session.ImportResponse) string {
+	if resp.SkippedSessions == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" Skipped %d existing sessions.", resp.SkippedSessions)
+}
+
+// migrate streams the export of the sessions of from to the import in to.
+func migrate(ctx context.Context, from, to session.Service, exportReq *session.ExportRequest, importReq *session.ImportRequest) (*session.ImportResponse, error) {
+	r, w := io.Pipe()
+	exported := make(chan error, 1)
+	go func() {
+		_, err := session.Export(ctx, from, w, exportReq)
+		w.CloseWithError(err)
+		exported <- err
+	}()
+	resp, err := session.Import(ctx, to, r, importReq)
+	if err != nil {
+		// Unblock the export.
+		r.CloseWithError(err)
+		<-exported
+		return nil, err
+	}
+	if err := <-exported; err != nil {
+		return nil, fmt.Errorf("export failed: %w", err)
+	}
+	return resp, nil
+}
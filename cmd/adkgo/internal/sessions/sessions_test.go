@@ -0,0 +1,152 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/cmd/adkgo/internal/root"
+	"google.golang.org/adk/session"
+)
+
+func TestExportImportMigrate(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	srcSpec := "dir:" + filepath.Join(dir, "src")
+	src, err := openService(srcSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ids := range [][2]string{{"user1", "s1"}, {"user1", "s2"}, {"user2", "s3"}} {
+		created, err := src.Create(ctx, &session.CreateRequest{AppName: "app", UserID: ids[0], SessionID: ids[1], State: map[string]any{"k": ids[1], "app:a": "x"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range 2 {
+			event := &session.Event{
+				ID:        fmt.Sprintf("%s-e%d", ids[1], i),
+				Author:    "agent",
+				Timestamp: time.Date(2025, 1, 2, 3, 4, i, 0, time.UTC),
+				Actions:   session.EventActions{StateDelta: map[string]any{"user:u": ids[1]}},
+			}
+			if err := src.AppendEvent(ctx, created.Session, event); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	exportFile := filepath.Join(dir, "export.jsonl")
+	sqliteSpec := "sqlite:" + filepath.Join(dir, "sessions.db")
+	dirSpec := "dir:" + filepath.Join(dir, "dst")
+	steps := []struct {
+		args       []string
+		wantStderr string
+		wantErr    bool
+		// check is the session service to compare with the source, if any.
+		check string
+	}{
+		{
+			args:       []string{"export", "--from", srcSpec, "--app", "app", "-o", exportFile},
+			wantStderr: "Exported 3 sessions, 6 events.",
+		},
+		{
+			args:       []string{"import", "--to", sqliteSpec, "-i", exportFile},
+			wantStderr: "Imported 3 sessions, 6 events.",
+			check:      sqliteSpec,
+		},
+		{
+			// The sessions exist.
+			args:    []string{"import", "--to", sqliteSpec, "-i", exportFile},
+			wantErr: true,
+		},
+		{
+			args:       []string{"import", "--to", sqliteSpec, "-i", exportFile, "--skip-existing"},
+			wantStderr: "Imported 0 sessions, 0 events. Skipped 3 existing sessions.",
+			check:      sqliteSpec,
+		},
+		{
+			// The export is piped from the database to the directory.
+			args:       []string{"migrate", "--from", sqliteSpec, "--to", dirSpec, "--app", "app"},
+			wantStderr: "Migrated 3 sessions, 6 events.",
+			check:      dirSpec,
+		},
+	}
+	for _, step := range steps {
+		stderr, err := runCommand(t, step.args...)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%v: error = %v, wantErr %v", step.args, err, step.wantErr)
+		}
+		if got := strings.TrimSpace(stderr); step.wantStderr != "" && got != step.wantStderr {
+			t.Errorf("%v: got output %q, want %q", step.args, got, step.wantStderr)
+		}
+		if step.check == "" {
+			continue
+		}
+		dst, err := openService(step.check)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(dump(t, src), dump(t, dst)); diff != "" {
+			t.Errorf("%v: sessions mismatch (-want +got):\n%s", step.args, diff)
+		}
+	}
+}
+
+// runCommand runs the sessions command with the args, returning its error
+// output.
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	flags = sessionsFlags{}
+	var stderr bytes.Buffer
+	root.RootCmd.SetArgs(append([]string{"sessions"}, args...))
+	root.RootCmd.SetErr(&stderr)
+	root.RootCmd.SetOut(&bytes.Buffer{})
+	err := root.RootCmd.ExecuteContext(t.Context())
+	return stderr.String(), err
+}
+
+// dump returns the states and the event IDs of the sessions of the app, by
+// session ID.
+func dump(t *testing.T, s session.Service) map[string]any {
+	t.Helper()
+	listResp, err := s.List(t.Context(), &session.ListRequest{AppName: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := make(map[string]any)
+	for _, listed := range listResp.Sessions {
+		getResp, err := s.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: listed.UserID(), SessionID: listed.ID()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []string
+		for event := range getResp.Session.Events().All() {
+			events = append(events, event.ID)
+		}
+		sessions[listed.ID()] = map[string]any{
+			"user":   listed.UserID(),
+			"state":  maps.Collect(getResp.Session.State().All()),
+			"events": events,
+		}
+	}
+	return sessions
+}
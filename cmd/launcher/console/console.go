@@ -22,11 +22,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/cmd/launcher"
 	"google.golang.org/adk/cmd/launcher/universal"
 	"google.golang.org/adk/internal/cli/util"
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
@@ -36,6 +40,7 @@ import (
 type consoleConfig struct {
 	streamingMode       agent.StreamingMode
 	streamingModeString string // command-line param to be converted to agent.StreamingMode
+	model               string // command-line param resolved with model.Resolve
 }
 
 // consoleLauncher allows to interact with an agent in console
@@ -51,6 +56,7 @@ func NewLauncher() launcher.SubLauncher {
 	fs := flag.NewFlagSet("console", flag.ContinueOnError)
 	fs.StringVar(&config.streamingModeString, "streaming_mode", string(agent.StreamingModeSSE),
 		fmt.Sprintf("defines streaming mode (%s|%s)", agent.StreamingModeNone, agent.StreamingModeSSE))
+	fs.StringVar(&config.model, "model", "", "overrides the model of the LLM agents (i.e. 'gemini-2.5-flash', 'openai/gpt-4o' - see model.Resolve for details)")
 
 	return &consoleLauncher{config: config, flags: fs}
 }
@@ -75,13 +81,22 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 
 	rootAgent := config.AgentLoader.RootAgent()
 
-	session := resp.Session
+	sessionID := resp.Session.ID()
+
+	llm := config.Model
+	if l.config.model != "" {
+		llm, err = model.Resolve(ctx, l.config.model)
+		if err != nil {
+			return fmt.Errorf("cannot override the model: %w", err)
+		}
+	}
 
 	r, err := runner.New(runner.Config{
 		AppName:         appName,
 		Agent:           rootAgent,
 		SessionService:  sessionService,
 		ArtifactService: config.ArtifactService,
+		Model:           llm,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create runner: %v", err)
@@ -89,6 +104,9 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 
 	reader := bufio.NewReader(os.Stdin)
 
+	if _, ok := sessionService.(session.RewindService); ok {
+		fmt.Println("Type /rewind [n] to undo the last n turns, /fork [n] to continue in a new session without them.")
+	}
 	for {
 		fmt.Print("\nUser -> ")
 
@@ -97,6 +115,14 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 			log.Fatal(err)
 		}
 
+		if isCommand(userInput) {
+			sessionID, err = runCommand(ctx, r, sessionService, appName, userID, sessionID, userInput)
+			if err != nil {
+				fmt.Printf("\nCOMMAND_ERROR: %v\n", err)
+			}
+			continue
+		}
+
 		userMsg := genai.NewContentFromText(userInput, genai.RoleUser)
 
 		streamingMode := l.config.streamingMode
@@ -105,7 +131,7 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 		}
 		fmt.Print("\nAgent -> ")
 		prevText := ""
-		for event, err := range r.Run(ctx, userID, session.ID(), userMsg, agent.RunConfig{
+		for event, err := range r.Run(ctx, userID, sessionID, userMsg, agent.RunConfig{
 			StreamingMode: streamingMode,
 		}) {
 			if err != nil {
@@ -143,6 +169,75 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 	}
 }
 
+// isCommand reports whether the user input is a console command rather than
+// a message to the agent.
+func isCommand(userInput string) bool {
+	fields := strings.Fields(userInput)
+	return len(fields) > 0 && (fields[0] == "/rewind" || fields[0] == "/fork")
+}
+
+// runCommand runs a console command:
+//
+//	/rewind [n]  removes the last n turns of the session, 1 by default
+//	/fork [n]    continues in a new session without the last n turns, none by default
+//
+// It returns the ID of the session of the next turns.
+func runCommand(ctx context.Context, r *runner.Runner, sessionService session.Service, appName, userID, sessionID, userInput string) (string, error) {
+	fields := strings.Fields(userInput)
+	if len(fields) > 2 {
+		return sessionID, fmt.Errorf("usage: %s [n]", fields[0])
+	}
+	turns := 0
+	if fields[0] == "/rewind" {
+		turns = 1
+	}
+	if len(fields) == 2 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 0 {
+			return sessionID, fmt.Errorf("invalid number of turns %q", fields[1])
+		}
+		turns = n
+	}
+
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return sessionID, fmt.Errorf("failed to get session: %w", err)
+	}
+	// Each turn is an invocation starting with the user message.
+	var turnInvocationIDs []string
+	for event := range resp.Session.Events().All() {
+		if event.Author == "user" && !slices.Contains(turnInvocationIDs, event.InvocationID) {
+			turnInvocationIDs = append(turnInvocationIDs, event.InvocationID)
+		}
+	}
+	if turns > len(turnInvocationIDs) {
+		return sessionID, fmt.Errorf("the session has %d turns", len(turnInvocationIDs))
+	}
+	var invocationID string
+	if turns > 0 {
+		invocationID = turnInvocationIDs[len(turnInvocationIDs)-turns]
+	}
+
+	switch fields[0] {
+	case "/rewind":
+		if turns == 0 {
+			return sessionID, nil
+		}
+		if _, err := r.Rewind(ctx, &session.RewindRequest{UserID: userID, SessionID: sessionID, InvocationID: invocationID}); err != nil {
+			return sessionID, err
+		}
+		fmt.Printf("\nRewound %d turns.\n", turns)
+		return sessionID, nil
+	default:
+		forkResp, err := r.Fork(ctx, &session.ForkRequest{UserID: userID, SessionID: sessionID, InvocationID: invocationID})
+		if err != nil {
+			return sessionID, err
+		}
+		fmt.Printf("\nForked session %s into session %s.\n", sessionID, forkResp.Session.ID())
+		return forkResp.Session.ID(), nil
+	}
+}
+
 // Parse implements launcher.SubLauncher. After parsing console-specific
 // arguments returns remaining un-parsed arguments
 func (l *consoleLauncher) Parse(args []string) ([]string, error) {
@@ -22,6 +22,7 @@ import (
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
 	"google.golang.org/adk/memory"
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
 )
 
@@ -58,4 +59,6 @@ type Config struct {
 	MemoryService   memory.Service
 	AgentLoader     agent.Loader
 	A2AOptions      []a2asrv.RequestHandlerOption
+	// Model, if set, overrides the models of the LLM agents.
+	Model model.LLM
 }
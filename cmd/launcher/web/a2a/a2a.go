@@ -104,6 +104,7 @@ func (a *a2aLauncher) SetupSubrouters(router *mux.Router, config *launcher.Confi
 			Agent:           agent,
 			SessionService:  config.SessionService,
 			ArtifactService: config.ArtifactService,
+			Model:           config.Model,
 		},
 	})
 	reqHandler := a2asrv.NewHandler(executor, config.A2AOptions...)
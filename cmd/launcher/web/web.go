@@ -28,6 +28,7 @@ import (
 	"google.golang.org/adk/cmd/launcher"
 	"google.golang.org/adk/cmd/launcher/universal"
 	"google.golang.org/adk/internal/cli/util"
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
 )
 
@@ -37,6 +38,7 @@ type webConfig struct {
 	writeTimeout time.Duration
 	readTimeout  time.Duration
 	idleTimeout  time.Duration
+	model        string
 }
 
 // webLauncher can launch web server
@@ -148,6 +150,13 @@ func (w *webLauncher) Run(ctx context.Context, config *launcher.Config) error {
 	if config.SessionService == nil {
 		config.SessionService = session.InMemoryService()
 	}
+	if w.config.model != "" {
+		llm, err := model.Resolve(ctx, w.config.model)
+		if err != nil {
+			return fmt.Errorf("cannot override the model: %w", err)
+		}
+		config.Model = llm
+	}
 
 	router := BuildBaseRouter()
 
@@ -207,6 +216,7 @@ func NewLauncher(sublaunchers ...Sublauncher) launcher.SubLauncher {
 	fs.DurationVar(&config.writeTimeout, "write-timeout", 15*time.Second, "Server write timeout (i.e. '10s', '2m' - see time.ParseDuration for details) - for writing the response after reading the headers & body")
 	fs.DurationVar(&config.readTimeout, "read-timeout", 15*time.Second, "Server read timeout (i.e. '10s', '2m' - see time.ParseDuration for details) - for reading the whole request including body")
 	fs.DurationVar(&config.idleTimeout, "idle-timeout", 60*time.Second, "Server idle timeout (i.e. '10s', '2m' - see time.ParseDuration for details) - for waiting for the next request (only when keep-alive is enabled)")
+	fs.StringVar(&config.model, "model", "", "Overrides the model of the LLM agents (i.e. 'gemini-2.5-flash', 'openai/gpt-4o' - see model.Resolve for details)")
 
 	return &webLauncher{
 		config:       config,
@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codeexecutor defines the interface for executing code generated
+// by an LLM agent.
+//
+// When a code executor is configured for an LLM agent (see
+// llmagent.Config.CodeExecutor), the agent extracts the first fenced code
+// block from the model response, runs it with the executor and sends the
+// result back to the model as a genai.CodeExecutionResult part.
+//
+// Code blocks are recognized by the delimiters in [CodeBlockDelimiters],
+// and the execution results are rendered back to the model using
+// [ResultDelimiter].
+package codeexecutor
+
+import (
+	"context"
+)
+
+// CodeExecutor executes code blocks extracted from model responses.
+type CodeExecutor interface {
+	// Execute runs the given code and returns its result.
+	//
+	// A non-nil error is reserved for failures of the executor itself (e.g.
+	// the interpreter could not be started). Errors raised by the executed
+	// code must be reported in ExecuteResponse.Stderr.
+	Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error)
+}
+
+// ExecuteRequest is the input of [CodeExecutor.Execute].
+type ExecuteRequest struct {
+	// Code to execute.
+	Code string
+	// InputFiles are made available to the code in its working directory.
+	InputFiles []File
+	// ExecutionID identifies the execution. Stateful executors may use it to
+	// correlate executions. Optional.
+	ExecutionID string
+}
+
+// ExecuteResponse is the result of [CodeExecutor.Execute].
+type ExecuteResponse struct {
+	// Stdout is the standard output of the execution.
+	Stdout string
+	// Stderr is the standard error of the execution. A non-empty Stderr
+	// means that the execution failed.
+	Stderr string
+	// TimedOut reports whether the execution was aborted because it exceeded
+	// the executor's time limit.
+	TimedOut bool
+	// OutputFiles are the files created by the execution.
+	OutputFiles []File
+}
+
+// File is a file consumed or produced by a code execution.
+type File struct {
+	// Name of the file, relative to the working directory of the execution.
+	Name string
+	// MIMEType of the file content.
+	MIMEType string
+	// Content of the file.
+	Content []byte
+}
+
+// Delimiter is a pair of strings enclosing a code block or an execution
+// result in text.
+type Delimiter struct {
+	Start, End string
+}
+
+var (
+	// CodeBlockDelimiters are the delimiters of code blocks that are extracted
+	// from the model response and executed.
+	CodeBlockDelimiters = []Delimiter{
+		{Start: "```tool_code\n", End: "\n```"},
+		{Start: "```python\n", End: "\n```"},
+	}
+	// ResultDelimiter is used to render execution results as text when they
+	// are sent back to the model.
+	ResultDelimiter = Delimiter{Start: "```tool_output\n", End: "\n```"}
+)
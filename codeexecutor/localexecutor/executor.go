@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package localexecutor provides a code executor that runs code in a local
+// subprocess.
+//
+// WARNING: the executor runs the model generated code on the host machine
+// with the privileges of the current process. The working directory and
+// environment isolation it provides is not a security boundary. Only use it
+// in trusted environments, e.g. for local development and tests.
+package localexecutor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/codeexecutor"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Config is the configuration of the local executor.
+type Config struct {
+	// Command is the interpreter invoked for each execution. The code is
+	// written to the standard input of the command.
+	// Optional: defaults to []string{"python3", "-"}.
+	Command []string
+	// WorkDir is the directory under which a fresh working directory is
+	// created for every execution. The per-execution directory is removed
+	// once the execution completes.
+	// Optional: defaults to os.TempDir().
+	WorkDir string
+	// Timeout limits the duration of a single execution.
+	// Optional: defaults to 30 seconds.
+	Timeout time.Duration
+	// Env is the environment of the executed command, in the "key=value"
+	// form. PATH is inherited from the current process, while HOME and
+	// TMPDIR point to the working directory of the execution.
+	Env []string
+}
+
+// New creates a code executor that runs code in a local subprocess.
+func New(cfg Config) (codeexecutor.CodeExecutor, error) {
+	command := cfg.Command
+	if len(command) == 0 {
+		command = []string{"python3", "-"}
+	}
+	if command[0] == "" {
+		return nil, fmt.Errorf("command must not be empty")
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative, got %v", timeout)
+	}
+	workDir := cfg.WorkDir
+	if workDir == "" {
+		workDir = os.TempDir()
+	}
+	if info, err := os.Stat(workDir); err != nil {
+		return nil, fmt.Errorf("invalid work dir: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("work dir %q is not a directory", workDir)
+	}
+	return &executor{
+		command: slices.Clone(command),
+		workDir: workDir,
+		timeout: timeout,
+		env:     slices.Clone(cfg.Env),
+	}, nil
+}
+
+type executor struct {
+	command []string
+	workDir string
+	timeout time.Duration
+	env     []string
+}
+
+// Execute implements codeexecutor.CodeExecutor.
+func (e *executor) Execute(ctx context.Context, req *codeexecutor.ExecuteRequest) (*codeexecutor.ExecuteResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request is nil")
+	}
+	dir, err := os.MkdirTemp(e.workDir, "adk-code-execution-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	inputs := make(map[string]bool)
+	for _, f := range req.InputFiles {
+		path, err := resolve(dir, f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to write input file %q: %w", f.Name, err)
+		}
+		if err := os.WriteFile(path, f.Content, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write input file %q: %w", f.Name, err)
+		}
+		inputs[filepath.ToSlash(f.Name)] = true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.command[0], e.command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir}, e.env...)
+	cmd.Stdin = strings.NewReader(req.Code)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Do not wait forever for the output of orphaned child processes.
+	cmd.WaitDelay = time.Second
+
+	runErr := cmd.Run()
+	resp := &codeexecutor.ExecuteResponse{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		resp.TimedOut = true
+		if resp.Stderr == "" {
+			resp.Stderr = fmt.Sprintf("code execution timed out after %v", e.timeout)
+		}
+		return resp, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return nil, fmt.Errorf("failed to run %q: %w", e.command[0], runErr)
+	}
+	if exitErr != nil && resp.Stderr == "" {
+		resp.Stderr = exitErr.Error()
+	}
+
+	resp.OutputFiles, err = collectOutputFiles(dir, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// resolve returns the path of the named file within dir. It rejects names
+// that would escape dir.
+func resolve(dir, name string) (string, error) {
+	if name == "" || filepath.IsAbs(name) || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid input file name %q", name)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// collectOutputFiles returns the regular files in dir that are not inputs.
+func collectOutputFiles(dir string, inputs map[string]bool) ([]codeexecutor.File, error) {
+	var files []codeexecutor.File
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if inputs[rel] {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, codeexecutor.File{
+			Name:     rel,
+			MIMEType: mimeType(rel, content),
+			Content:  content,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect output files: %w", err)
+	}
+	return files, nil
+}
+
+func mimeType(name string, content []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(content)
+}
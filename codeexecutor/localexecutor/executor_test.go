@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localexecutor_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/codeexecutor"
+	"google.golang.org/adk/codeexecutor/localexecutor"
+)
+
+func newShellExecutor(t *testing.T, timeout time.Duration) (codeexecutor.CodeExecutor, string) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	workDir := t.TempDir()
+	e, err := localexecutor.New(localexecutor.Config{
+		Command: []string{"sh"},
+		WorkDir: workDir,
+		Timeout: timeout,
+	})
+	if err != nil {
+		t.Fatalf("localexecutor.New() failed: %v", err)
+	}
+	return e, workDir
+}
+
+func TestExecute(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		req  *codeexecutor.ExecuteRequest
+		want *codeexecutor.ExecuteResponse
+	}{
+		{
+			name: "stdout",
+			req:  &codeexecutor.ExecuteRequest{Code: "echo hello"},
+			want: &codeexecutor.ExecuteResponse{Stdout: "hello\n"},
+		},
+		{
+			name: "stderr",
+			req:  &codeexecutor.ExecuteRequest{Code: "echo oops >&2; exit 3"},
+			want: &codeexecutor.ExecuteResponse{Stderr: "oops\n"},
+		},
+		{
+			name: "non-zero exit without stderr",
+			req:  &codeexecutor.ExecuteRequest{Code: "exit 3"},
+			want: &codeexecutor.ExecuteResponse{Stderr: "exit status 3"},
+		},
+		{
+			name: "input files",
+			req: &codeexecutor.ExecuteRequest{
+				Code:       "cat data/in.txt",
+				InputFiles: []codeexecutor.File{{Name: "data/in.txt", Content: []byte("from input")}},
+			},
+			want: &codeexecutor.ExecuteResponse{Stdout: "from input"},
+		},
+		{
+			name: "output files",
+			req: &codeexecutor.ExecuteRequest{
+				Code:       "cat in.txt > out.txt",
+				InputFiles: []codeexecutor.File{{Name: "in.txt", Content: []byte("copied")}},
+			},
+			want: &codeexecutor.ExecuteResponse{
+				OutputFiles: []codeexecutor.File{{Name: "out.txt", MIMEType: "text/plain; charset=utf-8", Content: []byte("copied")}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e, _ := newShellExecutor(t, 0)
+			got, err := e.Execute(t.Context(), tc.req)
+			if err != nil {
+				t.Fatalf("Execute() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Execute() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExecute_Timeout(t *testing.T) {
+	e, _ := newShellExecutor(t, 100*time.Millisecond)
+
+	got, err := e.Execute(t.Context(), &codeexecutor.ExecuteRequest{Code: "sleep 10"})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !got.TimedOut || !strings.Contains(got.Stderr, "timed out") {
+		t.Errorf("Execute() = %+v, want timed out execution", got)
+	}
+}
+
+func TestExecute_Sandbox(t *testing.T) {
+	e, workDir := newShellExecutor(t, 0)
+
+	got, err := e.Execute(t.Context(), &codeexecutor.ExecuteRequest{Code: `test "$HOME" = "$(pwd)" && echo ok`})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if got.Stdout != "ok\n" {
+		t.Errorf("Execute() = %+v, want HOME to be the working directory", got)
+	}
+
+	if _, err := e.Execute(t.Context(), &codeexecutor.ExecuteRequest{
+		Code:       "true",
+		InputFiles: []codeexecutor.File{{Name: "../escape.txt"}},
+	}); err == nil {
+		t.Error("Execute() with input file outside of the working directory succeeded, want error")
+	}
+
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("working directories were not cleaned up: %v", entries)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  localexecutor.Config
+	}{
+		{name: "empty command", cfg: localexecutor.Config{Command: []string{""}}},
+		{name: "negative timeout", cfg: localexecutor.Config{Timeout: -time.Second}},
+		{name: "missing work dir", cfg: localexecutor.Config{WorkDir: "/does/not/exist"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := localexecutor.New(tc.cfg); err == nil {
+				t.Errorf("New(%+v) succeeded, want error", tc.cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checkpoint saves the progress of the agents in the session, so that
+// an interrupted invocation can be resumed.
+//
+// A checkpoint is an event of the agent with the state in
+// [session.EventActions.AgentState]. When an invocation is resumed, the
+// agents load their latest checkpoint and skip the work already done.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/parentmap"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/session"
+)
+
+// Enabled reports whether the agents checkpoint their progress in the
+// invocation.
+func Enabled(ctx context.Context) bool {
+	cfg := runconfig.FromContext(ctx)
+	return cfg != nil && cfg.Resumable
+}
+
+// Resuming reports whether the agent continues from its checkpoint, in a
+// resumed invocation.
+func Resuming(ctx context.Context) bool {
+	resuming, _ := ctx.Value(resumingCtxKey).(bool)
+	return resuming
+}
+
+// WithResuming returns a copy of the invocation context, where the agents
+// continue from their checkpoints or not.
+//
+// The agents pass a resuming context only to the agents that were running
+// when the invocation was interrupted. The agents they run after that start
+// afresh.
+func WithResuming(ctx agent.InvocationContext, resuming bool) agent.InvocationContext {
+	return &resumingContext{InvocationContext: ctx, resuming: resuming}
+}
+
+type resumingContext struct {
+	agent.InvocationContext
+	resuming bool
+}
+
+func (c *resumingContext) Value(key any) any {
+	if key == resumingCtxKey {
+		return c.resuming
+	}
+	return c.InvocationContext.Value(key)
+}
+
+type ctxKey int
+
+const resumingCtxKey ctxKey = 0
+
+// NewEvent returns the event saving the state of the agent. The state must be
+// encodable as a JSON object.
+func NewEvent(ctx agent.InvocationContext, state any) (*session.Event, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the state of agent %q: %w", ctx.Agent().Name(), err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to encode the state of agent %q: %w", ctx.Agent().Name(), err)
+	}
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Actions.AgentState = m
+	return ev, nil
+}
+
+// Load decodes the latest state saved by the agent in the invocation into v.
+// It reports whether a state was found.
+func Load(ctx agent.InvocationContext, v any) (bool, error) {
+	ev := latest(ctx, func(ev *session.Event) bool { return ev.Actions.AgentState != nil })
+	if ev == nil {
+		return false, nil
+	}
+	data, err := json.Marshal(ev.Actions.AgentState)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("invalid state of agent %q: %w", ctx.Agent().Name(), err)
+	}
+	return true, nil
+}
+
+// LastEvent returns the last event of the agent in the invocation matching
+// the filter, or nil.
+func LastEvent(ctx agent.InvocationContext, match func(*session.Event) bool) *session.Event {
+	return latest(ctx, match)
+}
+
+// latest returns the latest event of the agent in the invocation matching
+// the filter, or nil.
+//
+// Only the events since the latest checkpoint of an ancestor agent count:
+// the ancestor has run the agent again after that, e.g. in a new loop
+// iteration.
+func latest(ctx agent.InvocationContext, match func(*session.Event) bool) *session.Event {
+	if ctx.Session() == nil {
+		return nil
+	}
+	name := ctx.Agent().Name()
+	ancestors := make(map[string]bool)
+	parents := parentmap.FromContext(ctx)
+	for p := parents[name]; p != nil; p = parents[p.Name()] {
+		ancestors[p.Name()] = true
+	}
+
+	events := ctx.Session().Events()
+	for i := events.Len() - 1; i >= 0; i-- {
+		ev := events.At(i)
+		if ev.InvocationID != ctx.InvocationID() {
+			continue
+		}
+		if ev.Author == name && match(ev) {
+			return ev
+		}
+		if ancestors[ev.Author] && ev.Actions.AgentState != nil {
+			return nil
+		}
+	}
+	return nil
+}
@@ -14,7 +14,13 @@
 
 package runconfig
 
-import "context"
+import (
+	"context"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/budget"
+	"google.golang.org/adk/model"
+)
 
 type StreamingMode string
 
@@ -26,6 +32,20 @@ const (
 
 type RunConfig struct {
 	StreamingMode StreamingMode
+	// LiveRequestQueue holds the requests of the client in bidi streaming
+	// mode.
+	LiveRequestQueue *agent.LiveRequestQueue
+
+	// Resumable enables the checkpoints of the agents' progress in the
+	// session.
+	Resumable bool
+	// Model overrides the models of the LLM agents.
+	Model model.LLM
+	// ContextCache is the context caching of the LLM agents which don't
+	// configure their own.
+	ContextCache *model.ContextCacheConfig
+	// Budget tracks the usage of the invocation when the run has a budget.
+	Budget *budget.Tracker
 }
 
 func ToContext(ctx context.Context, cfg *RunConfig) context.Context {
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"google.golang.org/adk/auth"
+)
+
+// Credentials gives access to the credentials of the user of the current
+// session.
+type Credentials struct {
+	Service auth.CredentialService
+	AppName string
+	UserID  string
+}
+
+// Load returns the credential described by the config.
+//
+// Stored credentials are refreshed when expired. Credentials that don't need
+// user interaction are exchanged and stored. It returns nil if the user has to
+// grant the access first.
+func (c *Credentials) Load(ctx context.Context, cfg *auth.Config) (*auth.Credential, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	key := cfg.Key()
+
+	resp, err := c.Service.Load(ctx, &auth.LoadRequest{AppName: c.AppName, UserID: c.UserID, Key: key})
+	switch {
+	case err == nil:
+		cred := resp.Credential
+		if !cred.Expired() {
+			return cred, nil
+		}
+		refreshed, err := auth.Refresh(ctx, cred)
+		if err != nil {
+			if !cfg.RawCredential.RequiresUserAuth() {
+				return nil, err
+			}
+			// The user has to grant the access again.
+			if err := c.Service.Delete(ctx, &auth.DeleteRequest{AppName: c.AppName, UserID: c.UserID, Key: key}); err != nil {
+				return nil, fmt.Errorf("failed to delete expired credential: %w", err)
+			}
+			return nil, nil
+		}
+		return refreshed, c.save(ctx, key, refreshed)
+	case errors.Is(err, fs.ErrNotExist):
+	default:
+		return nil, fmt.Errorf("failed to load credential: %w", err)
+	}
+
+	if cred := cfg.ExchangedCredential; cred.Token() != nil && !cred.Expired() {
+		return cred, nil
+	}
+	if cfg.RawCredential.RequiresUserAuth() {
+		return nil, nil
+	}
+	cred, err := auth.Exchange(ctx, cfg.RawCredential)
+	if err != nil {
+		return nil, err
+	}
+	return cred, c.save(ctx, key, cred)
+}
+
+// RequestAuth generates the authorization request of the credential and
+// keeps it until the client sends back the authorization response.
+//
+// The request holds the raw credential, e.g. the OAuth2 client secret, so it
+// stays server-side. The returned config is what is sent to the client: the
+// credential key and, for OAuth2 credentials, the consent page URL and the
+// state.
+func (c *Credentials) RequestAuth(ctx context.Context, cfg *auth.Config) (*auth.Config, error) {
+	req, err := auth.GenerateAuthRequest(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cred := req.ExchangedCredential
+	if cred == nil {
+		cred = req.RawCredential
+	}
+	if err := c.save(ctx, authRequestKey(req.CredentialKey), cred); err != nil {
+		return nil, err
+	}
+
+	clientCfg := &auth.Config{CredentialKey: req.CredentialKey}
+	if req.ExchangedCredential != nil {
+		clientCfg.ExchangedCredential = &auth.Credential{Type: cred.Type}
+		if o := cred.OAuth2; o != nil {
+			clientCfg.ExchangedCredential.OAuth2 = &auth.OAuth2{AuthURI: o.AuthURI, State: o.State}
+		}
+	}
+	return clientCfg, nil
+}
+
+// StoreAuthResponse exchanges the credential of the pending authorization
+// request with the authorization response sent by the client and stores it.
+//
+// The pending request is looked up by the credential key of the response.
+// Only the authorization response URI or code and the state are taken from
+// the client, and the state must match the one of the request: the responses
+// to requests without state are rejected.
+func (c *Credentials) StoreAuthResponse(ctx context.Context, resp *auth.Config) error {
+	key := resp.CredentialKey
+	if key == "" {
+		return fmt.Errorf("auth response has no credential key")
+	}
+	loaded, err := c.Service.Load(ctx, &auth.LoadRequest{AppName: c.AppName, UserID: c.UserID, Key: authRequestKey(key)})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("no pending auth request for credential %q", key)
+		}
+		return fmt.Errorf("failed to load auth request: %w", err)
+	}
+	cred := loaded.Credential
+	if cred.RequiresUserAuth() {
+		var got *auth.OAuth2
+		if resp.ExchangedCredential != nil {
+			got = resp.ExchangedCredential.OAuth2
+		}
+		if got == nil {
+			return fmt.Errorf("auth response for credential %q has no OAuth2 authorization response", key)
+		}
+		if got.State == "" || got.State != cred.OAuth2.State {
+			return fmt.Errorf("auth response state for credential %q does not match the request", key)
+		}
+		cred.OAuth2.AuthResponseURI = got.AuthResponseURI
+		cred.OAuth2.AuthCode = got.AuthCode
+	}
+	if cred.Token() == nil {
+		if cred, err = auth.Exchange(ctx, cred); err != nil {
+			return err
+		}
+	}
+	if err := c.save(ctx, key, cred); err != nil {
+		return err
+	}
+	if err := c.Service.Delete(ctx, &auth.DeleteRequest{AppName: c.AppName, UserID: c.UserID, Key: authRequestKey(key)}); err != nil {
+		return fmt.Errorf("failed to delete auth request: %w", err)
+	}
+	return nil
+}
+
+// authRequestKey returns the key of the pending authorization request of the
+// credential in the credential service.
+func authRequestKey(key string) string {
+	return key + "_auth_request"
+}
+
+func (c *Credentials) save(ctx context.Context, key string, cred *auth.Credential) error {
+	if err := c.Service.Save(ctx, &auth.SaveRequest{AppName: c.AppName, UserID: c.UserID, Key: key, Credential: cred}); err != nil {
+		return fmt.Errorf("failed to save credential: %w", err)
+	}
+	return nil
+}
+
+// ToContext returns a context carrying the credentials.
+func ToContext(ctx context.Context, c *Credentials) context.Context {
+	return context.WithValue(ctx, credentialsCtxKey, c)
+}
+
+// FromContext returns the credentials carried by the context, or nil.
+func FromContext(ctx context.Context) *Credentials {
+	c, ok := ctx.Value(credentialsCtxKey).(*Credentials)
+	if !ok {
+		return nil
+	}
+	return c
+}
+
+type ctxKey int
+
+const credentialsCtxKey ctxKey = 0
@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+	authinternal "google.golang.org/adk/internal/auth"
+	"google.golang.org/adk/internal/testutil"
+)
+
+func newCredentials() *authinternal.Credentials {
+	return &authinternal.Credentials{
+		Service: auth.InMemoryCredentialService(),
+		AppName: "app",
+		UserID:  "user",
+	}
+}
+
+func TestLoad_ClientCredentials(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	credentials := newCredentials()
+	cfg := &auth.Config{
+		RawCredential: &auth.Credential{
+			Type: auth.CredentialTypeClientCredentials,
+			OAuth2: &auth.OAuth2{
+				ClientID:     s.ClientID,
+				ClientSecret: s.ClientSecret,
+				TokenURL:     s.TokenURL(),
+			},
+		},
+	}
+
+	first, err := credentials.Load(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !s.ValidAccessToken(first.OAuth2.AccessToken) {
+		t.Fatalf("Load() returned an invalid access token %q", first.OAuth2.AccessToken)
+	}
+	// The exchanged credential is stored and reused.
+	second, err := credentials.Load(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if diff := cmp.Diff(first, second); diff != "" {
+		t.Errorf("second Load() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"client_credentials"}, s.GrantTypes()); diff != "" {
+		t.Errorf("unexpected grant types (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoad_AuthorizationCode(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	credentials := newCredentials()
+	cfg := &auth.Config{
+		RawCredential: &auth.Credential{
+			Type: auth.CredentialTypeOAuth2,
+			OAuth2: &auth.OAuth2{
+				ClientID:         s.ClientID,
+				ClientSecret:     s.ClientSecret,
+				AuthorizationURL: s.AuthorizationURL(),
+				TokenURL:         s.TokenURL(),
+				RedirectURI:      "http://localhost/callback",
+			},
+		},
+	}
+
+	cred, err := credentials.Load(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cred != nil {
+		t.Fatalf("Load() before the user authorization = %+v, want nil", cred)
+	}
+
+	authResp, err := credentials.RequestAuth(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RequestAuth() error = %v", err)
+	}
+	if authResp.RawCredential != nil || authResp.ExchangedCredential.OAuth2.ClientSecret != "" {
+		t.Fatalf("RequestAuth() returned the client secret to the client: %+v", authResp)
+	}
+	authResp.ExchangedCredential.OAuth2.AuthResponseURI = s.Authorize(t, authResp.ExchangedCredential.OAuth2.AuthURI)
+	// The client configuration sent back by the client is ignored.
+	authResp.ExchangedCredential.OAuth2.TokenURL = "http://attacker.invalid/token"
+	if err := credentials.StoreAuthResponse(t.Context(), authResp); err != nil {
+		t.Fatalf("StoreAuthResponse() error = %v", err)
+	}
+
+	cred, err = credentials.Load(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cred == nil || !s.ValidAccessToken(cred.OAuth2.AccessToken) {
+		t.Fatalf("Load() after the user authorization = %+v, want a valid access token", cred)
+	}
+
+	// An expired token is refreshed.
+	expired := *cred.OAuth2
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	if err := credentials.Service.Save(t.Context(), &auth.SaveRequest{
+		AppName: "app", UserID: "user", Key: cfg.Key(),
+		Credential: &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &expired},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := credentials.Load(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if refreshed == nil || refreshed.OAuth2.AccessToken == cred.OAuth2.AccessToken || !s.ValidAccessToken(refreshed.OAuth2.AccessToken) {
+		t.Fatalf("Load() of an expired credential = %+v, want a new valid access token", refreshed)
+	}
+
+	// If the refresh fails, the user has to authorize again.
+	expired = *refreshed.OAuth2
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	expired.RefreshToken = "revoked"
+	if err := credentials.Service.Save(t.Context(), &auth.SaveRequest{
+		AppName: "app", UserID: "user", Key: cfg.Key(),
+		Credential: &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &expired},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cred, err = credentials.Load(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cred != nil {
+		t.Errorf("Load() with a revoked refresh token = %+v, want nil", cred)
+	}
+}
+
+func TestStoreAuthResponse_Invalid(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	cfg := &auth.Config{
+		RawCredential: &auth.Credential{
+			Type: auth.CredentialTypeOAuth2,
+			OAuth2: &auth.OAuth2{
+				ClientID:         s.ClientID,
+				ClientSecret:     s.ClientSecret,
+				AuthorizationURL: s.AuthorizationURL(),
+				TokenURL:         s.TokenURL(),
+				RedirectURI:      "http://localhost/callback",
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(resp *auth.Config)
+	}{
+		{
+			name: "unknown credential key",
+			modify: func(resp *auth.Config) {
+				resp.CredentialKey = "other"
+			},
+		},
+		{
+			name: "state mismatch",
+			modify: func(resp *auth.Config) {
+				resp.ExchangedCredential.OAuth2.State = "forged"
+			},
+		},
+		{
+			name: "empty state",
+			modify: func(resp *auth.Config) {
+				resp.ExchangedCredential.OAuth2.State = ""
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			credentials := newCredentials()
+			authResp, err := credentials.RequestAuth(t.Context(), cfg)
+			if err != nil {
+				t.Fatalf("RequestAuth() error = %v", err)
+			}
+			tc.modify(authResp)
+			if err := credentials.StoreAuthResponse(t.Context(), authResp); err == nil {
+				t.Error("StoreAuthResponse() succeeded, want error")
+			}
+			if _, err := credentials.Service.Load(t.Context(), &auth.LoadRequest{AppName: "app", UserID: "user", Key: cfg.Key()}); err == nil {
+				t.Error("StoreAuthResponse() stored a credential")
+			}
+		})
+	}
+}
+
+func TestStoreAuthResponse_RequestWithoutState(t *testing.T) {
+	s := testutil.NewFakeOAuthServer(t)
+	// The consent page URL of the tool has no state.
+	authURI := s.AuthorizationURL() + "?" + url.Values{
+		"client_id":     {s.ClientID},
+		"response_type": {"code"},
+		"redirect_uri":  {"http://localhost/callback"},
+	}.Encode()
+	oauth2 := auth.OAuth2{
+		ClientID:         s.ClientID,
+		ClientSecret:     s.ClientSecret,
+		AuthorizationURL: s.AuthorizationURL(),
+		TokenURL:         s.TokenURL(),
+		RedirectURI:      "http://localhost/callback",
+	}
+	exchanged := oauth2
+	exchanged.AuthURI = authURI
+	cfg := &auth.Config{
+		RawCredential:       &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &oauth2},
+		ExchangedCredential: &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &exchanged},
+	}
+	credentials := newCredentials()
+	authResp, err := credentials.RequestAuth(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("RequestAuth() error = %v", err)
+	}
+	authResp.ExchangedCredential.OAuth2.AuthResponseURI = s.Authorize(t, authURI)
+	if err := credentials.StoreAuthResponse(t.Context(), authResp); err == nil {
+		t.Error("StoreAuthResponse() succeeded, want error")
+	}
+}
+
+func TestLoad_InvalidConfig(t *testing.T) {
+	if _, err := newCredentials().Load(t.Context(), &auth.Config{}); err == nil {
+		t.Error("Load() with an invalid config succeeded, want error")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package budget tracks the usage of the LLM calls of an invocation against
+// its [agent.Budget].
+package budget
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Tracker tracks the usage of an invocation. It is safe for concurrent use
+// by the agents of the invocation.
+type Tracker struct {
+	budget agent.Budget
+
+	mu sync.Mutex
+	// session is the usage of the session, including the invocation.
+	session    agent.Usage
+	invocation agent.Usage
+}
+
+// NewTracker returns the tracker of an invocation of the session with the
+// given state, which holds the usage of the previous invocations.
+func NewTracker(budget *agent.Budget, state session.ReadonlyState) *Tracker {
+	t := &Tracker{budget: *budget}
+	if v, err := state.Get(agent.UsageStateKey); err == nil {
+		t.session = FromStateValue(v)
+	}
+	return t
+}
+
+// StartCall counts a new LLM call. It returns an error, without counting
+// the call, if a limit of the budget has been reached.
+func (t *Tracker) StartCall() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := exceeded("invocation", t.invocation, t.budget.MaxInvocation); err != nil {
+		return err
+	}
+	if err := exceeded("session", t.session, t.budget.MaxSession); err != nil {
+		return err
+	}
+	t.invocation.LLMCalls++
+	t.session.LLMCalls++
+	return nil
+}
+
+// Record adds the usage of an LLM call response and returns the usage of the
+// invocation and the session.
+func (t *Tracker) Record(modelName string, usage *genai.GenerateContentResponseUsageMetadata) (invocation, session agent.Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if usage != nil {
+		var cost float64
+		if t.budget.Cost != nil {
+			cost = t.budget.Cost(modelName, usage)
+		}
+		for _, u := range []*agent.Usage{&t.invocation, &t.session} {
+			u.PromptTokens += int64(usage.PromptTokenCount)
+			u.OutputTokens += int64(usage.CandidatesTokenCount)
+			u.Cost += cost
+		}
+	}
+	return t.invocation, t.session
+}
+
+// exceeded returns an error if the usage reached one of the limits.
+func exceeded(scope string, usage, limits agent.Usage) error {
+	switch {
+	case limits.LLMCalls > 0 && usage.LLMCalls >= limits.LLMCalls:
+		return fmt.Errorf("%s budget of %d LLM calls exceeded", scope, limits.LLMCalls)
+	case limits.PromptTokens > 0 && usage.PromptTokens >= limits.PromptTokens:
+		return fmt.Errorf("%s budget of %d prompt tokens exceeded: %d used", scope, limits.PromptTokens, usage.PromptTokens)
+	case limits.OutputTokens > 0 && usage.OutputTokens >= limits.OutputTokens:
+		return fmt.Errorf("%s budget of %d output tokens exceeded: %d used", scope, limits.OutputTokens, usage.OutputTokens)
+	case limits.Cost > 0 && usage.Cost >= limits.Cost:
+		return fmt.Errorf("%s budget of %g cost exceeded: %g used", scope, limits.Cost, usage.Cost)
+	}
+	return nil
+}
+
+// ToStateValue returns the usage as a session state value, which can be
+// stored as JSON by the session services.
+func ToStateValue(u agent.Usage) map[string]any {
+	return map[string]any{
+		"prompt_tokens": u.PromptTokens,
+		"output_tokens": u.OutputTokens,
+		"llm_calls":     u.LLMCalls,
+		"cost":          u.Cost,
+	}
+}
+
+// FromStateValue returns the usage of a session state value set with
+// [ToStateValue], possibly after a JSON round trip.
+func FromStateValue(v any) agent.Usage {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return agent.Usage{}
+	}
+	return agent.Usage{
+		PromptTokens: int64(number(m["prompt_tokens"])),
+		OutputTokens: int64(number(m["output_tokens"])),
+		LLMCalls:     int64(number(m["llm_calls"])),
+		Cost:         number(m["cost"]),
+	}
+}
+
+func number(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package budget
+
+import (
+	"encoding/json"
+	"iter"
+	"maps"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestTracker(t *testing.T) {
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 100, CandidatesTokenCount: 10}
+
+	tests := []struct {
+		name      string
+		budget    agent.Budget
+		previous  *agent.Usage
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "llm calls",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{LLMCalls: 2}},
+			wantCalls: 2,
+			wantErr:   "invocation budget of 2 LLM calls exceeded",
+		},
+		{
+			name:      "prompt tokens",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{PromptTokens: 250}},
+			wantCalls: 3,
+			wantErr:   "invocation budget of 250 prompt tokens exceeded: 300 used",
+		},
+		{
+			name:      "output tokens",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{OutputTokens: 10}},
+			wantCalls: 1,
+			wantErr:   "invocation budget of 10 output tokens exceeded: 10 used",
+		},
+		{
+			name: "cost",
+			budget: agent.Budget{
+				MaxInvocation: agent.Usage{Cost: 1},
+				Cost: func(modelName string, usage *genai.GenerateContentResponseUsageMetadata) float64 {
+					return float64(usage.PromptTokenCount) / 200
+				},
+			},
+			wantCalls: 2,
+			wantErr:   "invocation budget of 1 cost exceeded: 1 used",
+		},
+		{
+			name:      "session",
+			budget:    agent.Budget{MaxSession: agent.Usage{LLMCalls: 3}},
+			previous:  &agent.Usage{LLMCalls: 2},
+			wantCalls: 1,
+			wantErr:   "session budget of 3 LLM calls exceeded",
+		},
+		{
+			name:      "session and invocation",
+			budget:    agent.Budget{MaxInvocation: agent.Usage{PromptTokens: 1000}, MaxSession: agent.Usage{PromptTokens: 1000}},
+			previous:  &agent.Usage{PromptTokens: 800},
+			wantCalls: 2,
+			wantErr:   "session budget of 1000 prompt tokens exceeded: 1000 used",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := stateMap{}
+			if tt.previous != nil {
+				state[agent.UsageStateKey] = ToStateValue(*tt.previous)
+			}
+			tracker := NewTracker(&tt.budget, state)
+
+			calls := 0
+			var err error
+			for range 10 {
+				if err = tracker.StartCall(); err != nil {
+					break
+				}
+				calls++
+				tracker.Record("model", usage)
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("StartCall() error = %v, want %q", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTracker_Record(t *testing.T) {
+	budget := &agent.Budget{
+		Cost: func(modelName string, usage *genai.GenerateContentResponseUsageMetadata) float64 {
+			if modelName != "model" {
+				t.Errorf("Cost() got model %q, want %q", modelName, "model")
+			}
+			return 0.5
+		},
+	}
+	tracker := NewTracker(budget, stateMap{agent.UsageStateKey: ToStateValue(agent.Usage{PromptTokens: 1000, OutputTokens: 100, LLMCalls: 5, Cost: 2})})
+
+	if err := tracker.StartCall(); err != nil {
+		t.Fatal(err)
+	}
+	invocation, session := tracker.Record("model", &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 100, CandidatesTokenCount: 10})
+
+	if diff := cmp.Diff(agent.Usage{PromptTokens: 100, OutputTokens: 10, LLMCalls: 1, Cost: 0.5}, invocation); diff != "" {
+		t.Errorf("invocation usage mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(agent.Usage{PromptTokens: 1100, OutputTokens: 110, LLMCalls: 6, Cost: 2.5}, session); diff != "" {
+		t.Errorf("session usage mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStateValue_JSON(t *testing.T) {
+	want := agent.Usage{PromptTokens: 1100, OutputTokens: 110, LLMCalls: 6, Cost: 2.5}
+	data, err := json.Marshal(ToStateValue(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, FromStateValue(v)); diff != "" {
+		t.Errorf("FromStateValue() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type stateMap map[string]any
+
+func (s stateMap) Get(key string) (any, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return v, nil
+}
+
+func (s stateMap) All() iter.Seq2[string, any] {
+	return maps.All(s)
+}
@@ -15,8 +15,11 @@
 package llminternal
 
 import (
+	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
 	"google.golang.org/adk/tool"
 	"google.golang.org/genai"
 )
@@ -35,6 +38,7 @@ type State struct {
 	IncludeContents string
 
 	GenerateContentConfig *genai.GenerateContentConfig
+	ContextCache          *model.ContextCacheConfig
 
 	Instruction               string
 	InstructionProvider       InstructionProvider
@@ -46,8 +50,18 @@ type State struct {
 
 	InputSchema  *genai.Schema
 	OutputSchema *genai.Schema
+	// InputJSONSchema and OutputJSONSchema are alternatives to InputSchema
+	// and OutputSchema.
+	InputJSONSchema  *jsonschema.Resolved
+	OutputJSONSchema *jsonschema.Resolved
 
 	OutputKey string
+	// OutputRetries is the number of times the model is asked again for a
+	// reply matching the output schema.
+	OutputRetries int
+
+	CodeExecutor codeexecutor.CodeExecutor
+	Planner      planner.Planner
 }
 
 type InstructionProvider func(ctx agent.ReadonlyContext) (string, error)
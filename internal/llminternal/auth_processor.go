@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	authinternal "google.golang.org/adk/internal/auth"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// authToolArguments are the arguments of the adk_request_credential function
+// call. The auth config is the client part of the authorization request, see
+// authinternal.Credentials.RequestAuth.
+type authToolArguments struct {
+	FunctionCallID string       `json:"functionCallId"`
+	AuthConfig     *auth.Config `json:"authConfig"`
+}
+
+// generateAuthEvent returns the event requesting the credentials asked for
+// by the tools in the function response event, or nil if no credential was
+// requested.
+//
+// The event has an adk_request_credential function call for every requested
+// credential. The calls are long-running, so the event ends the invocation
+// until the client sends back the function responses with the authorization
+// responses.
+func generateAuthEvent(ctx agent.InvocationContext, fnResponseEvent *session.Event) (*session.Event, error) {
+	configs := fnResponseEvent.Actions.RequestedAuthConfigs
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	var parts []*genai.Part
+	for _, fnResponse := range utils.FunctionResponses(fnResponseEvent.LLMResponse.Content) {
+		cfg, ok := configs[fnResponse.ID]
+		if !ok {
+			continue
+		}
+		args, err := toMap(&authToolArguments{FunctionCallID: fnResponse.ID, AuthConfig: cfg})
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, &genai.Part{
+			FunctionCall: &genai.FunctionCall{Name: requestEUCFunctionCallName, Args: args},
+		})
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	content := &genai.Content{Role: genai.RoleModel, Parts: parts}
+	utils.PopulateClientFunctionCallID(content)
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.LLMResponse = model.LLMResponse{Content: content}
+	for _, fnCall := range utils.FunctionCalls(content) {
+		ev.LongRunningToolIDs = append(ev.LongRunningToolIDs, fnCall.ID)
+	}
+	return ev, nil
+}
+
+// resumeAuthorizedFunctionCalls handles the authorization responses sent by
+// the client for the adk_request_credential function calls. It stores the
+// credentials and calls again the tools that requested them, yielding their
+// function response event.
+//
+// It only acts at the beginning of an invocation started by the function
+// responses.
+func (f *Flow) resumeAuthorizedFunctionCalls(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+	// reference: adk-python src/google/adk/auth/auth_preprocessor.py
+
+	return func(yield func(*session.Event, error) bool) {
+		if ctx.Session() == nil {
+			return
+		}
+		events := ctx.Session().Events()
+		if events.Len() == 0 {
+			return
+		}
+		lastEvent := events.At(events.Len() - 1)
+		if lastEvent.Author != "user" {
+			return
+		}
+
+		authCallIDs := make(map[string]bool)
+		for _, fnResponse := range utils.FunctionResponses(lastEvent.LLMResponse.Content) {
+			if fnResponse.Name != requestEUCFunctionCallName {
+				continue
+			}
+			var cfg auth.Config
+			if err := fromMap(fnResponse.Response, &cfg); err != nil {
+				yield(nil, fmt.Errorf("invalid auth response: %w", err))
+				return
+			}
+			credentials := authinternal.FromContext(ctx)
+			if credentials == nil {
+				yield(nil, fmt.Errorf("credential service is not configured"))
+				return
+			}
+			if err := credentials.StoreAuthResponse(ctx, &cfg); err != nil {
+				yield(nil, fmt.Errorf("failed to store auth response: %w", err))
+				return
+			}
+			authCallIDs[fnResponse.ID] = true
+		}
+		if len(authCallIDs) == 0 {
+			return
+		}
+
+		// Find the adk_request_credential function calls, and then the
+		// original function calls of the tools that requested the
+		// credentials.
+		toolCallIDs := make(map[string]bool)
+		for i := events.Len() - 2; i >= 0; i-- {
+			ev := events.At(i)
+			fnCalls := utils.FunctionCalls(ev.LLMResponse.Content)
+			if len(toolCallIDs) == 0 {
+				for _, fnCall := range fnCalls {
+					if !authCallIDs[fnCall.ID] {
+						continue
+					}
+					var args authToolArguments
+					if err := fromMap(fnCall.Args, &args); err != nil {
+						yield(nil, fmt.Errorf("invalid %s function call: %w", requestEUCFunctionCallName, err))
+						return
+					}
+					toolCallIDs[args.FunctionCallID] = true
+				}
+				continue
+			}
+			if !slices.ContainsFunc(fnCalls, func(fnCall *genai.FunctionCall) bool { return toolCallIDs[fnCall.ID] }) {
+				continue
+			}
+
+			tools, err := f.toolsByName(ctx)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			fnResponseEvent, err := f.handleFunctionCalls(ctx, tools, &ev.LLMResponse, toolCallIDs)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if fnResponseEvent == nil {
+				return
+			}
+			if !yield(fnResponseEvent, nil) {
+				return
+			}
+			// The tools may request the credentials again, e.g. if the
+			// user didn't grant the access.
+			authEvent, err := generateAuthEvent(ctx, fnResponseEvent)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if authEvent != nil {
+				yield(authEvent, nil)
+			}
+			return
+		}
+	}
+}
+
+// toolsByName returns the tools of the agent, including the tools of its
+// toolsets, by name.
+func (f *Flow) toolsByName(ctx agent.InvocationContext) (map[string]tool.Tool, error) {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil {
+		return nil, fmt.Errorf("agent %v is not an LLMAgent", ctx.Agent().Name())
+	}
+	tools, err := canonicalTools(ctx, llmAgent)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]tool.Tool, len(tools))
+	for _, t := range tools {
+		m[t.Name()] = t
+	}
+	return m, nil
+}
+
+func toMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func fromMap(m map[string]any, v any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
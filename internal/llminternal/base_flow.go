@@ -18,9 +18,13 @@ import (
 	"fmt"
 	"iter"
 	"maps"
+	"reflect"
 	"slices"
 
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/checkpoint"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
@@ -50,12 +54,22 @@ type Flow struct {
 	AfterModelCallbacks  []AfterModelCallback
 	BeforeToolCallbacks  []BeforeToolCallback
 	AfterToolCallbacks   []AfterToolCallback
+
+	// MaxConcurrentToolCalls limits the number of function calls of a
+	// model response running concurrently. Zero runs them sequentially, a
+	// negative value means no limit.
+	MaxConcurrentToolCalls int
+	// MaxContinuations is the number of continuation requests sent to the
+	// model when its response is truncated.
+	MaxContinuations int
+	// SelectCandidate picks the candidate of the model responses with
+	// several candidates. The first candidate is picked if nil.
+	SelectCandidate CandidateSelector
 }
 
 var (
 	DefaultRequestProcessors = []func(ctx agent.InvocationContext, req *model.LLMRequest) error{
 		basicRequestProcessor,
-		authPreprocessor,
 		instructionsRequestProcessor,
 		identityRequestProcessor,
 		ContentsRequestProcessor,
@@ -66,6 +80,7 @@ var (
 		// to optimize data files.
 		codeExecutionRequestProcessor,
 		AgentTransferRequestProcessor,
+		outputSchemaRequestProcessor,
 		removeDisplayNameIfExists,
 	}
 	DefaultResponseProcessors = []func(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error{
@@ -75,10 +90,18 @@ var (
 )
 
 func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+	if cfg := runconfig.FromContext(ctx); cfg != nil && cfg.StreamingMode == runconfig.StreamingModeBidi {
+		return f.runLive(ctx)
+	}
 	return func(yield func(*session.Event, error) bool) {
+		// A resumed agent continues the work of its last step first.
+		step := f.runOneStep
+		if checkpoint.Resuming(ctx) {
+			step = f.resumeStep
+		}
 		for {
 			var lastEvent *session.Event
-			for ev, err := range f.runOneStep(ctx) {
+			for ev, err := range step(ctx) {
 				if err != nil {
 					yield(nil, err)
 					return
@@ -93,17 +116,38 @@ func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error]
 				return
 			}
 			if lastEvent.LLMResponse.Partial {
-				// We may have reached max token limit during streaming mode.
-				// TODO: handle Partial response in model level. CL 781377328
-				yield(nil, fmt.Errorf("TODO: last event is not final"))
+				// The truncated responses end with a final event, see
+				// callLLMWithContinuations.
+				yield(nil, fmt.Errorf("agent %q: model response ended with a partial event", ctx.Agent().Name()))
 				return
 			}
+			if checkpoint.Resuming(ctx) {
+				// The next steps are new work, e.g. the agents
+				// transferred to start afresh.
+				step, ctx = f.runOneStep, checkpoint.WithResuming(ctx, false)
+			}
 		}
 	}
 }
 
 func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
+		// Resume the function calls that were waiting for the user
+		// authorization. This needs to happen before the preprocessing, so
+		// that the function responses are included in the LLM request.
+		for ev, err := range f.resumeAuthorizedFunctionCalls(ctx) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(ev, nil) {
+				return
+			}
+			if ev.IsFinalResponse() {
+				return
+			}
+		}
+
 		req := &model.LLMRequest{}
 
 		// Preprocess before calling the LLM.
@@ -114,11 +158,19 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 		if ctx.Ended() {
 			return
 		}
+		tracker := budgetTracker(ctx)
+		if tracker != nil {
+			if err := tracker.StartCall(); err != nil {
+				ctx.EndInvocation()
+				yield(budgetExceededEvent(ctx, err), nil)
+				return
+			}
+		}
 		spans := telemetry.StartTrace(ctx, "call_llm")
 		// Create event to pass to callback state delta
 		stateDelta := make(map[string]any)
 		// Calls the LLM.
-		for resp, err := range f.callLLM(ctx, req, stateDelta) {
+		for resp, err := range f.callLLMWithContinuations(ctx, req, stateDelta) {
 			if err != nil {
 				yield(nil, err)
 				return
@@ -134,16 +186,14 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 				continue
 			}
 
-			// TODO: temporarily convert
-			tools := make(map[string]tool.Tool)
-			for k, v := range req.Tools {
-				tool, ok := v.(tool.Tool)
-				if !ok {
-					if !yield(nil, fmt.Errorf("unexpected tool type %T for tool %v", v, k)) {
-						return
-					}
-				}
-				tools[k] = tool
+			tools, err := requestTools(req)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if tracker != nil && !resp.Partial && resp.UsageMetadata != nil {
+				recordUsage(tracker, f.Model, resp, stateDelta)
 			}
 
 			// Build the event and yield.
@@ -152,11 +202,9 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			if !yield(modelResponseEvent, nil) {
 				return
 			}
-			// TODO: generate and yield an auth event if needed.
-
 			// Handle function calls.
 
-			ev, err := f.handleFunctionCalls(ctx, tools, resp)
+			ev, err := f.handleFunctionCalls(ctx, tools, resp, nil)
 			if err != nil {
 				yield(nil, err)
 				return
@@ -168,25 +216,74 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			if !yield(ev, nil) {
 				return
 			}
+			f.postprocessFunctionResponse(ctx, ev, yield)
+			return
+		}
+	}
+}
 
-			// Actually handle "transfer_to_agent" tool. The function call sets the ev.Actions.TransferToAgent field.
-			// We are followng python's execution flow which is
-			//   BaseLlmFlow._postprocess_async
-			//    -> _postprocess_handle_function_calls_async
-			// TODO(hakim): figure out why this isn't handled by the runner.
-			if ev.Actions.TransferToAgent == "" {
-				return
-			}
-			nextAgent := f.agentToRun(ctx, ev.Actions.TransferToAgent)
-			if nextAgent == nil {
-				yield(nil, fmt.Errorf("failed to find agent: %s", ev.Actions.TransferToAgent))
-				return
-			}
-			for ev, err := range nextAgent.Run(ctx) {
-				if !yield(ev, err) || err != nil { // forward
-					return
-				}
-			}
+// requestTools returns the tools of the request by name.
+func requestTools(req *model.LLMRequest) (map[string]tool.Tool, error) {
+	// TODO: temporarily convert
+	tools := make(map[string]tool.Tool)
+	for k, v := range req.Tools {
+		tool, ok := v.(tool.Tool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tool type %T for tool %v", v, k)
+		}
+		tools[k] = tool
+	}
+	return tools, nil
+}
+
+// postprocessFunctionResponse handles the actions of the function response
+// event, yielding the resulting events.
+func (f *Flow) postprocessFunctionResponse(ctx agent.InvocationContext, ev *session.Event, yield func(*session.Event, error) bool) {
+	// Request the credentials asked for by the tools. The invocation
+	// is paused until the client sends the authorization responses.
+	authEvent, err := generateAuthEvent(ctx, ev)
+	if err != nil {
+		yield(nil, err)
+		return
+	}
+	if authEvent != nil {
+		yield(authEvent, nil)
+		return
+	}
+
+	// The model replied with the set_model_response tool. Its
+	// arguments are the final response of the agent.
+	responseEvent, err := setModelResponseEvent(ctx, ev)
+	if err != nil {
+		yield(nil, err)
+		return
+	}
+	if responseEvent != nil {
+		yield(responseEvent, nil)
+		return
+	}
+
+	// Actually handle "transfer_to_agent" tool. The function call sets the ev.Actions.TransferToAgent field.
+	// We are followng python's execution flow which is
+	//   BaseLlmFlow._postprocess_async
+	//    -> _postprocess_handle_function_calls_async
+	// TODO(hakim): figure out why this isn't handled by the runner.
+	if ev.Actions.TransferToAgent == "" {
+		return
+	}
+	f.transferToAgent(ctx, ev.Actions.TransferToAgent, yield)
+}
+
+// transferToAgent runs the agent the invocation is transferred to.
+func (f *Flow) transferToAgent(ctx agent.InvocationContext, agentName string, yield func(*session.Event, error) bool) {
+	nextAgent := f.agentToRun(ctx, agentName)
+	if nextAgent == nil {
+		yield(nil, fmt.Errorf("failed to find agent: %s", agentName))
+		return
+	}
+	for ev, err := range nextAgent.Run(ctx) {
+		if !yield(ev, err) || err != nil { // forward
+			return
 		}
 	}
 }
@@ -205,17 +302,27 @@ func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) er
 	}
 
 	// run processors for tools.
-	tools := Reveal(llmAgent).Tools
+	tools, err := canonicalTools(ctx, llmAgent)
+	if err != nil {
+		return err
+	}
+
+	return toolPreprocess(ctx, req, tools)
+}
+
+// canonicalTools returns the tools of the agent, including the tools of its
+// toolsets.
+func canonicalTools(ctx agent.InvocationContext, llmAgent Agent) ([]tool.Tool, error) {
+	tools := slices.Clone(Reveal(llmAgent).Tools)
 	for _, toolSet := range Reveal(llmAgent).Toolsets {
 		tsTools, err := toolSet.Tools(icontext.NewReadonlyContext(ctx))
 		if err != nil {
-			return fmt.Errorf("failed to extract tools from the tool set %q: %w", toolSet.Name(), err)
+			return nil, fmt.Errorf("failed to extract tools from the tool set %q: %w", toolSet.Name(), err)
 		}
 
 		tools = append(tools, tsTools...)
 	}
-
-	return toolPreprocess(ctx, req, tools)
+	return tools, nil
 }
 
 // toolPreprocess runs tool preprocess on the given request
@@ -256,10 +363,15 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 		// TODO: Set _ADK_AGENT_NAME_LABEL_KEY in req.GenerateConfig.Labels
 		// to help with slicing the billing reports on a per-agent basis.
 
-		// TODO: RunLive mode when invocation_context.run_config.support_cfc is true.
 		useStream := runconfig.FromContext(ctx).StreamingMode == runconfig.StreamingModeSSE
 
 		for resp, err := range f.Model.GenerateContent(ctx, req, useStream) {
+			if err == nil {
+				if resp, err = f.selectCandidate(ctx, resp, stateDelta); err != nil {
+					yield(nil, err)
+					return
+				}
+			}
 			callbackResp, callbackErr := f.runAfterModelCallbacks(ctx, resp, stateDelta, err)
 			// TODO: check if we should stop iterator on the first error from stream or continue yielding next results.
 			if callbackErr != nil {
@@ -358,14 +470,18 @@ func findLongRunningFunctionCallIDs(c *genai.Content, tools map[string]tool.Tool
 }
 
 // handleFunctionCalls calls the functions and returns the function response event.
+// If callIDs is not nil, only the function calls with the given IDs are handled.
 //
-// TODO: accept filters to include/exclude function calls.
-// TODO: check feasibility of running tool.Run concurrently.
-func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse) (*session.Event, error) {
-	var fnResponseEvents []*session.Event
-
-	fnCalls := utils.FunctionCalls(resp.Content)
-	for _, fnCall := range fnCalls {
+// The functions are called sequentially, or concurrently up to
+// f.MaxConcurrentToolCalls at a time. Each call has its own tool context,
+// and the function responses are merged in the order of the function calls.
+func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse, callIDs map[string]bool) (*session.Event, error) {
+	var fnCalls []*genai.FunctionCall
+	var funcTools []toolinternal.FunctionTool
+	for _, fnCall := range utils.FunctionCalls(resp.Content) {
+		if callIDs != nil && !callIDs[fnCall.ID] {
+			continue
+		}
 		curTool, ok := toolsDict[fnCall.Name]
 		if !ok {
 			return nil, fmt.Errorf("unknown tool: %q", fnCall.Name)
@@ -374,35 +490,27 @@ func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[st
 		if !ok {
 			return nil, fmt.Errorf("tool %q is not a function tool", curTool.Name())
 		}
-		toolCtx := toolinternal.NewToolContext(ctx, fnCall.ID, &session.EventActions{StateDelta: make(map[string]any)})
-		//toolCtx := tool.
-		spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
-
-		result := f.callTool(funcTool, fnCall.Args, toolCtx)
-
-		// TODO: agent.canonical_after_tool_callbacks
-		// TODO: handle long-running tool.
-		ev := session.NewEvent(ctx.InvocationID())
-		ev.LLMResponse = model.LLMResponse{
-			Content: &genai.Content{
-				Role: "user",
-				Parts: []*genai.Part{
-					{
-						FunctionResponse: &genai.FunctionResponse{
-							ID:       fnCall.ID,
-							Name:     fnCall.Name,
-							Response: result,
-						},
-					},
-				},
-			},
-		}
-		ev.Author = ctx.Agent().Name()
-		ev.Branch = ctx.Branch()
-		ev.Actions = *toolCtx.Actions()
-		telemetry.TraceToolCall(spans, curTool, fnCall.Args, ev)
-		fnResponseEvents = append(fnResponseEvents, ev)
+		fnCalls = append(fnCalls, fnCall)
+		funcTools = append(funcTools, funcTool)
+	}
+
+	fnResponseEvents := make([]*session.Event, len(fnCalls))
+	var g errgroup.Group
+	if f.MaxConcurrentToolCalls == 0 {
+		g.SetLimit(1)
+	} else {
+		g.SetLimit(f.MaxConcurrentToolCalls)
+	}
+	for i, fnCall := range fnCalls {
+		g.Go(func() error {
+			fnResponseEvents[i] = f.callFunction(ctx, funcTools[i], fnCall)
+			return nil
+		})
 	}
+	_ = g.Wait()
+	// The long-running tools may not respond yet.
+	fnResponseEvents = slices.DeleteFunc(fnResponseEvents, func(ev *session.Event) bool { return ev == nil })
+
 	mergedEvent, err := mergeParallelFunctionResponseEvents(fnResponseEvents)
 	if err != nil {
 		return mergedEvent, err
@@ -413,6 +521,45 @@ func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[st
 	return mergedEvent, nil
 }
 
+// callFunction calls the function tool and returns the function response
+// event. It is safe to call concurrently.
+//
+// A long-running tool may return a nil result, when it has no status to
+// report yet. There is no function response event then, so the function call
+// event ends the invocation. The client sends the function response when the
+// operation completes.
+func (f *Flow) callFunction(ctx agent.InvocationContext, funcTool toolinternal.FunctionTool, fnCall *genai.FunctionCall) *session.Event {
+	toolCtx := toolinternal.NewToolContext(ctx, fnCall.ID, &session.EventActions{StateDelta: make(map[string]any)})
+	spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
+
+	result := f.callTool(funcTool, fnCall.Args, toolCtx)
+	if result == nil && funcTool.IsLongRunning() {
+		return nil
+	}
+
+	// TODO: agent.canonical_after_tool_callbacks
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role: "user",
+			Parts: []*genai.Part{
+				{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:       fnCall.ID,
+						Name:     fnCall.Name,
+						Response: result,
+					},
+				},
+			},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Actions = *toolCtx.Actions()
+	telemetry.TraceToolCall(spans, funcTool, fnCall.Args, ev)
+	return ev
+}
+
 func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) map[string]any {
 	// If the result is present, it will be used instead of calling the actual tool.
 	result, err := f.invokeBeforeToolCallbacks(tool, fArgs, toolCtx)
@@ -480,7 +627,10 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 			continue
 		}
 		parts = append(parts, ev.LLMResponse.Content.Parts...)
-		actions = mergeEventActions(actions, &ev.Actions)
+		var err error
+		if actions, err = mergeEventActions(actions, &ev.Actions); err != nil {
+			return nil, err
+		}
 	}
 	// reuse events[0]
 	ev := events[0]
@@ -494,29 +644,52 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 	return ev, nil
 }
 
-func mergeEventActions(base, other *session.EventActions) *session.EventActions {
+// mergeEventActions merges the actions of the other function response into
+// the base actions.
+//
+// The state and artifact deltas are merged per key. It is an error for
+// parallel function calls to set a state key to different values, or to
+// transfer to different agents. For the artifacts, the latest version wins.
+func mergeEventActions(base, other *session.EventActions) (*session.EventActions, error) {
 	// flows/llm_flows/functions.py merge_parallel_function_response_events
-	//
-	// TODO: merge_parallel_function_response_events creates a "last one wins" scenario
-	// except parts and requested_auth_configs. Check with the ADK team about
-	// the intention.
 	if other == nil {
-		return base
+		return base, nil
 	}
 	if base == nil {
-		return other
+		return other, nil
 	}
 	if other.SkipSummarization {
 		base.SkipSummarization = true
 	}
 	if other.TransferToAgent != "" {
+		if base.TransferToAgent != "" && base.TransferToAgent != other.TransferToAgent {
+			return nil, fmt.Errorf("parallel function calls transfer to different agents: %q and %q", base.TransferToAgent, other.TransferToAgent)
+		}
 		base.TransferToAgent = other.TransferToAgent
 	}
 	if other.Escalate {
 		base.Escalate = true
 	}
-	if other.StateDelta != nil {
-		base.StateDelta = other.StateDelta
+	for key, val := range other.StateDelta {
+		if baseVal, ok := base.StateDelta[key]; ok && !reflect.DeepEqual(baseVal, val) {
+			return nil, fmt.Errorf("parallel function calls set conflicting values for state key %q", key)
+		}
+		if base.StateDelta == nil {
+			base.StateDelta = make(map[string]any)
+		}
+		base.StateDelta[key] = val
+	}
+	for name, version := range other.ArtifactDelta {
+		if base.ArtifactDelta == nil {
+			base.ArtifactDelta = make(map[string]int64)
+		}
+		base.ArtifactDelta[name] = max(base.ArtifactDelta[name], version)
+	}
+	if len(other.RequestedAuthConfigs) > 0 {
+		if base.RequestedAuthConfigs == nil {
+			base.RequestedAuthConfigs = make(map[string]*auth.Config)
+		}
+		maps.Copy(base.RequestedAuthConfigs, other.RequestedAuthConfigs)
 	}
-	return base
+	return base, nil
 }
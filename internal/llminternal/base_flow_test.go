@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/session"
+)
+
+func TestMergeEventActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    *session.EventActions
+		other   *session.EventActions
+		want    *session.EventActions
+		wantErr bool
+	}{
+		{
+			name: "state deltas are merged per key",
+			base: &session.EventActions{StateDelta: map[string]any{"a": 1, "shared": "x"}},
+			other: &session.EventActions{
+				StateDelta: map[string]any{"b": 2, "shared": "x"},
+			},
+			want: &session.EventActions{StateDelta: map[string]any{"a": 1, "b": 2, "shared": "x"}},
+		},
+		{
+			name:    "conflicting state values",
+			base:    &session.EventActions{StateDelta: map[string]any{"a": 1}},
+			other:   &session.EventActions{StateDelta: map[string]any{"a": 2}},
+			wantErr: true,
+		},
+		{
+			name:  "latest artifact version wins",
+			base:  &session.EventActions{ArtifactDelta: map[string]int64{"f": 2, "g": 1}},
+			other: &session.EventActions{ArtifactDelta: map[string]int64{"f": 1, "h": 1}},
+			want:  &session.EventActions{ArtifactDelta: map[string]int64{"f": 2, "g": 1, "h": 1}},
+		},
+		{
+			name:  "flags",
+			base:  &session.EventActions{TransferToAgent: "other"},
+			other: &session.EventActions{TransferToAgent: "other", Escalate: true, SkipSummarization: true},
+			want:  &session.EventActions{TransferToAgent: "other", Escalate: true, SkipSummarization: true},
+		},
+		{
+			name:    "transfer to different agents",
+			base:    &session.EventActions{TransferToAgent: "a"},
+			other:   &session.EventActions{TransferToAgent: "b"},
+			wantErr: true,
+		},
+		{
+			name:  "nil base",
+			other: &session.EventActions{StateDelta: map[string]any{"a": 1}},
+			want:  &session.EventActions{StateDelta: map[string]any{"a": 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeEventActions(tt.base, tt.other)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergeEventActions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mergeEventActions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"reflect"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 )
@@ -36,15 +37,40 @@ func basicRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) e
 	if req.Config == nil {
 		req.Config = &genai.GenerateContentConfig{}
 	}
-	if llmAgent.internal().OutputSchema != nil {
+	switch {
+	case useSetModelResponseTool(llmAgent.internal()):
+		// The output schema is enforced with the set_model_response tool,
+		// see outputSchemaRequestProcessor.
+	case llmAgent.internal().OutputSchema != nil:
 		req.Config.ResponseSchema = llmAgent.internal().OutputSchema
 		req.Config.ResponseMIMEType = "application/json"
+	case llmAgent.internal().OutputJSONSchema != nil:
+		req.Config.ResponseJsonSchema = llmAgent.internal().OutputJSONSchema.Schema()
+		req.Config.ResponseMIMEType = "application/json"
+	}
+	req.CacheConfig = llmAgent.internal().ContextCache
+	cfg := runconfig.FromContext(ctx)
+	if req.CacheConfig == nil && cfg != nil {
+		req.CacheConfig = cfg.ContextCache
+	}
+	if cfg != nil && cfg.StreamingMode == runconfig.StreamingModeBidi {
+		req.LiveConnectConfig = liveConnectConfig(ctx.RunConfig())
 	}
-	// TODO: missing features
-	//  populate LLMRequest LiveConnectConfig setting
 	return nil
 }
 
+// liveConnectConfig returns the connection settings of the run config.
+func liveConnectConfig(cfg *agent.RunConfig) *genai.LiveConnectConfig {
+	if cfg == nil {
+		return &genai.LiveConnectConfig{}
+	}
+	return &genai.LiveConnectConfig{
+		ResponseModalities:  cfg.ResponseModalities,
+		SpeechConfig:        cfg.SpeechConfig,
+		RealtimeInputConfig: cfg.RealtimeInputConfig,
+	}
+}
+
 // clone returns a deep copy of the src.
 // NOTE: this does not work for types with unexported fields.
 func clone[M any](src M) M {
@@ -0,0 +1,66 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"maps"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/internal/budget"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// budgetTracker returns the usage tracker of the invocation, or nil if the
+// run has no budget.
+func budgetTracker(ctx agent.InvocationContext) *budget.Tracker {
+	if cfg := runconfig.FromContext(ctx); cfg != nil {
+		return cfg.Budget
+	}
+	return nil
+}
+
+// recordUsage adds the usage of the response to the tracker. The usage
+// totals are attached to the response and the session totals are saved in
+// the state.
+func recordUsage(tracker *budget.Tracker, llm model.LLM, resp *model.LLMResponse, stateDelta map[string]any) {
+	var modelName string
+	if llm != nil {
+		modelName = llm.Name()
+	}
+	invocation, session := tracker.Record(modelName, resp.UsageMetadata)
+	resp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+	if resp.CustomMetadata == nil {
+		resp.CustomMetadata = make(map[string]any)
+	}
+	resp.CustomMetadata["usage"] = map[string]any{
+		"invocation": budget.ToStateValue(invocation),
+		"session":    budget.ToStateValue(session),
+	}
+	stateDelta[agent.UsageStateKey] = budget.ToStateValue(session)
+}
+
+// budgetExceededEvent returns the event ending the invocation which exceeded
+// its budget.
+func budgetExceededEvent(ctx agent.InvocationContext, err error) *session.Event {
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.ErrorCode = agent.ErrorCodeBudgetExceeded
+	ev.ErrorMessage = err.Error()
+	ev.TurnComplete = true
+	return ev
+}
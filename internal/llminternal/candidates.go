@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"maps"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/model"
+)
+
+// Keys of the custom metadata of the responses selected among several
+// candidates.
+const (
+	// SelectedCandidateMetadataKey is the key of the index of the selected
+	// candidate.
+	SelectedCandidateMetadataKey = "selected_candidate"
+	// CandidatesMetadataKey is the key of the candidates which were not
+	// selected.
+	CandidatesMetadataKey = "candidates"
+)
+
+type CandidateSelector func(ctx agent.CallbackContext, candidates []*model.LLMResponse) (int, error)
+
+// selectCandidate returns the candidate of the response picked by
+// f.SelectCandidate, or the first one if there is no selector. The other
+// candidates are kept in its custom metadata. The partial responses are
+// returned as they are, i.e. with the first candidate, since the selection
+// needs the complete candidates.
+func (f *Flow) selectCandidate(ctx agent.InvocationContext, resp *model.LLMResponse, stateDelta map[string]any) (*model.LLMResponse, error) {
+	if resp == nil || resp.Partial || len(resp.Candidates) < 2 {
+		return resp, nil
+	}
+	selected := 0
+	if f.SelectCandidate != nil {
+		var err error
+		selected, err = f.SelectCandidate(icontext.NewCallbackContextWithDelta(ctx, stateDelta), resp.Candidates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select candidate: %w", err)
+		}
+		if selected < 0 || selected >= len(resp.Candidates) {
+			return nil, fmt.Errorf("selected candidate %d out of %d candidates", selected, len(resp.Candidates))
+		}
+	}
+
+	winner := *resp.Candidates[selected]
+	winner.Candidates = nil
+	winner.UsageMetadata = resp.UsageMetadata
+	winner.Partial = resp.Partial
+	winner.TurnComplete = resp.TurnComplete
+	winner.Interrupted = resp.Interrupted
+	winner.CustomMetadata = maps.Clone(resp.CustomMetadata)
+	if winner.CustomMetadata == nil {
+		winner.CustomMetadata = make(map[string]any)
+	}
+	var losers []any
+	for i, candidate := range resp.Candidates {
+		if i == selected {
+			continue
+		}
+		loser := map[string]any{
+			"index":   i,
+			"content": candidate.Content,
+		}
+		if candidate.FinishReason != "" {
+			loser["finish_reason"] = string(candidate.FinishReason)
+		}
+		if candidate.AvgLogprobs != 0 {
+			loser["avg_logprobs"] = candidate.AvgLogprobs
+		}
+		losers = append(losers, loser)
+	}
+	winner.CustomMetadata[SelectedCandidateMetadataKey] = selected
+	winner.CustomMetadata[CandidatesMetadataKey] = losers
+	return &winner, nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// maxCodeExecutionErrorRetries is the number of consecutive failed code
+// executions within an invocation after which code blocks in the model
+// response are no longer executed.
+const maxCodeExecutionErrorRetries = 2
+
+// codeExecutionRequestProcessor converts the code execution parts of the
+// request contents to text, so that models without built-in code execution
+// can understand them.
+func codeExecutionRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	// reference: adk-python src/google/adk/flows/llm_flows/_code_execution.py
+
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || llmAgent.internal().CodeExecutor == nil {
+		return nil
+	}
+	req.Contents = convertCodeExecutionParts(req.Contents)
+	return nil
+}
+
+// codeExecutionResponseProcessor extracts the first code block from the
+// model response and executes it with the agent's code executor.
+//
+// The model response is truncated after the code block, which is replaced
+// with an ExecutableCode part, and the execution result is appended as a
+// CodeExecutionResult part. Since the response then ends with a code
+// execution result, it is not a final response and the flow calls the
+// model again with the result.
+func codeExecutionResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || llmAgent.internal().CodeExecutor == nil {
+		return nil
+	}
+	if resp == nil || resp.Content == nil || resp.Partial {
+		return nil
+	}
+	for _, p := range resp.Content.Parts {
+		if p.CodeExecutionResult != nil {
+			// The code was already executed, e.g. by the model.
+			return nil
+		}
+	}
+	if codeExecutionErrorCount(ctx) >= maxCodeExecutionErrorRetries {
+		return nil
+	}
+
+	code := extractCodeAndTruncateContent(resp.Content)
+	if code == "" {
+		return nil
+	}
+
+	result, err := llmAgent.internal().CodeExecutor.Execute(ctx, &codeexecutor.ExecuteRequest{
+		Code:        code,
+		ExecutionID: ctx.InvocationID(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to execute code: %w", err)
+	}
+
+	var savedFiles []string
+	if artifacts := ctx.Artifacts(); artifacts != nil {
+		for _, f := range result.OutputFiles {
+			if _, err := artifacts.Save(ctx, f.Name, genai.NewPartFromBytes(f.Content, f.MIMEType)); err != nil {
+				return fmt.Errorf("failed to save code execution output file %q: %w", f.Name, err)
+			}
+			savedFiles = append(savedFiles, f.Name)
+		}
+	}
+
+	resp.Content.Parts = append(resp.Content.Parts, codeExecutionResultPart(result, savedFiles))
+	return nil
+}
+
+// extractCodeAndTruncateContent returns the code of the first code block
+// in the content.
+//
+// The content is modified so that it ends with the code block as an
+// ExecutableCode part, i.e. any content following the code block is
+// dropped. If there's no code block, the content is left untouched and an
+// empty string is returned.
+func extractCodeAndTruncateContent(c *genai.Content) string {
+	for i, p := range c.Parts {
+		if p.ExecutableCode != nil {
+			c.Parts = c.Parts[:i+1]
+			return p.ExecutableCode.Code
+		}
+		if p.Text == "" || p.Thought {
+			continue
+		}
+		prefix, code, ok := findCodeBlock(p.Text)
+		if !ok {
+			continue
+		}
+		parts := c.Parts[:i:i]
+		if strings.TrimSpace(prefix) != "" {
+			parts = append(parts, genai.NewPartFromText(prefix))
+		}
+		c.Parts = append(parts, genai.NewPartFromExecutableCode(code, genai.LanguagePython))
+		return code
+	}
+	return ""
+}
+
+// findCodeBlock returns the text preceding the first code block in the text
+// and the code of the code block.
+func findCodeBlock(text string) (prefix, code string, ok bool) {
+	start, delim := -1, codeexecutor.Delimiter{}
+	for _, d := range codeexecutor.CodeBlockDelimiters {
+		if i := strings.Index(text, d.Start); i >= 0 && (start < 0 || i < start) {
+			start, delim = i, d
+		}
+	}
+	if start < 0 {
+		return "", "", false
+	}
+	rest := text[start+len(delim.Start):]
+	end := strings.Index(rest, delim.End)
+	if end < 0 {
+		return "", "", false
+	}
+	code = rest[:end]
+	if strings.TrimSpace(code) == "" {
+		return "", "", false
+	}
+	return text[:start], code, true
+}
+
+// codeExecutionResultPart builds the part reporting the execution result to
+// the model.
+func codeExecutionResultPart(result *codeexecutor.ExecuteResponse, savedFiles []string) *genai.Part {
+	if result.TimedOut {
+		return genai.NewPartFromCodeExecutionResult(genai.OutcomeDeadlineExceeded, result.Stderr)
+	}
+	if result.Stderr != "" {
+		return genai.NewPartFromCodeExecutionResult(genai.OutcomeFailed, result.Stderr)
+	}
+	var output []string
+	if result.Stdout != "" {
+		output = append(output, "Code execution result:\n"+result.Stdout)
+	}
+	if len(savedFiles) > 0 {
+		quoted := make([]string, len(savedFiles))
+		for i, f := range savedFiles {
+			quoted[i] = "`" + f + "`"
+		}
+		output = append(output, "Saved artifacts:\n"+strings.Join(quoted, ","))
+	}
+	return genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, strings.Join(output, "\n\n"))
+}
+
+// codeExecutionErrorCount returns the number of consecutive failed code
+// executions of the current agent at the end of the current invocation.
+func codeExecutionErrorCount(ctx agent.InvocationContext) int {
+	if ctx.Session() == nil {
+		return 0
+	}
+	events := ctx.Session().Events()
+	count := 0
+	for i := events.Len() - 1; i >= 0; i-- {
+		ev := events.At(i)
+		if ev.InvocationID != ctx.InvocationID() {
+			break
+		}
+		if ev.Author != ctx.Agent().Name() || ev.Content == nil {
+			continue
+		}
+		for _, p := range ev.Content.Parts {
+			if p.CodeExecutionResult == nil {
+				continue
+			}
+			if p.CodeExecutionResult.Outcome == genai.OutcomeOK {
+				return count
+			}
+			count++
+		}
+	}
+	return count
+}
+
+// convertCodeExecutionParts renders the code execution parts of the
+// contents as text.
+//
+// The execution results are reported as user contents, splitting the
+// content that holds them if needed.
+func convertCodeExecutionParts(contents []*genai.Content) []*genai.Content {
+	var converted []*genai.Content
+	for _, c := range contents {
+		if c == nil {
+			converted = append(converted, c)
+			continue
+		}
+		cur := &genai.Content{Role: c.Role}
+		for _, p := range c.Parts {
+			switch {
+			case p.ExecutableCode != nil:
+				d := codeexecutor.CodeBlockDelimiters[0]
+				cur.Parts = append(cur.Parts, genai.NewPartFromText(d.Start+p.ExecutableCode.Code+d.End))
+			case p.CodeExecutionResult != nil:
+				if len(cur.Parts) > 0 {
+					converted = append(converted, cur)
+				}
+				d := codeexecutor.ResultDelimiter
+				converted = append(converted, genai.NewContentFromText(d.Start+p.CodeExecutionResult.Output+d.End, genai.RoleUser))
+				cur = &genai.Content{Role: c.Role}
+			default:
+				cur.Parts = append(cur.Parts, p)
+			}
+		}
+		if len(cur.Parts) > 0 {
+			converted = append(converted, cur)
+		}
+	}
+	return converted
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/codeexecutor"
+	"google.golang.org/genai"
+)
+
+func TestExtractCodeAndTruncateContent(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		content     *genai.Content
+		wantCode    string
+		wantContent *genai.Content
+	}{
+		{
+			name:        "no code block",
+			content:     genai.NewContentFromText("just text", genai.RoleModel),
+			wantContent: genai.NewContentFromText("just text", genai.RoleModel),
+		},
+		{
+			name:        "unterminated code block",
+			content:     genai.NewContentFromText("```python\nprint(1)", genai.RoleModel),
+			wantContent: genai.NewContentFromText("```python\nprint(1)", genai.RoleModel),
+		},
+		{
+			name:     "python code block with prefix and suffix",
+			content:  genai.NewContentFromText("Let me compute.\n```python\nprint(1 + 1)\n```\nignored", genai.RoleModel),
+			wantCode: "print(1 + 1)",
+			wantContent: genai.NewContentFromParts([]*genai.Part{
+				genai.NewPartFromText("Let me compute.\n"),
+				genai.NewPartFromExecutableCode("print(1 + 1)", genai.LanguagePython),
+			}, genai.RoleModel),
+		},
+		{
+			name: "first of multiple code blocks",
+			content: genai.NewContentFromParts([]*genai.Part{
+				{Text: "```tool_code\nthought()\n```", Thought: true},
+				genai.NewPartFromText("```tool_code\nfirst()\n```\n```python\nsecond()\n```"),
+				genai.NewPartFromText("dropped"),
+			}, genai.RoleModel),
+			wantCode: "first()",
+			wantContent: genai.NewContentFromParts([]*genai.Part{
+				{Text: "```tool_code\nthought()\n```", Thought: true},
+				genai.NewPartFromExecutableCode("first()", genai.LanguagePython),
+			}, genai.RoleModel),
+		},
+		{
+			name: "executable code part",
+			content: genai.NewContentFromParts([]*genai.Part{
+				genai.NewPartFromExecutableCode("x = 1", genai.LanguagePython),
+				genai.NewPartFromText("dropped"),
+			}, genai.RoleModel),
+			wantCode: "x = 1",
+			wantContent: genai.NewContentFromParts([]*genai.Part{
+				genai.NewPartFromExecutableCode("x = 1", genai.LanguagePython),
+			}, genai.RoleModel),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotCode := extractCodeAndTruncateContent(tc.content)
+			if gotCode != tc.wantCode {
+				t.Errorf("extractCodeAndTruncateContent() = %q, want %q", gotCode, tc.wantCode)
+			}
+			if diff := cmp.Diff(tc.wantContent, tc.content); diff != "" {
+				t.Errorf("content mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCodeExecutionResultPart(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		result     *codeexecutor.ExecuteResponse
+		savedFiles []string
+		want       *genai.Part
+	}{
+		{
+			name:   "stdout",
+			result: &codeexecutor.ExecuteResponse{Stdout: "2\n"},
+			want:   genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "Code execution result:\n2\n"),
+		},
+		{
+			name:       "stdout and files",
+			result:     &codeexecutor.ExecuteResponse{Stdout: "done\n"},
+			savedFiles: []string{"a.png", "b.csv"},
+			want:       genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "Code execution result:\ndone\n\n\nSaved artifacts:\n`a.png`,`b.csv`"),
+		},
+		{
+			name:   "stderr",
+			result: &codeexecutor.ExecuteResponse{Stdout: "partial", Stderr: "boom"},
+			want:   genai.NewPartFromCodeExecutionResult(genai.OutcomeFailed, "boom"),
+		},
+		{
+			name:   "timeout",
+			result: &codeexecutor.ExecuteResponse{Stderr: "timed out", TimedOut: true},
+			want:   genai.NewPartFromCodeExecutionResult(genai.OutcomeDeadlineExceeded, "timed out"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := codeExecutionResultPart(tc.result, tc.savedFiles)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("codeExecutionResultPart() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertCodeExecutionParts(t *testing.T) {
+	contents := []*genai.Content{
+		genai.NewContentFromText("compute 1+1", genai.RoleUser),
+		genai.NewContentFromParts([]*genai.Part{
+			genai.NewPartFromText("Let me compute."),
+			genai.NewPartFromExecutableCode("print(1 + 1)", genai.LanguagePython),
+			genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "2"),
+		}, genai.RoleModel),
+		genai.NewContentFromText("The answer is 2.", genai.RoleModel),
+	}
+
+	got := convertCodeExecutionParts(contents)
+
+	want := []*genai.Content{
+		genai.NewContentFromText("compute 1+1", genai.RoleUser),
+		genai.NewContentFromParts([]*genai.Part{
+			genai.NewPartFromText("Let me compute."),
+			genai.NewPartFromText("```tool_code\nprint(1 + 1)\n```"),
+		}, genai.RoleModel),
+		genai.NewContentFromText("```tool_output\n2\n```", genai.RoleUser),
+		genai.NewContentFromText("The answer is 2.", genai.RoleModel),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("convertCodeExecutionParts() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -17,6 +17,7 @@ package llminternal
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -58,6 +59,8 @@ func ContentsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 // buildContentsDefault returns the contents for the LLM request by applying
 // filtering, rearrangement, and content processing to the given events.
 func buildContentsDefault(agentName, invocationBranch string, events []*session.Event) ([]*genai.Content, error) {
+	events = compactEvents(events)
+
 	// parse the events, leaving the contents and the function calls and responses from the current agent.
 	var filtered []*session.Event
 	for _, ev := range events {
@@ -110,6 +113,66 @@ func buildContentsDefault(agentName, invocationBranch string, events []*session.
 	return contents, nil
 }
 
+// compactEvents replaces the events summarized by the compaction events with
+// the summaries. A summary takes the place of the first event it replaces.
+// The summaries of overlapping compactions are not both sent: the
+// compactions overlapping a later one, which usually summarized them again,
+// are dropped, and their events not compacted by the later one are kept.
+func compactEvents(events []*session.Event) []*session.Event {
+	// reference: adk-python src/google/adk/flows/llm_flows/contents.py _process_compaction_events
+	var all []*session.Event
+	for _, ev := range events {
+		if ev.Actions.Compaction != nil {
+			all = append(all, ev)
+		}
+	}
+	if len(all) == 0 {
+		return events
+	}
+	// The latest compactions first.
+	slices.Reverse(all)
+	slices.SortStableFunc(all, func(a, b *session.Event) int {
+		return b.Actions.Compaction.EndTimestamp.Compare(a.Actions.Compaction.EndTimestamp)
+	})
+	var compactions []*session.Event
+	for _, c := range all {
+		overlaps := slices.ContainsFunc(compactions, func(kept *session.Event) bool {
+			return !c.Actions.Compaction.EndTimestamp.Before(kept.Actions.Compaction.StartTimestamp)
+		})
+		if !overlaps {
+			compactions = append(compactions, c)
+		}
+	}
+
+	var result []*session.Event
+	added := make(map[*session.Event]bool)
+	for _, ev := range events {
+		if ev.Actions.Compaction != nil {
+			continue
+		}
+		i := slices.IndexFunc(compactions, func(c *session.Event) bool {
+			compaction := c.Actions.Compaction
+			return !ev.Timestamp.Before(compaction.StartTimestamp) && !ev.Timestamp.After(compaction.EndTimestamp)
+		})
+		if i < 0 {
+			result = append(result, ev)
+			continue
+		}
+		if c := compactions[i]; !added[c] {
+			added[c] = true
+			compaction := c.Actions.Compaction
+			result = append(result, &session.Event{
+				ID:           c.ID,
+				InvocationID: c.InvocationID,
+				Author:       c.Author,
+				Timestamp:    compaction.StartTimestamp,
+				LLMResponse:  model.LLMResponse{Content: compaction.CompactedContent},
+			})
+		}
+	}
+	return result
+}
+
 func eventBelongsToBranch(invocationBranch string, event *session.Event) bool {
 	if invocationBranch == "" {
 		return true
@@ -364,6 +364,107 @@ func TestContentsRequestProcessor(t *testing.T) {
 			},
 			want: nil,
 		},
+		{
+			name: "CompactedEvents",
+			events: []*session.Event{
+				{Author: "user", Timestamp: time.Unix(1, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q1", "user")}},
+				{Author: agentName, Timestamp: time.Unix(2, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("a1", "model")}},
+				{Author: "user", Timestamp: time.Unix(3, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q2", "user")}},
+				{Author: agentName, Timestamp: time.Unix(4, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("a2", "model")}},
+				{
+					Author:    "user",
+					Timestamp: time.Unix(5, 0),
+					Actions: session.EventActions{Compaction: &session.EventCompaction{
+						StartTimestamp:   time.Unix(1, 0),
+						EndTimestamp:     time.Unix(2, 0),
+						CompactedContent: genai.NewContentFromText("summary 1", "model"),
+					}},
+				},
+				{Author: "user", Timestamp: time.Unix(6, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q3", "user")}},
+				{
+					Author:    "user",
+					Timestamp: time.Unix(7, 0),
+					Actions: session.EventActions{Compaction: &session.EventCompaction{
+						StartTimestamp:   time.Unix(3, 0),
+						EndTimestamp:     time.Unix(4, 0),
+						CompactedContent: genai.NewContentFromText("summary 2", "model"),
+					}},
+				},
+			},
+			want: []*genai.Content{
+				genai.NewContentFromText("summary 1", "model"),
+				genai.NewContentFromText("summary 2", "model"),
+				genai.NewContentFromText("q3", "user"),
+			},
+		},
+		{
+			name: "CoveredCompaction",
+			events: []*session.Event{
+				{Author: "user", Timestamp: time.Unix(1, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q1", "user")}},
+				{Author: agentName, Timestamp: time.Unix(2, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("a1", "model")}},
+				{Author: "user", Timestamp: time.Unix(3, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q2", "user")}},
+				{Author: agentName, Timestamp: time.Unix(4, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("a2", "model")}},
+				{
+					Author:    "user",
+					Timestamp: time.Unix(5, 0),
+					Actions: session.EventActions{Compaction: &session.EventCompaction{
+						StartTimestamp:   time.Unix(1, 0),
+						EndTimestamp:     time.Unix(2, 0),
+						CompactedContent: genai.NewContentFromText("summary 1", "model"),
+					}},
+				},
+				{Author: "user", Timestamp: time.Unix(6, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q3", "user")}},
+				{
+					Author:    "user",
+					Timestamp: time.Unix(7, 0),
+					Actions: session.EventActions{Compaction: &session.EventCompaction{
+						StartTimestamp:   time.Unix(1, 0),
+						EndTimestamp:     time.Unix(4, 0),
+						CompactedContent: genai.NewContentFromText("summary 2", "model"),
+					}},
+				},
+			},
+			// The later compaction summarized the previous one again.
+			want: []*genai.Content{
+				genai.NewContentFromText("summary 2", "model"),
+				genai.NewContentFromText("q3", "user"),
+			},
+		},
+		{
+			name: "OverlappingCompactions",
+			events: []*session.Event{
+				{Author: "user", Timestamp: time.Unix(1, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q1", "user")}},
+				{Author: agentName, Timestamp: time.Unix(2, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("a1", "model")}},
+				{Author: "user", Timestamp: time.Unix(3, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q2", "user")}},
+				{Author: agentName, Timestamp: time.Unix(4, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("a2", "model")}},
+				{
+					Author:    "user",
+					Timestamp: time.Unix(5, 0),
+					Actions: session.EventActions{Compaction: &session.EventCompaction{
+						StartTimestamp:   time.Unix(1, 0),
+						EndTimestamp:     time.Unix(2, 0),
+						CompactedContent: genai.NewContentFromText("summary 1", "model"),
+					}},
+				},
+				{Author: "user", Timestamp: time.Unix(6, 0), LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("q3", "user")}},
+				{
+					Author:    "user",
+					Timestamp: time.Unix(7, 0),
+					Actions: session.EventActions{Compaction: &session.EventCompaction{
+						StartTimestamp:   time.Unix(2, 0),
+						EndTimestamp:     time.Unix(4, 0),
+						CompactedContent: genai.NewContentFromText("summary 2", "model"),
+					}},
+				},
+			},
+			// The events of the earlier compaction which are not in the
+			// later one are sent instead of its summary.
+			want: []*genai.Content{
+				genai.NewContentFromText("q1", "user"),
+				genai.NewContentFromText("summary 2", "model"),
+				genai.NewContentFromText("q3", "user"),
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -22,29 +22,15 @@ import (
 func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 	usageMetadata := res.UsageMetadata
 	if len(res.Candidates) > 0 && res.Candidates[0] != nil {
-		candidate := res.Candidates[0]
-		if candidate.Content != nil && len(candidate.Content.Parts) > 0 {
-			return &model.LLMResponse{
-				Content:           candidate.Content,
-				GroundingMetadata: candidate.GroundingMetadata,
-				FinishReason:      candidate.FinishReason,
-				CitationMetadata:  candidate.CitationMetadata,
-				AvgLogprobs:       candidate.AvgLogprobs,
-				LogprobsResult:    candidate.LogprobsResult,
-				UsageMetadata:     usageMetadata,
+		resp := candidate2LLMResponse(res.Candidates[0], usageMetadata)
+		if len(res.Candidates) > 1 {
+			for _, candidate := range res.Candidates {
+				if candidate != nil {
+					resp.Candidates = append(resp.Candidates, candidate2LLMResponse(candidate, usageMetadata))
+				}
 			}
 		}
-		return &model.LLMResponse{
-			ErrorCode:         string(candidate.FinishReason),
-			ErrorMessage:      candidate.FinishMessage,
-			GroundingMetadata: candidate.GroundingMetadata,
-			FinishReason:      candidate.FinishReason,
-			CitationMetadata:  candidate.CitationMetadata,
-			AvgLogprobs:       candidate.AvgLogprobs,
-			LogprobsResult:    candidate.LogprobsResult,
-			UsageMetadata:     usageMetadata,
-		}
-
+		return resp
 	}
 	if res.PromptFeedback != nil {
 		return &model.LLMResponse{
@@ -59,3 +45,27 @@ func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 		UsageMetadata: usageMetadata,
 	}
 }
+
+func candidate2LLMResponse(candidate *genai.Candidate, usageMetadata *genai.GenerateContentResponseUsageMetadata) *model.LLMResponse {
+	if candidate.Content != nil && len(candidate.Content.Parts) > 0 {
+		return &model.LLMResponse{
+			Content:           candidate.Content,
+			GroundingMetadata: candidate.GroundingMetadata,
+			FinishReason:      candidate.FinishReason,
+			CitationMetadata:  candidate.CitationMetadata,
+			AvgLogprobs:       candidate.AvgLogprobs,
+			LogprobsResult:    candidate.LogprobsResult,
+			UsageMetadata:     usageMetadata,
+		}
+	}
+	return &model.LLMResponse{
+		ErrorCode:         string(candidate.FinishReason),
+		ErrorMessage:      candidate.FinishMessage,
+		GroundingMetadata: candidate.GroundingMetadata,
+		FinishReason:      candidate.FinishReason,
+		CitationMetadata:  candidate.CitationMetadata,
+		AvgLogprobs:       candidate.AvgLogprobs,
+		LogprobsResult:    candidate.LogprobsResult,
+		UsageMetadata:     usageMetadata,
+	}
+}
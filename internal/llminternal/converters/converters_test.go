@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestGenai2LLMResponse(t *testing.T) {
+	usage := &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: 10}
+	first := &model.LLMResponse{
+		Content:       genai.NewContentFromText("first", genai.RoleModel),
+		FinishReason:  genai.FinishReasonStop,
+		AvgLogprobs:   -0.5,
+		UsageMetadata: usage,
+	}
+	second := &model.LLMResponse{
+		Content:       genai.NewContentFromText("second", genai.RoleModel),
+		FinishReason:  genai.FinishReasonMaxTokens,
+		UsageMetadata: usage,
+	}
+
+	tests := []struct {
+		name string
+		res  *genai.GenerateContentResponse
+		want *model.LLMResponse
+	}{
+		{
+			name: "one candidate",
+			res: &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{
+					{Content: first.Content, FinishReason: first.FinishReason, AvgLogprobs: first.AvgLogprobs},
+				},
+				UsageMetadata: usage,
+			},
+			want: first,
+		},
+		{
+			name: "several candidates",
+			res: &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{
+					{Content: first.Content, FinishReason: first.FinishReason, AvgLogprobs: first.AvgLogprobs},
+					{Content: second.Content, FinishReason: second.FinishReason},
+				},
+				UsageMetadata: usage,
+			},
+			want: &model.LLMResponse{
+				Content:       first.Content,
+				FinishReason:  first.FinishReason,
+				AvgLogprobs:   first.AvgLogprobs,
+				UsageMetadata: usage,
+				Candidates:    []*model.LLMResponse{first, second},
+			},
+		},
+		{
+			name: "no content",
+			res: &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{
+					{FinishReason: genai.FinishReasonSafety, FinishMessage: "blocked"},
+				},
+			},
+			want: &model.LLMResponse{
+				ErrorCode:    string(genai.FinishReasonSafety),
+				ErrorMessage: "blocked",
+				FinishReason: genai.FinishReasonSafety,
+			},
+		},
+		{
+			name: "prompt blocked",
+			res: &genai.GenerateContentResponse{
+				PromptFeedback: &genai.GenerateContentResponsePromptFeedback{
+					BlockReason:        genai.BlockedReasonSafety,
+					BlockReasonMessage: "blocked",
+				},
+			},
+			want: &model.LLMResponse{
+				ErrorCode:    string(genai.BlockedReasonSafety),
+				ErrorMessage: "blocked",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, Genai2LLMResponse(tt.res)); diff != "" {
+				t.Errorf("Genai2LLMResponse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
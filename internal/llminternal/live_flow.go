@@ -0,0 +1,282 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// liveResponse is a response received from the live connection.
+type liveResponse struct {
+	resp *model.LLMResponse
+	err  error
+}
+
+// runLive runs the agent in bidi streaming mode.
+//
+// It connects to the model, forwards the requests of the live request queue
+// and yields the responses of the model as they arrive. Function calls are
+// handled as soon as they are received, and their responses are sent back to
+// the model. It returns when the queue is closed, the connection ends or the
+// control is transferred to another agent.
+func (f *Flow) runLive(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+	// reference: adk-python src/google/adk/flows/llm_flows/base_llm_flow.py BaseLlmFlow.run_live
+
+	return func(yield func(*session.Event, error) bool) {
+		queue := runconfig.FromContext(ctx).LiveRequestQueue
+		if queue == nil {
+			yield(nil, fmt.Errorf("agent %q is run in bidi streaming mode without a live request queue; use Runner.RunLive", ctx.Agent().Name()))
+			return
+		}
+		if f.Model == nil {
+			yield(nil, fmt.Errorf("agent %q has no Model configured; ensure Model is set in llmagent.Config", ctx.Agent().Name()))
+			return
+		}
+		liveModel, ok := f.Model.(model.LiveLLM)
+		if !ok {
+			yield(nil, fmt.Errorf("model %q does not support bidi streaming", f.Model.Name()))
+			return
+		}
+
+		req := &model.LLMRequest{}
+		if err := f.preprocess(ctx, req); err != nil {
+			yield(nil, err)
+			return
+		}
+		if ctx.Ended() {
+			return
+		}
+		tools := make(map[string]tool.Tool, len(req.Tools))
+		for name, v := range req.Tools {
+			t, ok := v.(tool.Tool)
+			if !ok {
+				yield(nil, fmt.Errorf("unexpected tool type %T for tool %v", v, name))
+				return
+			}
+			tools[name] = t
+		}
+
+		conn, err := liveModel.Connect(ctx, req)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to connect to model %q: %w", f.Model.Name(), err))
+			return
+		}
+		if len(req.Contents) > 0 {
+			if err := conn.SendHistory(ctx, req.Contents); err != nil {
+				_ = conn.Close()
+				yield(nil, fmt.Errorf("failed to send history: %w", err))
+				return
+			}
+		}
+
+		// The requests of the queue and the responses of the model are
+		// received in their own goroutines, and handled here, so that only
+		// this goroutine sends to the connection.
+		liveCtx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		stop := func() {
+			cancel()
+			_ = conn.Close()
+			wg.Wait()
+		}
+		defer stop()
+
+		requests := make(chan *agent.LiveRequest)
+		responses := make(chan liveResponse)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for {
+				// The request is only removed from the queue once it is
+				// handed over: if the run stops first, e.g. on a transfer,
+				// it is left to the next agent.
+				req, err := queue.Peek(liveCtx)
+				if err != nil {
+					return
+				}
+				select {
+				case requests <- req:
+				case <-liveCtx.Done():
+					return
+				}
+				// The request is still first, this goroutine is the only
+				// consumer of the queue.
+				if _, err := queue.Receive(liveCtx); err != nil {
+					return
+				}
+				if req.Close {
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			defer close(responses)
+			for resp, err := range conn.Receive(liveCtx) {
+				select {
+				case responses <- liveResponse{resp: resp, err: err}:
+				case <-liveCtx.Done():
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				yield(nil, ctx.Err())
+				return
+
+			case liveReq := <-requests:
+				if liveReq.Close {
+					// Keep handling the responses until the connection
+					// ends.
+					if err := conn.Close(); err != nil {
+						yield(nil, fmt.Errorf("failed to close the connection: %w", err))
+						return
+					}
+					continue
+				}
+				ev, err := sendLiveRequest(ctx, conn, liveReq)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if ev != nil && !yield(ev, nil) {
+					return
+				}
+
+			case r, ok := <-responses:
+				if !ok {
+					return
+				}
+				if r.err != nil {
+					yield(nil, r.err)
+					return
+				}
+				modelResponseEvent, fnResponseEvent, err := f.handleLiveResponse(ctx, req, tools, r.resp)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if modelResponseEvent != nil && !yield(modelResponseEvent, nil) {
+					return
+				}
+				if fnResponseEvent == nil {
+					continue
+				}
+				if !yield(fnResponseEvent, nil) {
+					return
+				}
+				if err := conn.SendContent(ctx, fnResponseEvent.LLMResponse.Content); err != nil {
+					yield(nil, fmt.Errorf("failed to send function responses: %w", err))
+					return
+				}
+				if fnResponseEvent.Actions.TransferToAgent == "" {
+					continue
+				}
+				nextAgent := f.agentToRun(ctx, fnResponseEvent.Actions.TransferToAgent)
+				if nextAgent == nil {
+					yield(nil, fmt.Errorf("failed to find agent: %s", fnResponseEvent.Actions.TransferToAgent))
+					return
+				}
+				// The next agent opens its own connection, and receives the
+				// following requests of the queue.
+				stop()
+				for ev, err := range nextAgent.Run(ctx) {
+					if !yield(ev, err) || err != nil { // forward
+						return
+					}
+				}
+				return
+			}
+		}
+	}
+}
+
+// sendLiveRequest sends the request of the client to the model. It returns
+// the event of the user content, so that it is saved in the session, or nil.
+func sendLiveRequest(ctx agent.InvocationContext, conn model.LiveConnection, liveReq *agent.LiveRequest) (*session.Event, error) {
+	switch {
+	case liveReq.Content != nil:
+		content := liveReq.Content
+		if content.Role == "" {
+			content.Role = genai.RoleUser
+		}
+		if err := conn.SendContent(ctx, content); err != nil {
+			return nil, fmt.Errorf("failed to send content: %w", err)
+		}
+		ev := session.NewEvent(ctx.InvocationID())
+		ev.Author = "user"
+		ev.Branch = ctx.Branch()
+		ev.LLMResponse = model.LLMResponse{Content: content}
+		return ev, nil
+	case liveReq.RealtimeInput != nil:
+		if err := conn.SendRealtime(ctx, liveReq.RealtimeInput); err != nil {
+			return nil, fmt.Errorf("failed to send realtime input: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// handleLiveResponse returns the event of the model response, or nil if the
+// response is empty, and the function response event if the model called
+// functions.
+func (f *Flow) handleLiveResponse(ctx agent.InvocationContext, req *model.LLMRequest, tools map[string]tool.Tool, resp *model.LLMResponse) (modelResponseEvent, fnResponseEvent *session.Event, err error) {
+	stateDelta := make(map[string]any)
+	callbackResp, err := f.runAfterModelCallbacks(ctx, resp, stateDelta, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if callbackResp != nil {
+		resp = callbackResp
+	}
+	if err := f.postprocess(ctx, req, resp); err != nil {
+		return nil, nil, err
+	}
+	// Unlike in the other modes, responses without content are meaningful:
+	// they mark the end of the turn or the interruption of the model.
+	if resp.Content == nil && resp.ErrorCode == "" && !resp.Interrupted && !resp.TurnComplete {
+		return nil, nil, nil
+	}
+
+	spans := telemetry.StartTrace(ctx, "call_llm")
+	modelResponseEvent = f.finalizeModelResponseEvent(ctx, resp, tools, stateDelta)
+	telemetry.TraceLLMCall(spans, ctx, req, modelResponseEvent)
+
+	if len(utils.FunctionCalls(resp.Content)) == 0 {
+		return modelResponseEvent, nil, nil
+	}
+	fnResponseEvent, err = f.handleFunctionCalls(ctx, tools, resp, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return modelResponseEvent, fnResponseEvent, nil
+}
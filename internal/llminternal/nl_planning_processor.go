@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"slices"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// nlPlanningRequestProcessor appends the planning instruction of the agent's
+// planner to the request and removes the thought marks from the request
+// contents, so that the thoughts of the previous responses are sent back to
+// the model as regular text.
+func nlPlanningRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	// reference: adk-python src/google/adk/flows/llm_flows/_nl_planning.py
+
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || llmAgent.internal().Planner == nil {
+		return nil
+	}
+
+	instruction, err := llmAgent.internal().Planner.BuildPlanningInstruction(icontext.NewReadonlyContext(ctx), req)
+	if err != nil {
+		return fmt.Errorf("failed to build planning instruction: %w", err)
+	}
+	if instruction != "" {
+		utils.AppendInstructions(req, instruction)
+	}
+
+	// The contents and their parts are shared with the session events, so
+	// the ones with thoughts are copied.
+	for i, content := range req.Contents {
+		if content == nil || !slices.ContainsFunc(content.Parts, isThought) {
+			continue
+		}
+		copied := *content
+		copied.Parts = make([]*genai.Part, len(content.Parts))
+		for j, part := range content.Parts {
+			if isThought(part) {
+				p := *part
+				p.Thought = false
+				part = &p
+			}
+			copied.Parts[j] = part
+		}
+		req.Contents[i] = &copied
+	}
+	return nil
+}
+
+// isThought reports whether the part is marked as a thought.
+func isThought(part *genai.Part) bool {
+	return part != nil && part.Thought
+}
+
+// nlPlanningResponseProcessor lets the agent's planner post-process the
+// parts of the model response.
+func nlPlanningResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || llmAgent.internal().Planner == nil {
+		return nil
+	}
+	if resp == nil || resp.Content == nil || len(resp.Content.Parts) == 0 || resp.Partial {
+		return nil
+	}
+
+	parts, err := llmAgent.internal().Planner.ProcessPlanningResponse(icontext.NewReadonlyContext(ctx), resp.Content.Parts)
+	if err != nil {
+		return fmt.Errorf("failed to process planning response: %w", err)
+	}
+	if parts != nil {
+		resp.Content.Parts = parts
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
+)
+
+// plannerAgent is an agent with a planner, as seen by the processors.
+type plannerAgent struct {
+	agent.Agent
+	*State
+}
+
+func TestNLPlanningRequestProcessor_KeepsSharedParts(t *testing.T) {
+	base, err := agent.New(agent.Config{Name: "planner_agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Agent: plannerAgent{Agent: base, State: &State{Planner: planner.NewPlanReAct()}},
+	})
+
+	thought := &genai.Part{Text: "/*PLANNING*/1. greet", Thought: true}
+	content := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{thought, {Text: "Hello!"}}}
+	req := &model.LLMRequest{Contents: []*genai.Content{content}}
+
+	if err := nlPlanningRequestProcessor(ctx, req); err != nil {
+		t.Fatalf("nlPlanningRequestProcessor() error = %v", err)
+	}
+
+	want := []*genai.Part{{Text: "/*PLANNING*/1. greet"}, {Text: "Hello!"}}
+	if diff := cmp.Diff(want, req.Contents[0].Parts); diff != "" {
+		t.Errorf("request parts mismatch (-want +got):\n%s", diff)
+	}
+	if !thought.Thought || content.Parts[0] != thought {
+		t.Errorf("the shared content was modified: %+v", content.Parts[0])
+	}
+}
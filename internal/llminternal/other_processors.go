@@ -23,28 +23,3 @@ func identityRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 	// TODO: implement (adk-python src/google/adk/flows/llm_flows/identity.py)
 	return nil
 }
-
-func nlPlanningRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
-	// TODO: implement (adk-python src/google/adk/flows/llm_flows/_nl_plnning.py)
-	return nil
-}
-
-func codeExecutionRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
-	// TODO: implement (adk-python src/google/adk/flows/llm_flows/_code_execution.py)
-	return nil
-}
-
-func authPreprocessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
-	// TODO: implement (adk-python src/google/adk/auth/auth_preprocessor.py)
-	return nil
-}
-
-func nlPlanningResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
-	// TODO: implement (adk-python src/google/adk/_nl_planning.py)
-	return nil
-}
-
-func codeExecutionResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
-	// TODO: implement (adk-python src/google/adk_code_execution.py)
-	return nil
-}
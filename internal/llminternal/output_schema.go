@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/internal/utils"
+)
+
+// HasOutputSchema reports whether the agent has an output schema.
+func (s *State) HasOutputSchema() bool {
+	return s.OutputSchema != nil || s.OutputJSONSchema != nil
+}
+
+// ParseOutput parses the final reply of the agent as JSON and validates it
+// against the output schema of the agent. A markdown code fence around the
+// JSON value is ignored.
+func (s *State) ParseOutput(output string) (any, error) {
+	output = trimCodeFence(output)
+	if s.OutputJSONSchema != nil {
+		var v any
+		if err := json.Unmarshal([]byte(output), &v); err != nil {
+			return nil, fmt.Errorf("failed to parse output JSON: %w", err)
+		}
+		if err := s.OutputJSONSchema.Validate(v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	if s.OutputSchema != nil {
+		return utils.ValidateOutputSchema(output, s.OutputSchema)
+	}
+	return nil, fmt.Errorf("agent has no output schema")
+}
+
+// ValidateInput validates the arguments of the agent used as a tool against
+// its input schema.
+func (s *State) ValidateInput(args map[string]any) error {
+	if s.InputJSONSchema != nil {
+		return s.InputJSONSchema.Validate(args)
+	}
+	if s.InputSchema != nil {
+		return utils.ValidateMapOnSchema(args, s.InputSchema, true)
+	}
+	return fmt.Errorf("agent has no input schema")
+}
+
+// trimCodeFence removes the markdown code fence models sometimes put around
+// JSON replies, e.g. ```json ... ```.
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	// Remove the language of the code block.
+	if i := strings.IndexByte(s, '\n'); i >= 0 && !strings.ContainsAny(s[:i], "{[\"") {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
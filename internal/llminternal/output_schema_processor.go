@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// outputSchemaRequestProcessor lets the agents with an output schema use
+// tools.
+//
+// Since models may not support function calling together with a response
+// schema, the response schema is not set in the request. Instead, the agent
+// gets a set_model_response tool taking the output schema as parameters, and
+// is instructed to call it with its final reply.
+func outputSchemaRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	// reference: adk-python src/google/adk/flows/llm_flows/_output_schema_processor.py
+
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || !useSetModelResponseTool(llmAgent.internal()) {
+		return nil
+	}
+	utils.AppendInstructions(req, "IMPORTANT: You have access to other tools, but you must provide your final response using the "+setModelResponseToolName+" tool with the required structured format. "+
+		"After using any other tools needed to complete the task, always call "+setModelResponseToolName+" with your final answer in the specified schema format.")
+	return appendTools(req, &setModelResponseTool{state: llmAgent.internal()})
+}
+
+// useSetModelResponseTool reports whether the agent replies with the
+// set_model_response tool, instead of a response schema.
+func useSetModelResponseTool(s *State) bool {
+	return s.HasOutputSchema() && (len(s.Tools) > 0 || len(s.Toolsets) > 0)
+}
+
+const setModelResponseToolName = "set_model_response"
+
+// setModelResponseTool is the tool used by the agents with an output schema
+// and tools to provide their final reply.
+type setModelResponseTool struct {
+	state *State
+}
+
+// Name implements tool.Tool.
+func (t *setModelResponseTool) Name() string {
+	return setModelResponseToolName
+}
+
+// Description implements tool.Tool.
+func (t *setModelResponseTool) Description() string {
+	return "Set your final response using the required output schema. Use this tool to provide your final structured answer instead of outputting text directly."
+}
+
+// IsLongRunning implements tool.Tool.
+func (t *setModelResponseTool) IsLongRunning() bool {
+	return false
+}
+
+func (t *setModelResponseTool) Declaration() *genai.FunctionDeclaration {
+	decl := &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+	}
+	if t.state.OutputJSONSchema != nil {
+		decl.ParametersJsonSchema = t.state.OutputJSONSchema.Schema()
+	} else {
+		decl.Parameters = t.state.OutputSchema
+	}
+	return decl
+}
+
+// setModelResponseResultKey is the key of the validated reply in the
+// set_model_response function response. The reply is wrapped, so that it
+// can't be mistaken for the error of a failed call, whatever the output
+// schema.
+const setModelResponseResultKey = "result"
+
+// Run validates the reply against the output schema, and returns it under
+// setModelResponseResultKey. The validation error is sent to the model
+// otherwise, so that it can fix the reply.
+func (t *setModelResponseTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected args type: %T", args)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.state.ParseOutput(string(data)); err != nil {
+		return nil, err
+	}
+	return map[string]any{setModelResponseResultKey: m}, nil
+}
+
+var _ tool.Tool = (*setModelResponseTool)(nil)
+
+// setModelResponseEvent returns the final reply of the agent if the function
+// response event has a successful set_model_response response, or nil.
+//
+// The event has the reply as JSON text, as if the model replied directly with
+// the response schema.
+func setModelResponseEvent(ctx agent.InvocationContext, fnResponseEvent *session.Event) (*session.Event, error) {
+	for _, fnResponse := range utils.FunctionResponses(fnResponseEvent.LLMResponse.Content) {
+		if fnResponse.Name != setModelResponseToolName {
+			continue
+		}
+		reply, ok := fnResponse.Response[setModelResponseResultKey]
+		if !ok {
+			// The call failed, e.g. the reply did not match the schema.
+			return nil, nil
+		}
+		data, err := json.Marshal(reply)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s response: %w", setModelResponseToolName, err)
+		}
+		ev := session.NewEvent(ctx.InvocationID())
+		ev.Author = ctx.Agent().Name()
+		ev.Branch = ctx.Branch()
+		ev.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(string(data), genai.RoleModel)}
+		return ev, nil
+	}
+	return nil, nil
+}
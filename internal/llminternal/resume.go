@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"iter"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/checkpoint"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// resumeStep continues the work of the agent in a resumed invocation, from
+// its last event in the invocation:
+//   - if the agent replied, or waits for the function responses of
+//     long-running tools, there is nothing to do.
+//   - if the agent transferred the invocation, the other agent resumes.
+//   - if the functions called by the model have not responded, they are
+//     called again.
+//
+// Otherwise, it runs a regular step.
+func (f *Flow) resumeStep(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		freshCtx := checkpoint.WithResuming(ctx, false)
+
+		lastEvent := checkpoint.LastEvent(ctx, func(ev *session.Event) bool {
+			// Skip the events without content, e.g. the state changes
+			// of the callbacks, and the feedback sent to the model.
+			c := ev.LLMResponse.Content
+			return c != nil && (c.Role != genai.RoleUser || len(utils.FunctionResponses(c)) > 0)
+		})
+		switch {
+		case lastEvent == nil:
+		case lastEvent.IsFinalResponse():
+			return
+		case lastEvent.Actions.TransferToAgent != "":
+			f.transferToAgent(ctx, lastEvent.Actions.TransferToAgent, yield)
+			return
+		case len(utils.FunctionCalls(lastEvent.LLMResponse.Content)) > 0:
+			// The tools are the ones of the LLM request, including the
+			// tools added by the request processors.
+			req := &model.LLMRequest{}
+			if err := f.preprocess(ctx, req); err != nil {
+				yield(nil, err)
+				return
+			}
+			tools, err := requestTools(req)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			ev, err := f.handleFunctionCalls(freshCtx, tools, &lastEvent.LLMResponse, nil)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if ev == nil {
+				return
+			}
+			if !yield(ev, nil) {
+				return
+			}
+			f.postprocessFunctionResponse(freshCtx, ev, yield)
+			return
+		}
+		for ev, err := range f.runOneStep(freshCtx) {
+			if !yield(ev, err) {
+				return
+			}
+		}
+	}
+}
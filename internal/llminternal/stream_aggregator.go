@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"maps"
+	"slices"
 
 	"google.golang.org/adk/internal/llminternal/converters"
 	"google.golang.org/adk/model"
@@ -32,6 +34,18 @@ type streamingResponseAggregator struct {
 	thoughtText string
 	response    *model.LLMResponse
 	role        string
+	// candidates are the texts aggregated for each candidate, by index,
+	// when the model generates several candidates.
+	candidates map[int32]*candidateText
+}
+
+// candidateText is the text aggregated for one candidate.
+type candidateText struct {
+	text         string
+	thoughtText  string
+	role         string
+	finishReason genai.FinishReason
+	avgLogprobs  float64
 }
 
 // NewStreamingResponseAggregator creates a new, initialized streamingResponseAggregator.
@@ -51,6 +65,9 @@ func (s *streamingResponseAggregator) ProcessResponse(ctx context.Context, genRe
 		candidate := genResp.Candidates[0]
 		resp := converters.Genai2LLMResponse(genResp)
 		resp.TurnComplete = candidate.FinishReason != ""
+		if len(genResp.Candidates) > 1 || s.candidates != nil {
+			s.aggregateCandidates(genResp.Candidates)
+		}
 		// Aggregate the response and check if an intermediate event to yield was created
 		if aggrResp := s.aggregateResponse(resp); aggrResp != nil {
 			if !yield(aggrResp, nil) {
@@ -97,6 +114,39 @@ func (s *streamingResponseAggregator) aggregateResponse(llmResponse *model.LLMRe
 	return nil
 }
 
+// aggregateCandidates aggregates the texts of each candidate, so that the
+// aggregated response keeps all the candidates.
+func (s *streamingResponseAggregator) aggregateCandidates(candidates []*genai.Candidate) {
+	if s.candidates == nil {
+		s.candidates = make(map[int32]*candidateText)
+	}
+	for _, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+		agg, ok := s.candidates[candidate.Index]
+		if !ok {
+			agg = &candidateText{}
+			s.candidates[candidate.Index] = agg
+		}
+		if candidate.FinishReason != "" {
+			agg.finishReason = candidate.FinishReason
+		}
+		if candidate.AvgLogprobs != 0 {
+			agg.avgLogprobs = candidate.AvgLogprobs
+		}
+		if candidate.Content == nil || len(candidate.Content.Parts) == 0 || candidate.Content.Parts[0] == nil {
+			continue
+		}
+		agg.role = candidate.Content.Role
+		if part0 := candidate.Content.Parts[0]; part0.Thought {
+			agg.thoughtText += part0.Text
+		} else {
+			agg.text += part0.Text
+		}
+	}
+}
+
 // Close generates an aggregated response at the end, if needed,
 // this should be called after all the model responses are processed.
 func (s *streamingResponseAggregator) Close() *model.LLMResponse {
@@ -121,6 +171,15 @@ func (s *streamingResponseAggregator) createAggregateResponse() *model.LLMRespon
 			GroundingMetadata: s.response.GroundingMetadata,
 			FinishReason:      s.response.FinishReason,
 		}
+		if len(s.candidates) > 1 {
+			for _, i := range slices.Sorted(maps.Keys(s.candidates)) {
+				response.Candidates = append(response.Candidates, s.candidates[i].response(s.response.UsageMetadata))
+			}
+			// The other fields are the ones of the first candidate.
+			response.Content = response.Candidates[0].Content
+			response.FinishReason = response.Candidates[0].FinishReason
+			response.AvgLogprobs = response.Candidates[0].AvgLogprobs
+		}
 		s.clear()
 		return response
 	}
@@ -133,4 +192,25 @@ func (s *streamingResponseAggregator) clear() {
 	s.text = ""
 	s.thoughtText = ""
 	s.role = ""
+	s.candidates = nil
+}
+
+// response returns the aggregated response of the candidate.
+func (c *candidateText) response(usage *genai.GenerateContentResponseUsageMetadata) *model.LLMResponse {
+	resp := &model.LLMResponse{
+		FinishReason:  c.finishReason,
+		AvgLogprobs:   c.avgLogprobs,
+		UsageMetadata: usage,
+	}
+	var parts []*genai.Part
+	if c.thoughtText != "" {
+		parts = append(parts, &genai.Part{Text: c.thoughtText, Thought: true})
+	}
+	if c.text != "" {
+		parts = append(parts, &genai.Part{Text: c.text, Thought: false})
+	}
+	if len(parts) > 0 {
+		resp.Content = &genai.Content{Parts: parts, Role: c.role}
+	}
+	return resp
 }
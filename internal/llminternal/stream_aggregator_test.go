@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
@@ -204,3 +205,47 @@ func TestStreamAggregator(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamAggregator_Candidates(t *testing.T) {
+	chunk := func(texts ...string) *genai.GenerateContentResponse {
+		resp := &genai.GenerateContentResponse{}
+		for i, text := range texts {
+			resp.Candidates = append(resp.Candidates, &genai.Candidate{
+				Index:   int32(i),
+				Content: genai.NewContentFromText(text, genai.RoleModel),
+			})
+		}
+		return resp
+	}
+	last := chunk("!", "?")
+	for _, c := range last.Candidates {
+		c.FinishReason = genai.FinishReasonStop
+	}
+
+	aggregator := llminternal.NewStreamingResponseAggregator()
+	for _, resp := range []*genai.GenerateContentResponse{chunk("Hel", "Bon"), chunk("lo", "jour"), last} {
+		for got, err := range aggregator.ProcessResponse(t.Context(), resp) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Partial {
+				t.Errorf("got a non partial response %v before the end of the stream", got)
+			}
+			if len(got.Candidates) != 2 {
+				t.Errorf("partial response has %d candidates, want 2", len(got.Candidates))
+			}
+		}
+	}
+	got := aggregator.Close()
+	want := &model.LLMResponse{
+		Content:      genai.NewContentFromText("Hello!", genai.RoleModel),
+		FinishReason: genai.FinishReasonStop,
+		Candidates: []*model.LLMResponse{
+			{Content: genai.NewContentFromText("Hello!", genai.RoleModel), FinishReason: genai.FinishReasonStop},
+			{Content: genai.NewContentFromText("Bonjour?", genai.RoleModel), FinishReason: genai.FinishReasonStop},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("aggregated response mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// ErrorCodeTruncated is the error code of the final event of a model
+// response which is still truncated, e.g. when the model reached the maximum
+// number of output tokens or the stream ended before the final response.
+const ErrorCodeTruncated = "TRUNCATED"
+
+// continuationPrompt asks the model to continue its truncated response.
+const continuationPrompt = "Your previous response was cut off. Continue it exactly where it stopped, without repeating anything."
+
+// callLLMWithContinuations calls the LLM, handling the truncated responses.
+//
+// The partial responses are yielded as they come. When the final response of
+// a call is truncated, up to f.MaxContinuations continuation requests are
+// sent to the model, and the responses are stitched into one final response.
+// If the response is still truncated, the final response is marked with
+// [ErrorCodeTruncated] and the finish reason of the model. The final response
+// of a call is never partial.
+func (f *Flow) callLLMWithContinuations(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var stitched *model.LLMResponse
+		for continuation := 0; ; continuation++ {
+			last, ok := f.callLLMHoldingLast(ctx, req, stateDelta, yield)
+			if !ok {
+				return
+			}
+			if last == nil {
+				if stitched != nil {
+					yield(truncatedResponse(stitched), nil)
+				}
+				return
+			}
+			if stitched != nil {
+				last = stitchResponses(stitched, last)
+			}
+			if !isTruncated(last) {
+				yield(last, nil)
+				return
+			}
+			if continuation >= f.MaxContinuations || len(utils.FunctionCalls(last.Content)) > 0 || !startContinuation(ctx) {
+				yield(truncatedResponse(last), nil)
+				return
+			}
+			stitched = last
+			req = continuationRequest(req, last.Content)
+		}
+	}
+}
+
+// callLLMHoldingLast calls the LLM, yielding all the responses but the last
+// one, which is returned. The last response of a stream ending with a
+// partial response is made of the text of the partial responses since the
+// previous final response, so it is not partial. It returns false if the
+// call failed or the consumer stopped.
+func (f *Flow) callLLMHoldingLast(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any, yield func(*model.LLMResponse, error) bool) (*model.LLMResponse, bool) {
+	var last *model.LLMResponse
+	var partials []*genai.Part
+	for resp, err := range f.callLLM(ctx, req, stateDelta) {
+		if err != nil {
+			yield(nil, err)
+			return nil, false
+		}
+		if last != nil && !last.Partial {
+			if !yield(last, nil) {
+				return nil, false
+			}
+		}
+		last = resp
+		if !resp.Partial {
+			partials = nil
+			continue
+		}
+		if resp.Content != nil {
+			partials = append(partials, resp.Content.Parts...)
+		}
+		if !yield(resp, nil) {
+			return nil, false
+		}
+	}
+	if last == nil || !last.Partial {
+		return last, true
+	}
+	// The stream ended before the final response.
+	resp := &model.LLMResponse{
+		UsageMetadata: last.UsageMetadata,
+		FinishReason:  last.FinishReason,
+		Partial:       true,
+	}
+	if parts := mergeTextParts(partials); len(parts) > 0 {
+		resp.Content = &genai.Content{Role: genai.RoleModel, Parts: parts}
+	}
+	return resp, true
+}
+
+// isTruncated reports whether the model stopped before the end of its
+// response. The partial responses returned by callLLMHoldingLast are the
+// streams which ended before the final response.
+func isTruncated(resp *model.LLMResponse) bool {
+	return resp.Partial || resp.FinishReason == genai.FinishReasonMaxTokens
+}
+
+// startContinuation reports whether the budget of the invocation allows
+// another LLM call.
+func startContinuation(ctx agent.InvocationContext) bool {
+	tracker := budgetTracker(ctx)
+	return tracker == nil || tracker.StartCall() == nil
+}
+
+// continuationRequest returns the request asking the model to continue its
+// truncated response.
+func continuationRequest(req *model.LLMRequest, truncated *genai.Content) *model.LLMRequest {
+	next := *req
+	next.Contents = append(slices.Clip(req.Contents), truncated, genai.NewContentFromText(continuationPrompt, genai.RoleUser))
+	return &next
+}
+
+// stitchResponses returns the response continuing the truncated response
+// prev with the contents of next. The token counts are summed up, the other
+// fields are the ones of next.
+func stitchResponses(prev, next *model.LLMResponse) *model.LLMResponse {
+	resp := *next
+	var parts []*genai.Part
+	if prev.Content != nil {
+		parts = append(parts, prev.Content.Parts...)
+	}
+	if next.Content != nil {
+		parts = append(parts, next.Content.Parts...)
+	}
+	if len(parts) > 0 {
+		resp.Content = &genai.Content{Role: genai.RoleModel, Parts: mergeTextParts(parts)}
+	}
+	resp.UsageMetadata = sumUsage(prev.UsageMetadata, next.UsageMetadata)
+	return &resp
+}
+
+// truncatedResponse returns the final response of a truncated model
+// response. Its function calls are dropped, since their arguments may be cut
+// off, so that the tools are not run.
+func truncatedResponse(resp *model.LLMResponse) *model.LLMResponse {
+	truncated := *resp
+	truncated.Partial = false
+	truncated.TurnComplete = true
+	truncated.ErrorCode = ErrorCodeTruncated
+	if resp.FinishReason != "" {
+		truncated.ErrorMessage = fmt.Sprintf("model response truncated: finish reason %s", resp.FinishReason)
+	} else {
+		truncated.ErrorMessage = "model response truncated: stream ended before the final response"
+	}
+	if calls := utils.FunctionCalls(resp.Content); len(calls) > 0 {
+		content := *resp.Content
+		content.Parts = slices.DeleteFunc(slices.Clone(content.Parts), func(part *genai.Part) bool {
+			return part != nil && part.FunctionCall != nil
+		})
+		truncated.Content = &content
+		if len(content.Parts) == 0 {
+			truncated.Content = nil
+		}
+		truncated.ErrorMessage += fmt.Sprintf(", %d function calls dropped", len(calls))
+	}
+	return &truncated
+}
+
+// mergeTextParts returns the parts with the consecutive text parts of the
+// same kind, thought or not, merged.
+func mergeTextParts(parts []*genai.Part) []*genai.Part {
+	var merged []*genai.Part
+	for _, part := range parts {
+		if part == nil {
+			continue
+		}
+		if n := len(merged); n > 0 && isPlainText(part) && isPlainText(merged[n-1]) && merged[n-1].Thought == part.Thought {
+			merged[n-1] = &genai.Part{Text: merged[n-1].Text + part.Text, Thought: part.Thought}
+			continue
+		}
+		merged = append(merged, part)
+	}
+	return merged
+}
+
+// isPlainText reports whether the part only holds text.
+func isPlainText(part *genai.Part) bool {
+	rest := *part
+	rest.Text, rest.Thought = "", false
+	return part.Text != "" && reflect.ValueOf(rest).IsZero()
+}
+
+func sumUsage(a, b *genai.GenerateContentResponseUsageMetadata) *genai.GenerateContentResponseUsageMetadata {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        a.PromptTokenCount + b.PromptTokenCount,
+		CachedContentTokenCount: a.CachedContentTokenCount + b.CachedContentTokenCount,
+		CandidatesTokenCount:    a.CandidatesTokenCount + b.CandidatesTokenCount,
+		ThoughtsTokenCount:      a.ThoughtsTokenCount + b.ThoughtsTokenCount,
+		ToolUsePromptTokenCount: a.ToolUsePromptTokenCount + b.ToolUsePromptTokenCount,
+		TotalTokenCount:         a.TotalTokenCount + b.TotalTokenCount,
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionutils
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+)
+
+var (
+	// ErrNotFound is wrapped by the errors of the rewinds and forks of a
+	// session, event or invocation which doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidRequest is wrapped by the errors of the invalid rewind and
+	// fork requests.
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// RewindIndex returns the index of the event eventID, or of the first event
+// of the invocation invocationID. It returns the number of events if neither
+// is set. ids returns the ID and the invocation ID of an event.
+func RewindIndex[E any](events []E, ids func(E) (id, invocationID string), eventID, invocationID string) (int, error) {
+	if eventID != "" && invocationID != "" {
+		return 0, fmt.Errorf("%w: event_id and invocation_id are mutually exclusive, got event_id: %q, invocation_id: %q", ErrInvalidRequest, eventID, invocationID)
+	}
+	if eventID == "" && invocationID == "" {
+		return len(events), nil
+	}
+	for i, event := range events {
+		id, invID := ids(event)
+		if (eventID != "" && id == eventID) || (invocationID != "" && invID == invocationID) {
+			return i, nil
+		}
+	}
+	if eventID != "" {
+		return 0, fmt.Errorf("event %q %w", eventID, ErrNotFound)
+	}
+	return 0, fmt.Errorf("invocation %q %w", invocationID, ErrNotFound)
+}
+
+// ReplayState returns the session scoped state after the state deltas of the
+// events, applied to the initial state. stateDelta returns the state delta of
+// an event.
+func ReplayState[E any](initialState map[string]any, events []E, stateDelta func(E) map[string]any) map[string]any {
+	state := make(map[string]any, len(initialState))
+	maps.Copy(state, initialState)
+	for _, event := range events {
+		_, _, sessionDelta := ExtractStateDeltas(stateDelta(event))
+		maps.Copy(state, sessionDelta)
+	}
+	return state
+}
@@ -19,9 +19,11 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/model"
@@ -51,13 +53,17 @@ var (
 )
 
 const (
-	systemName            = "gcp.vertex.agent"
-	genAiOperationName    = "gen_ai.operation.name"
-	genAiToolDescription  = "gen_ai.tool.description"
-	genAiToolName         = "gen_ai.tool.name"
-	genAiToolCallID       = "gen_ai.tool.call.id"
-	genAiSystemName       = "gen_ai.system"
-	genAiRequestModelName = "gen_ai.request.model"
+	systemName             = "gcp.vertex.agent"
+	genAiOperationName     = "gen_ai.operation.name"
+	genAiToolDescription   = "gen_ai.tool.description"
+	genAiToolName          = "gen_ai.tool.name"
+	genAiToolCallID        = "gen_ai.tool.call.id"
+	genAiSystemName        = "gen_ai.system"
+	genAiRequestModelName  = "gen_ai.request.model"
+	genAiUsageInputTokens  = "gen_ai.usage.input_tokens"
+	genAiUsageOutputTokens = "gen_ai.usage.output_tokens"
+	genAiUsageCachedTokens = "gen_ai.usage.cache_read.input_tokens"
+	genAiFinishReasons     = "gen_ai.response.finish_reasons"
 
 	gcpVertexAgentLLMRequestName   = "gcp.vertex.agent.llm_request"
 	gcpVertexAgentToolCallArgsName = "gcp.vertex.agent.tool_call_args"
@@ -66,9 +72,18 @@ const (
 	gcpVertexAgentLLMResponseName  = "gcp.vertex.agent.llm_response"
 	gcpVertexAgentInvocationID     = "gcp.vertex.agent.invocation_id"
 	gcpVertexAgentSessionID        = "gcp.vertex.agent.session_id"
+	gcpVertexAgentLLMAttempt       = "gcp.vertex.agent.llm_attempt"
+	gcpVertexAgentLLMRetryDelay    = "gcp.vertex.agent.llm_retry_delay_ms"
+	gcpVertexAgentLLMFallbackModel = "gcp.vertex.agent.llm_fallback_model"
+	gcpVertexAgentLLMError         = "gcp.vertex.agent.llm_error"
+	gcpVertexAgentCacheOperation   = "gcp.vertex.agent.context_cache_operation"
+	gcpVertexAgentCacheName        = "gcp.vertex.agent.context_cache_name"
 
-	executeToolName = "execute_tool"
-	mergeToolName   = "(merged tools)"
+	executeToolName  = "execute_tool"
+	retryLLMName     = "retry_llm"
+	fallbackLLMName  = "fallback_llm"
+	contextCacheName = "context_cache"
+	mergeToolName    = "(merged tools)"
 )
 
 // AddSpanProcessor adds a span processor to the local tracer config.
@@ -208,13 +223,70 @@ func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmReque
 			attributes = append(attributes, attribute.Int("gen_ai.request.max_tokens", int(llmRequest.Config.MaxOutputTokens)))
 		}
 
-		// TODO: add usage_metadata and finish_reason once ADK has them.
+		if usage := event.UsageMetadata; usage != nil {
+			attributes = append(attributes,
+				attribute.Int(genAiUsageInputTokens, int(usage.PromptTokenCount)),
+				attribute.Int(genAiUsageOutputTokens, int(usage.CandidatesTokenCount)),
+				attribute.Int(genAiUsageCachedTokens, int(usage.CachedContentTokenCount)),
+			)
+		}
+
+		if event.FinishReason != "" {
+			attributes = append(attributes, attribute.StringSlice(genAiFinishReasons, []string{string(event.FinishReason)}))
+		}
 
 		span.SetAttributes(attributes...)
 		span.End()
 	}
 }
 
+// TraceLLMRetry traces the failed attempt of a model call which is retried
+// after the delay.
+func TraceLLMRetry(ctx context.Context, modelName string, attempt int, delay time.Duration, err error) {
+	for _, span := range StartTrace(ctx, retryLLMName) {
+		span.SetAttributes(
+			attribute.String(genAiSystemName, systemName),
+			attribute.String(genAiRequestModelName, modelName),
+			attribute.Int(gcpVertexAgentLLMAttempt, attempt),
+			attribute.Int64(gcpVertexAgentLLMRetryDelay, delay.Milliseconds()),
+			attribute.String(gcpVertexAgentLLMError, err.Error()),
+		)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+	}
+}
+
+// TraceLLMFallback traces the failed model call which falls back to the
+// fallback model.
+func TraceLLMFallback(ctx context.Context, modelName, fallbackModelName string, err error) {
+	for _, span := range StartTrace(ctx, fallbackLLMName) {
+		span.SetAttributes(
+			attribute.String(genAiSystemName, systemName),
+			attribute.String(genAiRequestModelName, modelName),
+			attribute.String(gcpVertexAgentLLMFallbackModel, fallbackModelName),
+			attribute.String(gcpVertexAgentLLMError, err.Error()),
+		)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+	}
+}
+
+// TraceContextCacheError traces the failed operation on the context cache of
+// the model, e.g. "create". The model call continues without the cache.
+func TraceContextCacheError(ctx context.Context, modelName, operation, cacheName string, err error) {
+	for _, span := range StartTrace(ctx, contextCacheName) {
+		span.SetAttributes(
+			attribute.String(genAiSystemName, systemName),
+			attribute.String(genAiRequestModelName, modelName),
+			attribute.String(gcpVertexAgentCacheOperation, operation),
+			attribute.String(gcpVertexAgentCacheName, cacheName),
+			attribute.String(gcpVertexAgentLLMError, err.Error()),
+		)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+	}
+}
+
 func safeSerialize(obj any) string {
 	dump, err := json.Marshal(obj)
 	if err != nil {
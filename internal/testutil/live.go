@@ -0,0 +1,190 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// MockLiveModel is a [model.LiveLLM] replaying scripted turns of the model.
+//
+// A turn of the model starts when the connection receives a content, the
+// end of an activity of the user, or a history ending with a user content.
+// The model then responds with the next turn of Turns. The turn stays open
+// until a response with TurnComplete: if an activity start is received while
+// the turn is open, the model is interrupted.
+type MockLiveModel struct {
+	// Turns are the responses of the model, one slice per turn.
+	Turns [][]*model.LLMResponse
+
+	mu sync.Mutex
+	// Requests are the requests of the connections.
+	Requests []*model.LLMRequest
+	// History is the history sent to the connections.
+	History []*genai.Content
+	// Contents are the contents sent to the connections.
+	Contents []*genai.Content
+	// RealtimeInputs are the realtime inputs sent to the connections.
+	RealtimeInputs []*genai.LiveRealtimeInput
+}
+
+// Name implements model.LLM.
+func (m *MockLiveModel) Name() string {
+	return "mock-live"
+}
+
+// GenerateContent implements model.LLM. It always fails, since the model only
+// supports bidi streaming.
+func (m *MockLiveModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		yield(nil, fmt.Errorf("%s only supports bidi streaming", m.Name()))
+	}
+}
+
+// Connect implements model.LiveLLM.
+func (m *MockLiveModel) Connect(ctx context.Context, req *model.LLMRequest) (model.LiveConnection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Requests = append(m.Requests, req)
+	return &mockLiveConnection{model: m, ready: make(chan struct{}, 1)}, nil
+}
+
+// nextTurn removes and returns the next turn of the model.
+func (m *MockLiveModel) nextTurn() []*model.LLMResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.Turns) == 0 {
+		return nil
+	}
+	turn := m.Turns[0]
+	m.Turns = m.Turns[1:]
+	return turn
+}
+
+type mockLiveConnection struct {
+	model *MockLiveModel
+
+	mu        sync.Mutex
+	responses []*model.LLMResponse
+	turnOpen  bool
+	closed    bool
+	// ready has a value when responses is not empty or the connection is
+	// closed.
+	ready chan struct{}
+}
+
+func (c *mockLiveConnection) SendHistory(ctx context.Context, history []*genai.Content) error {
+	c.model.mu.Lock()
+	c.model.History = append(c.model.History, history...)
+	c.model.mu.Unlock()
+	if len(history) > 0 && history[len(history)-1].Role == genai.RoleUser {
+		c.startTurn()
+	}
+	return nil
+}
+
+func (c *mockLiveConnection) SendContent(ctx context.Context, content *genai.Content) error {
+	c.model.mu.Lock()
+	c.model.Contents = append(c.model.Contents, content)
+	c.model.mu.Unlock()
+	c.startTurn()
+	return nil
+}
+
+func (c *mockLiveConnection) SendRealtime(ctx context.Context, input *genai.LiveRealtimeInput) error {
+	c.model.mu.Lock()
+	c.model.RealtimeInputs = append(c.model.RealtimeInputs, input)
+	c.model.mu.Unlock()
+	switch {
+	case input.ActivityStart != nil:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.turnOpen {
+			c.turnOpen = false
+			c.push(&model.LLMResponse{Interrupted: true})
+		}
+	case input.ActivityEnd != nil:
+		c.startTurn()
+	}
+	return nil
+}
+
+// Receive yields the responses of the model. Pending responses are still
+// yielded after the connection is closed.
+func (c *mockLiveConnection) Receive(ctx context.Context) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for {
+			c.mu.Lock()
+			if len(c.responses) > 0 {
+				resp := c.responses[0]
+				c.responses = c.responses[1:]
+				c.mu.Unlock()
+				if !yield(resp, nil) {
+					return
+				}
+				continue
+			}
+			closed := c.closed
+			c.mu.Unlock()
+			if closed {
+				return
+			}
+
+			select {
+			case <-c.ready:
+			case <-ctx.Done():
+				yield(nil, ctx.Err())
+				return
+			}
+		}
+	}
+}
+
+func (c *mockLiveConnection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.signal()
+	return nil
+}
+
+// startTurn starts the next turn of the model.
+func (c *mockLiveConnection) startTurn() {
+	turn := c.model.nextTurn()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.push(turn...)
+	c.turnOpen = len(turn) > 0 && !turn[len(turn)-1].TurnComplete
+}
+
+func (c *mockLiveConnection) push(responses ...*model.LLMResponse) {
+	c.responses = append(c.responses, responses...)
+	c.signal()
+}
+
+func (c *mockLiveConnection) signal() {
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+var _ model.LiveLLM = (*MockLiveModel)(nil)
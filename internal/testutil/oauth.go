@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// FakeOAuthServer is a local OAuth2 server supporting the authorization code,
+// refresh token, client credentials and JWT bearer grants.
+//
+// It accepts any JWT bearer assertion, without verifying the signature.
+type FakeOAuthServer struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+	// ExpiresIn is the lifetime of the issued access tokens in seconds.
+	ExpiresIn int
+
+	mu           sync.Mutex
+	codes        map[string]bool
+	refresh      map[string]bool
+	accessTokens map[string]bool
+	issued       int
+	grantTypes   []string
+}
+
+// NewFakeOAuthServer starts a fake OAuth2 server, closed when the test ends.
+func NewFakeOAuthServer(t *testing.T) *FakeOAuthServer {
+	t.Helper()
+	s := &FakeOAuthServer{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		ExpiresIn:    3600,
+		codes:        make(map[string]bool),
+		refresh:      make(map[string]bool),
+		accessTokens: make(map[string]bool),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/token", s.handleToken)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// AuthorizationURL returns the URL of the authorization endpoint.
+func (s *FakeOAuthServer) AuthorizationURL() string { return s.URL + "/authorize" }
+
+// TokenURL returns the URL of the token endpoint.
+func (s *FakeOAuthServer) TokenURL() string { return s.URL + "/token" }
+
+// Authorize simulates the user granting the access on the consent page and
+// returns the URL the user is redirected to, with the authorization code.
+func (s *FakeOAuthServer) Authorize(t *testing.T, authURI string) string {
+	t.Helper()
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authURI)
+	if err != nil {
+		t.Fatalf("failed to authorize: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned status %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+	return resp.Header.Get("Location")
+}
+
+// ValidAccessToken reports whether the access token was issued by the server.
+func (s *FakeOAuthServer) ValidAccessToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accessTokens[token]
+}
+
+// GrantTypes returns the grant types of the successful token requests.
+func (s *FakeOAuthServer) GrantTypes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.grantTypes...)
+}
+
+func (s *FakeOAuthServer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.ClientID || q.Get("response_type") != "code" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.issued++
+	code := fmt.Sprintf("code-%d", s.issued)
+	s.codes[code] = true
+	s.mu.Unlock()
+
+	rq := redirect.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *FakeOAuthServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	grantType := r.PostForm.Get("grant_type")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if grantType != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		if id != s.ClientID || secret != s.ClientSecret {
+			tokenError(w, "invalid_client")
+			return
+		}
+	}
+
+	withRefreshToken := false
+	switch grantType {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		if !s.codes[code] {
+			tokenError(w, "invalid_grant")
+			return
+		}
+		delete(s.codes, code)
+		withRefreshToken = true
+	case "refresh_token":
+		token := r.PostForm.Get("refresh_token")
+		if !s.refresh[token] {
+			tokenError(w, "invalid_grant")
+			return
+		}
+		delete(s.refresh, token)
+		withRefreshToken = true
+	case "client_credentials":
+	case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+		if r.PostForm.Get("assertion") == "" {
+			tokenError(w, "invalid_grant")
+			return
+		}
+	default:
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+	s.grantTypes = append(s.grantTypes, grantType)
+
+	s.issued++
+	resp := map[string]any{
+		"access_token": fmt.Sprintf("access-%d", s.issued),
+		"token_type":   "Bearer",
+		"expires_in":   s.ExpiresIn,
+	}
+	s.accessTokens[resp["access_token"].(string)] = true
+	if withRefreshToken {
+		refreshToken := fmt.Sprintf("refresh-%d", s.issued)
+		s.refresh[refreshToken] = true
+		resp["refresh_token"] = refreshToken
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
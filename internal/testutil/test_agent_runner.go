@@ -93,6 +93,19 @@ func (r *TestAgentRunner) RunContentWithConfig(t *testing.T, sessionID string, c
 	return r.runner.Run(ctx, userID, session.ID(), content, cfg)
 }
 
+// RunLive runs the agent in bidi streaming mode in the session, created if
+// needed.
+func (r *TestAgentRunner) RunLive(t *testing.T, sessionID string, queue *agent.LiveRequestQueue, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	t.Helper()
+
+	session, err := r.session(t, r.appName, "test_user", sessionID)
+	if err != nil {
+		t.Fatalf("failed to get/create session: %v", err)
+	}
+
+	return r.runner.RunLive(t.Context(), "test_user", session.ID(), queue, cfg)
+}
+
 // NewTestAgentRunner creates a new TestAgentRunner for the given agent as root
 // initSessionState will be used to init all sessions created by this runner.
 func NewTestAgentRunner(t *testing.T, agent agent.Agent) *TestAgentRunner {
@@ -16,10 +16,13 @@ package toolinternal
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	authinternal "google.golang.org/adk/internal/auth"
 	contextinternal "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/session"
@@ -41,7 +44,8 @@ func (ia *internalArtifacts) Save(ctx context.Context, name string, data *genai.
 		if ia.eventActions.ArtifactDelta == nil {
 			ia.eventActions.ArtifactDelta = make(map[string]int64)
 		}
-		// TODO: RWLock, check the version stored is newer in case multiple tools save the same file.
+		// Every function call has its own actions. The latest version is
+		// kept when the actions of parallel calls are merged.
 		ia.eventActions.ArtifactDelta[name] = resp.Version
 	}
 	return resp, nil
@@ -95,6 +99,32 @@ func (c *toolContext) AgentName() string {
 	return c.invocationContext.Agent().Name()
 }
 
+func (c *toolContext) LoadCredential(ctx context.Context, cfg *auth.Config) (*auth.Credential, error) {
+	credentials := authinternal.FromContext(c.invocationContext)
+	if credentials == nil {
+		return nil, fmt.Errorf("credential service is not configured")
+	}
+	return credentials.Load(ctx, cfg)
+}
+
+func (c *toolContext) RequestCredential(cfg *auth.Config) error {
+	credentials := authinternal.FromContext(c.invocationContext)
+	if credentials == nil {
+		return fmt.Errorf("credential service is not configured")
+	}
+	// Only the client part of the request is recorded in the event, the
+	// raw credential is kept by the credential service.
+	cfg, err := credentials.RequestAuth(c.invocationContext, cfg)
+	if err != nil {
+		return err
+	}
+	if c.eventActions.RequestedAuthConfigs == nil {
+		c.eventActions.RequestedAuthConfigs = make(map[string]*auth.Config)
+	}
+	c.eventActions.RequestedAuthConfigs[c.functionCallID] = cfg
+	return nil
+}
+
 func (c *toolContext) SearchMemory(ctx context.Context, query string) (*memory.SearchResponse, error) {
 	return c.invocationContext.Memory().Search(ctx, query)
 }
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strings"
 
 	"google.golang.org/genai"
 )
@@ -134,3 +135,71 @@ func ValidateOutputSchema(output string, schema *genai.Schema) (map[string]any,
 	}
 	return outputMap, nil
 }
+
+// JSONSchema translates the schema to JSON Schema, for the models of other
+// providers.
+func JSONSchema(s *genai.Schema) map[string]any {
+	if s == nil {
+		return nil
+	}
+	m := make(map[string]any)
+	if s.Type != "" {
+		m["type"] = strings.ToLower(string(s.Type))
+		if s.Nullable != nil && *s.Nullable {
+			m["type"] = []string{strings.ToLower(string(s.Type)), "null"}
+		}
+	}
+	if s.Title != "" {
+		m["title"] = s.Title
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	if s.Format != "" {
+		m["format"] = s.Format
+	}
+	if s.Pattern != "" {
+		m["pattern"] = s.Pattern
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = s.Enum
+	}
+	if s.Default != nil {
+		m["default"] = s.Default
+	}
+	if s.Items != nil {
+		m["items"] = JSONSchema(s.Items)
+	}
+	if len(s.Properties) > 0 {
+		properties := make(map[string]any, len(s.Properties))
+		for name, p := range s.Properties {
+			properties[name] = JSONSchema(p)
+		}
+		m["properties"] = properties
+	}
+	if len(s.Required) > 0 {
+		m["required"] = s.Required
+	}
+	if len(s.AnyOf) > 0 {
+		var anyOf []any
+		for _, a := range s.AnyOf {
+			anyOf = append(anyOf, JSONSchema(a))
+		}
+		m["anyOf"] = anyOf
+	}
+	for name, v := range map[string]*float64{"minimum": s.Minimum, "maximum": s.Maximum} {
+		if v != nil {
+			m[name] = *v
+		}
+	}
+	for name, v := range map[string]*int64{
+		"minItems": s.MinItems, "maxItems": s.MaxItems,
+		"minLength": s.MinLength, "maxLength": s.MaxLength,
+		"minProperties": s.MinProperties, "maxProperties": s.MaxProperties,
+	} {
+		if v != nil {
+			m[name] = *v
+		}
+	}
+	return m
+}
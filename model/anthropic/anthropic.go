@@ -0,0 +1,326 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anthropic implements the [model.LLM] interface for Claude models,
+// backed by the Anthropic Messages API.
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+
+	"google.golang.org/adk/internal/version"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultBaseURL is the URL of the Anthropic API.
+	DefaultBaseURL = "https://api.anthropic.com"
+	// DefaultMaxTokens is the default maximum number of tokens of the
+	// replies, which the API requires.
+	DefaultMaxTokens = 4096
+
+	apiVersion = "2023-06-01"
+)
+
+// Config is the configuration of the Messages API client.
+type Config struct {
+	// BaseURL is the URL of the API, without the /v1/messages path.
+	// Defaults to [DefaultBaseURL].
+	BaseURL string
+	// APIKey defaults to the value of the ANTHROPIC_API_KEY environment
+	// variable.
+	APIKey string
+	// HTTPClient sends the requests. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+	// Headers are added to the requests, e.g. the anthropic-beta header.
+	Headers http.Header
+	// MaxTokens is the maximum number of tokens of the replies, when the
+	// requests do not set MaxOutputTokens. Defaults to [DefaultMaxTokens].
+	MaxTokens int32
+}
+
+func init() {
+	model.Register("claude-*", func(ctx context.Context, name string) (model.LLM, error) {
+		return NewModel(name, nil)
+	})
+	model.Register("anthropic/*", func(ctx context.Context, name string) (model.LLM, error) {
+		return NewModel(strings.TrimPrefix(name, "anthropic/"), nil)
+	})
+}
+
+type anthropicModel struct {
+	name               string
+	baseURL            string
+	apiKey             string
+	client             *http.Client
+	headers            http.Header
+	maxTokens          int32
+	versionHeaderValue string
+}
+
+// NewModel returns [model.LLM], backed by the Anthropic Messages API. The
+// modelName specifies which Claude model to target (e.g.,
+// "claude-sonnet-4-5"). The cfg may be nil.
+func NewModel(modelName string, cfg *Config) (model.LLM, error) {
+	if modelName == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	m := &anthropicModel{
+		name:      modelName,
+		baseURL:   strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:    cfg.APIKey,
+		client:    cfg.HTTPClient,
+		headers:   cfg.Headers,
+		maxTokens: cfg.MaxTokens,
+		versionHeaderValue: fmt.Sprintf("google-adk/%s gl-go/%s", version.Version,
+			strings.TrimPrefix(runtime.Version(), "go")),
+	}
+	if m.baseURL == "" {
+		m.baseURL = DefaultBaseURL
+	}
+	if m.apiKey == "" {
+		m.apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if m.client == nil {
+		m.client = http.DefaultClient
+	}
+	if m.maxTokens <= 0 {
+		m.maxTokens = DefaultMaxTokens
+	}
+	return m, nil
+}
+
+func (m *anthropicModel) Name() string {
+	return m.name
+}
+
+// GenerateContent calls the underlying model.
+//
+// In streaming mode, the text and thinking deltas are yielded as partial
+// responses, followed by the complete response, with the function calls, the
+// usage and TurnComplete set.
+func (m *anthropicModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		msgReq, err := m.messagesRequest(req, stream)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		body, err := m.send(ctx, msgReq)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer body.Close()
+
+		if !stream {
+			var msg messageResponse
+			if err := json.NewDecoder(body).Decode(&msg); err != nil {
+				yield(nil, fmt.Errorf("failed to decode the response: %w", err))
+				return
+			}
+			resp, err := llmResponse(&msg)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			resp.TurnComplete = true
+			yield(resp, nil)
+			return
+		}
+		for resp, err := range readStream(body) {
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// send posts the request to the API, returning the response body.
+func (m *anthropicModel) send(ctx context.Context, msgReq *messagesRequest) (io.ReadCloser, error) {
+	data, err := json.Marshal(msgReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v1/messages", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range m.headers {
+		httpReq.Header[k] = v
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", m.versionHeaderValue)
+	httpReq.Header.Set("Anthropic-Version", apiVersion)
+	if m.apiKey != "" {
+		httpReq.Header.Set("X-Api-Key", m.apiKey)
+	}
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call model: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp.Body, nil
+}
+
+// APIError is the error replied by the API.
+type APIError struct {
+	StatusCode int
+	// Type is the type of the error, e.g. "overloaded_error".
+	Type    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("failed to call model: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Retryable reports whether the request may succeed if sent again, i.e. the
+// API timed out, was rate limited, overloaded or failed with a server error.
+// Errors in the middle of a stream are reported with the status code 200 and
+// are retryable according to their type.
+func (e *APIError) Retryable() bool {
+	switch e.Type {
+	case "rate_limit_error", "overloaded_error", "api_error", "timeout_error":
+		return true
+	}
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newAPIError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var body errorResponse
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error.Message != "" {
+		apiErr.Type = body.Error.Type
+		apiErr.Message = body.Error.Message
+	}
+	return apiErr
+}
+
+// readStream reads the server-sent events of a streaming request.
+func readStream(body io.Reader) iter.Seq2[*model.LLMResponse, error] {
+	// reference: https://docs.anthropic.com/en/docs/build-with-claude/streaming
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var msg messageResponse
+		// The JSON of the tool inputs is streamed in fragments.
+		inputs := make(map[int]*strings.Builder)
+
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			var ev streamEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+				yield(nil, fmt.Errorf("failed to decode the response event: %w", err))
+				return
+			}
+
+			switch ev.Type {
+			case "error":
+				yield(nil, &APIError{StatusCode: http.StatusOK, Type: ev.Error.Type, Message: ev.Error.Message})
+				return
+			case "message_start":
+				if ev.Message != nil {
+					msg = *ev.Message
+				}
+			case "content_block_start":
+				if ev.ContentBlock == nil {
+					continue
+				}
+				for len(msg.Content) <= ev.Index {
+					msg.Content = append(msg.Content, &block{})
+				}
+				msg.Content[ev.Index] = ev.ContentBlock
+				if ev.ContentBlock.Type == "tool_use" {
+					inputs[ev.Index] = &strings.Builder{}
+				}
+			case "content_block_delta":
+				if ev.Delta == nil || ev.Index >= len(msg.Content) {
+					continue
+				}
+				b := msg.Content[ev.Index]
+				var part *genai.Part
+				switch ev.Delta.Type {
+				case "text_delta":
+					b.Text += ev.Delta.Text
+					part = genai.NewPartFromText(ev.Delta.Text)
+				case "thinking_delta":
+					b.Thinking += ev.Delta.Thinking
+					part = &genai.Part{Text: ev.Delta.Thinking, Thought: true}
+				case "signature_delta":
+					b.Signature += ev.Delta.Signature
+				case "input_json_delta":
+					if input := inputs[ev.Index]; input != nil {
+						input.WriteString(ev.Delta.PartialJSON)
+					}
+				}
+				if part != nil {
+					partial := &model.LLMResponse{
+						Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{part}},
+						Partial: true,
+					}
+					if !yield(partial, nil) {
+						return
+					}
+				}
+			case "message_delta":
+				if ev.Delta != nil && ev.Delta.StopReason != "" {
+					msg.StopReason = ev.Delta.StopReason
+				}
+				if ev.Usage != nil {
+					msg.Usage.OutputTokens = ev.Usage.OutputTokens
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(nil, fmt.Errorf("failed to read the response stream: %w", err))
+			return
+		}
+
+		for i, input := range inputs {
+			if input.Len() > 0 {
+				msg.Content[i].Input = json.RawMessage(input.String())
+			}
+		}
+		resp, err := llmResponse(&msg)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		resp.TurnComplete = true
+		yield(resp, nil)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+//go:generate go test -httprecord=testdata/.*\.httprr
+
+func TestModel_Generate(t *testing.T) {
+	weatherTool := &genai.Tool{FunctionDeclarations: []*genai.FunctionDeclaration{{
+		Name:        "get_weather",
+		Description: "Returns the weather in a city.",
+		Parameters: &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: map[string]*genai.Schema{"city": {Type: genai.TypeString}},
+			Required:   []string{"city"},
+		},
+	}}}
+
+	tests := []struct {
+		name    string
+		req     *model.LLMRequest
+		want    *model.LLMResponse
+		wantErr bool
+	}{
+		{
+			name: "text",
+			req: &model.LLMRequest{
+				Contents: genai.Text("What is the capital of France? One word."),
+				Config: &genai.GenerateContentConfig{
+					SystemInstruction: genai.NewContentFromText("You are a geography teacher.", genai.RoleUser),
+					Temperature:       new(float32),
+				},
+			},
+			want: &model.LLMResponse{
+				Content: genai.NewContentFromText("Paris", genai.RoleModel),
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+					PromptTokenCount:     25,
+					CandidatesTokenCount: 4,
+					TotalTokenCount:      29,
+				},
+				FinishReason: genai.FinishReasonStop,
+				TurnComplete: true,
+			},
+		},
+		{
+			name: "tool_use",
+			req: &model.LLMRequest{
+				Contents: genai.Text("What is the weather in Paris?"),
+				Config:   &genai.GenerateContentConfig{Tools: []*genai.Tool{weatherTool}},
+			},
+			want: &model.LLMResponse{
+				Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+					genai.NewPartFromText("I'll check the weather in Paris."),
+					{FunctionCall: &genai.FunctionCall{
+						ID:   "toolu_01A09q90qw90lq917835lq9",
+						Name: "get_weather",
+						Args: map[string]any{"city": "Paris"},
+					}},
+				}},
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+					PromptTokenCount:     380,
+					CandidatesTokenCount: 62,
+					TotalTokenCount:      442,
+				},
+				FinishReason: genai.FinishReasonStop,
+				TurnComplete: true,
+			},
+		},
+		{
+			name: "tool_result",
+			req: &model.LLMRequest{
+				Contents: []*genai.Content{
+					genai.NewContentFromText("What is the weather in Paris?", genai.RoleUser),
+					genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel),
+					genai.NewContentFromFunctionResponse("get_weather", map[string]any{"weather": "sunny"}, genai.RoleUser),
+				},
+				Config: &genai.GenerateContentConfig{Tools: []*genai.Tool{weatherTool}},
+			},
+			want: &model.LLMResponse{
+				Content: genai.NewContentFromText("It is sunny in Paris.", genai.RoleModel),
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+					PromptTokenCount:        450,
+					CachedContentTokenCount: 300,
+					CandidatesTokenCount:    9,
+					TotalTokenCount:         459,
+				},
+				FinishReason: genai.FinishReasonStop,
+				TurnComplete: true,
+			},
+		},
+		{
+			name: "image",
+			req: &model.LLMRequest{
+				Contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+					genai.NewPartFromBytes([]byte("\x89PNG\r\n\x1a\n"), "image/png"),
+					genai.NewPartFromText("Describe the image."),
+				}}},
+				Config: &genai.GenerateContentConfig{MaxOutputTokens: 100},
+			},
+			want: &model.LLMResponse{
+				Content: genai.NewContentFromText("The image is empty.", genai.RoleModel),
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+					PromptTokenCount:     30,
+					CandidatesTokenCount: 6,
+					TotalTokenCount:      36,
+				},
+				FinishReason: genai.FinishReasonStop,
+				TurnComplete: true,
+			},
+		},
+		{
+			name: "thinking",
+			req: &model.LLMRequest{
+				Contents: genai.Text("Is 1001 prime?"),
+				Config: &genai.GenerateContentConfig{
+					ThinkingConfig: &genai.ThinkingConfig{IncludeThoughts: true, ThinkingBudget: genai.Ptr[int32](1024)},
+				},
+			},
+			want: &model.LLMResponse{
+				Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+					{Text: "1001 = 7 * 11 * 13.", Thought: true, ThoughtSignature: []byte("EqQBCgIYAhIM1gbcDa9GJwZA2b3h")},
+					genai.NewPartFromText("No, 1001 = 7 × 11 × 13."),
+				}},
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+					PromptTokenCount:     40,
+					CandidatesTokenCount: 50,
+					TotalTokenCount:      90,
+				},
+				FinishReason: genai.FinishReasonStop,
+				TurnComplete: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRecordFilename := filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")+".httprr")
+
+			testModel := newTestModel(t, httpRecordFilename)
+			for got, err := range testModel.GenerateContent(t.Context(), tt.req, false) {
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Model.Generate() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("Model.Generate() = %v, want %v\ndiff(-want +got):\n%v", got, tt.want, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestModel_Generate_APIError(t *testing.T) {
+	httpRecordFilename := filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")+".httprr")
+
+	testModel := newTestModel(t, httpRecordFilename)
+	for _, err := range testModel.GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Hello")}, false) {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Model.Generate() error = %v, want APIError", err)
+		}
+		want := &APIError{StatusCode: 529, Type: "overloaded_error", Message: "Overloaded"}
+		if diff := cmp.Diff(want, apiErr); diff != "" {
+			t.Errorf("Model.Generate() error = %v, want %v\ndiff(-want +got):\n%v", apiErr, want, diff)
+		}
+	}
+}
+
+func TestModel_GenerateStream(t *testing.T) {
+	httpRecordFilename := filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")+".httprr")
+
+	testModel := newTestModel(t, httpRecordFilename)
+	req := &model.LLMRequest{
+		Contents: genai.Text("What is the weather in Paris?"),
+		Config: &genai.GenerateContentConfig{Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+			Name:                 "get_weather",
+			ParametersJsonSchema: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		}}}}},
+	}
+	var got []*model.LLMResponse
+	for resp, err := range testModel.GenerateContent(t.Context(), req, true) {
+		if err != nil {
+			t.Fatalf("Model.GenerateStream() failed: %v", err)
+		}
+		got = append(got, resp)
+	}
+
+	want := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Let me ", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("check.", genai.RoleModel), Partial: true},
+		{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				genai.NewPartFromText("Let me check."),
+				{FunctionCall: &genai.FunctionCall{ID: "toolu_01T1x1fJ34qAmk2tNTrN7Up6", Name: "get_weather", Args: map[string]any{"city": "Paris"}}},
+			}},
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+				PromptTokenCount:     472,
+				CandidatesTokenCount: 89,
+				TotalTokenCount:      561,
+			},
+			FinishReason: genai.FinishReasonStop,
+			TurnComplete: true,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Model.GenerateStream() = %v, want %v\ndiff(-want +got):\n%v", got, want, diff)
+	}
+}
+
+func newTestModel(t *testing.T, rrfile string) model.LLM {
+	t.Helper()
+	cfg, rr, err := NewRecordReplayConfig(rrfile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rr.Close() })
+	m, err := NewModel("claude-sonnet-4-5", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
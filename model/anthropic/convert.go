@@ -0,0 +1,351 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// The types of the Messages API.
+// reference: https://docs.anthropic.com/en/api/messages
+
+type messagesRequest struct {
+	Model         string     `json:"model"`
+	MaxTokens     int32      `json:"max_tokens"`
+	System        string     `json:"system,omitempty"`
+	Messages      []*message `json:"messages"`
+	Tools         []*tool    `json:"tools,omitempty"`
+	Temperature   *float32   `json:"temperature,omitempty"`
+	TopP          *float32   `json:"top_p,omitempty"`
+	TopK          *int32     `json:"top_k,omitempty"`
+	StopSequences []string   `json:"stop_sequences,omitempty"`
+	Thinking      *thinking  `json:"thinking,omitempty"`
+	Stream        bool       `json:"stream,omitempty"`
+}
+
+type message struct {
+	Role    string   `json:"role"`
+	Content []*block `json:"content"`
+}
+
+// block is a content block of a message.
+type block struct {
+	Type string `json:"type"`
+	// text
+	Text string `json:"text,omitempty"`
+	// image and document
+	Source *source `json:"source,omitempty"`
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+	// thinking and redacted_thinking
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+type source struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type thinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int32  `json:"budget_tokens"`
+}
+
+type messageResponse struct {
+	Content    []*block `json:"content"`
+	StopReason string   `json:"stop_reason"`
+	Usage      usage    `json:"usage"`
+}
+
+type usage struct {
+	InputTokens              int32 `json:"input_tokens"`
+	OutputTokens             int32 `json:"output_tokens"`
+	CacheCreationInputTokens int32 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int32 `json:"cache_read_input_tokens"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type streamEvent struct {
+	Type         string           `json:"type"`
+	Index        int              `json:"index"`
+	Message      *messageResponse `json:"message"`
+	ContentBlock *block           `json:"content_block"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		Signature   string `json:"signature"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *usage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// messagesRequest translates the LLM request to a Messages API request.
+func (m *anthropicModel) messagesRequest(req *model.LLMRequest, stream bool) (*messagesRequest, error) {
+	cfg := req.Config
+	if cfg == nil {
+		cfg = &genai.GenerateContentConfig{}
+	}
+	msgReq := &messagesRequest{
+		Model:         m.name,
+		MaxTokens:     m.maxTokens,
+		Temperature:   cfg.Temperature,
+		TopP:          cfg.TopP,
+		StopSequences: cfg.StopSequences,
+		Stream:        stream,
+	}
+	if cfg.MaxOutputTokens > 0 {
+		msgReq.MaxTokens = cfg.MaxOutputTokens
+	}
+	if cfg.TopK != nil {
+		topK := int32(*cfg.TopK)
+		msgReq.TopK = &topK
+	}
+	if tc := cfg.ThinkingConfig; tc != nil && tc.ThinkingBudget != nil && *tc.ThinkingBudget > 0 {
+		msgReq.Thinking = &thinking{Type: "enabled", BudgetTokens: *tc.ThinkingBudget}
+	}
+	if cfg.SystemInstruction != nil {
+		var texts []string
+		for _, part := range cfg.SystemInstruction.Parts {
+			if part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+		msgReq.System = strings.Join(texts, "\n")
+	}
+
+	msgs, err := apiMessages(req.Contents)
+	if err != nil {
+		return nil, err
+	}
+	msgReq.Messages = msgs
+
+	for _, t := range cfg.Tools {
+		for _, decl := range t.FunctionDeclarations {
+			tl := &tool{Name: decl.Name, Description: decl.Description}
+			switch {
+			case decl.ParametersJsonSchema != nil:
+				tl.InputSchema = decl.ParametersJsonSchema
+			case decl.Parameters != nil:
+				tl.InputSchema = utils.JSONSchema(decl.Parameters)
+			default:
+				tl.InputSchema = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			msgReq.Tools = append(msgReq.Tools, tl)
+		}
+	}
+	return msgReq, nil
+}
+
+// apiMessages translates the contents to messages. The consecutive contents of
+// the same role are merged, since the API requires alternating roles.
+//
+// The IDs of the function calls are not always sent to the model, but the
+// API requires them to match the tool results. Missing IDs are generated,
+// and the responses are matched with the calls by name, in order.
+func apiMessages(contents []*genai.Content) ([]*message, error) {
+	var messages []*message
+	pendingIDs := make(map[string][]string)
+	generated := 0
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		role := "user"
+		if content.Role == genai.RoleModel {
+			role = "assistant"
+		}
+		var blocks []*block
+		for _, part := range content.Parts {
+			switch {
+			case part.Thought:
+				// The thinking blocks are sent back with their signatures,
+				// which the API verifies.
+				if len(part.ThoughtSignature) == 0 || role != "assistant" {
+					continue
+				}
+				if part.Text == "" {
+					blocks = append(blocks, &block{Type: "redacted_thinking", Data: string(part.ThoughtSignature)})
+				} else {
+					blocks = append(blocks, &block{Type: "thinking", Thinking: part.Text, Signature: string(part.ThoughtSignature)})
+				}
+			case part.FunctionCall != nil:
+				fc := part.FunctionCall
+				id := fc.ID
+				if id == "" {
+					generated++
+					id = fmt.Sprintf("toolu_%d", generated)
+				}
+				pendingIDs[fc.Name] = append(pendingIDs[fc.Name], id)
+				input, err := json.Marshal(fc.Args)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode the arguments of function %q: %w", fc.Name, err)
+				}
+				if fc.Args == nil {
+					input = []byte("{}")
+				}
+				blocks = append(blocks, &block{Type: "tool_use", ID: id, Name: fc.Name, Input: input})
+			case part.FunctionResponse != nil:
+				fr := part.FunctionResponse
+				id := fr.ID
+				if ids := pendingIDs[fr.Name]; len(ids) > 0 {
+					if id == "" {
+						id = ids[0]
+					}
+					pendingIDs[fr.Name] = ids[1:]
+				}
+				data, err := json.Marshal(fr.Response)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode the response of function %q: %w", fr.Name, err)
+				}
+				_, isError := fr.Response["error"]
+				blocks = append(blocks, &block{Type: "tool_result", ToolUseID: id, Content: string(data), IsError: isError})
+			case part.Text != "":
+				blocks = append(blocks, &block{Type: "text", Text: part.Text})
+			case part.InlineData != nil:
+				b, err := mediaBlock(part.InlineData.MIMEType, &source{
+					Type:      "base64",
+					MediaType: part.InlineData.MIMEType,
+					Data:      base64.StdEncoding.EncodeToString(part.InlineData.Data),
+				})
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, b)
+			case part.FileData != nil:
+				b, err := mediaBlock(part.FileData.MIMEType, &source{Type: "url", URL: part.FileData.FileURI})
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, b)
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, &message{Role: role, Content: blocks})
+	}
+	return messages, nil
+}
+
+// mediaBlock returns the block of the image or the PDF document.
+func mediaBlock(mimeType string, src *source) (*block, error) {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return &block{Type: "image", Source: src}, nil
+	case mimeType == "application/pdf":
+		return &block{Type: "document", Source: src}, nil
+	default:
+		return nil, fmt.Errorf("unsupported data of type %q", mimeType)
+	}
+}
+
+// llmResponse translates the message replied by the model.
+func llmResponse(msg *messageResponse) (*model.LLMResponse, error) {
+	var parts []*genai.Part
+	for _, b := range msg.Content {
+		switch b.Type {
+		case "text":
+			if b.Text != "" {
+				parts = append(parts, genai.NewPartFromText(b.Text))
+			}
+		case "thinking":
+			parts = append(parts, &genai.Part{Text: b.Thinking, Thought: true, ThoughtSignature: []byte(b.Signature)})
+		case "redacted_thinking":
+			parts = append(parts, &genai.Part{Thought: true, ThoughtSignature: []byte(b.Data)})
+		case "tool_use":
+			var args map[string]any
+			if len(b.Input) > 0 {
+				if err := json.Unmarshal(b.Input, &args); err != nil {
+					return nil, fmt.Errorf("invalid input of tool %q: %w", b.Name, err)
+				}
+			}
+			parts = append(parts, &genai.Part{FunctionCall: &genai.FunctionCall{ID: b.ID, Name: b.Name, Args: args}})
+		}
+	}
+	resp := &model.LLMResponse{
+		UsageMetadata: usageMetadata(msg.Usage),
+		FinishReason:  finishReason(msg.StopReason),
+	}
+	if len(parts) > 0 {
+		resp.Content = &genai.Content{Role: genai.RoleModel, Parts: parts}
+	}
+	return resp, nil
+}
+
+// usageMetadata maps the usage. The input tokens of the API exclude the
+// tokens read from or written to the prompt cache.
+func usageMetadata(u usage) *genai.GenerateContentResponseUsageMetadata {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        prompt,
+		CachedContentTokenCount: u.CacheReadInputTokens,
+		CandidatesTokenCount:    u.OutputTokens,
+		TotalTokenCount:         prompt + u.OutputTokens,
+	}
+}
+
+func finishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "end_turn", "stop_sequence", "tool_use", "pause_turn":
+		return genai.FinishReasonStop
+	case "max_tokens":
+		return genai.FinishReasonMaxTokens
+	case "refusal":
+		return genai.FinishReasonSafety
+	default:
+		return genai.FinishReasonOther
+	}
+}
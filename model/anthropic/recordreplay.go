@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/adk/adktest/httprr"
+)
+
+// NewRecordReplayConfig returns a copy of cfg whose HTTP client records the
+// traffic to the Anthropic API in rrfile, or replays it from there, using the
+// [httprr] package. It is meant for the tests of agents using Claude models.
+//
+// The requests are scrubbed of the User-Agent header and their JSON bodies
+// are compacted. In replay mode, a placeholder API key is set when cfg has
+// none, since the credentials are redacted from the recordings.
+//
+// The caller should close the returned [httprr.RecordReplay] when done.
+func NewRecordReplayConfig(rrfile string, cfg *Config) (*Config, *httprr.RecordReplay, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	base := http.DefaultTransport
+	if c.HTTPClient != nil && c.HTTPClient.Transport != nil {
+		base = c.HTTPClient.Transport
+	}
+	rr, err := httprr.Open(rrfile, base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("httprr.Open(%q) failed: %w", rrfile, err)
+	}
+	// User-Agent contains version numbers.
+	rr.ScrubReq(httprr.ScrubHeaders("User-Agent"), httprr.CompactJSON)
+	client := rr.Client()
+	if c.HTTPClient != nil {
+		copied := *c.HTTPClient
+		copied.Transport = rr
+		client = &copied
+	}
+	c.HTTPClient = client
+	if !rr.Recording() && c.APIKey == "" {
+		c.APIKey = "fakekey"
+	}
+	return &c, rr, nil
+}
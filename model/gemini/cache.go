@@ -0,0 +1,262 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+const (
+	defaultCacheTTL       = 30 * time.Minute
+	defaultCacheMinTokens = 1024
+	defaultCacheMaxUses   = 10
+)
+
+// contextCache manages the cached contents of the stable prefixes of the
+// requests, i.e. the system instruction, tools, tool config and earlier
+// contents.
+//
+// A request uses the cache of its longest prefix. When there is none, or the
+// cache has been used MaxUses times, a cache is created for the request
+// contents but the last one, superseding the previous cache. The caches of
+// the same contents with another system instruction, tools or tool config,
+// e.g. after a new instruction, are superseded too. The caches without
+// contents can't be told apart from the caches of other agents: they are not
+// used anymore and expire after their TTL.
+type contextCache struct {
+	caches *genai.Caches
+	now    func() time.Time
+
+	mu sync.Mutex
+	// entries are the cached contents by fingerprint of their prefix.
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	name        string
+	fingerprint string
+	// contentsFingerprint is the fingerprint of the cached contents alone.
+	contentsFingerprint string
+	// numContents is the number of request contents in the cache.
+	numContents int
+	expireTime  time.Time
+	uses        int
+}
+
+func newContextCache(caches *genai.Caches) *contextCache {
+	return &contextCache{caches: caches, now: time.Now, entries: make(map[string]*cacheEntry)}
+}
+
+// apply returns the request using the cache of its prefix, creating or
+// refreshing the cache if needed, and the cache used. It returns the request
+// unchanged and a nil cache if the prefix is not cached. Cache failures are
+// traced, the request is then sent without the cache.
+func (c *contextCache) apply(ctx context.Context, modelName string, req *model.LLMRequest) (*model.LLMRequest, *cacheEntry) {
+	cfg := *req.CacheConfig
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultCacheTTL
+	}
+	if cfg.MinTokens <= 0 {
+		cfg.MinTokens = defaultCacheMinTokens
+	}
+	if cfg.MaxUses <= 0 {
+		cfg.MaxUses = defaultCacheMaxUses
+	}
+	if req.Config.CachedContent != "" || len(req.Contents) == 0 {
+		return req, nil
+	}
+
+	fingerprints, contentsFingerprints := prefixFingerprints(modelName, req)
+	entry, expireTime, superseded := c.lookup(fingerprints, contentsFingerprints, cfg.MaxUses)
+	defer func() {
+		for _, e := range superseded {
+			c.invalidate(ctx, modelName, e)
+		}
+	}()
+	if entry != nil {
+		if expireTime.Sub(c.now()) < cfg.TTL/2 {
+			if err := c.refresh(ctx, entry, cfg.TTL, req.Config.HTTPOptions); err != nil {
+				telemetry.TraceContextCacheError(ctx, modelName, "refresh", entry.name, err)
+				c.invalidate(ctx, modelName, entry)
+				return req, nil
+			}
+		}
+		return cachedRequest(req, entry), entry
+	}
+
+	numContents := len(req.Contents) - 1
+	if numContents == 0 && req.Config.SystemInstruction == nil && len(req.Config.Tools) == 0 {
+		return req, nil
+	}
+	if estimateTokens(req, numContents) < cfg.MinTokens {
+		return req, nil
+	}
+	entry, err := c.create(ctx, modelName, req, numContents, fingerprints[numContents], contentsFingerprints[numContents], cfg.TTL)
+	if err != nil {
+		telemetry.TraceContextCacheError(ctx, modelName, "create", "", err)
+		return req, nil
+	}
+	return cachedRequest(req, entry), entry
+}
+
+// lookup returns the usable cache of the longest prefix, with its expire
+// time, and counts its use. If the cache has been used too many times, it is
+// returned as superseded instead, with the caches of the same contents whose
+// prefix changed.
+func (c *contextCache) lookup(fingerprints, contentsFingerprints []string, maxUses int) (entry *cacheEntry, expireTime time.Time, superseded []*cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for fp, e := range c.entries {
+		if !e.expireTime.After(now) {
+			delete(c.entries, fp)
+			continue
+		}
+		if n := e.numContents; n > 0 && n < len(fingerprints) && e.contentsFingerprint == contentsFingerprints[n] && e.fingerprint != fingerprints[n] {
+			superseded = append(superseded, e)
+		}
+	}
+	for n := len(fingerprints) - 1; n >= 0; n-- {
+		e, ok := c.entries[fingerprints[n]]
+		if !ok {
+			continue
+		}
+		if e.uses >= maxUses {
+			return nil, time.Time{}, append(superseded, e)
+		}
+		e.uses++
+		return e, e.expireTime, superseded
+	}
+	return nil, time.Time{}, superseded
+}
+
+func (c *contextCache) create(ctx context.Context, modelName string, req *model.LLMRequest, numContents int, fingerprint, contentsFingerprint string, ttl time.Duration) (*cacheEntry, error) {
+	cached, err := c.caches.Create(ctx, modelName, &genai.CreateCachedContentConfig{
+		HTTPOptions:       req.Config.HTTPOptions,
+		TTL:               ttl,
+		DisplayName:       "adk-" + fingerprint[:16],
+		Contents:          req.Contents[:numContents],
+		SystemInstruction: req.Config.SystemInstruction,
+		Tools:             req.Config.Tools,
+		ToolConfig:        req.Config.ToolConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry := &cacheEntry{
+		name:                cached.Name,
+		fingerprint:         fingerprint,
+		contentsFingerprint: contentsFingerprint,
+		numContents:         numContents,
+		expireTime:          cached.ExpireTime,
+		uses:                1,
+	}
+	if entry.expireTime.IsZero() {
+		entry.expireTime = c.now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[fingerprint] = entry
+	return entry, nil
+}
+
+func (c *contextCache) refresh(ctx context.Context, entry *cacheEntry, ttl time.Duration, httpOptions *genai.HTTPOptions) error {
+	cached, err := c.caches.Update(ctx, entry.name, &genai.UpdateCachedContentConfig{HTTPOptions: httpOptions, TTL: ttl})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.expireTime = cached.ExpireTime
+	if entry.expireTime.IsZero() {
+		entry.expireTime = c.now().Add(ttl)
+	}
+	return nil
+}
+
+// invalidate forgets the cache and deletes it. Deletion failures are traced,
+// the cache expires anyway.
+func (c *contextCache) invalidate(ctx context.Context, modelName string, entry *cacheEntry) {
+	c.mu.Lock()
+	if c.entries[entry.fingerprint] == entry {
+		delete(c.entries, entry.fingerprint)
+	}
+	c.mu.Unlock()
+	if _, err := c.caches.Delete(ctx, entry.name, nil); err != nil && !isCacheNotFound(err) {
+		telemetry.TraceContextCacheError(ctx, modelName, "delete", entry.name, err)
+	}
+}
+
+// cachedRequest returns the request using the cached content instead of its
+// prefix.
+func cachedRequest(req *model.LLMRequest, entry *cacheEntry) *model.LLMRequest {
+	config := *req.Config
+	config.CachedContent = entry.name
+	config.SystemInstruction = nil
+	config.Tools = nil
+	config.ToolConfig = nil
+	cachedReq := *req
+	cachedReq.Config = &config
+	cachedReq.Contents = req.Contents[entry.numContents:]
+	return &cachedReq
+}
+
+// prefixFingerprints returns the fingerprints of the request prefixes with
+// 0 to len(req.Contents)-1 contents, and of their contents alone. The last
+// content is never cached.
+func prefixFingerprints(modelName string, req *model.LLMRequest) (fingerprints, contentsFingerprints []string) {
+	h, ch := sha256.New(), sha256.New()
+	enc := json.NewEncoder(io.MultiWriter(h, ch))
+	// Encoding errors are ignored, the fingerprint only needs to change
+	// with the prefix.
+	_ = json.NewEncoder(h).Encode([]any{modelName, req.Config.SystemInstruction, req.Config.Tools, req.Config.ToolConfig})
+	fingerprints = make([]string, len(req.Contents))
+	contentsFingerprints = make([]string, len(req.Contents))
+	for i := range fingerprints {
+		fingerprints[i] = hex.EncodeToString(h.Sum(nil))
+		contentsFingerprints[i] = hex.EncodeToString(ch.Sum(nil))
+		_ = enc.Encode(req.Contents[i])
+	}
+	return fingerprints, contentsFingerprints
+}
+
+// estimateTokens estimates the number of tokens of the request prefix with
+// numContents contents, counting four bytes of JSON per token.
+func estimateTokens(req *model.LLMRequest, numContents int) int {
+	data, err := json.Marshal([]any{req.Config.SystemInstruction, req.Config.Tools, req.Config.ToolConfig, req.Contents[:numContents]})
+	if err != nil {
+		return 0
+	}
+	return len(data) / 4
+}
+
+// isCacheNotFound reports whether the error is due to a cached content which
+// expired or was deleted.
+func isCacheNotFound(err error) bool {
+	var apiErr genai.APIError
+	return errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusForbidden)
+}
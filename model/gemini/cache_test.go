@@ -0,0 +1,328 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+var spans = func() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	telemetry.AddSpanProcessor(recorder)
+	return recorder
+}()
+
+func TestModel_ContextCache(t *testing.T) {
+	instruction := genai.NewContentFromText(strings.Repeat("Follow the long instructions. ", 200), genai.RoleUser)
+	otherInstruction := genai.NewContentFromText(strings.Repeat("Follow the other instructions. ", 200), genai.RoleUser)
+	history := func(n int) []*genai.Content {
+		var contents []*genai.Content
+		for i := range n {
+			contents = append(contents, genai.NewContentFromText(fmt.Sprintf("question %d", i), genai.RoleUser))
+			contents = append(contents, genai.NewContentFromText(fmt.Sprintf("answer %d", i), genai.RoleModel))
+		}
+		return append(contents, genai.NewContentFromText("question", genai.RoleUser))
+	}
+	cacheConfig := &model.ContextCacheConfig{TTL: time.Hour, MaxUses: 2}
+
+	steps := []struct {
+		name            string
+		instruction     *genai.Content
+		contents        []*genai.Content
+		cacheConfig     *model.ContextCacheConfig
+		advance         time.Duration
+		deleteOnBackend string
+		wantCalls       []string
+		wantCachedToken int32
+		// notIterated calls GenerateContent without iterating the
+		// responses.
+		notIterated bool
+	}{
+		{
+			name:        "not configured",
+			instruction: instruction,
+			contents:    history(0),
+			wantCalls:   []string{"generate contents=1 instruction"},
+		},
+		{
+			name:        "prefix too small",
+			instruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+			contents:    history(0),
+			cacheConfig: cacheConfig,
+			wantCalls:   []string{"generate contents=1 instruction"},
+		},
+		{
+			name:        "not iterated",
+			instruction: instruction,
+			contents:    history(0),
+			cacheConfig: cacheConfig,
+			notIterated: true,
+		},
+		{
+			name:            "cache created",
+			instruction:     instruction,
+			contents:        history(0),
+			cacheConfig:     cacheConfig,
+			wantCalls:       []string{"create cachedContents/1 contents=0", "generate cachedContents/1 contents=1"},
+			wantCachedToken: 1000,
+		},
+		{
+			name:            "cache reused",
+			instruction:     instruction,
+			contents:        history(1),
+			cacheConfig:     cacheConfig,
+			wantCalls:       []string{"generate cachedContents/1 contents=3"},
+			wantCachedToken: 1000,
+		},
+		{
+			name:            "cache superseded",
+			instruction:     instruction,
+			contents:        history(2),
+			cacheConfig:     cacheConfig,
+			wantCalls:       []string{"create cachedContents/2 contents=4", "delete cachedContents/1", "generate cachedContents/2 contents=1"},
+			wantCachedToken: 1000,
+		},
+		{
+			name:            "instruction changed",
+			instruction:     otherInstruction,
+			contents:        history(2),
+			cacheConfig:     cacheConfig,
+			wantCalls:       []string{"create cachedContents/3 contents=4", "delete cachedContents/2", "generate cachedContents/3 contents=1"},
+			wantCachedToken: 1000,
+		},
+		{
+			name:            "ttl refreshed",
+			instruction:     otherInstruction,
+			contents:        history(3),
+			cacheConfig:     cacheConfig,
+			advance:         40 * time.Minute,
+			wantCalls:       []string{"update cachedContents/3 ttl=3600s", "generate cachedContents/3 contents=3"},
+			wantCachedToken: 1000,
+		},
+		{
+			name:            "cache expired",
+			instruction:     instruction,
+			contents:        history(3),
+			cacheConfig:     cacheConfig,
+			advance:         time.Hour,
+			wantCalls:       []string{"create cachedContents/4 contents=6", "generate cachedContents/4 contents=1"},
+			wantCachedToken: 1000,
+		},
+		{
+			name:            "cache deleted on backend",
+			instruction:     instruction,
+			contents:        history(4),
+			cacheConfig:     cacheConfig,
+			deleteOnBackend: "cachedContents/4",
+			wantCalls:       []string{"generate cachedContents/4 contents=3", "delete cachedContents/4", "generate contents=9 instruction"},
+		},
+	}
+
+	backend := newFakeBackend(t)
+	llm, err := NewModel(t.Context(), "gemini-2.5-flash", &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: backend.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	llm.(*geminiModel).cache.now = func() time.Time { return now }
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		backend.reset(step.deleteOnBackend)
+		req := &model.LLMRequest{
+			Contents:    step.contents,
+			Config:      &genai.GenerateContentConfig{SystemInstruction: step.instruction},
+			CacheConfig: step.cacheConfig,
+		}
+		responses := llm.GenerateContent(t.Context(), req, false)
+		if step.notIterated {
+			responses = func(func(*model.LLMResponse, error) bool) {}
+		}
+		for resp, err := range responses {
+			if err != nil {
+				t.Fatalf("%s: GenerateContent() error = %v", step.name, err)
+			}
+			if got := resp.UsageMetadata.CachedContentTokenCount; got != step.wantCachedToken {
+				t.Errorf("%s: CachedContentTokenCount = %d, want %d", step.name, got, step.wantCachedToken)
+			}
+		}
+		if diff := cmp.Diff(step.wantCalls, backend.calls); diff != "" {
+			t.Errorf("%s: backend calls mismatch (-want +got):\n%s", step.name, diff)
+		}
+	}
+}
+
+func TestModel_ContextCacheFailure(t *testing.T) {
+	spans.Reset()
+	backend := newFakeBackend(t)
+	backend.failCreate = true
+	llm, err := NewModel(t.Context(), "gemini-2.5-flash", &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: backend.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The request is sent without the cache.
+	req := &model.LLMRequest{
+		Contents:    []*genai.Content{genai.NewContentFromText("question", genai.RoleUser)},
+		Config:      &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText(strings.Repeat("Follow the long instructions. ", 200), genai.RoleUser)},
+		CacheConfig: &model.ContextCacheConfig{},
+	}
+	for _, err := range llm.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+	}
+	if diff := cmp.Diff([]string{"create failed", "generate contents=1 instruction"}, backend.calls); diff != "" {
+		t.Errorf("backend calls mismatch (-want +got):\n%s", diff)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 || ended[0].Name() != "context_cache" {
+		t.Fatalf("got spans %v, want one context_cache span", ended)
+	}
+	for _, attr := range ended[0].Attributes() {
+		if attr.Key == "gcp.vertex.agent.context_cache_operation" && attr.Value.AsString() != "create" {
+			t.Errorf("operation attribute = %q, want %q", attr.Value.AsString(), "create")
+		}
+	}
+}
+
+// fakeBackend is a fake Gemini API serving the cached contents and the
+// content generation.
+type fakeBackend struct {
+	*httptest.Server
+
+	// failCreate makes the creation of the caches fail.
+	failCreate bool
+
+	mu     sync.Mutex
+	caches map[string]bool
+	nextID int
+	calls  []string
+}
+
+func newFakeBackend(t *testing.T) *fakeBackend {
+	b := &fakeBackend{caches: make(map[string]bool)}
+	b.Server = httptest.NewServer(http.HandlerFunc(b.serve))
+	t.Cleanup(b.Close)
+	return b
+}
+
+// reset clears the recorded calls and deletes the cache, as if it was
+// deleted by another client.
+func (b *fakeBackend) reset(deleteCache string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = nil
+	delete(b.caches, deleteCache)
+}
+
+func (b *fakeBackend) serve(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var body struct {
+		Contents          []*genai.Content `json:"contents"`
+		SystemInstruction *genai.Content   `json:"systemInstruction"`
+		CachedContent     string           `json:"cachedContent"`
+		TTL               string           `json:"ttl"`
+	}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1beta/")
+
+	switch {
+	case r.Method == http.MethodPost && path == "cachedContents" && b.failCreate:
+		b.calls = append(b.calls, "create failed")
+		writeError(w, http.StatusInternalServerError)
+	case r.Method == http.MethodPost && path == "cachedContents":
+		b.nextID++
+		name := fmt.Sprintf("cachedContents/%d", b.nextID)
+		b.caches[name] = true
+		b.calls = append(b.calls, fmt.Sprintf("create %s contents=%d", name, len(body.Contents)))
+		writeJSON(w, map[string]any{"name": name, "model": "models/gemini-2.5-flash"})
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "cachedContents/"):
+		b.calls = append(b.calls, fmt.Sprintf("update %s ttl=%s", path, body.TTL))
+		writeJSON(w, map[string]any{"name": path})
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "cachedContents/"):
+		b.calls = append(b.calls, "delete "+path)
+		if !b.caches[path] {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		delete(b.caches, path)
+		writeJSON(w, map[string]any{})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, ":generateContent"):
+		call := "generate"
+		if body.CachedContent != "" {
+			call += " " + body.CachedContent
+		}
+		call += fmt.Sprintf(" contents=%d", len(body.Contents))
+		if body.SystemInstruction != nil {
+			call += " instruction"
+		}
+		b.calls = append(b.calls, call)
+		usage := map[string]any{"promptTokenCount": 1200}
+		if body.CachedContent != "" {
+			if !b.caches[body.CachedContent] {
+				writeError(w, http.StatusForbidden)
+				return
+			}
+			usage["cachedContentTokenCount"] = 1000
+		}
+		writeJSON(w, map[string]any{
+			"candidates": []any{map[string]any{
+				"content":      genai.NewContentFromText("answer", genai.RoleModel),
+				"finishReason": "STOP",
+			}},
+			"usageMetadata": usage,
+		})
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": code, "message": http.StatusText(code)}})
+}
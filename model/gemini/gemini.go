@@ -30,11 +30,22 @@ import (
 	"google.golang.org/genai"
 )
 
+func init() {
+	// The client is configured with the environment variables, e.g.
+	// GOOGLE_API_KEY.
+	factory := func(ctx context.Context, name string) (model.LLM, error) {
+		return NewModel(ctx, name, &genai.ClientConfig{})
+	}
+	model.Register("gemini-*", factory)
+	model.Register("gemma-*", factory)
+}
+
 // TODO: test coverage
 type geminiModel struct {
 	client             *genai.Client
 	name               string
 	versionHeaderValue string
+	cache              *contextCache
 }
 
 // NewModel returns [model.LLM], backed by the Gemini API.
@@ -58,6 +69,7 @@ func NewModel(ctx context.Context, modelName string, cfg *genai.ClientConfig) (m
 		name:               modelName,
 		client:             client,
 		versionHeaderValue: headerValue,
+		cache:              newContextCache(client.Caches),
 	}, nil
 }
 
@@ -79,6 +91,42 @@ func (m *geminiModel) GenerateContent(ctx context.Context, req *model.LLMRequest
 	}
 	m.addHeaders(req.Config.HTTPOptions.Headers)
 
+	if req.CacheConfig == nil {
+		return m.generateContent(ctx, req, stream)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		// The cache is only created or refreshed when the request is sent.
+		cachedReq, entry := m.cache.apply(ctx, m.name, req)
+		if entry == nil {
+			for resp, err := range m.generateContent(ctx, req, stream) {
+				if !yield(resp, err) {
+					return
+				}
+			}
+			return
+		}
+		started := false
+		for resp, err := range m.generateContent(ctx, cachedReq, stream) {
+			if err != nil && !started && isCacheNotFound(err) {
+				// The cache was deleted or expired earlier than expected,
+				// send the whole request.
+				m.cache.invalidate(ctx, m.name, entry)
+				for resp, err := range m.generateContent(ctx, req, stream) {
+					if !yield(resp, err) {
+						return
+					}
+				}
+				return
+			}
+			started = true
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+func (m *geminiModel) generateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
 	if stream {
 		return m.generateStream(ctx, req)
 	}
@@ -24,8 +24,6 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
-	"google.golang.org/adk/internal/httprr"
-	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 )
@@ -135,14 +133,11 @@ func TestModel_TrackingHeaders(t *testing.T) {
 	t.Run("verifies_headers_are_set", func(t *testing.T) {
 		httpRecordFilename := filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")+".httprr")
 
-		baseTransport, err := testutil.NewGeminiTransport(httpRecordFilename)
-		if err != nil {
-			t.Fatal(err)
-		}
+		cfg := newGeminiTestClientConfig(t, httpRecordFilename)
 
 		headersChecked := false
 		interceptor := &headerInterceptor{
-			base: baseTransport,
+			base: cfg.HTTPClient.Transport,
 			check: func(req *http.Request) {
 				headersChecked = true
 				// Verify that standard tracking headers are present.
@@ -157,15 +152,7 @@ func TestModel_TrackingHeaders(t *testing.T) {
 			},
 		}
 
-		apiKey := ""
-		if recording, _ := httprr.Recording(httpRecordFilename); !recording {
-			apiKey = "fakekey"
-		}
-
-		cfg := &genai.ClientConfig{
-			HTTPClient: &http.Client{Transport: interceptor},
-			APIKey:     apiKey,
-		}
+		cfg.HTTPClient.Transport = interceptor
 
 		geminiModel, err := NewModel(t.Context(), "gemini-2.0-flash", cfg)
 		if err != nil {
@@ -190,18 +177,12 @@ func TestModel_TrackingHeaders(t *testing.T) {
 // newGeminiTestClientConfig returns the genai.ClientConfig configured for record and replay.
 func newGeminiTestClientConfig(t *testing.T, rrfile string) *genai.ClientConfig {
 	t.Helper()
-	rr, err := testutil.NewGeminiTransport(rrfile)
+	cfg, rr, err := NewRecordReplayClientConfig(rrfile, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	apiKey := ""
-	if recording, _ := httprr.Recording(rrfile); !recording {
-		apiKey = "fakekey"
-	}
-	return &genai.ClientConfig{
-		HTTPClient: &http.Client{Transport: rr},
-		APIKey:     apiKey,
-	}
+	t.Cleanup(func() { rr.Close() })
+	return cfg
 }
 
 // TextResponse holds the concatenated text from a response stream,
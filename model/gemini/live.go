@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+var _ model.LiveLLM = (*geminiModel)(nil)
+
+// Connect opens a Gemini Live API session.
+//
+// The live connect config of the request is completed with the system
+// instruction, tools and generation settings of the request config.
+func (m *geminiModel) Connect(ctx context.Context, req *model.LLMRequest) (model.LiveConnection, error) {
+	cfg := &genai.LiveConnectConfig{}
+	if req.LiveConnectConfig != nil {
+		c := *req.LiveConnectConfig
+		cfg = &c
+	}
+	if c := req.Config; c != nil {
+		cfg.SystemInstruction = c.SystemInstruction
+		cfg.Tools = c.Tools
+		cfg.Temperature = c.Temperature
+		cfg.TopP = c.TopP
+		cfg.TopK = c.TopK
+		cfg.MaxOutputTokens = c.MaxOutputTokens
+		cfg.Seed = c.Seed
+	}
+	session, err := m.client.Live.Connect(ctx, m.name, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to model: %w", err)
+	}
+	return &liveConnection{session: session}, nil
+}
+
+// liveConnection is a [model.LiveConnection] over a Gemini Live API session.
+type liveConnection struct {
+	session *genai.Session
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *liveConnection) SendHistory(ctx context.Context, history []*genai.Content) error {
+	if len(history) == 0 {
+		return nil
+	}
+	turnComplete := history[len(history)-1].Role == genai.RoleUser
+	return c.session.SendClientContent(genai.LiveClientContentInput{
+		Turns:        history,
+		TurnComplete: &turnComplete,
+	})
+}
+
+func (c *liveConnection) SendContent(ctx context.Context, content *genai.Content) error {
+	if fnResponses := utils.FunctionResponses(content); len(fnResponses) > 0 {
+		return c.session.SendToolResponse(genai.LiveToolResponseInput{FunctionResponses: fnResponses})
+	}
+	turnComplete := true
+	return c.session.SendClientContent(genai.LiveClientContentInput{
+		Turns:        []*genai.Content{content},
+		TurnComplete: &turnComplete,
+	})
+}
+
+func (c *liveConnection) SendRealtime(ctx context.Context, input *genai.LiveRealtimeInput) error {
+	return c.session.SendRealtimeInput(*input)
+}
+
+// Receive yields the responses of the model.
+//
+// Text is yielded as partial responses as it arrives, followed by the full
+// text when the model completes its turn, is interrupted or calls a function.
+// Other parts, e.g. audio, are yielded as they arrive.
+func (c *liveConnection) Receive(ctx context.Context) iter.Seq2[*model.LLMResponse, error] {
+	// reference: adk-python src/google/adk/models/gemini_llm_connection.py GeminiLlmConnection.receive
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var text strings.Builder
+		// flushText yields the text received since the last flush.
+		flushText := func() bool {
+			if text.Len() == 0 {
+				return true
+			}
+			resp := &model.LLMResponse{Content: genai.NewContentFromText(text.String(), genai.RoleModel)}
+			text.Reset()
+			return yield(resp, nil)
+		}
+
+		for {
+			msg, err := c.session.Receive()
+			if err != nil {
+				if c.isClosed() {
+					return
+				}
+				yield(nil, fmt.Errorf("failed to receive from model: %w", err))
+				return
+			}
+
+			if content := msg.ServerContent; content != nil {
+				if turn := content.ModelTurn; turn != nil && len(turn.Parts) > 0 {
+					if turn.Parts[0].Text != "" {
+						text.WriteString(turn.Parts[0].Text)
+						resp := &model.LLMResponse{Content: turn, Partial: true, GroundingMetadata: content.GroundingMetadata}
+						if !yield(resp, nil) {
+							return
+						}
+					} else if !yield(&model.LLMResponse{Content: turn, GroundingMetadata: content.GroundingMetadata}, nil) {
+						return
+					}
+				}
+				if content.TurnComplete {
+					if !flushText() || !yield(&model.LLMResponse{TurnComplete: true, Interrupted: content.Interrupted}, nil) {
+						return
+					}
+				} else if content.Interrupted {
+					if !flushText() || !yield(&model.LLMResponse{Interrupted: true}, nil) {
+						return
+					}
+				}
+			}
+
+			if toolCall := msg.ToolCall; toolCall != nil && len(toolCall.FunctionCalls) > 0 {
+				if !flushText() {
+					return
+				}
+				content := &genai.Content{Role: genai.RoleModel}
+				for _, fnCall := range toolCall.FunctionCalls {
+					content.Parts = append(content.Parts, &genai.Part{FunctionCall: fnCall})
+				}
+				if !yield(&model.LLMResponse{Content: content}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *liveConnection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.session.Close()
+}
+
+func (c *liveConnection) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
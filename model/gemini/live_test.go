@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/websocket"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// fakeLiveServer is a Gemini Live API server replaying scripted messages.
+//
+// For every step, it reads a client message, records it, and writes the
+// server messages of the step.
+func fakeLiveServer(t *testing.T, steps [][]string) (url string, received <-chan map[string]any) {
+	t.Helper()
+	messages := make(chan map[string]any, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade connection: %v", err)
+			return
+		}
+		defer conn.Close()
+		for _, step := range steps {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Errorf("failed to read client message: %v", err)
+				return
+			}
+			var msg map[string]any
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Errorf("invalid client message %s: %v", data, err)
+				return
+			}
+			messages <- msg
+			for _, resp := range step {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(resp)); err != nil {
+					t.Errorf("failed to write server message: %v", err)
+					return
+				}
+			}
+		}
+		// Wait until the client closes the connection.
+		_, _, _ = conn.ReadMessage()
+	}))
+	t.Cleanup(srv.Close)
+	return "ws://" + strings.TrimPrefix(srv.URL, "http://"), messages
+}
+
+func TestModel_Connect(t *testing.T) {
+	url, received := fakeLiveServer(t, [][]string{
+		// setup
+		{`{"setupComplete": {}}`},
+		// user content
+		{
+			`{"serverContent": {"modelTurn": {"role": "model", "parts": [{"text": "Let me "}]}}}`,
+			`{"serverContent": {"modelTurn": {"role": "model", "parts": [{"text": "check."}]}}}`,
+			`{"toolCall": {"functionCalls": [{"id": "call-1", "name": "get_weather", "args": {"city": "Paris"}}]}}`,
+		},
+		// tool response
+		{
+			`{"serverContent": {"modelTurn": {"role": "model", "parts": [{"inlineData": {"mimeType": "audio/pcm", "data": "YXVkaW8="}}]}}}`,
+			`{"serverContent": {"turnComplete": true}}`,
+		},
+		// activity start
+		{`{"serverContent": {"interrupted": true}}`},
+	})
+
+	m, err := NewModel(t.Context(), "gemini-live", &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: url, APIVersion: "v1alpha"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := m.(model.LiveLLM).Connect(t.Context(), &model.LLMRequest{
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be nice.", genai.RoleUser),
+		},
+		LiveConnectConfig: &genai.LiveConnectConfig{
+			ResponseModalities: []genai.Modality{genai.ModalityAudio},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer conn.Close()
+
+	setup := (<-received)["setup"].(map[string]any)
+	if got, want := setup["model"], "models/gemini-live"; got != want {
+		t.Errorf("setup model = %v, want %v", got, want)
+	}
+	if _, ok := setup["systemInstruction"]; !ok {
+		t.Errorf("setup has no system instruction: %v", setup)
+	}
+	if _, ok := setup["generationConfig"].(map[string]any)["responseModalities"]; !ok {
+		t.Errorf("setup has no response modalities: %v", setup)
+	}
+
+	if err := conn.SendContent(t.Context(), genai.NewContentFromText("Weather in Paris?", genai.RoleUser)); err != nil {
+		t.Fatalf("SendContent failed: %v", err)
+	}
+
+	var got []*model.LLMResponse
+	for resp, err := range conn.Receive(t.Context()) {
+		if err != nil {
+			t.Fatalf("Receive failed: %v", err)
+		}
+		got = append(got, resp)
+		switch {
+		case resp.Content != nil && resp.Content.Parts[0].FunctionCall != nil:
+			fnResponse := &genai.FunctionResponse{ID: "call-1", Name: "get_weather", Response: map[string]any{"weather": "sunny"}}
+			if err := conn.SendContent(t.Context(), &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: fnResponse}}}); err != nil {
+				t.Fatalf("SendContent failed: %v", err)
+			}
+		case resp.TurnComplete:
+			if err := conn.SendRealtime(t.Context(), &genai.LiveRealtimeInput{ActivityStart: &genai.ActivityStart{}}); err != nil {
+				t.Fatalf("SendRealtime failed: %v", err)
+			}
+		case resp.Interrupted:
+			if err := conn.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+		}
+	}
+
+	want := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Let me ", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("check.", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("Let me check.", genai.RoleModel)},
+		{Content: genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel)},
+		{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{InlineData: &genai.Blob{MIMEType: "audio/pcm", Data: []byte("audio")}}}}},
+		{TurnComplete: true},
+		{Interrupted: true},
+	}
+	want[3].Content.Parts[0].FunctionCall.ID = "call-1"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected responses (-want +got):\n%s", diff)
+	}
+
+	wantKeys := []string{"clientContent", "toolResponse", "realtimeInput"}
+	for _, key := range wantKeys {
+		msg := <-received
+		if _, ok := msg[key]; !ok {
+			t.Errorf("got client message %v, want %s", msg, key)
+		}
+	}
+}
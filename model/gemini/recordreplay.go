@@ -0,0 +1,68 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/adk/adktest/httprr"
+	"google.golang.org/genai"
+)
+
+// NewRecordReplayClientConfig returns a copy of cfg whose HTTP client records
+// the traffic to the Gemini API in rrfile, or replays it from there, using the
+// [httprr] package. It is meant for the tests of agents using Gemini models.
+//
+// The requests are scrubbed of the headers with version numbers and their
+// JSON bodies are compacted, so that the recordings do not depend on the
+// versions of the SDKs. In replay mode, a placeholder API key is set when cfg
+// has none, since the credentials are redacted from the recordings.
+//
+// The caller should close the returned [httprr.RecordReplay] when done.
+func NewRecordReplayClientConfig(rrfile string, cfg *genai.ClientConfig) (*genai.ClientConfig, *httprr.RecordReplay, error) {
+	c := genai.ClientConfig{}
+	if cfg != nil {
+		c = *cfg
+	}
+	base := http.DefaultTransport
+	if c.HTTPClient != nil && c.HTTPClient.Transport != nil {
+		base = c.HTTPClient.Transport
+	}
+	rr, err := httprr.Open(rrfile, base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("httprr.Open(%q) failed: %w", rrfile, err)
+	}
+	rr.ScrubReq(
+		// Contain google-genai-sdk, google-adk and gl-go version numbers.
+		httprr.ScrubHeaders("X-Goog-Api-Client", "User-Agent"),
+		// google.golang.org/protobuf/internal/encoding.json
+		// goes out of its way to randomize the JSON encodings
+		// of protobuf messages by adding or not adding spaces
+		// after commas. Derandomize by compacting the JSON.
+		httprr.CompactJSON,
+	)
+	client := rr.Client()
+	if c.HTTPClient != nil {
+		copied := *c.HTTPClient
+		copied.Transport = rr
+		client = &copied
+	}
+	c.HTTPClient = client
+	if !rr.Recording() && c.APIKey == "" {
+		c.APIKey = "fakekey"
+	}
+	return &c, rr, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"iter"
+
+	"google.golang.org/genai"
+)
+
+// LiveLLM is an [LLM] supporting bidirectional streaming, where the client
+// and the model exchange contents, realtime input and responses over a
+// persistent connection.
+type LiveLLM interface {
+	LLM
+	// Connect opens a connection to the model, configured by the request
+	// config, live connect config and tools. The request contents are not
+	// sent; use [LiveConnection.SendHistory].
+	Connect(ctx context.Context, req *LLMRequest) (LiveConnection, error)
+}
+
+// LiveConnection is a bidirectional streaming connection to a model.
+//
+// The Send methods are not safe for concurrent use, but may be called while
+// receiving the responses.
+type LiveConnection interface {
+	// SendHistory sends the previous contents of the conversation. If the
+	// last content is from the user, the model responds to it.
+	SendHistory(ctx context.Context, history []*genai.Content) error
+	// SendContent sends a complete turn of the user, e.g. text or function
+	// responses. The model responds to it.
+	SendContent(ctx context.Context, content *genai.Content) error
+	// SendRealtime streams realtime input, e.g. audio chunks or activity
+	// signals. The model decides when to respond to it.
+	SendRealtime(ctx context.Context, input *genai.LiveRealtimeInput) error
+	// Receive returns the responses of the model as they arrive. Responses
+	// with TurnComplete mark the end of a turn of the model, responses with
+	// Interrupted signal that the user interrupted the model. The sequence
+	// ends when the connection is closed.
+	Receive(ctx context.Context) iter.Seq2[*LLMResponse, error]
+	// Close closes the connection. It can be called multiple times.
+	Close() error
+}
@@ -18,6 +18,7 @@ package model
 import (
 	"context"
 	"iter"
+	"time"
 
 	"google.golang.org/genai"
 )
@@ -33,10 +34,33 @@ type LLMRequest struct {
 	Model    string
 	Contents []*genai.Content
 	Config   *genai.GenerateContentConfig
+	// LiveConnectConfig is the configuration of the bidirectional streaming
+	// connection, used by [LiveLLM.Connect].
+	LiveConnectConfig *genai.LiveConnectConfig
+	// CacheConfig enables the caching of the stable prefix of the request,
+	// i.e. the system instruction, tools and earlier contents, by the models
+	// supporting it. It is ignored by the other models.
+	CacheConfig *ContextCacheConfig
 
 	Tools map[string]any `json:"-"`
 }
 
+// ContextCacheConfig configures the context caching of the models, which
+// store the stable prefix of the requests on the provider side instead of
+// sending it again with every request.
+type ContextCacheConfig struct {
+	// TTL is the time to live of the caches. It is extended when a cache is
+	// used after half of it has passed. Defaults to 30 minutes.
+	TTL time.Duration
+	// MinTokens is the minimum estimated number of tokens of a prefix to
+	// cache it. Smaller prefixes are sent with the requests. Defaults to
+	// 1024.
+	MinTokens int
+	// MaxUses is the number of requests using a cache before it is replaced
+	// by a cache of the longer prefix of the next request. Defaults to 10.
+	MaxUses int
+}
+
 // LLMResponse is the raw LLM response.
 // It provides the first candidate response from the model if available.
 type LLMResponse struct {
@@ -59,4 +83,9 @@ type LLMResponse struct {
 	ErrorMessage string
 	FinishReason genai.FinishReason
 	AvgLogprobs  float64
+	// Candidates are all the candidate responses of the model, in order,
+	// when it generated several ones, e.g. with the CandidateCount of the
+	// GenerateContentConfig. The other fields are the ones of the first
+	// candidate. The candidates don't have candidates themselves.
+	Candidates []*LLMResponse
 }
@@ -0,0 +1,368 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// The types of the Chat Completions API.
+// reference: https://platform.openai.com/docs/api-reference/chat
+
+type chatRequest struct {
+	Model            string          `json:"model"`
+	Messages         []*message      `json:"messages"`
+	Tools            []*chatTool     `json:"tools,omitempty"`
+	Temperature      *float32        `json:"temperature,omitempty"`
+	TopP             *float32        `json:"top_p,omitempty"`
+	MaxTokens        int32           `json:"max_tokens,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	Seed             *int32          `json:"seed,omitempty"`
+	PresencePenalty  *float32        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float32        `json:"frequency_penalty,omitempty"`
+	ResponseFormat   *responseFormat `json:"response_format,omitempty"`
+	Stream           bool            `json:"stream,omitempty"`
+	StreamOptions    *streamOptions  `json:"stream_options,omitempty"`
+}
+
+type message struct {
+	Role string `json:"role"`
+	// Content is either a string or a list of content parts.
+	Content    any         `json:"content,omitempty"`
+	ToolCalls  []*toolCall `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type toolCall struct {
+	// Index identifies the tool call in the streamed deltas.
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function functionCall `json:"function"`
+}
+
+type functionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function *functionDef `json:"function"`
+}
+
+type functionDef struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatResponse struct {
+	Choices []*chatChoice `json:"choices"`
+	Usage   *chatUsage    `json:"usage"`
+	Error   *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+type chatChoice struct {
+	Message      responseMessage `json:"message"`
+	Delta        responseMessage `json:"delta"`
+	FinishReason string          `json:"finish_reason"`
+}
+
+type responseMessage struct {
+	Content string `json:"content"`
+	// ReasoningContent is the reasoning of the model, returned by some
+	// servers, e.g. vLLM.
+	ReasoningContent string      `json:"reasoning_content"`
+	ToolCalls        []*toolCall `json:"tool_calls"`
+}
+
+type chatUsage struct {
+	PromptTokens        int32 `json:"prompt_tokens"`
+	CompletionTokens    int32 `json:"completion_tokens"`
+	TotalTokens         int32 `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int32 `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int32 `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// chatRequest translates the LLM request to a chat completions request.
+func (m *openaiModel) chatRequest(req *model.LLMRequest, stream bool) (*chatRequest, error) {
+	chatReq := &chatRequest{Model: m.name}
+	if stream {
+		chatReq.Stream = true
+		chatReq.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+
+	cfg := req.Config
+	if cfg == nil {
+		cfg = &genai.GenerateContentConfig{}
+	}
+	if instruction := text(cfg.SystemInstruction); instruction != "" {
+		chatReq.Messages = append(chatReq.Messages, &message{Role: "system", Content: instruction})
+	}
+	messages, err := chatMessages(req.Contents)
+	if err != nil {
+		return nil, err
+	}
+	chatReq.Messages = append(chatReq.Messages, messages...)
+
+	for _, t := range cfg.Tools {
+		for _, decl := range t.FunctionDeclarations {
+			def := &functionDef{Name: decl.Name, Description: decl.Description}
+			switch {
+			case decl.ParametersJsonSchema != nil:
+				def.Parameters = decl.ParametersJsonSchema
+			case decl.Parameters != nil:
+				def.Parameters = utils.JSONSchema(decl.Parameters)
+			default:
+				def.Parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			chatReq.Tools = append(chatReq.Tools, &chatTool{Type: "function", Function: def})
+		}
+	}
+
+	chatReq.Temperature = cfg.Temperature
+	chatReq.TopP = cfg.TopP
+	chatReq.MaxTokens = cfg.MaxOutputTokens
+	chatReq.Stop = cfg.StopSequences
+	chatReq.Seed = cfg.Seed
+	chatReq.PresencePenalty = cfg.PresencePenalty
+	chatReq.FrequencyPenalty = cfg.FrequencyPenalty
+	switch {
+	case cfg.ResponseJsonSchema != nil:
+		chatReq.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: cfg.ResponseJsonSchema}}
+	case cfg.ResponseSchema != nil:
+		chatReq.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: utils.JSONSchema(cfg.ResponseSchema)}}
+	case cfg.ResponseMIMEType == "application/json":
+		chatReq.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+	return chatReq, nil
+}
+
+// chatMessages translates the contents to chat messages.
+//
+// The IDs of the function calls are not always sent to the model, but the
+// API requires them to match the tool responses. Missing IDs are generated,
+// and the responses are matched with the calls by name, in order.
+func chatMessages(contents []*genai.Content) ([]*message, error) {
+	var messages []*message
+	pendingIDs := make(map[string][]string)
+	generated := 0
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		if content.Role == genai.RoleModel {
+			msg := &message{Role: "assistant"}
+			var texts []string
+			for _, part := range content.Parts {
+				switch {
+				case part.Thought:
+					// The reasoning is not sent back to the model.
+				case part.FunctionCall != nil:
+					id := part.FunctionCall.ID
+					if id == "" {
+						generated++
+						id = fmt.Sprintf("call_%d", generated)
+					}
+					pendingIDs[part.FunctionCall.Name] = append(pendingIDs[part.FunctionCall.Name], id)
+					args, err := json.Marshal(part.FunctionCall.Args)
+					if err != nil {
+						return nil, fmt.Errorf("failed to encode the arguments of function %q: %w", part.FunctionCall.Name, err)
+					}
+					if part.FunctionCall.Args == nil {
+						args = []byte("{}")
+					}
+					msg.ToolCalls = append(msg.ToolCalls, &toolCall{
+						ID:       id,
+						Type:     "function",
+						Function: functionCall{Name: part.FunctionCall.Name, Arguments: string(args)},
+					})
+				case part.Text != "":
+					texts = append(texts, part.Text)
+				}
+			}
+			if len(texts) > 0 {
+				msg.Content = strings.Join(texts, "")
+			}
+			if msg.Content != nil || len(msg.ToolCalls) > 0 {
+				messages = append(messages, msg)
+			}
+			continue
+		}
+
+		// The tool messages follow the assistant message with the calls.
+		var parts []*contentPart
+		for _, part := range content.Parts {
+			switch {
+			case part.FunctionResponse != nil:
+				fr := part.FunctionResponse
+				id := fr.ID
+				if ids := pendingIDs[fr.Name]; len(ids) > 0 {
+					if id == "" {
+						id = ids[0]
+					}
+					pendingIDs[fr.Name] = ids[1:]
+				}
+				data, err := json.Marshal(fr.Response)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode the response of function %q: %w", fr.Name, err)
+				}
+				messages = append(messages, &message{Role: "tool", ToolCallID: id, Content: string(data)})
+			case part.Text != "":
+				parts = append(parts, &contentPart{Type: "text", Text: part.Text})
+			case part.InlineData != nil:
+				if !strings.HasPrefix(part.InlineData.MIMEType, "image/") {
+					return nil, fmt.Errorf("unsupported inline data of type %q", part.InlineData.MIMEType)
+				}
+				url := "data:" + part.InlineData.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(part.InlineData.Data)
+				parts = append(parts, &contentPart{Type: "image_url", ImageURL: &imageURL{URL: url}})
+			case part.FileData != nil:
+				if !strings.HasPrefix(part.FileData.MIMEType, "image/") {
+					return nil, fmt.Errorf("unsupported file data of type %q", part.FileData.MIMEType)
+				}
+				parts = append(parts, &contentPart{Type: "image_url", ImageURL: &imageURL{URL: part.FileData.FileURI}})
+			}
+		}
+		switch {
+		case len(parts) == 0:
+		case len(parts) == 1 && parts[0].Type == "text":
+			messages = append(messages, &message{Role: "user", Content: parts[0].Text})
+		default:
+			messages = append(messages, &message{Role: "user", Content: parts})
+		}
+	}
+	return messages, nil
+}
+
+// text returns the text of the content.
+func text(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range c.Parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// modelContent returns the content of the model reply, or nil if it is empty.
+// The arguments of the tool calls of a reply cut off by the maximum number of
+// tokens may be cut off too: the calls with invalid arguments are kept
+// without their arguments then, and dropped by the flow as those of every
+// truncated response.
+func modelContent(reasoning, text string, toolCalls []*toolCall, reason string) (*genai.Content, error) {
+	var parts []*genai.Part
+	if reasoning != "" {
+		parts = append(parts, &genai.Part{Text: reasoning, Thought: true})
+	}
+	if text != "" {
+		parts = append(parts, genai.NewPartFromText(text))
+	}
+	for _, call := range toolCalls {
+		var args map[string]any
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				if reason != "length" {
+					return nil, fmt.Errorf("invalid arguments of function %q: %w", call.Function.Name, err)
+				}
+				args = nil
+			}
+		}
+		parts = append(parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+			ID:   call.ID,
+			Name: call.Function.Name,
+			Args: args,
+		}})
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return &genai.Content{Role: genai.RoleModel, Parts: parts}, nil
+}
+
+func usageMetadata(u *chatUsage) *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+	usage := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     u.PromptTokens,
+		CandidatesTokenCount: u.CompletionTokens,
+		TotalTokenCount:      u.TotalTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		usage.CachedContentTokenCount = u.PromptTokensDetails.CachedTokens
+	}
+	if u.CompletionTokensDetails != nil {
+		usage.ThoughtsTokenCount = u.CompletionTokensDetails.ReasoningTokens
+	}
+	return usage
+}
+
+func finishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "stop", "tool_calls", "function_call":
+		return genai.FinishReasonStop
+	case "length":
+		return genai.FinishReasonMaxTokens
+	case "content_filter":
+		return genai.FinishReasonSafety
+	default:
+		return genai.FinishReasonOther
+	}
+}
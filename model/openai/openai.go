@@ -0,0 +1,360 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openai implements the [model.LLM] interface for the models served
+// with the OpenAI Chat Completions API, e.g. by OpenAI, vLLM or LiteLLM.
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+
+	"google.golang.org/adk/internal/version"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// DefaultBaseURL is the URL of the OpenAI API.
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Config is the configuration of the Chat Completions endpoint.
+type Config struct {
+	// BaseURL is the URL of the API, without the /chat/completions path.
+	// Defaults to [DefaultBaseURL].
+	BaseURL string
+	// APIKey is sent as a bearer token. Defaults to the value of the
+	// OPENAI_API_KEY environment variable.
+	APIKey string
+	// HTTPClient sends the requests. Defaults to [http.DefaultClient].
+	HTTPClient *http.Client
+	// Headers are added to the requests, e.g. for the gateways requiring
+	// their own authentication.
+	Headers http.Header
+}
+
+func init() {
+	// The models named "openai/<model>" are served by OpenAI, and the ones
+	// named "ollama/<model>" by the local Ollama server, or the one of the
+	// OLLAMA_HOST environment variable.
+	model.Register("openai/*", func(ctx context.Context, name string) (model.LLM, error) {
+		return NewModel(strings.TrimPrefix(name, "openai/"), nil)
+	})
+	model.Register("ollama/*", func(ctx context.Context, name string) (model.LLM, error) {
+		host := os.Getenv("OLLAMA_HOST")
+		if host == "" {
+			host = "localhost:11434"
+		}
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		return NewModel(strings.TrimPrefix(name, "ollama/"), &Config{BaseURL: host + "/v1", APIKey: "ollama"})
+	})
+}
+
+type openaiModel struct {
+	name               string
+	baseURL            string
+	apiKey             string
+	client             *http.Client
+	headers            http.Header
+	versionHeaderValue string
+}
+
+// NewModel returns [model.LLM], backed by the Chat Completions API of the
+// endpoint. The modelName specifies which model to target (e.g., "gpt-4o").
+// The cfg may be nil.
+func NewModel(modelName string, cfg *Config) (model.LLM, error) {
+	if modelName == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	m := &openaiModel{
+		name:    modelName,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
+		client:  cfg.HTTPClient,
+		headers: cfg.Headers,
+		versionHeaderValue: fmt.Sprintf("google-adk/%s gl-go/%s", version.Version,
+			strings.TrimPrefix(runtime.Version(), "go")),
+	}
+	if m.baseURL == "" {
+		m.baseURL = DefaultBaseURL
+	}
+	if m.apiKey == "" {
+		m.apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if m.client == nil {
+		m.client = http.DefaultClient
+	}
+	return m, nil
+}
+
+func (m *openaiModel) Name() string {
+	return m.name
+}
+
+// GenerateContent calls the underlying model.
+//
+// In streaming mode, the text deltas are yielded as partial responses,
+// followed by the complete response, with the function calls, the usage and
+// TurnComplete set.
+func (m *openaiModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		chatReq, err := m.chatRequest(req, stream)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		body, err := m.send(ctx, chatReq)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer body.Close()
+
+		if !stream {
+			resp, err := readResponse(body)
+			yield(resp, err)
+			return
+		}
+		for resp, err := range readStream(body) {
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// send posts the request to the endpoint, returning the response body.
+func (m *openaiModel) send(ctx context.Context, chatReq *chatRequest) (io.ReadCloser, error) {
+	data, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range m.headers {
+		httpReq.Header[k] = v
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", m.versionHeaderValue)
+	if m.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	if chatReq.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call model: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp.Body, nil
+}
+
+// APIError is the error replied by the endpoint.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("failed to call model: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Retryable reports whether the request may succeed if sent again, i.e. the
+// endpoint timed out, was rate limited or failed with a server error.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newAPIError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Code    any    `json:"code"`
+		} `json:"error"`
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error.Message != "" {
+		apiErr.Message = body.Error.Message
+		apiErr.Type = body.Error.Type
+		if body.Error.Code != nil {
+			apiErr.Code = fmt.Sprint(body.Error.Code)
+		}
+	}
+	return apiErr
+}
+
+// readResponse reads the response of a non-streaming request.
+func readResponse(body io.Reader) (*model.LLMResponse, error) {
+	var resp chatResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode the response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+	choice := resp.Choices[0]
+	content, err := modelContent(choice.Message.ReasoningContent, choice.Message.Content, choice.Message.ToolCalls, choice.FinishReason)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LLMResponse{
+		Content:       content,
+		UsageMetadata: usageMetadata(resp.Usage),
+		FinishReason:  finishReason(choice.FinishReason),
+		TurnComplete:  true,
+	}, nil
+}
+
+// readStream reads the server-sent events of a streaming request.
+func readStream(body io.Reader) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var (
+			reasoning, text strings.Builder
+			toolCalls       []*toolCall
+			reason          string
+			usage           *chatUsage
+		)
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				break
+			}
+			var chunk chatResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				yield(nil, fmt.Errorf("failed to decode the response chunk: %w", err))
+				return
+			}
+			if chunk.Error != nil {
+				yield(nil, &APIError{StatusCode: http.StatusOK, Type: chunk.Error.Type, Message: chunk.Error.Message})
+				return
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+			choice := chunk.Choices[0]
+			if choice.FinishReason != "" {
+				reason = choice.FinishReason
+			}
+			delta := choice.Delta
+			merged, err := mergeToolCalls(toolCalls, delta.ToolCalls)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			toolCalls = merged
+			var part *genai.Part
+			switch {
+			case delta.ReasoningContent != "":
+				reasoning.WriteString(delta.ReasoningContent)
+				part = &genai.Part{Text: delta.ReasoningContent, Thought: true}
+			case delta.Content != "":
+				text.WriteString(delta.Content)
+				part = &genai.Part{Text: delta.Content}
+			default:
+				continue
+			}
+			partial := &model.LLMResponse{
+				Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{part}},
+				Partial: true,
+			}
+			if !yield(partial, nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(nil, fmt.Errorf("failed to read the response stream: %w", err))
+			return
+		}
+
+		content, err := modelContent(reasoning.String(), text.String(), toolCalls, reason)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if content == nil && reason == "" {
+			yield(nil, errors.New("empty response"))
+			return
+		}
+		yield(&model.LLMResponse{
+			Content:       content,
+			UsageMetadata: usageMetadata(usage),
+			FinishReason:  finishReason(reason),
+			TurnComplete:  true,
+		}, nil)
+	}
+}
+
+// maxToolCalls is the maximum number of tool calls of a streamed reply.
+const maxToolCalls = 128
+
+// mergeToolCalls adds the fragments of the tool calls streamed in a delta.
+// The fragments of a tool call have the same index, which is below
+// maxToolCalls.
+func mergeToolCalls(calls, deltas []*toolCall) ([]*toolCall, error) {
+	for _, d := range deltas {
+		index := len(calls)
+		if d.Index != nil {
+			index = *d.Index
+		}
+		if index < 0 || index >= maxToolCalls {
+			return nil, fmt.Errorf("invalid tool call index %d", index)
+		}
+		for len(calls) <= index {
+			calls = append(calls, &toolCall{Type: "function"})
+		}
+		call := calls[index]
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Function.Name != "" {
+			call.Function.Name = d.Function.Name
+		}
+		call.Function.Arguments += d.Function.Arguments
+	}
+	return calls, nil
+}
@@ -0,0 +1,313 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/genai"
+)
+
+// server is a stand-in of a Chat Completions endpoint, replying with the
+// response and recording the request.
+func server(t *testing.T, status int, contentType, response string) (*httptest.Server, *map[string]any) {
+	t.Helper()
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("got request path %q, want /v1/chat/completions", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer key" {
+			t.Errorf("got authorization header %q, want %q", got, "Bearer key")
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func newModel(t *testing.T, srv *httptest.Server) model.LLM {
+	t.Helper()
+	m, err := openai.NewModel("gpt-4o", &openai.Config{BaseURL: srv.URL + "/v1", APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestModel_Request(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	params, err := jsonschema.For[Args](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, got := server(t, http.StatusOK, "application/json", `{"choices": [{"message": {"content": "ok"}, "finish_reason": "stop"}]}`)
+
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			genai.NewContentFromText("Weather in Paris?", genai.RoleUser),
+			genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel),
+			genai.NewContentFromFunctionResponse("get_weather", map[string]any{"weather": "sunny"}, genai.RoleUser),
+			{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "thinking", Thought: true}, {Text: "Sunny."}}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("And here?"),
+				genai.NewPartFromBytes([]byte("img"), "image/png"),
+			}},
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("You are a weather bot.", genai.RoleUser),
+			Temperature:       genai.Ptr[float32](0.5),
+			MaxOutputTokens:   100,
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+				{Name: "get_weather", Description: "returns the weather", ParametersJsonSchema: params},
+				{Name: "get_time", Parameters: &genai.Schema{
+					Type:       genai.TypeObject,
+					Properties: map[string]*genai.Schema{"zone": {Type: genai.TypeString}},
+				}},
+			}}},
+		},
+	}
+	for _, err := range newModel(t, srv).GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+	}
+
+	want := map[string]any{
+		"model":       "gpt-4o",
+		"temperature": 0.5,
+		"max_tokens":  100.0,
+		"messages": []any{
+			map[string]any{"role": "system", "content": "You are a weather bot."},
+			map[string]any{"role": "user", "content": "Weather in Paris?"},
+			map[string]any{"role": "assistant", "tool_calls": []any{map[string]any{
+				"id":       "call_1",
+				"type":     "function",
+				"function": map[string]any{"name": "get_weather", "arguments": `{"city":"Paris"}`},
+			}}},
+			map[string]any{"role": "tool", "tool_call_id": "call_1", "content": `{"weather":"sunny"}`},
+			map[string]any{"role": "assistant", "content": "Sunny."},
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "text", "text": "And here?"},
+				map[string]any{"type": "image_url", "image_url": map[string]any{"url": "data:image/png;base64,aW1n"}},
+			}},
+		},
+		"tools": []any{
+			map[string]any{"type": "function", "function": map[string]any{
+				"name":        "get_weather",
+				"description": "returns the weather",
+				"parameters": map[string]any{
+					"type":                 "object",
+					"properties":           map[string]any{"city": map[string]any{"type": "string"}},
+					"required":             []any{"city"},
+					"additionalProperties": false,
+				},
+			}},
+			map[string]any{"type": "function", "function": map[string]any{
+				"name": "get_time",
+				"parameters": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"zone": map[string]any{"type": "string"}},
+				},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, *got); diff != "" {
+		t.Errorf("unexpected request (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Generate(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     *model.LLMResponse
+	}{
+		{
+			name: "text",
+			response: `{
+				"choices": [{"message": {"role": "assistant", "content": "Paris"}, "finish_reason": "stop"}],
+				"usage": {"prompt_tokens": 10, "completion_tokens": 2, "total_tokens": 12, "prompt_tokens_details": {"cached_tokens": 4}}
+			}`,
+			want: &model.LLMResponse{
+				Content: genai.NewContentFromText("Paris", genai.RoleModel),
+				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+					PromptTokenCount:        10,
+					CandidatesTokenCount:    2,
+					TotalTokenCount:         12,
+					CachedContentTokenCount: 4,
+				},
+				FinishReason: genai.FinishReasonStop,
+				TurnComplete: true,
+			},
+		},
+		{
+			name: "tool calls",
+			response: `{"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_a", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\": \"Paris\"}"}}
+			]}, "finish_reason": "tool_calls"}]}`,
+			want: &model.LLMResponse{
+				Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{
+					ID:   "call_a",
+					Name: "get_weather",
+					Args: map[string]any{"city": "Paris"},
+				}}}},
+				FinishReason: genai.FinishReasonStop,
+				TurnComplete: true,
+			},
+		},
+		{
+			name:     "truncated",
+			response: `{"choices": [{"message": {"role": "assistant", "content": "The capital"}, "finish_reason": "length"}]}`,
+			want: &model.LLMResponse{
+				Content:      genai.NewContentFromText("The capital", genai.RoleModel),
+				FinishReason: genai.FinishReasonMaxTokens,
+				TurnComplete: true,
+			},
+		},
+		{
+			name: "truncated tool call",
+			response: `{"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_a", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\": \"Pa"}}
+			]}, "finish_reason": "length"}]}`,
+			want: &model.LLMResponse{
+				Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{
+					ID:   "call_a",
+					Name: "get_weather",
+				}}}},
+				FinishReason: genai.FinishReasonMaxTokens,
+				TurnComplete: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := server(t, http.StatusOK, "application/json", tt.response)
+			var got []*model.LLMResponse
+			for resp, err := range newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Capital of France?")}, false) {
+				if err != nil {
+					t.Fatalf("GenerateContent() failed: %v", err)
+				}
+				got = append(got, resp)
+			}
+			if diff := cmp.Diff([]*model.LLMResponse{tt.want}, got); diff != "" {
+				t.Errorf("unexpected responses (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_GenerateStream(t *testing.T) {
+	chunks := []string{
+		`{"choices": [{"delta": {"role": "assistant", "content": "Let me "}}]}`,
+		`{"choices": [{"delta": {"content": "check."}}]}`,
+		`{"choices": [{"delta": {"tool_calls": [{"index": 0, "id": "call_a", "type": "function", "function": {"name": "get_weather", "arguments": ""}}]}}]}`,
+		`{"choices": [{"delta": {"tool_calls": [{"index": 0, "function": {"arguments": "{\"city\":"}}]}}]}`,
+		`{"choices": [{"delta": {"tool_calls": [{"index": 0, "function": {"arguments": " \"Paris\"}"}}]}}]}`,
+		`{"choices": [{"delta": {}, "finish_reason": "tool_calls"}]}`,
+		`{"choices": [], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`,
+		`[DONE]`,
+	}
+	var sse strings.Builder
+	for _, c := range chunks {
+		fmt.Fprintf(&sse, "data: %s\n\n", c)
+	}
+	srv, got := server(t, http.StatusOK, "text/event-stream", sse.String())
+
+	var responses []*model.LLMResponse
+	for resp, err := range newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Weather in Paris?")}, true) {
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+
+	if (*got)["stream"] != true {
+		t.Errorf("stream is not set in the request: %v", *got)
+	}
+	want := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Let me ", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("check.", genai.RoleModel), Partial: true},
+		{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				genai.NewPartFromText("Let me check."),
+				{FunctionCall: &genai.FunctionCall{ID: "call_a", Name: "get_weather", Args: map[string]any{"city": "Paris"}}},
+			}},
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+				PromptTokenCount:     10,
+				CandidatesTokenCount: 5,
+				TotalTokenCount:      15,
+			},
+			FinishReason: genai.FinishReasonStop,
+			TurnComplete: true,
+		},
+	}
+	if diff := cmp.Diff(want, responses); diff != "" {
+		t.Errorf("unexpected responses (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_GenerateStreamInvalidToolCallIndex(t *testing.T) {
+	chunk := `{"choices": [{"delta": {"tool_calls": [{"index": 1000000000, "id": "call_a", "type": "function", "function": {"name": "get_weather"}}]}}]}`
+	srv, _ := server(t, http.StatusOK, "text/event-stream", "data: "+chunk+"\n\ndata: [DONE]\n\n")
+
+	var gotErr error
+	for _, err := range newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Weather in Paris?")}, true) {
+		if err != nil {
+			gotErr = err
+		}
+	}
+	if gotErr == nil || !strings.Contains(gotErr.Error(), "invalid tool call index") {
+		t.Errorf("GenerateContent() error = %v, want invalid tool call index", gotErr)
+	}
+}
+
+func TestModel_Error(t *testing.T) {
+	srv, _ := server(t, http.StatusTooManyRequests, "application/json",
+		`{"error": {"message": "Rate limit reached", "type": "rate_limit_exceeded", "code": "rate_limit"}}`)
+
+	for _, err := range newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Hi")}, false) {
+		var apiErr *openai.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("GenerateContent() error = %v, want APIError", err)
+		}
+		want := &openai.APIError{StatusCode: 429, Type: "rate_limit_exceeded", Code: "rate_limit", Message: "Rate limit reached"}
+		if diff := cmp.Diff(want, apiErr); diff != "" {
+			t.Errorf("unexpected error (-want +got):\n%s", diff)
+		}
+	}
+}
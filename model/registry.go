@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+)
+
+// Factory creates the LLM of the model name.
+type Factory func(ctx context.Context, name string) (LLM, error)
+
+// Registry resolves the LLMs from the model names, with the factories of
+// the providers registered for the name patterns.
+//
+// The patterns have the syntax of [path.Match], e.g. "gemini-*" or
+// "openai/*". The factories receive the full model name.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []registryEntry
+}
+
+type registryEntry struct {
+	pattern string
+	factory Factory
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register registers the factory for the model names matching the pattern.
+// When several patterns match a name, the last registered one is used, which
+// allows to override the default providers.
+func (r *Registry) Register(pattern string, factory Factory) error {
+	if factory == nil {
+		return fmt.Errorf("factory is required")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid model name pattern %q: %w", pattern, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, registryEntry{pattern: pattern, factory: factory})
+	return nil
+}
+
+// Resolve returns the LLM of the model name, created by the factory
+// registered for it.
+func (r *Registry) Resolve(ctx context.Context, name string) (LLM, error) {
+	r.mu.RLock()
+	var factory Factory
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if ok, _ := path.Match(r.entries[i].pattern, name); ok {
+			factory = r.entries[i].factory
+			break
+		}
+	}
+	r.mu.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("no provider is registered for model %q", name)
+	}
+	llm, err := factory(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model %q: %w", name, err)
+	}
+	return llm, nil
+}
+
+// DefaultRegistry is the registry used by [Register] and [Resolve]. The
+// model packages, e.g. [google.golang.org/adk/model/gemini], register their
+// providers in it when they are imported.
+var DefaultRegistry = NewRegistry()
+
+// Register registers the factory in the [DefaultRegistry].
+func Register(pattern string, factory Factory) error {
+	return DefaultRegistry.Register(pattern, factory)
+}
+
+// Resolve returns the LLM of the model name from the [DefaultRegistry].
+func Resolve(ctx context.Context, name string) (LLM, error) {
+	return DefaultRegistry.Resolve(ctx, name)
+}
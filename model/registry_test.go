@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+
+	"google.golang.org/adk/model"
+)
+
+type namedModel struct {
+	name string
+}
+
+func (m *namedModel) Name() string {
+	return m.name
+}
+
+func (m *namedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {}
+}
+
+func TestRegistry(t *testing.T) {
+	factory := func(provider string) model.Factory {
+		return func(ctx context.Context, name string) (model.LLM, error) {
+			return &namedModel{name: provider + ":" + name}, nil
+		}
+	}
+	r := model.NewRegistry()
+	for pattern, f := range map[string]model.Factory{
+		"gemini-*": factory("gemini"),
+		"openai/*": factory("openai"),
+		"broken/*": func(ctx context.Context, name string) (model.LLM, error) {
+			return nil, errors.New("no credentials")
+		},
+	} {
+		if err := r.Register(pattern, f); err != nil {
+			t.Fatalf("Register(%q) failed: %v", pattern, err)
+		}
+	}
+	// The last registered provider takes precedence.
+	if err := r.Register("gemini-2.5-*", factory("custom")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "gemini-2.0-flash", want: "gemini:gemini-2.0-flash"},
+		{name: "gemini-2.5-flash", want: "custom:gemini-2.5-flash"},
+		{name: "openai/gpt-4o", want: "openai:openai/gpt-4o"},
+		{name: "openai/org/gpt-4o", wantErr: true},
+		{name: "claude-sonnet-4-5", wantErr: true},
+		{name: "broken/model", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Resolve(t.Context(), tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if err == nil && got.Name() != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.name, got.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_Register_Invalid(t *testing.T) {
+	r := model.NewRegistry()
+	if err := r.Register("gemini-[", func(ctx context.Context, name string) (model.LLM, error) { return nil, nil }); err == nil {
+		t.Errorf("Register() with an invalid pattern succeeded, want error")
+	}
+	if err := r.Register("gemini-*", nil); err == nil {
+		t.Errorf("Register() without factory succeeded, want error")
+	}
+}
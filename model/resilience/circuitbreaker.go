@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrCircuitOpen is the error of the calls rejected by an open circuit
+// breaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig configures the circuit breaker of [CircuitBreaker].
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed calls opening the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is the time the circuit stays open before letting a trial
+	// call through. Defaults to 30s.
+	OpenTimeout time.Duration
+	// IsFailure reports whether the error of a call counts as a failure.
+	// Defaults to [IsRetryable], i.e. the errors of the request itself don't
+	// open the circuit.
+	IsFailure func(error) bool
+}
+
+// CircuitBreaker returns a model failing fast with [ErrCircuitOpen] while
+// llm keeps failing.
+//
+// The circuit opens after FailureThreshold consecutive failures. Once open,
+// the calls are rejected for OpenTimeout, then a single trial call is let
+// through: the circuit closes if it succeeds and opens again if it fails.
+func CircuitBreaker(llm model.LLM, cfg CircuitBreakerConfig) model.LLM {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = IsRetryable
+	}
+	return &circuitBreakerModel{llm: llm, cfg: cfg, now: time.Now}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreakerModel struct {
+	llm model.LLM
+	cfg CircuitBreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+func (m *circuitBreakerModel) Name() string {
+	return m.llm.Name()
+}
+
+func (m *circuitBreakerModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if !m.allow() {
+			yield(nil, ErrCircuitOpen)
+			return
+		}
+		var failure error
+		defer func() { m.record(failure) }()
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if err != nil && m.cfg.IsFailure(err) {
+				failure = err
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// allow reports whether a call is let through, moving the open circuit to
+// half-open once the timeout has passed.
+func (m *circuitBreakerModel) allow() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch m.state {
+	case circuitOpen:
+		if m.now().Sub(m.openedAt) < m.cfg.OpenTimeout {
+			return false
+		}
+		m.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// The trial call is in progress.
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the circuit with the result of a call.
+func (m *circuitBreakerModel) record(failure error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if failure == nil {
+		m.state = circuitClosed
+		m.failures = 0
+		return
+	}
+	m.failures++
+	if m.state == circuitHalfOpen || m.failures >= m.cfg.FailureThreshold {
+		m.state = circuitOpen
+		m.openedAt = m.now()
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resilience provides [model.LLM] decorators making the model calls
+// resilient to transient failures of the model providers.
+//
+// The decorators compose, e.g. a model retrying the rate limited calls of
+// Gemini and falling back to Claude when Gemini keeps failing:
+//
+//	llm := resilience.Fallback(
+//		resilience.Retry(resilience.RateLimit(gemini, limiter), resilience.RetryConfig{}),
+//		resilience.FallbackConfig{Models: []model.LLM{claude}},
+//	)
+//
+// The calls are retried, or fall back to other models, only if they fail
+// before the first response is yielded. Failures in the middle of a stream
+// are passed to the caller, which has already consumed partial responses.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// IsRetryable reports whether the error of a model call is transient, i.e.
+// the call may succeed if retried. These are the rate limits, timeouts and
+// server errors of the model providers, network timeouts, and errors
+// implementing Retryable() bool which return true.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatusCode(apiErr.Code)
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) {
+		return retryableStatusCode(apiErrPtr.Code)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func retryableStatusCode(code int) bool {
+	return code == 408 || code == 429 || code >= 500
+}
+
+// RetryConfig configures the retries of [Retry].
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of calls, including the first one.
+	// Defaults to 3.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry. Defaults to 1s.
+	InitialDelay time.Duration
+	// MaxDelay is the maximum delay between two calls. Defaults to 30s.
+	MaxDelay time.Duration
+	// Multiplier is the growth of the delay after each retry. Defaults to 2.
+	Multiplier float64
+	// Retryable reports whether a failed call is retried. Defaults to
+	// [IsRetryable].
+	Retryable func(error) bool
+}
+
+// Retry returns a model retrying the failed calls of llm with an exponential
+// backoff. The delays are jittered between half and all of their value.
+func Retry(llm model.LLM, cfg RetryConfig) model.LLM {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.InitialDelay <= 0 {
+		cfg.InitialDelay = time.Second
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 30 * time.Second
+	}
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = 2
+	}
+	if cfg.Retryable == nil {
+		cfg.Retryable = IsRetryable
+	}
+	return &retryModel{llm: llm, cfg: cfg}
+}
+
+type retryModel struct {
+	llm model.LLM
+	cfg RetryConfig
+}
+
+func (m *retryModel) Name() string {
+	return m.llm.Name()
+}
+
+func (m *retryModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		delay := m.cfg.InitialDelay
+		for attempt := 1; ; attempt++ {
+			err := forward(m.llm.GenerateContent(ctx, req, stream), yield)
+			if err == nil {
+				return
+			}
+			if attempt >= m.cfg.MaxAttempts || !m.cfg.Retryable(err) {
+				yield(nil, err)
+				return
+			}
+			jittered := delay/2 + rand.N(delay/2+1)
+			telemetry.TraceLLMRetry(ctx, m.llm.Name(), attempt, jittered, err)
+			if err := sleep(ctx, jittered); err != nil {
+				yield(nil, err)
+				return
+			}
+			delay = min(time.Duration(float64(delay)*m.cfg.Multiplier), m.cfg.MaxDelay)
+		}
+	}
+}
+
+// sleep waits for the duration, or fails with the error of the context if it
+// is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RateLimit returns a model waiting for the limiter before each call of llm.
+// The limiter may be shared by several models, e.g. the models of the same
+// provider.
+func RateLimit(llm model.LLM, limiter *rate.Limiter) model.LLM {
+	return &rateLimitModel{llm: llm, limiter: limiter}
+}
+
+type rateLimitModel struct {
+	llm     model.LLM
+	limiter *rate.Limiter
+}
+
+func (m *rateLimitModel) Name() string {
+	return m.llm.Name()
+}
+
+func (m *rateLimitModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if err := m.limiter.Wait(ctx); err != nil {
+			yield(nil, err)
+			return
+		}
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// FallbackConfig configures the fallback models of [Fallback].
+type FallbackConfig struct {
+	// Models are the models called in order when the previous one fails.
+	Models []model.LLM
+	// ShouldFallback reports whether a failed call falls back to the next
+	// model. Defaults to the retryable errors and [ErrCircuitOpen].
+	ShouldFallback func(error) bool
+}
+
+// Fallback returns a model calling llm and, if the call fails, the fallback
+// models in order. The request is sent unchanged to the fallback models,
+// which should accept the same request config and tools. The name of the
+// returned model is the name of llm.
+func Fallback(llm model.LLM, cfg FallbackConfig) model.LLM {
+	if cfg.ShouldFallback == nil {
+		cfg.ShouldFallback = func(err error) bool {
+			return IsRetryable(err) || errors.Is(err, ErrCircuitOpen)
+		}
+	}
+	return &fallbackModel{models: append([]model.LLM{llm}, cfg.Models...), shouldFallback: cfg.ShouldFallback}
+}
+
+type fallbackModel struct {
+	models         []model.LLM
+	shouldFallback func(error) bool
+}
+
+func (m *fallbackModel) Name() string {
+	return m.models[0].Name()
+}
+
+func (m *fallbackModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for i, llm := range m.models {
+			err := forward(llm.GenerateContent(ctx, req, stream), yield)
+			if err == nil {
+				return
+			}
+			if i == len(m.models)-1 || !m.shouldFallback(err) || ctx.Err() != nil {
+				yield(nil, err)
+				return
+			}
+			telemetry.TraceLLMFallback(ctx, llm.Name(), m.models[i+1].Name(), err)
+		}
+	}
+}
+
+// forward passes the responses of seq to yield. It returns the error of seq
+// if it fails before the first response, so that the call can be retried.
+// The later errors are passed to yield.
+func forward(seq iter.Seq2[*model.LLMResponse, error], yield func(*model.LLMResponse, error) bool) error {
+	started := false
+	for resp, err := range seq {
+		if err != nil && !started {
+			return err
+		}
+		started = true
+		if !yield(resp, err) {
+			return nil
+		}
+	}
+	return nil
+}
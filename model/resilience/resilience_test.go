@@ -0,0 +1,440 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/time/rate"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/anthropic"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/genai"
+)
+
+var spans = func() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	telemetry.AddSpanProcessor(recorder)
+	return recorder
+}()
+
+var (
+	errUnavailable = &genai.APIError{Code: 503, Status: "UNAVAILABLE"}
+	errInvalid     = &genai.APIError{Code: 400, Status: "INVALID_ARGUMENT"}
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "gemini unavailable", err: errUnavailable, want: true},
+		{name: "gemini rate limited", err: genai.APIError{Code: 429}, want: true},
+		{name: "gemini invalid argument", err: errInvalid, want: false},
+		{name: "wrapped", err: fmt.Errorf("failed: %w", errUnavailable), want: true},
+		{name: "openai server error", err: &openai.APIError{StatusCode: 500}, want: true},
+		{name: "openai unauthorized", err: &openai.APIError{StatusCode: 401}, want: false},
+		{name: "anthropic overloaded in stream", err: &anthropic.APIError{StatusCode: 200, Type: "overloaded_error"}, want: true},
+		{name: "anthropic invalid request", err: &anthropic.APIError{StatusCode: 400, Type: "invalid_request_error"}, want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "other", err: errors.New("other"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		calls     [][]result
+		wantTexts []string
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "success",
+			calls:     [][]result{{text("hello")}},
+			wantTexts: []string{"hello"},
+			wantCalls: 1,
+		},
+		{
+			name:      "retryable errors",
+			calls:     [][]result{{fail(errUnavailable)}, {fail(errUnavailable)}, {text("hello")}},
+			wantTexts: []string{"hello"},
+			wantCalls: 3,
+		},
+		{
+			name:      "attempts exhausted",
+			calls:     [][]result{{fail(errUnavailable)}},
+			wantErr:   errUnavailable,
+			wantCalls: 3,
+		},
+		{
+			name:      "not retryable",
+			calls:     [][]result{{fail(errInvalid)}, {text("hello")}},
+			wantErr:   errInvalid,
+			wantCalls: 1,
+		},
+		{
+			name:      "stream fails before first chunk",
+			calls:     [][]result{{fail(errUnavailable)}, {partial("hel"), partial("lo"), text("hello")}},
+			wantTexts: []string{"hel", "lo", "hello"},
+			wantCalls: 2,
+		},
+		{
+			name:      "stream fails after first chunk",
+			calls:     [][]result{{partial("hel"), fail(errUnavailable)}, {text("hello")}},
+			wantTexts: []string{"hel"},
+			wantErr:   errUnavailable,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &fakeLLM{name: "fake", calls: tt.calls}
+			retry := Retry(llm, RetryConfig{InitialDelay: time.Millisecond})
+
+			texts, err := generate(t.Context(), retry)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GenerateContent() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantTexts, texts); diff != "" {
+				t.Errorf("GenerateContent() texts mismatch (-want +got):\n%s", diff)
+			}
+			if got := llm.numCalls(); got != tt.wantCalls {
+				t.Errorf("model called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetry_Canceled(t *testing.T) {
+	llm := &fakeLLM{name: "fake", calls: [][]result{{fail(errUnavailable)}}}
+	retry := Retry(llm, RetryConfig{InitialDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := generate(ctx, retry); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GenerateContent() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := llm.numCalls(); got != 1 {
+		t.Errorf("model called %d times, want 1", got)
+	}
+}
+
+func TestRetry_Telemetry(t *testing.T) {
+	spans.Reset()
+	llm := &fakeLLM{name: "retried-model", calls: [][]result{{fail(errUnavailable)}, {text("hello")}}}
+	if _, err := generate(t.Context(), Retry(llm, RetryConfig{InitialDelay: time.Millisecond})); err != nil {
+		t.Fatal(err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 || ended[0].Name() != "retry_llm" {
+		t.Fatalf("got spans %v, want one retry_llm span", ended)
+	}
+	attrs := attributes(ended[0].Attributes())
+	if got := attrs["gen_ai.request.model"]; got != "retried-model" {
+		t.Errorf("model attribute = %q, want %q", got, "retried-model")
+	}
+	if got := attrs["gcp.vertex.agent.llm_attempt"]; got != "1" {
+		t.Errorf("attempt attribute = %q, want %q", got, "1")
+	}
+	if got := attrs["gcp.vertex.agent.llm_error"]; got != errUnavailable.Error() {
+		t.Errorf("error attribute = %q, want %q", got, errUnavailable.Error())
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	llm := &fakeLLM{name: "fake", calls: [][]result{{text("hello")}}}
+	limited := RateLimit(llm, rate.NewLimiter(rate.Every(time.Hour), 1))
+
+	if _, err := generate(t.Context(), limited); err != nil {
+		t.Fatalf("first GenerateContent() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := generate(ctx, limited); err == nil {
+		t.Error("second GenerateContent() succeeded, want rate limit error")
+	}
+	if got := llm.numCalls(); got != 1 {
+		t.Errorf("model called %d times, want 1", got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	llm := &fakeLLM{name: "fake"}
+	breaker := CircuitBreaker(llm, CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}).(*circuitBreakerModel)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		call      []result
+		advance   time.Duration
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "invalid request", call: []result{fail(errInvalid)}, wantErr: errInvalid, wantCalls: 1},
+		{name: "first failure", call: []result{fail(errUnavailable)}, wantErr: errUnavailable, wantCalls: 2},
+		{name: "second failure opens", call: []result{fail(errUnavailable)}, wantErr: errUnavailable, wantCalls: 3},
+		{name: "open", call: []result{text("hello")}, wantErr: ErrCircuitOpen, wantCalls: 3},
+		{name: "still open", call: []result{text("hello")}, advance: 30 * time.Second, wantErr: ErrCircuitOpen, wantCalls: 3},
+		{name: "failed trial reopens", call: []result{fail(errUnavailable)}, advance: 30 * time.Second, wantErr: errUnavailable, wantCalls: 4},
+		{name: "reopened", call: []result{text("hello")}, wantErr: ErrCircuitOpen, wantCalls: 4},
+		{name: "successful trial closes", call: []result{text("hello")}, advance: time.Minute, wantCalls: 5},
+		{name: "closed", call: []result{fail(errUnavailable)}, wantErr: errUnavailable, wantCalls: 6},
+		{name: "still closed", call: []result{text("hello")}, wantCalls: 7},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		llm.calls = append(llm.calls, step.call)
+		if _, err := generate(t.Context(), breaker); !errors.Is(err, step.wantErr) {
+			t.Errorf("%s: GenerateContent() error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if got := llm.numCalls(); got != step.wantCalls {
+			t.Errorf("%s: model called %d times, want %d", step.name, got, step.wantCalls)
+		}
+		if llm.numCalls() < len(llm.calls) {
+			// The call was rejected, drop its script.
+			llm.calls = llm.calls[:llm.numCalls()]
+		}
+	}
+}
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   []result
+		fallbacks [][]result
+		wantTexts []string
+		wantErr   error
+		wantCalls []int
+	}{
+		{
+			name:      "primary succeeds",
+			primary:   []result{text("primary")},
+			fallbacks: [][]result{{text("fallback")}},
+			wantTexts: []string{"primary"},
+			wantCalls: []int{1, 0},
+		},
+		{
+			name:      "primary fails",
+			primary:   []result{fail(errUnavailable)},
+			fallbacks: [][]result{{text("fallback")}},
+			wantTexts: []string{"fallback"},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "circuit open",
+			primary:   []result{fail(ErrCircuitOpen)},
+			fallbacks: [][]result{{text("fallback")}},
+			wantTexts: []string{"fallback"},
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "invalid request",
+			primary:   []result{fail(errInvalid)},
+			fallbacks: [][]result{{text("fallback")}},
+			wantErr:   errInvalid,
+			wantCalls: []int{1, 0},
+		},
+		{
+			name:      "all fail",
+			primary:   []result{fail(errUnavailable)},
+			fallbacks: [][]result{{fail(errUnavailable)}, {fail(ErrCircuitOpen)}},
+			wantErr:   ErrCircuitOpen,
+			wantCalls: []int{1, 1, 1},
+		},
+		{
+			name:      "stream fails after first chunk",
+			primary:   []result{partial("prim"), fail(errUnavailable)},
+			fallbacks: [][]result{{text("fallback")}},
+			wantTexts: []string{"prim"},
+			wantErr:   errUnavailable,
+			wantCalls: []int{1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &fakeLLM{name: "primary", calls: [][]result{tt.primary}}
+			llms := []*fakeLLM{primary}
+			var fallbacks []model.LLM
+			for i, calls := range tt.fallbacks {
+				llm := &fakeLLM{name: fmt.Sprintf("fallback-%d", i), calls: [][]result{calls}}
+				llms = append(llms, llm)
+				fallbacks = append(fallbacks, llm)
+			}
+			fallback := Fallback(primary, FallbackConfig{Models: fallbacks})
+
+			if got := fallback.Name(); got != "primary" {
+				t.Errorf("Name() = %q, want %q", got, "primary")
+			}
+			texts, err := generate(t.Context(), fallback)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GenerateContent() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantTexts, texts); diff != "" {
+				t.Errorf("GenerateContent() texts mismatch (-want +got):\n%s", diff)
+			}
+			var calls []int
+			for _, llm := range llms {
+				calls = append(calls, llm.numCalls())
+			}
+			if diff := cmp.Diff(tt.wantCalls, calls); diff != "" {
+				t.Errorf("model calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFallback_Telemetry(t *testing.T) {
+	spans.Reset()
+	primary := &fakeLLM{name: "primary", calls: [][]result{{fail(errUnavailable)}}}
+	fallback := &fakeLLM{name: "fallback", calls: [][]result{{text("hello")}}}
+	if _, err := generate(t.Context(), Fallback(primary, FallbackConfig{Models: []model.LLM{fallback}})); err != nil {
+		t.Fatal(err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 1 || ended[0].Name() != "fallback_llm" {
+		t.Fatalf("got spans %v, want one fallback_llm span", ended)
+	}
+	attrs := attributes(ended[0].Attributes())
+	if got := attrs["gen_ai.request.model"]; got != "primary" {
+		t.Errorf("model attribute = %q, want %q", got, "primary")
+	}
+	if got := attrs["gcp.vertex.agent.llm_fallback_model"]; got != "fallback" {
+		t.Errorf("fallback model attribute = %q, want %q", got, "fallback")
+	}
+}
+
+// TestComposition checks a retried, circuit broken model falling back to
+// another model.
+func TestComposition(t *testing.T) {
+	primary := &fakeLLM{name: "primary", calls: [][]result{{fail(errUnavailable)}}}
+	secondary := &fakeLLM{name: "secondary", calls: [][]result{{text("hello")}}}
+	llm := Fallback(
+		Retry(CircuitBreaker(primary, CircuitBreakerConfig{FailureThreshold: 2}), RetryConfig{InitialDelay: time.Millisecond}),
+		FallbackConfig{Models: []model.LLM{secondary}},
+	)
+
+	for range 2 {
+		texts, err := generate(t.Context(), llm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"hello"}, texts); diff != "" {
+			t.Errorf("GenerateContent() texts mismatch (-want +got):\n%s", diff)
+		}
+	}
+	// The circuit opened after the second attempt of the first call.
+	if got := primary.numCalls(); got != 2 {
+		t.Errorf("primary model called %d times, want 2", got)
+	}
+	if got := secondary.numCalls(); got != 2 {
+		t.Errorf("secondary model called %d times, want 2", got)
+	}
+}
+
+// result is a response or an error yielded by fakeLLM.
+type result struct {
+	resp *model.LLMResponse
+	err  error
+}
+
+func text(s string) result {
+	return result{resp: &model.LLMResponse{Content: genai.NewContentFromText(s, genai.RoleModel), TurnComplete: true}}
+}
+
+func partial(s string) result {
+	return result{resp: &model.LLMResponse{Content: genai.NewContentFromText(s, genai.RoleModel), Partial: true}}
+}
+
+func fail(err error) result {
+	return result{err: err}
+}
+
+// fakeLLM yields the scripted results of each call. The last script is
+// repeated for the subsequent calls.
+type fakeLLM struct {
+	name  string
+	calls [][]result
+
+	mu sync.Mutex
+	n  int
+}
+
+func (m *fakeLLM) Name() string {
+	return m.name
+}
+
+func (m *fakeLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.mu.Lock()
+	results := m.calls[min(m.n, len(m.calls)-1)]
+	m.n++
+	m.mu.Unlock()
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, r := range results {
+			if !yield(r.resp, r.err) || r.err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (m *fakeLLM) numCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.n
+}
+
+// generate returns the texts of the responses of llm and the first error.
+func generate(ctx context.Context, llm model.LLM) ([]string, error) {
+	var texts []string
+	for resp, err := range llm.GenerateContent(ctx, &model.LLMRequest{}, true) {
+		if err != nil {
+			return texts, err
+		}
+		texts = append(texts, resp.Content.Parts[0].Text)
+	}
+	return texts, nil
+}
+
+func attributes(kvs []attribute.KeyValue) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range kvs {
+		m[string(kv.Key)] = kv.Value.Emit()
+	}
+	return m
+}
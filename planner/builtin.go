@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// NewBuiltIn returns a planner that uses the built-in thinking features of
+// the model.
+//
+// The thinking config is applied to every model request of the agent,
+// taking precedence over the thinking config of
+// llmagent.Config.GenerateContentConfig. The model must support thinking,
+// otherwise the model call fails.
+func NewBuiltIn(thinkingConfig *genai.ThinkingConfig) Planner {
+	return &builtInPlanner{thinkingConfig: thinkingConfig}
+}
+
+type builtInPlanner struct {
+	thinkingConfig *genai.ThinkingConfig
+}
+
+// BuildPlanningInstruction implements Planner.
+// It applies the thinking config to the request and returns no instruction.
+func (p *builtInPlanner) BuildPlanningInstruction(ctx agent.ReadonlyContext, req *model.LLMRequest) (string, error) {
+	if p.thinkingConfig == nil {
+		return "", nil
+	}
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	thinkingConfig := *p.thinkingConfig
+	req.Config.ThinkingConfig = &thinkingConfig
+	return "", nil
+}
+
+// ProcessPlanningResponse implements Planner.
+// The thoughts are already marked by the model, so the response is kept
+// unchanged.
+func (p *builtInPlanner) ProcessPlanningResponse(ctx agent.ReadonlyContext, parts []*genai.Part) ([]*genai.Part, error) {
+	return nil, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package planner provides planners that allow LLM agents to generate plans
+// for the queries to guide their actions.
+//
+// A planner is configured with llmagent.Config.Planner. Two planners are
+// available:
+//   - [NewBuiltIn] uses the built-in thinking features of the model.
+//   - [NewPlanReAct] instructs the model to plan before taking actions and
+//     marks the planning and reasoning parts of its responses as thoughts.
+package planner
+
+import (
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Planner guides the model to plan its actions.
+type Planner interface {
+	// BuildPlanningInstruction returns the planning instruction that is
+	// appended to the system instruction of the LLM request. An empty
+	// instruction is ignored.
+	//
+	// It is called before every model call and may also adjust other
+	// parameters of the request, e.g. the model thinking configuration.
+	BuildPlanningInstruction(ctx agent.ReadonlyContext, req *model.LLMRequest) (string, error)
+	// ProcessPlanningResponse post-processes the parts of the model response
+	// for planning. It returns the parts that replace the response parts, or
+	// nil to keep the response unchanged.
+	ProcessPlanningResponse(ctx agent.ReadonlyContext, parts []*genai.Part) ([]*genai.Part, error)
+}
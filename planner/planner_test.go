@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
+	"google.golang.org/genai"
+)
+
+func TestBuiltIn(t *testing.T) {
+	thinkingConfig := &genai.ThinkingConfig{IncludeThoughts: true, ThinkingBudget: genai.Ptr[int32](1024)}
+	p := planner.NewBuiltIn(thinkingConfig)
+
+	req := &model.LLMRequest{}
+	instruction, err := p.BuildPlanningInstruction(nil, req)
+	if err != nil {
+		t.Fatalf("BuildPlanningInstruction() error = %v", err)
+	}
+	if instruction != "" {
+		t.Errorf("BuildPlanningInstruction() = %q, want empty", instruction)
+	}
+	if req.Config == nil {
+		t.Fatal("BuildPlanningInstruction() did not set the request config")
+	}
+	if diff := cmp.Diff(thinkingConfig, req.Config.ThinkingConfig); diff != "" {
+		t.Errorf("unexpected thinking config (-want +got):\n%s", diff)
+	}
+
+	parts, err := p.ProcessPlanningResponse(nil, []*genai.Part{genai.NewPartFromText("answer")})
+	if err != nil {
+		t.Fatalf("ProcessPlanningResponse() error = %v", err)
+	}
+	if parts != nil {
+		t.Errorf("ProcessPlanningResponse() = %v, want nil", parts)
+	}
+}
+
+func TestPlanReAct_BuildPlanningInstruction(t *testing.T) {
+	instruction, err := planner.NewPlanReAct().BuildPlanningInstruction(nil, &model.LLMRequest{})
+	if err != nil {
+		t.Fatalf("BuildPlanningInstruction() error = %v", err)
+	}
+	for _, tag := range []string{planner.PlanningTag, planner.ReplanningTag, planner.ReasoningTag, planner.ActionTag, planner.FinalAnswerTag} {
+		if !strings.Contains(instruction, tag) {
+			t.Errorf("planning instruction does not mention %q", tag)
+		}
+	}
+}
+
+func TestPlanReAct_ProcessPlanningResponse(t *testing.T) {
+	functionCall := func(name string) *genai.Part {
+		return genai.NewPartFromFunctionCall(name, map[string]any{})
+	}
+
+	for _, tc := range []struct {
+		name  string
+		parts []*genai.Part
+		want  []*genai.Part
+	}{
+		{
+			name: "no parts",
+		},
+		{
+			name:  "plain text",
+			parts: []*genai.Part{genai.NewPartFromText("hello")},
+			want:  []*genai.Part{genai.NewPartFromText("hello")},
+		},
+		{
+			name: "planning and reasoning are thoughts",
+			parts: []*genai.Part{
+				genai.NewPartFromText(planner.PlanningTag + "1. look up the weather"),
+				genai.NewPartFromText(planner.ReasoningTag + "need the city"),
+			},
+			want: []*genai.Part{
+				{Text: planner.PlanningTag + "1. look up the weather", Thought: true},
+				{Text: planner.ReasoningTag + "need the city", Thought: true},
+			},
+		},
+		{
+			name: "final answer is split from reasoning",
+			parts: []*genai.Part{
+				genai.NewPartFromText(planner.ReasoningTag + "it is sunny" + planner.FinalAnswerTag + "Sunny."),
+			},
+			want: []*genai.Part{
+				{Text: planner.ReasoningTag + "it is sunny" + planner.FinalAnswerTag, Thought: true},
+				genai.NewPartFromText("Sunny."),
+			},
+		},
+		{
+			name: "parts after function calls are dropped",
+			parts: []*genai.Part{
+				genai.NewPartFromText(planner.ActionTag + "call tools"),
+				functionCall("weather"),
+				functionCall(""),
+				functionCall("time"),
+				genai.NewPartFromText("hallucinated result"),
+				functionCall("other"),
+			},
+			want: []*genai.Part{
+				{Text: planner.ActionTag + "call tools", Thought: true},
+				functionCall("weather"),
+				functionCall("time"),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := planner.NewPlanReAct().ProcessPlanningResponse(nil, tc.parts)
+			if err != nil {
+				t.Fatalf("ProcessPlanningResponse() error = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ProcessPlanningResponse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
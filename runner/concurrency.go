@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"google.golang.org/adk/session"
+)
+
+// SessionConcurrency defines how a [Runner] handles the concurrent
+// invocations of a session.
+//
+// The invocations are serialized among the runners of the process sharing
+// the session service, when the service is a pointer, a map or a channel:
+// the invocations of the other services are only serialized by their
+// runner. The session services detect the concurrent
+// invocations of the other processes: the events appended to a session
+// updated since the invocation started are rejected with
+// [session.ErrStaleSession].
+//
+// The compaction following an invocation, see [Config.Compaction], holds the
+// session as the invocation: the next invocations of the session wait for
+// it, or are rejected with [SessionConcurrencyReject].
+type SessionConcurrency string
+
+const (
+	// SessionConcurrencyUnrestricted runs the concurrent invocations of a
+	// session. An invocation fails with [session.ErrStaleSession] when it
+	// saves an event after another one updated the session.
+	SessionConcurrencyUnrestricted SessionConcurrency = ""
+	// SessionConcurrencyQueue runs the invocations of a session one at a
+	// time, the others wait for their turn.
+	SessionConcurrencyQueue SessionConcurrency = "queue"
+	// SessionConcurrencyReject fails the invocations of a session with
+	// [ErrSessionBusy] while another one, or the compaction following it,
+	// runs.
+	SessionConcurrencyReject SessionConcurrency = "reject"
+)
+
+// ErrSessionBusy is the error of the invocations rejected by
+// [SessionConcurrencyReject].
+var ErrSessionBusy = errors.New("session is busy with another invocation")
+
+// sessionKey identifies a session of a session service.
+type sessionKey struct {
+	service                    serviceID
+	appName, userID, sessionID string
+}
+
+// serviceID identifies a session service without comparing it, the services
+// may not be comparable: it is the address of the service, or of the runner
+// when the service has none.
+type serviceID struct {
+	typ  reflect.Type
+	addr unsafe.Pointer
+}
+
+func newServiceID(s session.Service, r *Runner) serviceID {
+	v := reflect.ValueOf(s)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan:
+		return serviceID{typ: v.Type(), addr: v.UnsafePointer()}
+	default:
+		return serviceID{addr: unsafe.Pointer(r)}
+	}
+}
+
+// sessionLock serializes the invocations and the compactions of a session.
+type sessionLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+var sessionLocks = struct {
+	mu    sync.Mutex
+	locks map[sessionKey]*sessionLock
+}{locks: make(map[sessionKey]*sessionLock)}
+
+// lockSession locks the session, waiting for the lock unless wait is false:
+// it fails with ErrSessionBusy then. It returns the function unlocking the
+// session.
+func lockSession(ctx context.Context, key sessionKey, wait bool) (func(), error) {
+	sessionLocks.mu.Lock()
+	l, ok := sessionLocks.locks[key]
+	if !ok {
+		l = &sessionLock{ch: make(chan struct{}, 1)}
+		sessionLocks.locks[key] = l
+	}
+	l.refs++
+	sessionLocks.mu.Unlock()
+
+	release := func() {
+		sessionLocks.mu.Lock()
+		defer sessionLocks.mu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(sessionLocks.locks, key)
+		}
+	}
+	if !wait {
+		select {
+		case l.ch <- struct{}{}:
+		default:
+			release()
+			return nil, ErrSessionBusy
+		}
+	} else {
+		select {
+		case l.ch <- struct{}{}:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return func() {
+		<-l.ch
+		release()
+	}, nil
+}
+
+// lockInvocation locks the session for an invocation according to the
+// session concurrency of the runner. The unrestricted invocations only wait
+// for the compaction of the session, if any, so that they start from the
+// compacted session.
+func (r *Runner) lockInvocation(ctx context.Context, userID, sessionID string) (func(), error) {
+	unlock, err := lockSession(ctx, r.sessionKey(userID, sessionID), r.sessionConcurrency != SessionConcurrencyReject)
+	if err != nil {
+		return nil, err
+	}
+	if r.sessionConcurrency == SessionConcurrencyUnrestricted {
+		unlock()
+		return func() {}, nil
+	}
+	return unlock, nil
+}
+
+func (r *Runner) sessionKey(userID, sessionID string) sessionKey {
+	return sessionKey{service: r.serviceID, appName: r.appName, userID: userID, sessionID: sessionID}
+}
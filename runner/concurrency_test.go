@@ -0,0 +1,269 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// blockingAgent replies to the user message once the test releases the
+// invocation.
+type blockingAgent struct {
+	started chan string
+	release map[string]chan struct{}
+}
+
+func newBlockingAgent(t *testing.T, msgs ...string) (agent.Agent, *blockingAgent) {
+	b := &blockingAgent{started: make(chan string, len(msgs)), release: make(map[string]chan struct{})}
+	for _, msg := range msgs {
+		b.release[msg] = make(chan struct{})
+	}
+	a := must(agent.New(agent.Config{
+		Name: "blocking_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				msg := ctx.UserContent().Parts[0].Text
+				b.started <- msg
+				<-b.release[msg]
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.Author = "blocking_agent"
+				ev.Content = genai.NewContentFromText("reply to "+msg, genai.RoleModel)
+				yield(ev, nil)
+			}
+		},
+	}))
+	return a, b
+}
+
+func runAsync(r *Runner, msg string) chan error {
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		for _, runErr := range r.Run(context.Background(), "user", "session", genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}) {
+			if runErr != nil {
+				err = runErr
+			}
+		}
+		done <- err
+	}()
+	return done
+}
+
+func TestSessionConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency SessionConcurrency
+		// wantErr1 and wantErr2 are the errors of the first and second
+		// invocations, started one after the other.
+		wantErr1, wantErr2 error
+		wantEvents         []string
+	}{
+		{
+			name:        "unrestricted",
+			concurrency: SessionConcurrencyUnrestricted,
+			// The first invocation is stale after the second one saved its
+			// user message.
+			wantErr1:   session.ErrStaleSession,
+			wantEvents: []string{"first", "second", "reply to second"},
+		},
+		{
+			name:        "queue",
+			concurrency: SessionConcurrencyQueue,
+			wantEvents:  []string{"first", "reply to first", "second", "reply to second"},
+		},
+		{
+			name:        "reject",
+			concurrency: SessionConcurrencyReject,
+			wantErr2:    ErrSessionBusy,
+			wantEvents:  []string{"first", "reply to first"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := newBlockingAgent(t, "first", "second")
+			sessionService := session.InMemoryService()
+			r, err := New(Config{AppName: "app", Agent: a, SessionService: sessionService, SessionConcurrency: tt.concurrency})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+				t.Fatal(err)
+			}
+
+			done1 := runAsync(r, "first")
+			<-b.started
+			done2 := runAsync(r, "second")
+			switch tt.concurrency {
+			case SessionConcurrencyUnrestricted:
+				<-b.started
+				close(b.release["first"])
+				close(b.release["second"])
+			case SessionConcurrencyQueue:
+				select {
+				case <-b.started:
+					t.Fatalf("second invocation started while the first one runs")
+				case <-time.After(50 * time.Millisecond):
+				}
+				close(b.release["first"])
+				<-b.started
+				close(b.release["second"])
+			case SessionConcurrencyReject:
+				if err := <-done2; !errors.Is(err, tt.wantErr2) {
+					t.Errorf("second invocation error = %v, want %v", err, tt.wantErr2)
+				}
+				done2 = nil
+				close(b.release["first"])
+			}
+			if err := <-done1; !errors.Is(err, tt.wantErr1) {
+				t.Errorf("first invocation error = %v, want %v", err, tt.wantErr1)
+			}
+			if done2 != nil {
+				if err := <-done2; !errors.Is(err, tt.wantErr2) {
+					t.Errorf("second invocation error = %v, want %v", err, tt.wantErr2)
+				}
+			}
+
+			resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for ev := range resp.Session.Events().All() {
+				got = append(got, ev.Content.Parts[0].Text)
+			}
+			if diff := cmp.Diff(tt.wantEvents, got); diff != "" {
+				t.Errorf("session events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSessionConcurrency_QueueCanceled(t *testing.T) {
+	a, b := newBlockingAgent(t, "first")
+	sessionService := session.InMemoryService()
+	r, err := New(Config{AppName: "app", Agent: a, SessionService: sessionService, SessionConcurrency: SessionConcurrencyQueue})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	done := runAsync(r, "first")
+	<-b.started
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	for _, err := range r.Run(ctx, "user", "session", genai.NewContentFromText("second", genai.RoleUser), agent.RunConfig{}) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("queued invocation error = %v, want %v", err, context.Canceled)
+		}
+	}
+
+	close(b.release["first"])
+	if err := <-done; err != nil {
+		t.Errorf("first invocation failed: %v", err)
+	}
+}
+
+func TestSessionConcurrency_Invalid(t *testing.T) {
+	a, _ := newBlockingAgent(t)
+	if _, err := New(Config{AppName: "app", Agent: a, SessionService: session.InMemoryService(), SessionConcurrency: "unknown"}); err == nil {
+		t.Errorf("New() with an invalid session concurrency succeeded, want error")
+	}
+}
+
+// uncomparableService is a session service which is not comparable.
+type uncomparableService struct {
+	session.Service
+	_ []string
+}
+
+func TestSessionConcurrency_Runners(t *testing.T) {
+	tests := []struct {
+		name string
+		// service returns the session services of the two runners.
+		service func() (session.Service, session.Service)
+		// sameRunner runs the two invocations with the first runner.
+		sameRunner bool
+		wantErr2   error
+	}{
+		{
+			name: "shared service",
+			service: func() (session.Service, session.Service) {
+				s := session.InMemoryService()
+				return s, s
+			},
+			wantErr2: ErrSessionBusy,
+		},
+		{
+			name: "uncomparable service",
+			service: func() (session.Service, session.Service) {
+				s := uncomparableService{Service: session.InMemoryService()}
+				return s, s
+			},
+			sameRunner: true,
+			wantErr2:   ErrSessionBusy,
+		},
+		{
+			name: "uncomparable service of other runner",
+			service: func() (session.Service, session.Service) {
+				s := uncomparableService{Service: session.InMemoryService()}
+				return s, s
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := newBlockingAgent(t, "first", "second")
+			s1, s2 := tt.service()
+			r1, err := New(Config{AppName: "app", Agent: a, SessionService: s1, SessionConcurrency: SessionConcurrencyReject})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r2, err := New(Config{AppName: "app", Agent: a, SessionService: s2, SessionConcurrency: SessionConcurrencyReject})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.sameRunner {
+				r2 = r1
+			}
+			if _, err := s1.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+				t.Fatal(err)
+			}
+
+			done1 := runAsync(r1, "first")
+			<-b.started
+			done2 := runAsync(r2, "second")
+			if tt.wantErr2 == nil {
+				<-b.started
+				close(b.release["second"])
+			}
+			if err := <-done2; !errors.Is(err, tt.wantErr2) {
+				t.Errorf("second invocation error = %v, want %v", err, tt.wantErr2)
+			}
+			close(b.release["first"])
+			<-done1
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/session"
+)
+
+// userArtifactPrefix is the prefix of the user scoped artifacts, shared by
+// the sessions of the user.
+const userArtifactPrefix = "user:"
+
+// Rewind rewinds the session to before the event or the invocation of the
+// request, see [session.RewindService.Rewind], and deletes the artifact
+// versions saved by the removed events. The user scoped artifacts are kept,
+// as the app and user states. The session service must implement
+// [session.RewindService].
+//
+// The app name of the request defaults to the app of the runner. The session
+// is locked as by an invocation, whatever the session concurrency.
+func (r *Runner) Rewind(ctx context.Context, req *session.RewindRequest) (*session.RewindResponse, error) {
+	rs, err := r.rewindService()
+	if err != nil {
+		return nil, err
+	}
+	rewindReq := *req
+	if rewindReq.AppName, err = r.requestAppName(req.AppName); err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockSession(ctx, r.sessionKey(req.UserID, req.SessionID), r.sessionConcurrency != SessionConcurrencyReject)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	resp, err := rs.Rewind(ctx, &rewindReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind session: %w", err)
+	}
+	if r.artifactService == nil {
+		return resp, nil
+	}
+	for _, event := range resp.RemovedEvents {
+		for fileName, version := range event.Actions.ArtifactDelta {
+			if strings.HasPrefix(fileName, userArtifactPrefix) || version == 0 {
+				continue
+			}
+			err := r.artifactService.Delete(ctx, &artifact.DeleteRequest{
+				AppName:   rewindReq.AppName,
+				UserID:    req.UserID,
+				SessionID: req.SessionID,
+				FileName:  fileName,
+				Version:   version,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("session rewound, failed to delete artifact %s version %d: %w", fileName, version, err)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// Fork forks the session into a new session up to the event or the
+// invocation of the request, see [session.RewindService.Fork], and copies
+// the artifact versions of the copied events to the new session. The session
+// service must implement [session.RewindService].
+//
+// The app name of the request defaults to the app of the runner.
+func (r *Runner) Fork(ctx context.Context, req *session.ForkRequest) (*session.ForkResponse, error) {
+	rs, err := r.rewindService()
+	if err != nil {
+		return nil, err
+	}
+	forkReq := *req
+	if forkReq.AppName, err = r.requestAppName(req.AppName); err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockSession(ctx, r.sessionKey(req.UserID, req.SessionID), r.sessionConcurrency != SessionConcurrencyReject)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	resp, err := rs.Fork(ctx, &forkReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork session: %w", err)
+	}
+	if r.artifactService == nil {
+		return resp, nil
+	}
+
+	// The versions of the files up to the latest one of the events are
+	// copied in order, so that they keep their numbers.
+	latestVersions := make(map[string]int64)
+	for event := range resp.Session.Events().All() {
+		for fileName, version := range event.Actions.ArtifactDelta {
+			if !strings.HasPrefix(fileName, userArtifactPrefix) {
+				latestVersions[fileName] = max(latestVersions[fileName], version)
+			}
+		}
+	}
+	for fileName, latest := range latestVersions {
+		if err := r.copyArtifact(ctx, &forkReq, resp.Session.ID(), fileName, latest); err != nil {
+			return nil, fmt.Errorf("session forked, failed to copy artifact %s: %w", fileName, err)
+		}
+	}
+	return resp, nil
+}
+
+// copyArtifact copies the versions of the artifact of the forked session up
+// to latest to the new session.
+func (r *Runner) copyArtifact(ctx context.Context, req *session.ForkRequest, newSessionID, fileName string, latest int64) error {
+	versionsResp, err := r.artifactService.Versions(ctx, &artifact.VersionsRequest{
+		AppName:   req.AppName,
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+		FileName:  fileName,
+	})
+	if err != nil {
+		return err
+	}
+	versions := slices.Sorted(slices.Values(versionsResp.Versions))
+	for _, version := range versions {
+		if version > latest {
+			break
+		}
+		loadResp, err := r.artifactService.Load(ctx, &artifact.LoadRequest{
+			AppName:   req.AppName,
+			UserID:    req.UserID,
+			SessionID: req.SessionID,
+			FileName:  fileName,
+			Version:   version,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load version %d: %w", version, err)
+		}
+		_, err = r.artifactService.Save(ctx, &artifact.SaveRequest{
+			AppName:   req.AppName,
+			UserID:    req.UserID,
+			SessionID: newSessionID,
+			FileName:  fileName,
+			Part:      loadResp.Part,
+			Version:   version,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save version %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) rewindService() (session.RewindService, error) {
+	rs, ok := r.sessionService.(session.RewindService)
+	if !ok {
+		return nil, fmt.Errorf("session service of type %T doesn't support rewinds", r.sessionService)
+	}
+	return rs, nil
+}
+
+// requestAppName returns the app name of a request, which defaults to the
+// app of the runner.
+func (r *Runner) requestAppName(appName string) (string, error) {
+	if appName == "" {
+		return r.appName, nil
+	}
+	if appName != r.appName {
+		return "", fmt.Errorf("%w: session of app %q, the runner runs app %q", session.ErrInvalidRequest, appName, r.appName)
+	}
+	return appName, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// newArtifactAgent returns an agent saving the user message in the notes.txt
+// session artifact and the user:last.txt user artifact.
+func newArtifactAgent(t *testing.T) agent.Agent {
+	return must(agent.New(agent.Config{
+		Name: "artifact_agent",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				msg := ctx.UserContent().Parts[0].Text
+				ev := session.NewEvent(ctx.InvocationID())
+				ev.Author = "artifact_agent"
+				ev.Actions.StateDelta["last"] = msg
+				ev.Actions.ArtifactDelta = make(map[string]int64)
+				for _, name := range []string{"notes.txt", "user:last.txt"} {
+					resp, err := ctx.Artifacts().Save(ctx, name, genai.NewPartFromText(msg))
+					if err != nil {
+						yield(nil, err)
+						return
+					}
+					ev.Actions.ArtifactDelta[name] = resp.Version
+				}
+				yield(ev, nil)
+			}
+		},
+	}))
+}
+
+func TestRewindAndFork(t *testing.T) {
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	artifactService := artifact.InMemoryService()
+	r, err := New(Config{AppName: "app", Agent: newArtifactAgent(t), SessionService: sessionService, ArtifactService: artifactService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	var invocationIDs []string
+	for _, msg := range []string{"one", "two", "three"} {
+		for ev, err := range r.Run(ctx, "user", "session", genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			invocationIDs = append(invocationIDs, ev.InvocationID)
+		}
+	}
+
+	versions := func(sessionID, fileName string) []int64 {
+		t.Helper()
+		resp, err := artifactService.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: sessionID, FileName: fileName})
+		if err != nil {
+			t.Fatalf("Versions(%s, %s) failed: %v", sessionID, fileName, err)
+		}
+		return resp.Versions
+	}
+	load := func(sessionID, fileName string) string {
+		t.Helper()
+		resp, err := artifactService.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: sessionID, FileName: fileName})
+		if err != nil {
+			t.Fatalf("Load(%s, %s) failed: %v", sessionID, fileName, err)
+		}
+		return resp.Part.Text
+	}
+
+	// The fork before the third invocation has the first two versions of
+	// the session artifact.
+	forkResp, err := r.Fork(ctx, &session.ForkRequest{UserID: "user", SessionID: "session", InvocationID: invocationIDs[2], NewSessionID: "forked"})
+	if err != nil {
+		t.Fatalf("Fork() failed: %v", err)
+	}
+	if got := forkResp.Session.Events().Len(); got != 4 {
+		t.Errorf("Fork() session has %d events, want 4", got)
+	}
+	if diff := cmp.Diff([]int64{1, 2}, versions("forked", "notes.txt"), sortInt64s); diff != "" {
+		t.Errorf("forked session artifact versions mismatch (-want +got):\n%s", diff)
+	}
+	if got := load("forked", "notes.txt"); got != "two" {
+		t.Errorf("forked session artifact = %q, want %q", got, "two")
+	}
+
+	// The rewind before the second invocation deletes the later versions of
+	// the session artifact, the user artifact is kept.
+	rewindResp, err := r.Rewind(ctx, &session.RewindRequest{UserID: "user", SessionID: "session", InvocationID: invocationIDs[1]})
+	if err != nil {
+		t.Fatalf("Rewind() failed: %v", err)
+	}
+	if got := len(rewindResp.RemovedEvents); got != 4 {
+		t.Errorf("Rewind() removed %d events, want 4", got)
+	}
+	if diff := cmp.Diff([]int64{1}, versions("session", "notes.txt"), sortInt64s); diff != "" {
+		t.Errorf("rewound session artifact versions mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int64{1, 2, 3}, versions("session", "user:last.txt"), sortInt64s); diff != "" {
+		t.Errorf("user artifact versions mismatch (-want +got):\n%s", diff)
+	}
+	if got, err := rewindResp.Session.State().Get("last"); err != nil || got != "one" {
+		t.Errorf("rewound session state last = %v, %v, want %q", got, err, "one")
+	}
+
+	// The conversation continues from the rewound session.
+	for _, err := range r.Run(ctx, "user", "session", genai.NewContentFromText("again", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := load("session", "notes.txt"); got != "again" {
+		t.Errorf("session artifact after the rewind = %q, want %q", got, "again")
+	}
+}
+
+func TestRewind_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		sessionService session.Service
+		appName        string
+	}{
+		{
+			name:           "unsupported service",
+			sessionService: struct{ session.Service }{session.InMemoryService()},
+		},
+		{
+			name:           "other app",
+			sessionService: session.InMemoryService(),
+			appName:        "other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(Config{AppName: "app", Agent: newArtifactAgent(t), SessionService: tt.sessionService})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Rewind(t.Context(), &session.RewindRequest{AppName: tt.appName, UserID: "user", SessionID: "session", EventID: "event"}); err == nil {
+				t.Errorf("Rewind() succeeded, want error")
+			}
+			if _, err := r.Fork(t.Context(), &session.ForkRequest{AppName: tt.appName, UserID: "user", SessionID: "session"}); err == nil {
+				t.Errorf("Fork() succeeded, want error")
+			}
+		})
+	}
+}
+
+var sortInt64s = cmpopts.SortSlices(func(a, b int64) bool { return a < b })
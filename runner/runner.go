@@ -20,19 +20,26 @@ import (
 	"fmt"
 	"iter"
 	"log"
+	"sync"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/checkpoint"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	artifactinternal "google.golang.org/adk/internal/artifact"
+	authinternal "google.golang.org/adk/internal/auth"
+	"google.golang.org/adk/internal/budget"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/llminternal"
 	imemory "google.golang.org/adk/internal/memory"
 	"google.golang.org/adk/internal/sessioninternal"
+	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/compaction"
 	"google.golang.org/genai"
 )
 
@@ -47,6 +54,31 @@ type Config struct {
 	ArtifactService artifact.Service
 	// optional
 	MemoryService memory.Service
+	// optional, stores the credentials obtained by the tools.
+	// Defaults to an in-memory credential service.
+	CredentialService auth.CredentialService
+
+	// Resumable makes the agents checkpoint their progress in the session
+	// during the invocations, e.g. the current sub-agent of a workflow agent,
+	// so that an interrupted invocation can be continued with
+	// [Runner.Resume].
+	Resumable bool
+
+	// optional, overrides the models of the LLM agents, e.g. to run the
+	// agents with the model chosen when launching them.
+	Model model.LLM
+
+	// optional, enables the context caching of the LLM agents which don't
+	// configure their own.
+	ContextCache *model.ContextCacheConfig
+
+	// optional, compacts the older events of the sessions in the background
+	// after the invocations. See [Runner.Wait].
+	Compaction *compaction.Config
+
+	// optional, defines how the concurrent invocations of a session are
+	// handled. Defaults to [SessionConcurrencyUnrestricted].
+	SessionConcurrency SessionConcurrency
 }
 
 // New creates a new [Runner].
@@ -64,114 +96,354 @@ func New(cfg Config) (*Runner, error) {
 		return nil, fmt.Errorf("failed to create agent tree: %w", err)
 	}
 
-	return &Runner{
-		appName:         cfg.AppName,
-		rootAgent:       cfg.Agent,
-		sessionService:  cfg.SessionService,
-		artifactService: cfg.ArtifactService,
-		memoryService:   cfg.MemoryService,
-		parents:         parents,
-	}, nil
+	if c := cfg.Compaction; c != nil {
+		if c.Summarizer == nil {
+			return nil, fmt.Errorf("compaction summarizer is required")
+		}
+		if c.Interval < 1 || c.Overlap < 0 {
+			return nil, fmt.Errorf("invalid compaction interval %d or overlap %d", c.Interval, c.Overlap)
+		}
+	}
+
+	switch cfg.SessionConcurrency {
+	case SessionConcurrencyUnrestricted, SessionConcurrencyQueue, SessionConcurrencyReject:
+	default:
+		return nil, fmt.Errorf("invalid session concurrency %q", cfg.SessionConcurrency)
+	}
+
+	credentialService := cfg.CredentialService
+	if credentialService == nil {
+		credentialService = auth.InMemoryCredentialService()
+	}
+
+	r := &Runner{
+		appName:            cfg.AppName,
+		rootAgent:          cfg.Agent,
+		sessionService:     cfg.SessionService,
+		artifactService:    cfg.ArtifactService,
+		memoryService:      cfg.MemoryService,
+		credentialService:  credentialService,
+		resumable:          cfg.Resumable,
+		compaction:         cfg.Compaction,
+		model:              cfg.Model,
+		contextCache:       cfg.ContextCache,
+		sessionConcurrency: cfg.SessionConcurrency,
+		parents:            parents,
+	}
+	r.serviceID = newServiceID(cfg.SessionService, r)
+	return r, nil
 }
 
 // Runner manages the execution of the agent within a session, handling message
 // processing, event generation, and interaction with various services like
 // artifact storage, session management, and memory.
 type Runner struct {
-	appName         string
-	rootAgent       agent.Agent
-	sessionService  session.Service
-	artifactService artifact.Service
-	memoryService   memory.Service
+	appName            string
+	rootAgent          agent.Agent
+	sessionService     session.Service
+	artifactService    artifact.Service
+	memoryService      memory.Service
+	credentialService  auth.CredentialService
+	resumable          bool
+	compaction         *compaction.Config
+	model              model.LLM
+	contextCache       *model.ContextCacheConfig
+	sessionConcurrency SessionConcurrency
 
 	parents parentmap.Map
+
+	// serviceID identifies the session service in the session locks.
+	serviceID serviceID
+
+	// compactions tracks the compactions running in the background. A
+	// compaction holds the lock of its session, so that the compactions of
+	// different sessions run concurrently.
+	compactions sync.WaitGroup
 }
 
 // Run runs the agent for the given user input, yielding events from agents.
 // For each user message it finds the proper agent within an agent tree to
 // continue the conversation within the session.
+//
+// Long-running tools return a pending status, or no result, and the
+// invocation ends waiting for the operations to complete. The client resumes
+// it by sending the function responses, with the IDs of the function calls,
+// as msg. The agent that issued the function calls runs then, wherever it is
+// in the agent tree. Function responses with WillContinue set are progress
+// updates: they are saved in the session without running the agent, and the
+// model sees the latest response of every function call.
 func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.Content, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
 	// TODO(hakim): we need to validate whether cfg is compatible with the Agent.
 	//   see adk-python/src/google/adk/runners.py Runner._new_invocation_context.
 	// TODO: setup tracer.
 	return func(yield func(*session.Event, error) bool) {
+		unlock, err := r.lockInvocation(ctx, userID, sessionID)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer func() { unlock() }()
+
+		ctx, session, agentToRun, err := r.newInvocationContext(ctx, userID, sessionID, msg, cfg, nil)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		if err := r.appendMessageToSession(ctx, session, msg, cfg.SaveInputBlobsAsArtifacts); err != nil {
+			yield(nil, err)
+			return
+		}
+		if isProgressUpdate(msg) {
+			return
+		}
+
+		r.runAgent(ctx, session, agentToRun, yield)
+		r.compact(ctx, userID, sessionID, unlock)
+		unlock = func() {}
+	}
+}
+
+// Wait waits for the event compactions started by the runner to complete.
+func (r *Runner) Wait() {
+	r.compactions.Wait()
+}
+
+// compact compacts the events of the session in the background, on behalf of
+// the invocation. It takes over the lock of the session held by the
+// invocation, see [Runner.lockInvocation], and releases it after the
+// compaction.
+func (r *Runner) compact(invocationCtx agent.InvocationContext, userID, sessionID string, unlock func()) {
+	if r.compaction == nil {
+		unlock()
+		return
+	}
+	author, invocationID := invocationCtx.Agent().Name(), invocationCtx.InvocationID()
+	// The compaction outlives the invocation.
+	ctx := context.WithoutCancel(invocationCtx)
+	if r.sessionConcurrency == SessionConcurrencyUnrestricted {
+		// The invocation doesn't hold the lock, which makes the next
+		// invocations wait for the compaction.
+		var err error
+		if unlock, err = lockSession(ctx, r.sessionKey(userID, sessionID), true); err != nil {
+			log.Printf("Failed to compact the events of session %s: %v", sessionID, err)
+			return
+		}
+	}
+	r.compactions.Add(1)
+	go func() {
+		defer r.compactions.Done()
+		defer unlock()
+
 		resp, err := r.sessionService.Get(ctx, &session.GetRequest{
 			AppName:   r.appName,
 			UserID:    userID,
 			SessionID: sessionID,
 		})
+		if err == nil {
+			err = compaction.Compact(ctx, r.sessionService, resp.Session, r.compaction, author, invocationID)
+		}
+		if err != nil {
+			log.Printf("Failed to compact the events of session %s: %v", sessionID, err)
+		}
+	}()
+}
+
+// RunLive runs the agent in bidirectional streaming mode, yielding events from
+// agents as they are generated.
+//
+// The client sends the user contents, the realtime input (e.g. audio or
+// video) and the activity signals through the queue, while the events are
+// received. The run ends when the queue is closed. The streaming mode of cfg
+// is ignored.
+//
+// The models of the agents must implement [model.LiveLLM].
+func (r *Runner) RunLive(ctx context.Context, userID, sessionID string, queue *agent.LiveRequestQueue, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	// reference: adk-python src/google/adk/runners.py Runner.run_live
+	return func(yield func(*session.Event, error) bool) {
+		if queue == nil {
+			yield(nil, fmt.Errorf("live request queue is required"))
+			return
+		}
+		cfg.StreamingMode = agent.StreamingModeBidi
+		unlock, err := r.lockInvocation(ctx, userID, sessionID)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
+		defer unlock()
 
-		session := resp.Session
+		ctx, session, agentToRun, err := r.newInvocationContext(ctx, userID, sessionID, nil, cfg, queue)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		r.runAgent(ctx, session, agentToRun, yield)
+	}
+}
 
-		agentToRun, err := r.findAgentToRun(session)
+// Resume continues the invocation of the session from the last checkpoints of
+// the agents, e.g. after the process running it crashed. The runner must be
+// [Config.Resumable].
+//
+// The agents skip the work they completed in the invocation: the workflow
+// agents continue with their current sub-agents, and the LLM agents from
+// their last event, calling again the functions that did not respond.
+//
+// The run config is not saved in the session: cfg should be the one the
+// invocation was started with, e.g. to keep its streaming mode and budget.
+func (r *Runner) Resume(ctx context.Context, userID, sessionID, invocationID string, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	// reference: adk-python src/google/adk/runners.py Runner._setup_context_for_resumed_invocation
+	return func(yield func(*session.Event, error) bool) {
+		if !r.resumable {
+			yield(nil, fmt.Errorf("runner is not resumable"))
+			return
+		}
+		unlock, err := r.lockInvocation(ctx, userID, sessionID)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
+		defer func() { unlock() }()
 
-		ctx = parentmap.ToContext(ctx, r.parents)
-		ctx = runconfig.ToContext(ctx, &runconfig.RunConfig{
-			StreamingMode: runconfig.StreamingMode(cfg.StreamingMode),
+		resp, err := r.sessionService.Get(ctx, &session.GetRequest{
+			AppName:   r.appName,
+			UserID:    userID,
+			SessionID: sessionID,
 		})
-
-		var artifacts agent.Artifacts
-		if r.artifactService != nil {
-			artifacts = &artifactinternal.Artifacts{
-				Service:   r.artifactService,
-				SessionID: session.ID(),
-				AppName:   session.AppName(),
-				UserID:    session.UserID(),
-			}
+		if err != nil {
+			yield(nil, err)
+			return
 		}
-
-		var memoryImpl agent.Memory = nil
-		if r.memoryService != nil {
-			memoryImpl = &imemory.Memory{
-				Service:   r.memoryService,
-				SessionID: session.ID(),
-				UserID:    session.UserID(),
-				AppName:   session.AppName(),
+		storedSession := resp.Session
+
+		events := storedSession.Events()
+		start := -1
+		for i := range events.Len() {
+			if events.At(i).InvocationID == invocationID {
+				start = i
+				break
 			}
 		}
-
-		ctx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
-			Artifacts:   artifacts,
-			Memory:      memoryImpl,
-			Session:     sessioninternal.NewMutableSession(r.sessionService, session),
-			Agent:       agentToRun,
-			UserContent: msg,
-			RunConfig:   &cfg,
-		})
-
-		if err := r.appendMessageToSession(ctx, session, msg, cfg.SaveInputBlobsAsArtifacts); err != nil {
+		if start < 0 {
+			yield(nil, fmt.Errorf("invocation %q not found in session %q", invocationID, sessionID))
+			return
+		}
+		// The invocation starts with the user message, if any. The agent
+		// to run is found as when the invocation started.
+		var msg *genai.Content
+		if first := events.At(start); first.Author == "user" {
+			msg = first.LLMResponse.Content
+		}
+		agentToRun, err := r.findAgentToRun(eventsBefore{events: events, end: start}, msg)
+		if err != nil {
 			yield(nil, err)
 			return
 		}
 
-		for event, err := range agentToRun.Run(ctx) {
-			if err != nil {
-				if !yield(event, err) {
-					return
-				}
-				continue
-			}
+		invocationCtx := r.invocationContext(ctx, storedSession, agentToRun, invocationID, msg, cfg, nil)
+		r.runAgent(checkpoint.WithResuming(invocationCtx, true), storedSession, agentToRun, yield)
+		r.compact(invocationCtx, userID, sessionID, unlock)
+		unlock = func() {}
+	}
+}
+
+// newInvocationContext returns the context of a new invocation in the session,
+// the stored session and the agent to run.
+func (r *Runner) newInvocationContext(ctx context.Context, userID, sessionID string, msg *genai.Content, cfg agent.RunConfig, queue *agent.LiveRequestQueue) (agent.InvocationContext, session.Session, agent.Agent, error) {
+	resp, err := r.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   r.appName,
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	session := resp.Session
+
+	agentToRun, err := r.findAgentToRun(session.Events(), msg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return r.invocationContext(ctx, session, agentToRun, "", msg, cfg, queue), session, agentToRun, nil
+}
 
-			// only commit non-partial event to a session service
-			if !event.LLMResponse.Partial {
-				if err := r.sessionService.AppendEvent(ctx, session, event); err != nil {
-					yield(nil, fmt.Errorf("failed to add event to session: %w", err))
-					return
-				}
+// invocationContext returns the context of the invocation in the session. A
+// new invocation ID is generated if invocationID is empty.
+func (r *Runner) invocationContext(ctx context.Context, session session.Session, agentToRun agent.Agent, invocationID string, msg *genai.Content, cfg agent.RunConfig, queue *agent.LiveRequestQueue) agent.InvocationContext {
+	ctx = parentmap.ToContext(ctx, r.parents)
+	var tracker *budget.Tracker
+	if cfg.Budget != nil {
+		tracker = budget.NewTracker(cfg.Budget, session.State())
+	}
+	ctx = runconfig.ToContext(ctx, &runconfig.RunConfig{
+		StreamingMode:    runconfig.StreamingMode(cfg.StreamingMode),
+		LiveRequestQueue: queue,
+		Resumable:        r.resumable,
+		Model:            r.model,
+		ContextCache:     r.contextCache,
+		Budget:           tracker,
+	})
+	ctx = authinternal.ToContext(ctx, &authinternal.Credentials{
+		Service: r.credentialService,
+		AppName: session.AppName(),
+		UserID:  session.UserID(),
+	})
+
+	var artifacts agent.Artifacts
+	if r.artifactService != nil {
+		artifacts = &artifactinternal.Artifacts{
+			Service:   r.artifactService,
+			SessionID: session.ID(),
+			AppName:   session.AppName(),
+			UserID:    session.UserID(),
+		}
+	}
+
+	var memoryImpl agent.Memory = nil
+	if r.memoryService != nil {
+		memoryImpl = &imemory.Memory{
+			Service:   r.memoryService,
+			SessionID: session.ID(),
+			UserID:    session.UserID(),
+			AppName:   session.AppName(),
+		}
+	}
+
+	return icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
+		InvocationID: invocationID,
+		Artifacts:    artifacts,
+		Memory:       memoryImpl,
+		Session:      sessioninternal.NewMutableSession(r.sessionService, session),
+		Agent:        agentToRun,
+		UserContent:  msg,
+		RunConfig:    &cfg,
+	})
+}
+
+// runAgent runs the agent, saving the events in the session and yielding
+// them.
+func (r *Runner) runAgent(ctx agent.InvocationContext, storedSession session.Session, agentToRun agent.Agent, yield func(*session.Event, error) bool) {
+	for event, err := range agentToRun.Run(ctx) {
+		if err != nil {
+			if !yield(event, err) {
+				return
 			}
+			continue
+		}
 
-			if !yield(event, nil) {
+		// only commit non-partial event to a session service
+		if !event.LLMResponse.Partial {
+			if err := r.sessionService.AppendEvent(ctx, storedSession, event); err != nil {
+				yield(nil, fmt.Errorf("failed to add event to session: %w", err))
 				return
 			}
 		}
+
+		if !yield(event, nil) {
+			return
+		}
 	}
 }
 
@@ -212,13 +484,21 @@ func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSessi
 
 // findAgentToRun returns the agent that should handle the next request based on
 // session history.
-func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
-	events := session.Events()
+//
+// The function responses in msg go to the agent that issued the function
+// calls.
+func (r *Runner) findAgentToRun(events session.Events, msg *genai.Content) (agent.Agent, error) {
+	// reference: adk-python src/google/adk/runners.py Runner._find_agent_to_run
+	if event := findMatchingFunctionCall(events, msg); event != nil {
+		if subAgent := findAgent(r.rootAgent, event.Author); subAgent != nil {
+			return subAgent, nil
+		}
+		log.Printf("Function call from an unknown agent: %s, event id: %s", event.Author, event.ID)
+	}
+
 	for i := events.Len() - 1; i >= 0; i-- {
 		event := events.At(i)
 
-		// TODO: findMatchingFunctionCall.
-
 		if event.Author == "user" {
 			continue
 		}
@@ -239,6 +519,66 @@ func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
 	return r.rootAgent, nil
 }
 
+// findMatchingFunctionCall returns the event with the function call of the
+// first function response in msg, or nil.
+func findMatchingFunctionCall(events session.Events, msg *genai.Content) *session.Event {
+	fnResponses := utils.FunctionResponses(msg)
+	if len(fnResponses) == 0 {
+		return nil
+	}
+	id := fnResponses[0].ID
+	for i := events.Len() - 1; i >= 0; i-- {
+		event := events.At(i)
+		for _, fnCall := range utils.FunctionCalls(event.LLMResponse.Content) {
+			if fnCall.ID == id {
+				return event
+			}
+		}
+	}
+	return nil
+}
+
+// isProgressUpdate reports whether msg only has function responses that
+// will be followed by other responses.
+func isProgressUpdate(msg *genai.Content) bool {
+	if msg == nil || len(msg.Parts) == 0 {
+		return false
+	}
+	for _, part := range msg.Parts {
+		if part.FunctionResponse == nil || part.FunctionResponse.WillContinue == nil || !*part.FunctionResponse.WillContinue {
+			return false
+		}
+	}
+	return true
+}
+
+// eventsBefore is the view of the session events before the end index.
+type eventsBefore struct {
+	events session.Events
+	end    int
+}
+
+func (e eventsBefore) All() iter.Seq[*session.Event] {
+	return func(yield func(*session.Event) bool) {
+		for i := range e.end {
+			if !yield(e.events.At(i)) {
+				return
+			}
+		}
+	}
+}
+
+func (e eventsBefore) Len() int {
+	return e.end
+}
+
+func (e eventsBefore) At(i int) *session.Event {
+	if i >= 0 && i < e.end {
+		return e.events.At(i)
+	}
+	return nil
+}
+
 // checks if the agent and its parent chain allow transfer up the tree.
 func (r *Runner) isTransferableAcrossAgentTree(agentToRun agent.Agent) bool {
 	for curAgent := agentToRun; curAgent != nil; curAgent = r.parents[curAgent.Name()] {
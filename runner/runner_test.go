@@ -25,6 +25,7 @@ import (
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
 	"google.golang.org/genai"
 )
@@ -40,6 +41,7 @@ func TestRunner_findAgentToRun(t *testing.T) {
 		name      string
 		rootAgent agent.Agent
 		session   session.Session
+		msg       *genai.Content
 		wantAgent agent.Agent
 		wantErr   bool
 	}{
@@ -69,6 +71,28 @@ func TestRunner_findAgentToRun(t *testing.T) {
 			rootAgent: agentTree.root,
 			wantAgent: agentTree.root,
 		},
+		{
+			name: "function response goes to the agent that issued the call",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{
+					Author: "no_transfer_agent",
+					LLMResponse: model.LLMResponse{
+						Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+							{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "approve"}},
+						}},
+					},
+					LongRunningToolIDs: []string{"call-1"},
+				},
+				{
+					Author: "allows_transfer_agent",
+				},
+			}),
+			msg: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "approve", Response: map[string]any{"status": "approved"}}},
+			}},
+			rootAgent: agentTree.root,
+			wantAgent: agentTree.noTransferAgent,
+		},
 		{
 			name: "no events from agents, call root",
 			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
@@ -86,7 +110,7 @@ func TestRunner_findAgentToRun(t *testing.T) {
 			r := &Runner{
 				rootAgent: tt.rootAgent,
 			}
-			gotAgent, err := r.findAgentToRun(tt.session)
+			gotAgent, err := r.findAgentToRun(tt.session.Events(), tt.msg)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Runner.findAgentToRun() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -84,7 +84,23 @@ func buildLLMAgentSkills(agent agent.Agent, llmState *llminternal.State) []a2a.A
 		}
 	}
 
-	// TODO(yarolegovich): mention planning and code-execution skills once supported (and if configured)
+	if llmState.Planner != nil {
+		skills = append(skills, a2a.AgentSkill{
+			ID:          fmt.Sprintf("%s-planner", agent.Name()),
+			Name:        "planning",
+			Description: "Can think about the tasks to do and make plans",
+			Tags:        []string{"llm", "planning"},
+		})
+	}
+
+	if llmState.CodeExecutor != nil {
+		skills = append(skills, a2a.AgentSkill{
+			ID:          fmt.Sprintf("%s-code-executor", agent.Name()),
+			Name:        "code-execution",
+			Description: "Can execute code",
+			Tags:        []string{"llm", "code_execution"},
+		})
+	}
 
 	return skills
 }
@@ -24,6 +24,8 @@ import (
 	"google.golang.org/adk/agent/workflowagents/loopagent"
 	"google.golang.org/adk/agent/workflowagents/parallelagent"
 	"google.golang.org/adk/agent/workflowagents/sequentialagent"
+	"google.golang.org/adk/codeexecutor/localexecutor"
+	"google.golang.org/adk/planner"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/geminitool"
 	"google.golang.org/adk/tool/loadartifactstool"
@@ -38,6 +40,10 @@ func must[T agent.Agent](a T, err error) T {
 
 func TestGetAgentSkills_LLMAgent(t *testing.T) {
 	googleSearch, loadArtifacts := geminitool.GoogleSearch{}, loadartifactstool.New()
+	codeExecutor, err := localexecutor.New(localexecutor.Config{})
+	if err != nil {
+		t.Fatalf("localexecutor.New() error = %v", err)
+	}
 
 	testCases := []struct {
 		name  string
@@ -96,6 +102,35 @@ func TestGetAgentSkills_LLMAgent(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "llm with planner and code executor",
+			agent: must(llmagent.New(llmagent.Config{
+				Name:         "Test LLM",
+				Description:  "Test llm.",
+				Planner:      planner.NewPlanReAct(),
+				CodeExecutor: codeExecutor,
+			})),
+			want: []a2a.AgentSkill{
+				{
+					ID:          "Test LLM",
+					Description: "Test llm.",
+					Name:        "model",
+					Tags:        []string{"llm"},
+				},
+				{
+					ID:          "Test LLM-planner",
+					Name:        "planning",
+					Description: "Can think about the tasks to do and make plans",
+					Tags:        []string{"llm", "planning"},
+				},
+				{
+					ID:          "Test LLM-code-executor",
+					Name:        "code-execution",
+					Description: "Can execute code",
+					Tags:        []string{"llm", "code_execution"},
+				},
+			},
+		},
 		{
 			name: "empty loop agent",
 			agent: must(loopagent.New(loopagent.Config{
@@ -82,7 +82,17 @@ func toEventMeta(meta invocationMeta, event *session.Event) (map[string]any, err
 		result[ToA2AMetaKey("grounding_metadata")] = v
 	}
 
-	// TODO(yarolegovich): include custom and usage metadata when added to session.Event
+	if response.UsageMetadata != nil {
+		v, err := converters.ToMapStructure(response.UsageMetadata)
+		if err != nil {
+			return nil, err
+		}
+		result[ToA2AMetaKey("usage_metadata")] = v
+	}
+
+	if len(response.CustomMetadata) > 0 {
+		result[ToA2AMetaKey("custom_metadata")] = response.CustomMetadata
+	}
 
 	return result, nil
 }
@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adka2a
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestToEventMeta(t *testing.T) {
+	meta := invocationMeta{userID: "user", sessionID: "session", eventMeta: map[string]any{ToA2AMetaKey("app_name"): "app"}}
+
+	tests := []struct {
+		name  string
+		event *session.Event
+		want  map[string]any
+	}{
+		{
+			name:  "invocation",
+			event: &session.Event{InvocationID: "inv", Author: "agent"},
+			want: map[string]any{
+				ToA2AMetaKey("app_name"):      "app",
+				ToA2AMetaKey("invocation_id"): "inv",
+				ToA2AMetaKey("author"):        "agent",
+			},
+		},
+		{
+			name: "usage and custom metadata",
+			event: &session.Event{
+				InvocationID: "inv",
+				LLMResponse: model.LLMResponse{
+					UsageMetadata:  &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 100, CandidatesTokenCount: 10, TotalTokenCount: 110},
+					CustomMetadata: map[string]any{"usage": map[string]any{"llm_calls": 1}},
+				},
+			},
+			want: map[string]any{
+				ToA2AMetaKey("app_name"):      "app",
+				ToA2AMetaKey("invocation_id"): "inv",
+				ToA2AMetaKey("usage_metadata"): map[string]any{
+					"promptTokenCount":     float64(100),
+					"candidatesTokenCount": float64(10),
+					"totalTokenCount":      float64(110),
+				},
+				ToA2AMetaKey("custom_metadata"): map[string]any{"usage": map[string]any{"llm_calls": 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toEventMeta(meta, tt.event)
+			if err != nil {
+				t.Fatalf("toEventMeta() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("toEventMeta() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,11 +17,17 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/internal/budget"
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/server/adkrest/internal/models"
 	"google.golang.org/adk/session"
@@ -32,10 +38,13 @@ type RuntimeAPIController struct {
 	sessionService  session.Service
 	artifactService artifact.Service
 	agentLoader     agent.Loader
+	model           model.LLM
 }
 
-func NewRuntimeAPIRouter(sessionService session.Service, agentLoader agent.Loader, artifactService artifact.Service) *RuntimeAPIController {
-	return &RuntimeAPIController{sessionService: sessionService, agentLoader: agentLoader, artifactService: artifactService}
+// NewRuntimeAPIRouter creates the controller. The llm, if not nil, overrides
+// the models of the LLM agents.
+func NewRuntimeAPIRouter(sessionService session.Service, agentLoader agent.Loader, artifactService artifact.Service, llm model.LLM) *RuntimeAPIController {
+	return &RuntimeAPIController{sessionService: sessionService, agentLoader: agentLoader, artifactService: artifactService, model: llm}
 }
 
 // RunAgent executes a non-streaming agent run for a given session and message.
@@ -49,8 +58,9 @@ func (c *RuntimeAPIController) RunHandler(rw http.ResponseWriter, req *http.Requ
 		return err
 	}
 	var events []models.Event
+	var usage agent.Usage
 	for _, event := range sessionEvents {
-		events = append(events, models.FromSessionEvent(*event))
+		events = append(events, withUsage(models.FromSessionEvent(*event), &usage))
 	}
 	EncodeJSONResponse(events, http.StatusOK, rw)
 	return nil
@@ -73,7 +83,12 @@ func (c *RuntimeAPIController) runAgent(ctx context.Context, runAgentRequest mod
 	var events []*session.Event
 	for event, err := range resp {
 		if err != nil {
-			return nil, newStatusError(fmt.Errorf("run agent: %w", err), http.StatusInternalServerError)
+			status := http.StatusInternalServerError
+			if errors.Is(err, session.ErrStaleSession) || errors.Is(err, runner.ErrSessionBusy) {
+				// The session was updated by a concurrent invocation.
+				status = http.StatusConflict
+			}
+			return nil, newStatusError(fmt.Errorf("run agent: %w", err), status)
 		}
 		events = append(events, event)
 	}
@@ -109,6 +124,7 @@ func (c *RuntimeAPIController) RunSSEHandler(rw http.ResponseWriter, req *http.R
 	resp := r.Run(req.Context(), runAgentRequest.UserId, runAgentRequest.SessionId, &runAgentRequest.NewMessage, *rCfg)
 
 	rw.WriteHeader(http.StatusOK)
+	var usage agent.Usage
 	for event, err := range resp {
 		if err != nil {
 			_, err := fmt.Fprintf(rw, "Error while running agent: %v\n", err)
@@ -118,7 +134,7 @@ func (c *RuntimeAPIController) RunSSEHandler(rw http.ResponseWriter, req *http.R
 			flusher.Flush()
 			continue
 		}
-		err := flashEvent(flusher, rw, *event)
+		err := flashEvent(flusher, rw, withUsage(models.FromSessionEvent(*event), &usage))
 		if err != nil {
 			return err
 		}
@@ -126,12 +142,34 @@ func (c *RuntimeAPIController) RunSSEHandler(rw http.ResponseWriter, req *http.R
 	return nil
 }
 
-func flashEvent(flusher http.Flusher, rw http.ResponseWriter, event session.Event) error {
+// withUsage returns the event with the usage totals of the invocation in its
+// custom metadata, under the "usage" key as with a budget, adding the usage
+// of the event to the totals. The totals are only reported, not saved in the
+// session. Events whose usage is tracked by a budget are returned unchanged.
+func withUsage(event models.Event, total *agent.Usage) models.Event {
+	if event.UsageMetadata == nil || event.Partial {
+		return event
+	}
+	if _, ok := event.CustomMetadata["usage"]; ok {
+		return event
+	}
+	total.PromptTokens += int64(event.UsageMetadata.PromptTokenCount)
+	total.OutputTokens += int64(event.UsageMetadata.CandidatesTokenCount)
+	total.LLMCalls++
+	event.CustomMetadata = maps.Clone(event.CustomMetadata)
+	if event.CustomMetadata == nil {
+		event.CustomMetadata = make(map[string]any)
+	}
+	event.CustomMetadata["usage"] = map[string]any{"invocation": budget.ToStateValue(*total)}
+	return event
+}
+
+func flashEvent(flusher http.Flusher, rw http.ResponseWriter, event models.Event) error {
 	_, err := fmt.Fprintf(rw, "data: ")
 	if err != nil {
 		return newStatusError(fmt.Errorf("write response: %w", err), http.StatusInternalServerError)
 	}
-	err = json.NewEncoder(rw).Encode(models.FromSessionEvent(event))
+	err = json.NewEncoder(rw).Encode(event)
 	if err != nil {
 		return newStatusError(fmt.Errorf("encode response: %w", err), http.StatusInternalServerError)
 	}
@@ -155,21 +193,121 @@ func (c *RuntimeAPIController) validateSessionExists(ctx context.Context, appNam
 	return nil
 }
 
-func (c *RuntimeAPIController) getRunner(req models.RunAgentRequest) (*runner.Runner, *agent.RunConfig, error) {
-	curAgent, err := c.agentLoader.LoadAgent(req.AppName)
+// RewindSessionHandler rewinds a session to before an event or an
+// invocation, reverting its state and artifacts.
+func (c *RuntimeAPIController) RewindSessionHandler(rw http.ResponseWriter, req *http.Request) error {
+	sessionID, err := models.SessionIDFromHTTPParameters(mux.Vars(req))
+	if err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	var rewindRequest models.RewindSessionRequest
+	if err := decodeOptionalBody(req, &rewindRequest); err != nil {
+		return err
+	}
+	r, err := c.newRewindRunner(sessionID.AppName)
+	if err != nil {
+		return err
+	}
+	resp, err := r.Rewind(req.Context(), &session.RewindRequest{
+		AppName:      sessionID.AppName,
+		UserID:       sessionID.UserID,
+		SessionID:    sessionID.ID,
+		EventID:      rewindRequest.EventID,
+		InvocationID: rewindRequest.InvocationID,
+	})
+	if err != nil {
+		return newStatusError(fmt.Errorf("rewind session: %w", err), rewindErrorStatus(err))
+	}
+	respSession, err := models.FromSession(resp.Session)
 	if err != nil {
-		return nil, nil, newStatusError(fmt.Errorf("load agent: %w", err), http.StatusInternalServerError)
+		return newStatusError(err, http.StatusInternalServerError)
+	}
+	EncodeJSONResponse(respSession, http.StatusOK, rw)
+	return nil
+}
+
+// ForkSessionHandler forks a session into a new session up to an event or
+// an invocation.
+func (c *RuntimeAPIController) ForkSessionHandler(rw http.ResponseWriter, req *http.Request) error {
+	sessionID, err := models.SessionIDFromHTTPParameters(mux.Vars(req))
+	if err != nil {
+		return newStatusError(err, http.StatusBadRequest)
+	}
+	var forkRequest models.ForkSessionRequest
+	if err := decodeOptionalBody(req, &forkRequest); err != nil {
+		return err
+	}
+	r, err := c.newRewindRunner(sessionID.AppName)
+	if err != nil {
+		return err
+	}
+	resp, err := r.Fork(req.Context(), &session.ForkRequest{
+		AppName:      sessionID.AppName,
+		UserID:       sessionID.UserID,
+		SessionID:    sessionID.ID,
+		EventID:      forkRequest.EventID,
+		InvocationID: forkRequest.InvocationID,
+		NewSessionID: forkRequest.NewSessionID,
+	})
+	if err != nil {
+		return newStatusError(fmt.Errorf("fork session: %w", err), rewindErrorStatus(err))
+	}
+	respSession, err := models.FromSession(resp.Session)
+	if err != nil {
+		return newStatusError(err, http.StatusInternalServerError)
+	}
+	EncodeJSONResponse(respSession, http.StatusOK, rw)
+	return nil
+}
+
+// newRewindRunner returns the runner of the app, failing if the session
+// service doesn't support the rewinds.
+func (c *RuntimeAPIController) newRewindRunner(appName string) (*runner.Runner, error) {
+	if _, ok := c.sessionService.(session.RewindService); !ok {
+		return nil, newStatusError(fmt.Errorf("session service doesn't support rewinds"), http.StatusNotImplemented)
+	}
+	return c.newRunner(appName)
+}
+
+// rewindErrorStatus returns the status of the errors of the rewinds and the
+// forks.
+func rewindErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, runner.ErrSessionBusy):
+		return http.StatusConflict
+	case errors.Is(err, session.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, session.ErrInvalidRequest):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (c *RuntimeAPIController) newRunner(appName string) (*runner.Runner, error) {
+	curAgent, err := c.agentLoader.LoadAgent(appName)
+	if err != nil {
+		return nil, newStatusError(fmt.Errorf("load agent: %w", err), http.StatusInternalServerError)
 	}
 
 	r, err := runner.New(runner.Config{
-		AppName:         req.AppName,
+		AppName:         appName,
 		Agent:           curAgent,
 		SessionService:  c.sessionService,
 		ArtifactService: c.artifactService,
+		Model:           c.model,
 	},
 	)
 	if err != nil {
-		return nil, nil, newStatusError(fmt.Errorf("create runner: %w", err), http.StatusInternalServerError)
+		return nil, newStatusError(fmt.Errorf("create runner: %w", err), http.StatusInternalServerError)
+	}
+	return r, nil
+}
+
+func (c *RuntimeAPIController) getRunner(req models.RunAgentRequest) (*runner.Runner, *agent.RunConfig, error) {
+	r, err := c.newRunner(req.AppName)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	streamingMode := agent.StreamingModeNone
@@ -193,3 +331,17 @@ func decodeRequestBody(req *http.Request) (decodedReq models.RunAgentRequest, er
 	}
 	return runAgentRequest, nil
 }
+
+// decodeOptionalBody decodes the JSON body of the request, if any, into v.
+func decodeOptionalBody(req *http.Request, v any) error {
+	defer req.Body.Close()
+	if req.ContentLength == 0 {
+		return nil
+	}
+	d := json.NewDecoder(req.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return newStatusError(fmt.Errorf("decode request: %w", err), http.StatusBadRequest)
+	}
+	return nil
+}
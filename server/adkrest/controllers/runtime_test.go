@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/server/adkrest/internal/fakes"
+	"google.golang.org/adk/server/adkrest/internal/models"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestRewindAndForkSession(t *testing.T) {
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(func(*session.Event, error) bool) {}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiController := controllers.NewRuntimeAPIRouter(sessionService, agent.NewSingleLoader(a), artifact.InMemoryService(), nil)
+
+	created, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range []*session.Event{
+		{ID: "e1", InvocationID: "inv1", Actions: session.EventActions{StateDelta: map[string]any{"turn": 1.0}}},
+		{ID: "e2", InvocationID: "inv2", Actions: session.EventActions{StateDelta: map[string]any{"turn": 2.0}}},
+	} {
+		if err := sessionService.AppendEvent(ctx, created.Session, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		handler func(http.ResponseWriter, *http.Request) error
+		// sessionID defaults to testSession.
+		sessionID  string
+		body       any
+		wantStatus int
+		wantID     string
+		wantEvents []string
+		wantState  map[string]any
+	}{
+		{
+			name:       "fork",
+			handler:    apiController.ForkSessionHandler,
+			body:       models.ForkSessionRequest{EventID: "e2", NewSessionID: "forked"},
+			wantStatus: http.StatusOK,
+			wantID:     "forked",
+			wantEvents: []string{"e1"},
+			wantState:  map[string]any{"turn": 1.0},
+		},
+		{
+			name:       "rewind",
+			handler:    apiController.RewindSessionHandler,
+			body:       models.RewindSessionRequest{InvocationID: "inv2"},
+			wantStatus: http.StatusOK,
+			wantID:     "testSession",
+			wantEvents: []string{"e1"},
+			wantState:  map[string]any{"turn": 1.0},
+		},
+		{
+			name:       "rewind unknown event",
+			handler:    apiController.RewindSessionHandler,
+			body:       models.RewindSessionRequest{EventID: "unknown"},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "rewind unknown session",
+			handler:    apiController.RewindSessionHandler,
+			sessionID:  "unknown",
+			body:       models.RewindSessionRequest{EventID: "e1"},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "rewind without event",
+			handler:    apiController.RewindSessionHandler,
+			body:       models.RewindSessionRequest{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "fork unknown invocation",
+			handler:    apiController.ForkSessionHandler,
+			body:       models.ForkSessionRequest{InvocationID: "unknown"},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "fork event and invocation",
+			handler:    apiController.ForkSessionHandler,
+			body:       models.ForkSessionRequest{EventID: "e1", InvocationID: "inv1"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown field",
+			handler:    apiController.RewindSessionHandler,
+			body:       map[string]any{"event": "e1"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest(http.MethodPost, "/apps/testApp/users/testUser/sessions/testSession", bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			sessionID := tt.sessionID
+			if sessionID == "" {
+				sessionID = "testSession"
+			}
+			req = mux.SetURLVars(req, sessionVars(fakes.SessionKey{AppName: "testApp", UserID: "testUser", SessionID: sessionID}))
+			rr := httptest.NewRecorder()
+
+			controllers.NewErrorHandler(tt.handler)(rr, req)
+
+			if status := rr.Code; status != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v, body: %s", status, tt.wantStatus, rr.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var gotSession models.Session
+			if err := json.NewDecoder(rr.Body).Decode(&gotSession); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			var gotEvents []string
+			for _, ev := range gotSession.Events {
+				gotEvents = append(gotEvents, ev.ID)
+			}
+			if gotSession.ID != tt.wantID {
+				t.Errorf("session ID = %q, want %q", gotSession.ID, tt.wantID)
+			}
+			if diff := cmp.Diff(tt.wantEvents, gotEvents); diff != "" {
+				t.Errorf("session events mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantState, gotSession.State); diff != "" {
+				t.Errorf("session state mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRewindSession_Unsupported(t *testing.T) {
+	apiController := controllers.NewRuntimeAPIRouter(&fakes.FakeSessionService{}, nil, nil, nil)
+	req, err := http.NewRequest(http.MethodPost, "/apps/testApp/users/testUser/sessions/testSession", bytes.NewReader([]byte(`{"eventId": "e1"}`)))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req = mux.SetURLVars(req, sessionVars(fakes.SessionKey{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}))
+	rr := httptest.NewRecorder()
+
+	controllers.NewErrorHandler(apiController.RewindSessionHandler)(rr, req)
+
+	if status := rr.Code; status != http.StatusNotImplemented {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotImplemented)
+	}
+}
+
+func TestRunHandler_Usage(t *testing.T) {
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	a, err := agent.New(agent.Config{
+		Name: "testApp",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				for range 2 {
+					ev := session.NewEvent(ctx.InvocationID())
+					ev.Author = "testApp"
+					ev.LLMResponse = model.LLMResponse{
+						Content:       genai.NewContentFromText("hi", genai.RoleModel),
+						UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, CandidatesTokenCount: 2},
+					}
+					if !yield(ev, nil) {
+						return
+					}
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiController := controllers.NewRuntimeAPIRouter(sessionService, agent.NewSingleLoader(a), artifact.InMemoryService(), nil)
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"}); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := json.Marshal(models.RunAgentRequest{
+		AppName:    "testApp",
+		UserId:     "testUser",
+		SessionId:  "testSession",
+		NewMessage: *genai.NewContentFromText("hello", genai.RoleUser),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, "/run", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	controllers.NewErrorHandler(apiController.RunHandler)(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+
+	var events []models.Event
+	if err := json.NewDecoder(rr.Body).Decode(&events); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	var got []any
+	for _, ev := range events {
+		got = append(got, ev.CustomMetadata["usage"])
+	}
+	want := []any{
+		map[string]any{"invocation": map[string]any{"prompt_tokens": 10.0, "output_tokens": 2.0, "llm_calls": 1.0, "cost": 0.0}},
+		map[string]any{"invocation": map[string]any{"prompt_tokens": 20.0, "output_tokens": 4.0, "llm_calls": 2.0, "cost": 0.0}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("usage totals mismatch (-want +got):\n%s", diff)
+	}
+
+	// The usage is not saved in the session without a budget.
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := resp.Session.State().Get(agent.UsageStateKey); err == nil {
+		t.Errorf("session state has the usage %v", v)
+	}
+	for ev := range resp.Session.Events().All() {
+		if _, ok := ev.CustomMetadata["usage"]; ok {
+			t.Errorf("stored event %s has usage custom metadata", ev.ID)
+		}
+	}
+}
@@ -35,9 +35,11 @@ func NewHandler(config *launcher.Config) http.Handler {
 	router := mux.NewRouter().StrictSlash(true)
 	// TODO: Allow taking a prefix to allow customizing the path
 	// where the ADK REST API will be served.
+	// The runtime routes come first, the session routes would otherwise
+	// match the rewinds and forks of the sessions, e.g. sessions/{id}:rewind.
 	setupRouter(router,
+		routers.NewRuntimeAPIRouter(controllers.NewRuntimeAPIRouter(config.SessionService, config.AgentLoader, config.ArtifactService, config.Model)),
 		routers.NewSessionsAPIRouter(controllers.NewSessionsAPIController(config.SessionService)),
-		routers.NewRuntimeAPIRouter(controllers.NewRuntimeAPIRouter(config.SessionService, config.AgentLoader, config.ArtifactService)),
 		routers.NewAppsAPIRouter(controllers.NewAppsAPIController(config.AgentLoader)),
 		routers.NewDebugAPIRouter(controllers.NewDebugAPIController(config.SessionService, config.AgentLoader, adkExporter)),
 		routers.NewArtifactsAPIRouter(controllers.NewArtifactsAPIController(config.ArtifactService)),
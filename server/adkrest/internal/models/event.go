@@ -30,20 +30,22 @@ type EventActions struct {
 
 // Event represents a single event in a session.
 type Event struct {
-	ID                 string                   `json:"id"`
-	Time               int64                    `json:"time"`
-	InvocationID       string                   `json:"invocationId"`
-	Branch             string                   `json:"branch"`
-	Author             string                   `json:"author"`
-	Partial            bool                     `json:"partial"`
-	LongRunningToolIDs []string                 `json:"longRunningToolIds"`
-	Content            *genai.Content           `json:"content"`
-	GroundingMetadata  *genai.GroundingMetadata `json:"groundingMetadata"`
-	TurnComplete       bool                     `json:"turnComplete"`
-	Interrupted        bool                     `json:"interrupted"`
-	ErrorCode          string                   `json:"errorCode"`
-	ErrorMessage       string                   `json:"errorMessage"`
-	Actions            EventActions             `json:"actions"`
+	ID                 string                                      `json:"id"`
+	Time               int64                                       `json:"time"`
+	InvocationID       string                                      `json:"invocationId"`
+	Branch             string                                      `json:"branch"`
+	Author             string                                      `json:"author"`
+	Partial            bool                                        `json:"partial"`
+	LongRunningToolIDs []string                                    `json:"longRunningToolIds"`
+	Content            *genai.Content                              `json:"content"`
+	GroundingMetadata  *genai.GroundingMetadata                    `json:"groundingMetadata"`
+	UsageMetadata      *genai.GenerateContentResponseUsageMetadata `json:"usageMetadata,omitempty"`
+	CustomMetadata     map[string]any                              `json:"customMetadata,omitempty"`
+	TurnComplete       bool                                        `json:"turnComplete"`
+	Interrupted        bool                                        `json:"interrupted"`
+	ErrorCode          string                                      `json:"errorCode"`
+	ErrorMessage       string                                      `json:"errorMessage"`
+	Actions            EventActions                                `json:"actions"`
 }
 
 // ToSessionEvent maps Event data struct to session.Event
@@ -58,6 +60,8 @@ func ToSessionEvent(event Event) *session.Event {
 		LLMResponse: model.LLMResponse{
 			Content:           event.Content,
 			GroundingMetadata: event.GroundingMetadata,
+			UsageMetadata:     event.UsageMetadata,
+			CustomMetadata:    event.CustomMetadata,
 			Partial:           event.Partial,
 			TurnComplete:      event.TurnComplete,
 			Interrupted:       event.Interrupted,
@@ -83,6 +87,8 @@ func FromSessionEvent(event session.Event) Event {
 		LongRunningToolIDs: event.LongRunningToolIDs,
 		Content:            event.LLMResponse.Content,
 		GroundingMetadata:  event.LLMResponse.GroundingMetadata,
+		UsageMetadata:      event.LLMResponse.UsageMetadata,
+		CustomMetadata:     event.LLMResponse.CustomMetadata,
 		TurnComplete:       event.LLMResponse.TurnComplete,
 		Interrupted:        event.LLMResponse.Interrupted,
 		ErrorCode:          event.LLMResponse.ErrorCode,
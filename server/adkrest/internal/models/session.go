@@ -37,6 +37,23 @@ type CreateSessionRequest struct {
 	Events []Event        `json:"events"`
 }
 
+// RewindSessionRequest is the body of a session rewind. Exactly one of
+// EventID and InvocationID is required: the event, or the first event of the
+// invocation, and the later ones are removed.
+type RewindSessionRequest struct {
+	EventID      string `json:"eventId,omitempty"`
+	InvocationID string `json:"invocationId,omitempty"`
+}
+
+// ForkSessionRequest is the body of a session fork. The events before the
+// event, or the invocation, are copied to the new session, all of them if
+// neither is set. NewSessionID is generated if not set.
+type ForkSessionRequest struct {
+	EventID      string `json:"eventId,omitempty"`
+	InvocationID string `json:"invocationId,omitempty"`
+	NewSessionID string `json:"newSessionId,omitempty"`
+}
+
 type SessionID struct {
 	ID      string `mapstructure:"session_id,optional"`
 	AppName string `mapstructure:"app_name,required"`
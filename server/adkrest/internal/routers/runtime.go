@@ -46,5 +46,17 @@ func (r *RuntimeAPIRouter) Routes() Routes {
 			Pattern:     "/run_sse",
 			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.RunSSEHandler),
 		},
+		Route{
+			Name:        "RewindSession",
+			Methods:     []string{http.MethodPost, http.MethodOptions},
+			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}:rewind",
+			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.RewindSessionHandler),
+		},
+		Route{
+			Name:        "ForkSession",
+			Methods:     []string{http.MethodPost, http.MethodOptions},
+			Pattern:     "/apps/{app_name}/users/{user_id}/sessions/{session_id}:fork",
+			HandlerFunc: controllers.NewErrorHandler(r.runtimeController.ForkSessionHandler),
+		},
 	}
 }
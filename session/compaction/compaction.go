@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compaction summarizes the older events of the sessions, so that the
+// LLM requests do not grow with the length of the sessions.
+//
+// The events are compacted with a sliding window over the invocations of the
+// session: once [Config.Interval] new invocations completed since the last
+// compaction, their events are summarized into a compaction event stored in
+// the session. The window includes the last [Config.Overlap] invocations of
+// the previous compaction, to keep the continuity between the summaries, and
+// the previous summary: the new compaction covers the previous one, so that
+// the LLM agents send one summary instead of the compacted events.
+package compaction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Config is the configuration of the event compaction.
+type Config struct {
+	// Summarizer summarizes the events. Required.
+	Summarizer Summarizer
+	// Interval is the number of new invocations that triggers a compaction.
+	// It must be positive.
+	Interval int
+	// Overlap is the number of invocations of the previous compaction that
+	// are summarized again with the new invocations.
+	Overlap int
+}
+
+// Summarizer summarizes the events of a session.
+type Summarizer interface {
+	// Summarize returns the summary of the events.
+	Summarize(ctx context.Context, events []*session.Event) (*genai.Content, error)
+}
+
+// Compact compacts the events of the session if enough invocations completed
+// since the last compaction. The compaction event is appended to the session,
+// with the author and the invocation ID of the invocation which triggered it.
+func Compact(ctx context.Context, service session.Service, s session.Session, cfg *Config, author, invocationID string) error {
+	// reference: adk-python src/google/adk/apps/compaction.py _run_compaction_for_sliding_window
+	if cfg.Summarizer == nil {
+		return fmt.Errorf("summarizer is required")
+	}
+	if cfg.Interval < 1 {
+		return fmt.Errorf("compaction interval must be positive, got %d", cfg.Interval)
+	}
+
+	var events []*session.Event
+	var lastCompaction *session.Event
+	var lastCompacted *session.EventCompaction
+	for ev := range s.Events().All() {
+		if c := ev.Actions.Compaction; c != nil {
+			if lastCompacted == nil || c.EndTimestamp.After(lastCompacted.EndTimestamp) {
+				lastCompaction, lastCompacted = ev, c
+			}
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	// The invocations in the order they started, and the ones with events
+	// after the last compaction.
+	var invocations []string
+	seen := make(map[string]bool)
+	newInvocations := make(map[string]bool)
+	for _, ev := range events {
+		if !seen[ev.InvocationID] {
+			seen[ev.InvocationID] = true
+			invocations = append(invocations, ev.InvocationID)
+		}
+		if lastCompacted == nil || ev.Timestamp.After(lastCompacted.EndTimestamp) {
+			newInvocations[ev.InvocationID] = true
+		}
+	}
+	if len(newInvocations) < cfg.Interval {
+		return nil
+	}
+
+	start := len(invocations)
+	for i, id := range invocations {
+		if newInvocations[id] {
+			start = i
+			break
+		}
+	}
+	window := make(map[string]bool)
+	for _, id := range invocations[max(0, start-cfg.Overlap):] {
+		window[id] = true
+	}
+	var toCompact []*session.Event
+	for _, ev := range events {
+		if window[ev.InvocationID] {
+			toCompact = append(toCompact, ev)
+		}
+	}
+	if len(toCompact) == 0 {
+		return nil
+	}
+	startTimestamp := toCompact[0].Timestamp
+	if lastCompacted != nil {
+		// The previous summary is summarized again with the window.
+		startTimestamp = lastCompacted.StartTimestamp
+		toCompact = append([]*session.Event{{
+			ID:           lastCompaction.ID,
+			InvocationID: lastCompaction.InvocationID,
+			Author:       lastCompaction.Author,
+			Timestamp:    lastCompacted.StartTimestamp,
+			LLMResponse:  model.LLMResponse{Content: lastCompacted.CompactedContent},
+		}}, toCompact...)
+	}
+
+	summary, err := cfg.Summarizer.Summarize(ctx, toCompact)
+	if err != nil {
+		return fmt.Errorf("failed to summarize the events: %w", err)
+	}
+	if summary == nil {
+		return nil
+	}
+	ev := session.NewEvent(invocationID)
+	ev.Author = author
+	ev.Actions.Compaction = &session.EventCompaction{
+		StartTimestamp:   startTimestamp,
+		EndTimestamp:     toCompact[len(toCompact)-1].Timestamp,
+		CompactedContent: summary,
+	}
+	if err := service.AppendEvent(ctx, s, ev); err != nil {
+		return fmt.Errorf("failed to append the compaction event: %w", err)
+	}
+	return nil
+}
+
+// DefaultPrompt is the prompt of the LLM summarizer. The conversation is
+// appended to it.
+const DefaultPrompt = "The following is a conversation history between a user and an AI agent. " +
+	"Please summarize the conversation, focusing on key information and decisions made, " +
+	"as well as any unresolved questions or tasks. " +
+	"The summary should be concise and capture the essence of the interaction."
+
+// LLMSummarizer returns a [Summarizer] asking the LLM to summarize the text
+// of the events. The prompt defaults to [DefaultPrompt].
+func LLMSummarizer(llm model.LLM, prompt string) Summarizer {
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+	return &llmSummarizer{llm: llm, prompt: prompt}
+}
+
+type llmSummarizer struct {
+	llm    model.LLM
+	prompt string
+}
+
+func (s *llmSummarizer) Summarize(ctx context.Context, events []*session.Event) (*genai.Content, error) {
+	// reference: adk-python src/google/adk/apps/llm_event_summarizer.py
+	var conversation strings.Builder
+	for _, ev := range events {
+		if ev.LLMResponse.Content == nil {
+			continue
+		}
+		for _, part := range ev.LLMResponse.Content.Parts {
+			if part.Text != "" {
+				fmt.Fprintf(&conversation, "%s: %s\n", ev.Author, part.Text)
+			}
+		}
+	}
+	if conversation.Len() == 0 {
+		return nil, nil
+	}
+
+	req := &model.LLMRequest{
+		Model: s.llm.Name(),
+		Contents: []*genai.Content{
+			genai.NewContentFromText(s.prompt+"\n\n"+conversation.String(), genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{},
+	}
+	var summary *genai.Content
+	for resp, err := range s.llm.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return nil, err
+		}
+		if resp.Content != nil && !resp.Partial {
+			summary = resp.Content
+		}
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("the model did not reply with a summary")
+	}
+	summary.Role = genai.RoleModel
+	return summary, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compaction_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/compaction"
+	"google.golang.org/genai"
+)
+
+// fakeSummarizer records the texts of the summarized events.
+type fakeSummarizer struct {
+	calls [][]string
+}
+
+func (s *fakeSummarizer) Summarize(ctx context.Context, events []*session.Event) (*genai.Content, error) {
+	var texts []string
+	for _, ev := range events {
+		texts = append(texts, ev.LLMResponse.Content.Parts[0].Text)
+	}
+	s.calls = append(s.calls, texts)
+	return genai.NewContentFromText("summary of "+strings.Join(texts, ","), genai.RoleModel), nil
+}
+
+func TestCompact(t *testing.T) {
+	ctx := t.Context()
+	service := session.InMemoryService()
+	created, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summarizer := &fakeSummarizer{}
+	cfg := &compaction.Config{Summarizer: summarizer, Interval: 2, Overlap: 1}
+
+	steps := []struct {
+		invocation string
+		want       []string
+	}{
+		{invocation: "1"},
+		{invocation: "2", want: []string{"q1", "a1", "q2", "a2"}},
+		{invocation: "3"},
+		// The window includes the previous summary and the last invocation
+		// of the previous compaction.
+		{invocation: "4", want: []string{"summary of q1,a1,q2,a2", "q2", "a2", "q3", "a3", "q4", "a4"}},
+	}
+	// The compaction events are appended to the sessions loaded by Get.
+	sess := created.Session
+	for _, step := range steps {
+		for _, ev := range []*session.Event{
+			newEvent(step.invocation, "user", genai.NewContentFromText("q"+step.invocation, genai.RoleUser)),
+			newEvent(step.invocation, "agent", genai.NewContentFromText("a"+step.invocation, genai.RoleModel)),
+		} {
+			if err := service.AppendEvent(ctx, sess, ev); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		resp, err := service.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sess = resp.Session
+		summarizer.calls = nil
+		if err := compaction.Compact(ctx, service, sess, cfg, "agent", step.invocation); err != nil {
+			t.Fatalf("Compact() failed after invocation %s: %v", step.invocation, err)
+		}
+		var got []string
+		if len(summarizer.calls) > 0 {
+			got = summarizer.calls[0]
+		}
+		if diff := cmp.Diff(step.want, got); diff != "" {
+			t.Errorf("unexpected summarized events after invocation %s (-want +got):\n%s", step.invocation, diff)
+		}
+	}
+
+	resp, err := service.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compactions []*session.Event
+	for ev := range resp.Session.Events().All() {
+		if ev.Actions.Compaction != nil {
+			compactions = append(compactions, ev)
+		}
+	}
+	if len(compactions) != 2 {
+		t.Fatalf("got %d compaction events, want 2", len(compactions))
+	}
+	for i, invocation := range []string{"2", "4"} {
+		if c := compactions[i]; c.Author != "agent" || c.InvocationID != invocation {
+			t.Errorf("compaction %d has author %q and invocation %q, want %q and %q", i, c.Author, c.InvocationID, "agent", invocation)
+		}
+	}
+	// The last compaction covers the previous one.
+	first, last := compactions[0].Actions.Compaction, compactions[1].Actions.Compaction
+	if got, want := last.CompactedContent.Parts[0].Text, "summary of summary of q1,a1,q2,a2,q2,a2,q3,a3,q4,a4"; got != want {
+		t.Errorf("got summary %q, want %q", got, want)
+	}
+	if !last.StartTimestamp.Equal(first.StartTimestamp) {
+		t.Errorf("last compaction starts at %v, want %v", last.StartTimestamp, first.StartTimestamp)
+	}
+}
+
+func newEvent(invocationID, author string, content *genai.Content) *session.Event {
+	ev := session.NewEvent(invocationID)
+	ev.Author = author
+	ev.LLMResponse = model.LLMResponse{Content: content}
+	return ev
+}
+
+func TestLLMSummarizer(t *testing.T) {
+	llm := &testutil.MockModel{
+		Responses: []*genai.Content{genai.NewContentFromText("The user greeted the agent.", genai.RoleModel)},
+	}
+	summarizer := compaction.LLMSummarizer(llm, "")
+
+	got, err := summarizer.Summarize(t.Context(), []*session.Event{
+		{Author: "user", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleUser)}},
+		{Author: "agent", LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("Hi!", genai.RoleModel)}},
+	})
+	if err != nil {
+		t.Fatalf("Summarize() failed: %v", err)
+	}
+	if diff := cmp.Diff(genai.NewContentFromText("The user greeted the agent.", genai.RoleModel), got); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+	prompt := llm.Requests[0].Contents[0].Parts[0].Text
+	for _, want := range []string{compaction.DefaultPrompt, "user: Hello\n", "agent: Hi!\n"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt %q does not contain %q", prompt, want)
+		}
+	}
+}
@@ -23,8 +23,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/adk/internal/sessionutils"
 	"google.golang.org/adk/session"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // databaseService is an database implementation of sessionService.Service.
@@ -32,13 +34,16 @@ type databaseService struct {
 	db *gorm.DB
 }
 
+var _ session.RewindService = (*databaseService)(nil)
+
 // NewSessionService creates a new [session.Service] implementation that uses a
 // relational database (e.g., PostgreSQL, Spanner, SQLite) via the GORM library.
 //
 // It requires a [gorm.Dialector] to specify the database connection and
 // accepts optional [gorm.Option] values for further GORM configuration.
 //
-// It returns the new [session.Service] or an error if the database connection
+// It returns the new [session.Service], which implements
+// [session.RewindService], or an error if the database connection
 // [gorm.Open] fails.
 func NewSessionService(dialector gorm.Dialector, opts ...gorm.Option) (session.Service, error) {
 	db, err := gorm.Open(dialector, opts...)
@@ -119,6 +124,7 @@ func (s *databaseService) Create(ctx context.Context, req *session.CreateRequest
 			}
 		}
 		createdSession.State = sessionState
+		createdSession.InitialState = sessionState
 
 		if err := tx.Create(createdSession).Error; err != nil {
 			return fmt.Errorf("error creating session on database: %w", err)
@@ -299,6 +305,17 @@ func (s *databaseService) Delete(ctx context.Context, req *session.DeleteRequest
 	}
 
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The events are deleted first since their foreign key doesn't cascade
+		// the deletion of the session.
+		err := tx.Where(&storageEvent{
+			AppName:   req.AppName,
+			UserID:    req.UserID,
+			SessionID: req.SessionID,
+		}).Delete(&storageEvent{}).Error
+		if err != nil {
+			return fmt.Errorf("database error during session events deletion: %w", err)
+		}
+
 		target := &storageSession{}
 
 		result := tx.Where(&storageSession{
@@ -347,12 +364,14 @@ func (s *databaseService) AppendEvent(ctx context.Context, curSession session.Se
 
 // applyEvent fetches the session, validates it, applies state changes from an
 // event, and saves the event atomically.
-func (s *databaseService) applyEvent(ctx context.Context, session *localSession, event *session.Event) error {
+func (s *databaseService) applyEvent(ctx context.Context, sess *localSession, event *session.Event) error {
 	// Wrap database operations in a single transaction.
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Fetch the session object from storage.
+		// Fetch the session object from storage. Its row is locked until the
+		// end of the transaction, as the rows of the app and user states.
+		forUpdate := clause.Locking{Strength: clause.LockingStrengthUpdate}
 		var storageSess storageSession
-		err := tx.Where(&storageSession{AppName: session.AppName(), UserID: session.UserID(), ID: session.ID()}).
+		err := tx.Clauses(forUpdate).Where(&storageSession{AppName: sess.AppName(), UserID: sess.UserID(), ID: sess.ID()}).
 			First(&storageSess).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -362,23 +381,17 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 		}
 
 		// Ensure the session object is not stale.
-		// We use UnixNano() for microsecond-level precision, matching the Python code.
-		storageUpdateTime := storageSess.UpdateTime.UnixNano()
-		sessionUpdateTime := session.updatedAt.UnixNano()
-		if storageUpdateTime > sessionUpdateTime {
-			return fmt.Errorf(
-				"stale session error: last update time from request (%s) is older than in database (%s)",
-				time.Unix(0, sessionUpdateTime).Format(time.RFC3339Nano),
-				time.Unix(0, storageUpdateTime).Format(time.RFC3339Nano),
-			)
-		}
-
-		// Fetch App and User states.
-		storageApp, err := fetchStorageAppState(tx, session.AppName())
+		if storageSess.Revision != sess.revision {
+			return fmt.Errorf("%w: session %s is at revision %d, got revision %d", session.ErrStaleSession, sess.ID(), storageSess.Revision, sess.revision)
+		}
+
+		// Fetch App and User states, locking their rows so that the
+		// concurrent updates are not lost.
+		storageApp, err := fetchStorageAppState(tx.Clauses(forUpdate), sess.AppName())
 		if err != nil {
 			return err
 		}
-		storageUser, err := fetchStorageUserState(tx, session.AppName(), session.UserID())
+		storageUser, err := fetchStorageUserState(tx.Clauses(forUpdate), sess.AppName(), sess.UserID())
 		if err != nil {
 			return err
 		}
@@ -405,7 +418,7 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 		}
 
 		// Create the new event record in the database.
-		storageEv, err := createStorageEvent(session, event)
+		storageEv, err := createStorageEvent(sess, event)
 		if err != nil {
 			return fmt.Errorf("failed to map event to storage model: %w", err)
 		}
@@ -413,13 +426,22 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 			return fmt.Errorf("failed to save event: %w", err)
 		}
 
-		storageSess.UpdateTime = event.Timestamp
-		// Save the session to update its state and UpdateTime.
-		if err := tx.Save(&storageSess).Error; err != nil {
-			return fmt.Errorf("failed to save session state: %w", err)
+		// Update the session state, UpdateTime and Revision, unless the
+		// session was updated concurrently.
+		result := tx.Model(&storageSess).Where("revision = ?", sess.revision).Updates(map[string]any{
+			"state":       storageSess.State,
+			"update_time": event.Timestamp,
+			"revision":    sess.revision + 1,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to save session state: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: session %s was updated concurrently", session.ErrStaleSession, sess.ID())
 		}
 
-		session.updatedAt = storageSess.UpdateTime
+		sess.updatedAt = event.Timestamp
+		sess.revision++
 
 		return nil // Returning nil commits the transaction.
 	})
@@ -427,6 +449,197 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 	return err
 }
 
+// Rewind removes the events of the session from the event of the request,
+// implements session.RewindService.
+func (s *databaseService) Rewind(ctx context.Context, req *session.RewindRequest) (*session.RewindResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("%w: app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", session.ErrInvalidRequest, appName, userID, sessionID)
+	}
+	if req.EventID == "" && req.InvocationID == "" {
+		return nil, fmt.Errorf("%w: event_id or invocation_id is required", session.ErrInvalidRequest)
+	}
+
+	var resp *session.RewindResponse
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The session row is locked until the end of the transaction, so
+		// that no event is appended concurrently.
+		var storageSess storageSession
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where(&storageSession{AppName: appName, UserID: userID, ID: sessionID}).
+			First(&storageSess).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("session %+v %w", sessionID, session.ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("database error while fetching session: %w", err)
+		}
+		if err := checkInitialState(tx, &storageSess); err != nil {
+			return err
+		}
+		events, err := fetchEvents(tx, appName, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		i, err := sessionutils.RewindIndex(events, eventIDs, req.EventID, req.InvocationID)
+		if err != nil {
+			return err
+		}
+
+		removedIDs := make([]string, 0, len(events)-i)
+		for _, event := range events[i:] {
+			removedIDs = append(removedIDs, event.ID)
+		}
+		err = tx.Where(&storageEvent{AppName: appName, UserID: userID, SessionID: sessionID}).
+			Where("id IN ?", removedIDs).
+			Delete(&storageEvent{}).Error
+		if err != nil {
+			return fmt.Errorf("database error during events deletion: %w", err)
+		}
+
+		storageSess.State = sessionutils.ReplayState(storageSess.InitialState, events[:i], eventStateDelta)
+		storageSess.UpdateTime = time.Now()
+		storageSess.Revision++
+		err = tx.Model(&storageSess).Updates(map[string]any{
+			"state":       storageSess.State,
+			"update_time": storageSess.UpdateTime,
+			"revision":    storageSess.Revision,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to save session state: %w", err)
+		}
+
+		sess, err := newResponseSession(tx, &storageSess, events[:i])
+		if err != nil {
+			return err
+		}
+		resp = &session.RewindResponse{
+			Session:       sess,
+			RemovedEvents: events[i:],
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Fork creates a new session with the events of the session before the event
+// of the request, implements session.RewindService.
+func (s *databaseService) Fork(ctx context.Context, req *session.ForkRequest) (*session.ForkResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("%w: app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", session.ErrInvalidRequest, appName, userID, sessionID)
+	}
+
+	newSessionID := req.NewSessionID
+	if newSessionID == "" {
+		newSessionID = uuid.NewString()
+	}
+
+	var resp *session.ForkResponse
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var storageSess storageSession
+		err := tx.Where(&storageSession{AppName: appName, UserID: userID, ID: sessionID}).
+			First(&storageSess).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("session %+v %w", sessionID, session.ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("database error while fetching session: %w", err)
+		}
+		if err := checkInitialState(tx, &storageSess); err != nil {
+			return err
+		}
+		events, err := fetchEvents(tx, appName, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		i, err := sessionutils.RewindIndex(events, eventIDs, req.EventID, req.InvocationID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		forkedSess := &storageSession{
+			AppName:      appName,
+			UserID:       userID,
+			ID:           newSessionID,
+			State:        sessionutils.ReplayState(storageSess.InitialState, events[:i], eventStateDelta),
+			InitialState: storageSess.InitialState,
+			CreateTime:   now,
+			UpdateTime:   now,
+		}
+		if err := tx.Create(forkedSess).Error; err != nil {
+			return fmt.Errorf("error creating session on database: %w", err)
+		}
+		forked := &localSession{appName: appName, userID: userID, sessionID: newSessionID}
+		for _, event := range events[:i] {
+			storageEv, err := createStorageEvent(forked, event)
+			if err != nil {
+				return fmt.Errorf("failed to map event to storage model: %w", err)
+			}
+			if err := tx.Create(storageEv).Error; err != nil {
+				return fmt.Errorf("failed to save event: %w", err)
+			}
+		}
+
+		sess, err := newResponseSession(tx, forkedSess, events[:i])
+		if err != nil {
+			return err
+		}
+		resp = &session.ForkResponse{
+			Session: sess,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// fetchEvents returns all the events of a session in chronological order.
+func fetchEvents(tx *gorm.DB, appName, userID, sessionID string) ([]*session.Event, error) {
+	var storageEvents []storageEvent
+	err := tx.Where(&storageEvent{AppName: appName, UserID: userID, SessionID: sessionID}).
+		Order("timestamp ASC").
+		Find(&storageEvents).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error while fetching events: %w", err)
+	}
+	events := make([]*session.Event, 0, len(storageEvents))
+	for i := range storageEvents {
+		evt, err := createEventFromStorageEvent(&storageEvents[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to map storage event: %w", err)
+		}
+		events = append(events, evt)
+	}
+	return events, nil
+}
+
+// newResponseSession returns the session of a storage session with its
+// events, and the app and user states merged to its state.
+func newResponseSession(tx *gorm.DB, storageSess *storageSession, events []*session.Event) (*localSession, error) {
+	storageApp, err := fetchStorageAppState(tx, storageSess.AppName)
+	if err != nil {
+		return nil, err
+	}
+	storageUser, err := fetchStorageUserState(tx, storageSess.AppName, storageSess.UserID)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := createSessionFromStorageSession(storageSess)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map storage object: %w", err)
+	}
+	sess.state = mergeStates(storageApp.State, storageUser.State, storageSess.State)
+	sess.events = events
+	return sess, nil
+}
+
 func fetchStorageAppState(tx *gorm.DB, appName string) (*storageAppState, error) {
 	var storageApp storageAppState
 	if err := tx.First(&storageApp, "app_name = ?", appName).Error; err != nil {
@@ -516,3 +729,33 @@ func mergeStates(appState, userState, sessionState map[string]any) map[string]an
 
 	return mergedState
 }
+
+// checkInitialState returns an error if the initial state of the session is
+// unknown, because it was created before the initial_state column. Such a
+// session can't be rewound nor forked.
+func checkInitialState(tx *gorm.DB, storageSess *storageSession) error {
+	var count int64
+	err := tx.Model(&storageSession{}).
+		Where(&storageSession{AppName: storageSess.AppName, UserID: storageSess.UserID, ID: storageSess.ID}).
+		Where("initial_state IS NULL").
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("database error while fetching session: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("session %q has no initial state, it was created before the rewinds were supported", storageSess.ID)
+	}
+	return nil
+}
+
+// eventIDs returns the ID and the invocation ID of the event, for
+// [sessionutils.RewindIndex].
+func eventIDs(event *session.Event) (string, string) {
+	return event.ID, event.InvocationID
+}
+
+// eventStateDelta returns the state delta of the event, for
+// [sessionutils.ReplayState].
+func eventStateDelta(event *session.Event) map[string]any {
+	return event.Actions.StateDelta
+}
@@ -16,6 +16,7 @@ package database
 
 import (
 	"maps"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -24,6 +25,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/sessiontest"
 	"google.golang.org/genai"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -349,7 +351,7 @@ func Test_databaseService_Get(t *testing.T) {
 			if tt.wantResponse != nil {
 				if diff := cmp.Diff(tt.wantResponse, got,
 					cmp.AllowUnexported(localSession{}),
-					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt")); diff != "" {
+					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt", "revision")); diff != "" {
 					t.Errorf("Get session mismatch: (-want +got):\n%s", diff)
 				}
 			}
@@ -459,7 +461,7 @@ func Test_databaseService_List(t *testing.T) {
 				// Sort slices for stable comparison
 				opts := []cmp.Option{
 					cmp.AllowUnexported(localSession{}),
-					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt"),
+					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt", "revision"),
 					cmpopts.SortSlices(func(a, b session.Session) bool {
 						return a.ID() < b.ID()
 					}),
@@ -521,6 +523,7 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 				appName:   "app2",
 				userID:    "user2",
 				sessionID: "session2",
+				revision:  1,
 			},
 			event: &session.Event{
 				ID: "new_event1",
@@ -722,7 +725,6 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 
 			s := tt.setup(t)
 
-			tt.session.updatedAt = time.Now() // set updatedAt value to pass stale validation
 			err := s.AppendEvent(ctx, tt.session, tt.event)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("databaseService.AppendEvent() error = %v, wantErr %v", err, tt.wantErr)
@@ -750,7 +752,7 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 			// Define comparison options
 			opts := []cmp.Option{
 				cmp.AllowUnexported(localSession{}),
-				cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt"),
+				cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt", "revision"),
 				cmpopts.IgnoreFields(session.Event{}, "Timestamp"),
 				// Add sorters if event order is not guaranteed
 				cmpopts.SortSlices(func(a, b *session.Event) bool {
@@ -952,6 +954,58 @@ func serviceDbWithData(t *testing.T) *databaseService {
 	return service
 }
 
+func Test_databaseService_Conformance(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T) session.Service {
+		// The tables of a shared in-memory database are locked by the
+		// concurrent transactions, a database file serializes them instead.
+		dsn := filepath.Join(t.TempDir(), "sessions.db") + "?_txlock=immediate&_busy_timeout=10000"
+		service, err := NewSessionService(sqlite.Open(dsn), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("Failed to create session service: %v", err)
+		}
+		if err := AutoMigrate(service); err != nil {
+			t.Fatalf("Failed to AutoMigrate db: %v", err)
+		}
+		t.Cleanup(func() {
+			if sqlDB, err := service.(*databaseService).db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+		return service
+	})
+}
+
+func Test_databaseService_RewindWithoutInitialState(t *testing.T) {
+	ctx := t.Context()
+	s := emptyService(t)
+	created, err := s.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1", State: map[string]any{"k": "v"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendEvent(ctx, created.Session, &session.Event{ID: "e1", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	// The sessions created before the initial_state column have none.
+	if err := s.db.Exec("UPDATE sessions SET initial_state = NULL").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Rewind(ctx, &session.RewindRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "e1"}); err == nil {
+		t.Error("Rewind() succeeded, want an error for the session without initial state")
+	}
+	if _, err := s.Fork(ctx, &session.ForkRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "e1"}); err == nil {
+		t.Error("Fork() succeeded, want an error for the session without initial state")
+	}
+	// The session is left unchanged.
+	got, err := s.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := got.Session.Events().Len(); n != 1 {
+		t.Errorf("got %d events, want 1", n)
+	}
+}
+
 func emptyService(t *testing.T) *databaseService {
 	t.Helper()
 	gormConfig := &gorm.Config{
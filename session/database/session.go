@@ -35,6 +35,9 @@ type localSession struct {
 	events    []*session.Event
 	state     map[string]any
 	updatedAt time.Time
+	// revision is the revision of the stored session when the session was
+	// loaded, it detects the stale sessions.
+	revision int64
 }
 
 func (s *localSession) ID() string {
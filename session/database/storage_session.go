@@ -32,6 +32,12 @@ type storageSession struct {
 	State      stateMap
 	CreateTime time.Time
 	UpdateTime time.Time
+	// Revision is incremented by each change of the session.
+	Revision int64 `gorm:"not null;default:0"`
+	// InitialState is the session scoped state at creation, it is the base
+	// state of the rewinds and forks. It is NULL for the sessions created
+	// before the column, which can't be rewound nor forked.
+	InitialState stateMap
 
 	// Has-Many relationship: A session has many events.
 	Events []storageEvent `gorm:"foreignKey:AppName,UserID,SessionID;references:AppName,UserID,ID"`
@@ -62,6 +68,7 @@ func createSessionFromStorageSession(storage *storageSession) (*localSession, er
 		sessionID: storage.ID,
 		state:     storage.State,
 		updatedAt: storage.UpdateTime,
+		revision:  storage.Revision,
 	}, nil
 }
 
@@ -87,12 +94,18 @@ type storageEvent struct {
 	CustomMetadata    dynamicJSON
 	UsageMetadata     dynamicJSON
 	CitationMetadata  dynamicJSON
+	LogprobsResult    dynamicJSON
+	// Candidates are all the candidates of the model response when it has
+	// more than one.
+	Candidates dynamicJSON
 
 	Partial      *bool
 	TurnComplete *bool
 	ErrorCode    *string
 	ErrorMessage *string
 	Interrupted  *bool
+	FinishReason *string
+	AvgLogprobs  *float64
 
 	// Belongs-To relationship: An event belongs to a session.
 	Session storageSession `gorm:"foreignKey:AppName,UserID,SessionID;references:AppName,UserID,ID"`
@@ -145,6 +158,13 @@ func createStorageEvent(session session.Session, event *session.Event) (*storage
 	if event.ErrorMessage != "" {
 		storageEv.ErrorMessage = &event.ErrorMessage
 	}
+	if event.FinishReason != "" {
+		finishReason := string(event.FinishReason)
+		storageEv.FinishReason = &finishReason
+	}
+	if event.AvgLogprobs != 0 {
+		storageEv.AvgLogprobs = &event.AvgLogprobs
+	}
 
 	// For booleans, we can assign pointers directly.
 	storageEv.Partial = &event.Partial
@@ -182,6 +202,18 @@ func createStorageEvent(session session.Session, event *session.Event) (*storage
 			return nil, fmt.Errorf("failed to marshal citation metadata: %w", err)
 		}
 	}
+	if event.LogprobsResult != nil {
+		storageEv.LogprobsResult, err = json.Marshal(event.LogprobsResult)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal logprobs result: %w", err)
+		}
+	}
+	if len(event.Candidates) > 0 {
+		storageEv.Candidates, err = json.Marshal(event.Candidates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal candidates: %w", err)
+		}
+	}
 
 	return storageEv, nil
 }
@@ -241,6 +273,20 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 		}
 	}
 
+	var logprobsResult *genai.LogprobsResult
+	if len(se.LogprobsResult) > 0 {
+		if err := json.Unmarshal(se.LogprobsResult, &logprobsResult); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal logprobs result: %w", err)
+		}
+	}
+
+	var candidates []*model.LLMResponse
+	if len(se.Candidates) > 0 {
+		if err := json.Unmarshal(se.Candidates, &candidates); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal candidates: %w", err)
+		}
+	}
+
 	// --- Handle JSON-encoded *string field ---
 	var toolIDs []string
 	if se.LongRunningToolIDsJSON != nil {
@@ -257,6 +303,8 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 	partial := derefOrZero(se.Partial)
 	turnComplete := derefOrZero(se.TurnComplete)
 	interrupted := derefOrZero(se.Interrupted)
+	finishReason := genai.FinishReason(derefOrZero(se.FinishReason))
+	avgLogprobs := derefOrZero(se.AvgLogprobs)
 
 	// --- Assemble the final Event struct ---
 	event := &session.Event{
@@ -273,11 +321,15 @@ func createEventFromStorageEvent(se *storageEvent) (*session.Event, error) {
 			CustomMetadata:    customMetadata,
 			UsageMetadata:     usageMetadata,
 			CitationMetadata:  citationMetadata,
+			LogprobsResult:    logprobsResult,
 			ErrorCode:         errorCode,
 			ErrorMessage:      errorMessage,
 			Partial:           partial,
 			TurnComplete:      turnComplete,
 			Interrupted:       interrupted,
+			FinishReason:      finishReason,
+			AvgLogprobs:       avgLogprobs,
+			Candidates:        candidates,
 		},
 	}
 
@@ -0,0 +1,301 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/internal/sessionutils"
+)
+
+// ExportFormatVersion is the version of the export format written by
+// [Export]. [Import] reads the exports of this version and the earlier ones.
+const ExportFormatVersion = 1
+
+// exportFormat identifies the export files.
+const exportFormat = "adk.sessions"
+
+// The export format is JSONL, with one record per line:
+//
+//	{"header": {"format": "adk.sessions", "version": 1, ...}}
+//	{"session": {"appName": ..., "userId": ..., "id": ..., "state": {...}}}
+//	{"event": {...}}
+//	...
+//	{"session": ...}
+//	...
+//	{"scopedState": {"appName": ..., "userId": ..., "appState": {...}, "userState": {...}}}
+//
+// The header is the first record. Each session is followed by its events,
+// in order. The app and user states are last, so that they are set after
+// the state deltas of the events.
+type exportRecord struct {
+	Header      *exportHeader      `json:"header,omitempty"`
+	Session     *exportSession     `json:"session,omitempty"`
+	Event       *Event             `json:"event,omitempty"`
+	ScopedState *exportScopedState `json:"scopedState,omitempty"`
+}
+
+type exportHeader struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportTime time.Time `json:"exportTime"`
+}
+
+// exportSession is a session, without its events. State is the session
+// scoped state before the events: the state of the session without the keys
+// set by the events, whose values before the first event are not known.
+type exportSession struct {
+	AppName string         `json:"appName"`
+	UserID  string         `json:"userId"`
+	ID      string         `json:"id"`
+	State   map[string]any `json:"state"`
+}
+
+// exportScopedState is the state of an app and of one of its users, without
+// the prefixes of the keys.
+type exportScopedState struct {
+	AppName   string         `json:"appName"`
+	UserID    string         `json:"userId"`
+	AppState  map[string]any `json:"appState"`
+	UserState map[string]any `json:"userState"`
+}
+
+// ExportRequest represents a request to export sessions.
+type ExportRequest struct {
+	AppName string
+	// UserID is the user of the sessions to export.
+	// Optional: if not set, the sessions of all the users are exported.
+	UserID string
+}
+
+// ExportResponse represents a response from [Export].
+type ExportResponse struct {
+	Sessions int
+	Events   int
+}
+
+// Export writes the sessions of the app, with their events and their app,
+// user and session scoped states, to w in a versioned JSONL format read by
+// [Import]. The sessions are loaded one at a time. The temporary state keys
+// are not exported.
+func Export(ctx context.Context, s Service, w io.Writer, req *ExportRequest) (*ExportResponse, error) {
+	if req.AppName == "" {
+		return nil, fmt.Errorf("app_name is required")
+	}
+	listResp, err := s.List(ctx, &ListRequest{AppName: req.AppName, UserID: req.UserID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sessions := slices.SortedFunc(slices.Values(listResp.Sessions), func(a, b Session) int {
+		return cmp.Or(cmp.Compare(a.UserID(), b.UserID()), cmp.Compare(a.ID(), b.ID()))
+	})
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(&exportRecord{Header: &exportHeader{Format: exportFormat, Version: ExportFormatVersion, ExportTime: time.Now()}}); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	resp := &ExportResponse{}
+	// scopedStates holds the app and user states of the users, in the
+	// order of their first session.
+	var scopedStates []*exportScopedState
+	for _, listed := range sessions {
+		getResp, err := s.Get(ctx, &GetRequest{AppName: listed.AppName(), UserID: listed.UserID(), SessionID: listed.ID()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session %s: %w", listed.ID(), err)
+		}
+		sess := getResp.Session
+		appState, userState, sessionState := sessionutils.ExtractStateDeltas(maps.Collect(sess.State().All()))
+		for event := range sess.Events().All() {
+			for key := range event.Actions.StateDelta {
+				delete(sessionState, key)
+			}
+		}
+		if len(scopedStates) == 0 || scopedStates[len(scopedStates)-1].UserID != sess.UserID() {
+			scopedStates = append(scopedStates, &exportScopedState{AppName: sess.AppName(), UserID: sess.UserID(), AppState: appState, UserState: userState})
+		}
+
+		err = enc.Encode(&exportRecord{Session: &exportSession{AppName: sess.AppName(), UserID: sess.UserID(), ID: sess.ID(), State: sessionState}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write session %s: %w", sess.ID(), err)
+		}
+		for event := range sess.Events().All() {
+			if err := enc.Encode(&exportRecord{Event: event}); err != nil {
+				return nil, fmt.Errorf("failed to write event %s: %w", event.ID, err)
+			}
+			resp.Events++
+		}
+		resp.Sessions++
+	}
+	for _, state := range scopedStates {
+		if err := enc.Encode(&exportRecord{ScopedState: state}); err != nil {
+			return nil, fmt.Errorf("failed to write state of user %s: %w", state.UserID, err)
+		}
+	}
+	return resp, nil
+}
+
+// ImportRequest represents a request to import sessions.
+type ImportRequest struct {
+	// SkipExisting keeps the sessions which exist instead of failing, and
+	// only appends their events which are missing, by ID. It resumes an
+	// interrupted import.
+	SkipExisting bool
+}
+
+// ImportResponse represents a response from [Import].
+type ImportResponse struct {
+	// Sessions and Events are the numbers of sessions created and of
+	// events appended.
+	Sessions int
+	Events   int
+	// SkippedSessions is the number of sessions which existed, with
+	// [ImportRequest.SkipExisting].
+	SkippedSessions int
+}
+
+// Import creates the sessions written by [Export] in r, with their events
+// and states. It fails if a session exists, unless req.SkipExisting is set.
+// The records are read one at a time.
+//
+// The app and user states are set with a session created and deleted for
+// the purpose, as the [Service] sets them from the state of the sessions.
+func Import(ctx context.Context, s Service, r io.Reader, req *ImportRequest) (*ImportResponse, error) {
+	dec := json.NewDecoder(r)
+	var header exportRecord
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if header.Header == nil || header.Header.Format != exportFormat {
+		return nil, fmt.Errorf("not a sessions export: missing %s header", exportFormat)
+	}
+	if header.Header.Version > ExportFormatVersion {
+		return nil, fmt.Errorf("unsupported export version %d, want at most %d", header.Header.Version, ExportFormatVersion)
+	}
+
+	resp := &ImportResponse{}
+	var sess Session
+	// existingEvents are the IDs of the events of sess when it existed.
+	var existingEvents map[string]bool
+	// existingSessions are the IDs of the sessions which exist, listed by
+	// app and user when needed.
+	existingSessions := make(map[[2]string]map[string]bool)
+	for {
+		var rec exportRecord
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return resp, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+		switch {
+		case rec.Session != nil:
+			existingEvents = nil
+			if req.SkipExisting {
+				existing, err := existingSession(ctx, s, existingSessions, rec.Session)
+				if err != nil {
+					return nil, err
+				}
+				if existing != nil {
+					sess = existing
+					existingEvents = make(map[string]bool)
+					for event := range existing.Events().All() {
+						existingEvents[event.ID] = true
+					}
+					resp.SkippedSessions++
+					continue
+				}
+			}
+			createResp, err := s.Create(ctx, &CreateRequest{AppName: rec.Session.AppName, UserID: rec.Session.UserID, SessionID: rec.Session.ID, State: rec.Session.State})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create session %s: %w", rec.Session.ID, err)
+			}
+			sess = createResp.Session
+			resp.Sessions++
+		case rec.Event != nil:
+			if sess == nil {
+				return nil, fmt.Errorf("event %s before any session", rec.Event.ID)
+			}
+			if existingEvents[rec.Event.ID] {
+				continue
+			}
+			if err := s.AppendEvent(ctx, sess, rec.Event); err != nil {
+				return nil, fmt.Errorf("failed to append event %s to session %s: %w", rec.Event.ID, sess.ID(), err)
+			}
+			resp.Events++
+		case rec.ScopedState != nil:
+			if err := importScopedState(ctx, s, rec.ScopedState); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown record")
+		}
+	}
+}
+
+// existingSession returns the session of the record if it exists, nil
+// otherwise. The IDs of the sessions of its app and user are listed once, in
+// listed.
+func existingSession(ctx context.Context, s Service, listed map[[2]string]map[string]bool, rec *exportSession) (Session, error) {
+	key := [2]string{rec.AppName, rec.UserID}
+	ids, ok := listed[key]
+	if !ok {
+		listResp, err := s.List(ctx, &ListRequest{AppName: rec.AppName, UserID: rec.UserID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		ids = make(map[string]bool, len(listResp.Sessions))
+		for _, sess := range listResp.Sessions {
+			ids[sess.ID()] = true
+		}
+		listed[key] = ids
+	}
+	if !ids[rec.ID] {
+		return nil, nil
+	}
+	getResp, err := s.Get(ctx, &GetRequest{AppName: rec.AppName, UserID: rec.UserID, SessionID: rec.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session %s: %w", rec.ID, err)
+	}
+	return getResp.Session, nil
+}
+
+// importScopedState sets the app and user states with a session created with
+// them, and deleted.
+func importScopedState(ctx context.Context, s Service, state *exportScopedState) error {
+	if len(state.AppState) == 0 && len(state.UserState) == 0 {
+		return nil
+	}
+	delta := sessionutils.MergeStates(state.AppState, state.UserState, nil)
+	createResp, err := s.Create(ctx, &CreateRequest{AppName: state.AppName, UserID: state.UserID, SessionID: "import-" + uuid.NewString(), State: delta})
+	if err != nil {
+		return fmt.Errorf("failed to import state of user %s: %w", state.UserID, err)
+	}
+	err = s.Delete(ctx, &DeleteRequest{AppName: state.AppName, UserID: state.UserID, SessionID: createResp.Session.ID()})
+	if err != nil {
+		return fmt.Errorf("failed to import state of user %s: %w", state.UserID, err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session_test
+
+import (
+	"bytes"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/filestore"
+	"google.golang.org/adk/session/sessiontest"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := t.Context()
+	src := session.InMemoryService()
+	baseTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	fullEvent := sessiontest.FullEvent()
+	sessions := []struct {
+		appName, userID, sessionID string
+		state                      map[string]any
+		events                     []*session.Event
+	}{
+		{
+			appName: "app", userID: "user1", sessionID: "s1",
+			state: map[string]any{"k0": "init", "k1": "init", "app:a": "x", "user:u": "y", "temp:t": "z"},
+			events: []*session.Event{
+				{ID: "e1", Timestamp: baseTime, Actions: session.EventActions{StateDelta: map[string]any{"k1": "v1", "app:a": "changed"}}},
+				{ID: "e2", Timestamp: baseTime.Add(time.Second), Actions: session.EventActions{StateDelta: map[string]any{"k2": "v2", "user:u": "changed"}}},
+				fullEvent,
+			},
+		},
+		{appName: "app", userID: "user1", sessionID: "s2", state: map[string]any{"k": "v"}},
+		{appName: "app", userID: "user2", sessionID: "s3", state: map[string]any{"user:u": "user2"}},
+		{appName: "other", userID: "user1", sessionID: "s4"},
+	}
+	for _, s := range sessions {
+		resp, err := src.Create(ctx, &session.CreateRequest{AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, State: s.state})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range s.events {
+			if err := src.AppendEvent(ctx, resp.Session, ev); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var export bytes.Buffer
+	exportResp, err := session.Export(ctx, src, &export, &session.ExportRequest{AppName: "app"})
+	if err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+	if diff := cmp.Diff(&session.ExportResponse{Sessions: 3, Events: 3}, exportResp); diff != "" {
+		t.Errorf("Export() response mismatch (-want +got):\n%s", diff)
+	}
+
+	fileService, err := filestore.NewSessionService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, dst := range map[string]session.Service{"in-memory": session.InMemoryService(), "file": fileService} {
+		t.Run(name, func(t *testing.T) {
+			importResp, err := session.Import(ctx, dst, bytes.NewReader(export.Bytes()), &session.ImportRequest{})
+			if err != nil {
+				t.Fatalf("Import() failed: %v", err)
+			}
+			if diff := cmp.Diff(&session.ImportResponse{Sessions: 3, Events: 3}, importResp); diff != "" {
+				t.Errorf("Import() response mismatch (-want +got):\n%s", diff)
+			}
+
+			listResp, err := dst.List(ctx, &session.ListRequest{AppName: "app"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(listResp.Sessions); got != 3 {
+				t.Errorf("imported %d sessions, want 3", got)
+			}
+			for _, s := range sessions[:3] {
+				req := &session.GetRequest{AppName: s.appName, UserID: s.userID, SessionID: s.sessionID}
+				want, err := src.Get(ctx, req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := dst.Get(ctx, req)
+				if err != nil {
+					t.Fatalf("Get(%s) failed: %v", s.sessionID, err)
+				}
+				if diff := cmp.Diff(maps.Collect(want.Session.State().All()), maps.Collect(got.Session.State().All())); diff != "" {
+					t.Errorf("session %s state mismatch (-want +got):\n%s", s.sessionID, diff)
+				}
+				if diff := cmp.Diff(collectEvents(want.Session), collectEvents(got.Session)); diff != "" {
+					t.Errorf("session %s events mismatch (-want +got):\n%s", s.sessionID, diff)
+				}
+			}
+
+			// The sessions exist.
+			if _, err := session.Import(ctx, dst, bytes.NewReader(export.Bytes()), &session.ImportRequest{}); err == nil {
+				t.Errorf("Import() of existing sessions succeeded, want error")
+			}
+			importResp, err = session.Import(ctx, dst, bytes.NewReader(export.Bytes()), &session.ImportRequest{SkipExisting: true})
+			if err != nil {
+				t.Fatalf("Import() with SkipExisting failed: %v", err)
+			}
+			if diff := cmp.Diff(&session.ImportResponse{SkippedSessions: 3}, importResp); diff != "" {
+				t.Errorf("Import() with SkipExisting response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImport_Resume(t *testing.T) {
+	ctx := t.Context()
+	src := session.InMemoryService()
+	created, err := src.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"e1", "e2", "e3"} {
+		if err := src.AppendEvent(ctx, created.Session, &session.Event{ID: id, Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := src.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s2"}); err != nil {
+		t.Fatal(err)
+	}
+	var export bytes.Buffer
+	if _, err := session.Export(ctx, src, &export, &session.ExportRequest{AppName: "app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An import interrupted after the first event of s1.
+	dst := session.InMemoryService()
+	interrupted, err := dst.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := src.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.AppendEvent(ctx, interrupted.Session, first.Session.Events().At(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := session.Import(ctx, dst, &export, &session.ImportRequest{SkipExisting: true})
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if diff := cmp.Diff(&session.ImportResponse{Sessions: 1, Events: 2, SkippedSessions: 1}, resp); diff != "" {
+		t.Errorf("Import() response mismatch (-want +got):\n%s", diff)
+	}
+	got, err := dst.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(collectEvents(first.Session), collectEvents(got.Session)); diff != "" {
+		t.Errorf("resumed session events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		export string
+	}{
+		{name: "empty"},
+		{name: "missing header", export: `{"session": {"appName": "app", "userId": "user", "id": "s1"}}`},
+		{name: "unsupported version", export: `{"header": {"format": "adk.sessions", "version": 1000}}`},
+		{name: "event before session", export: `{"header": {"format": "adk.sessions", "version": 1}}
+{"event": {"ID": "e1"}}`},
+		{name: "unknown record", export: `{"header": {"format": "adk.sessions", "version": 1}}
+{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := session.Import(t.Context(), session.InMemoryService(), strings.NewReader(tt.export), &session.ImportRequest{}); err == nil {
+				t.Errorf("Import() succeeded, want error")
+			}
+		})
+	}
+}
+
+func collectEvents(sess session.Session) []*session.Event {
+	var events []*session.Event
+	for ev := range sess.Events().All() {
+		events = append(events, ev)
+	}
+	return events
+}
@@ -0,0 +1,344 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/internal/sessionutils"
+	"google.golang.org/adk/session"
+)
+
+// formatVersion is the version of the format of the session files.
+const formatVersion = 1
+
+// The layout of the directory of the service:
+//
+//	<app>/.lock                                  lock of the files of the app
+//	<app>/state.json                             app state
+//	<app>/users/<user>/state.json                user state
+//	<app>/users/<user>/sessions/<session>.jsonl  session records
+//
+// The names are escaped with escapeName.
+const (
+	lockFileName    = ".lock"
+	stateFileName   = "state.json"
+	usersDirName    = "users"
+	sessionsDirName = "sessions"
+	sessionFileExt  = ".jsonl"
+)
+
+// record is a line of a session file. The first record of the file is the
+// header of the session, the next ones are its events and rewinds.
+type record struct {
+	Session *sessionHeader `json:"session,omitempty"`
+	Event   *session.Event `json:"event,omitempty"`
+	Rewind  *rewindMarker  `json:"rewind,omitempty"`
+}
+
+// sessionHeader holds the fields of the session set at its creation.
+type sessionHeader struct {
+	Version    int            `json:"version"`
+	AppName    string         `json:"appName"`
+	UserID     string         `json:"userId"`
+	ID         string         `json:"id"`
+	State      map[string]any `json:"state"`
+	CreateTime time.Time      `json:"createTime"`
+}
+
+// rewindMarker removes the events of the session from the event EventID,
+// included. The file is never truncated, so that its size, the revision of
+// the session, never goes back to a previous value.
+type rewindMarker struct {
+	EventID string    `json:"eventId"`
+	Time    time.Time `json:"time"`
+}
+
+// escapeName returns the name of the file or directory holding the
+// app, user or session with the name. It doesn't contain any path
+// separator and is never "." or "..".
+func escapeName(name string) string {
+	escaped := url.PathEscape(name)
+	if strings.HasPrefix(escaped, ".") {
+		escaped = "%2E" + escaped[1:]
+	}
+	return escaped
+}
+
+// unescapeName returns the name of the app, user or session of the escaped
+// name.
+func unescapeName(escaped string) (string, error) {
+	return url.PathUnescape(escaped)
+}
+
+func (s *fileService) appDir(appName string) string {
+	return filepath.Join(s.dir, escapeName(appName))
+}
+
+func (s *fileService) userDir(appName, userID string) string {
+	return filepath.Join(s.appDir(appName), usersDirName, escapeName(userID))
+}
+
+func (s *fileService) sessionsDir(appName, userID string) string {
+	return filepath.Join(s.userDir(appName, userID), sessionsDirName)
+}
+
+func (s *fileService) sessionFile(appName, userID, sessionID string) string {
+	return filepath.Join(s.sessionsDir(appName, userID), escapeName(sessionID)+sessionFileExt)
+}
+
+// readState reads the state file, returning an empty state if it does not
+// exist.
+func readState(file string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]any), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := make(map[string]any)
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("corrupt state file %s: %w", file, err)
+	}
+	return state, nil
+}
+
+// updateState applies the delta to the state file.
+func updateState(file string, delta map[string]any) (map[string]any, error) {
+	state, err := readState(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(delta) == 0 {
+		return state, nil
+	}
+	for key, value := range delta {
+		state[key] = value
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	if err := writeFileAtomic(file, data); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// writeFileAtomic replaces the file with the data. A crash leaves either the
+// previous or the new content.
+func writeFileAtomic(file string, data []byte) (err error) {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
+
+// appendRecord appends the record to the session file, which must end at
+// the offset revision. It returns the new end of the file. A crash in the
+// middle of a write leaves an incomplete last line, which is ignored by
+// readSession and removed by the next append.
+func appendRecord(file string, rec *record, revision int64) (int64, error) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal record: %w", err)
+	}
+	f, err := os.OpenFile(file, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	end, err := completeLinesEnd(f)
+	if err != nil {
+		return 0, err
+	}
+	if end != revision {
+		return 0, fmt.Errorf("%w: session file %s ends at %d, got revision %d", session.ErrStaleSession, file, end, revision)
+	}
+	newEnd := end + int64(len(line)) + 1
+	if _, err := f.WriteAt(append(line, '\n'), end); err != nil {
+		return 0, err
+	}
+	if err := f.Truncate(newEnd); err != nil {
+		return 0, err
+	}
+	return newEnd, f.Sync()
+}
+
+// completeLinesEnd returns the offset of the end of the last complete line
+// of the file.
+func completeLinesEnd(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	const chunkSize = 4096
+	buf := make([]byte, chunkSize)
+	for end := size; end > 0; {
+		start := max(end-chunkSize, 0)
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return start + int64(i) + 1, nil
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// createSessionFile creates the session file with its header and events. It
+// fails if the file exists. It returns the size of the file.
+func createSessionFile(file string, header *sessionHeader, events []*session.Event) (size int64, err error) {
+	line, err := json.Marshal(&record{Session: header})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal session: %w", err)
+	}
+	line = append(line, '\n')
+	for _, event := range events {
+		eventLine, err := json.Marshal(&record{Event: event})
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal record: %w", err)
+		}
+		line = append(append(line, eventLine...), '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(file)
+		}
+	}()
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return 0, err
+	}
+	return int64(len(line)), f.Close()
+}
+
+// readSession reads the session file. The session state only holds the
+// session scoped keys. An incomplete last line, left by a crash, is ignored.
+func readSession(file string) (*sessionHeader, *localSession, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var header *sessionHeader
+	var sess *localSession
+	var end int64
+	r := bufio.NewReader(f)
+	for lineNum := 1; ; lineNum++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// An incomplete last line is the append interrupted by a crash.
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		end += int64(len(line))
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, nil, fmt.Errorf("corrupt session file %s, line %d: %w", file, lineNum, err)
+		}
+		switch {
+		case lineNum == 1:
+			if rec.Session == nil {
+				return nil, nil, fmt.Errorf("corrupt session file %s: missing session header", file)
+			}
+			if rec.Session.Version > formatVersion {
+				return nil, nil, fmt.Errorf("session file %s has unsupported version %d", file, rec.Session.Version)
+			}
+			header = rec.Session
+			sess = &localSession{
+				appName:   header.AppName,
+				userID:    header.UserID,
+				sessionID: header.ID,
+				state:     make(map[string]any),
+				updatedAt: header.CreateTime,
+			}
+			for key, value := range header.State {
+				sess.state[key] = value
+			}
+		case rec.Event != nil:
+			_, _, sessionDelta := sessionutils.ExtractStateDeltas(rec.Event.Actions.StateDelta)
+			for key, value := range sessionDelta {
+				sess.state[key] = value
+			}
+			sess.events = append(sess.events, rec.Event)
+			sess.updatedAt = rec.Event.Timestamp
+		case rec.Rewind != nil:
+			i, err := sessionutils.RewindIndex(sess.events, eventIDs, rec.Rewind.EventID, "")
+			if err != nil {
+				return nil, nil, fmt.Errorf("corrupt session file %s, line %d: %w", file, lineNum, err)
+			}
+			sess.events = sess.events[:i:i]
+			sess.state = sessionutils.ReplayState(header.State, sess.events, eventStateDelta)
+			sess.updatedAt = rec.Rewind.Time
+		default:
+			return nil, nil, fmt.Errorf("corrupt session file %s, line %d: unknown record", file, lineNum)
+		}
+	}
+	if header == nil {
+		return nil, nil, fmt.Errorf("corrupt session file %s: missing session header", file)
+	}
+	sess.revision = end
+	return header, sess, nil
+}
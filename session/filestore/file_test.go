@@ -0,0 +1,274 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestore
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+func TestPersistence(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	s1, err := NewSessionService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := s1.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1", State: map[string]any{"k": "v", "app:a": "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := &session.Event{
+		ID:        "e1",
+		Author:    "agent",
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC),
+		Actions:   session.EventActions{StateDelta: map[string]any{"k": "v2", "user:u": "y", "temp:t": "z"}},
+		LLMResponse: model.LLMResponse{
+			Content:      genai.NewContentFromText("hello", genai.RoleModel),
+			FinishReason: genai.FinishReasonStop,
+			AvgLogprobs:  -0.5,
+		},
+	}
+	if err := s1.AppendEvent(ctx, created.Session, event); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another service on the same directory, e.g. after a restart, sees
+	// the session.
+	s2, err := NewSessionService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s2.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantState := map[string]any{"k": "v2", "app:a": "x", "user:u": "y"}
+	if diff := cmp.Diff(wantState, maps.Collect(got.Session.State().All())); diff != "" {
+		t.Errorf("state mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]*session.Event{event}, []*session.Event(got.Session.Events().(events))); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+	// The temporary state is not written to the files.
+	if diff := cmp.Diff(map[string]any{"k": "v2", "user:u": "y"}, got.Session.Events().At(0).Actions.StateDelta); diff != "" {
+		t.Errorf("event state delta mismatch (-want +got):\n%s", diff)
+	}
+	if !got.Session.LastUpdateTime().Equal(event.Timestamp) {
+		t.Errorf("LastUpdateTime() = %v, want %v", got.Session.LastUpdateTime(), event.Timestamp)
+	}
+}
+
+func TestIncompleteLastLine(t *testing.T) {
+	ctx := t.Context()
+	s := emptyService(t)
+	created, err := s.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendEvent(ctx, created.Session, &session.Event{ID: "e1", Actions: session.EventActions{StateDelta: map[string]any{"k": "v1"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of an append.
+	file := s.sessionFile("app", "user", "s1")
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"event":{"ID":"lost","Actions":{"StateDelta":{"k":`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	checkSession := func(wantEvents []string, wantState map[string]any) {
+		t.Helper()
+		got, err := s.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gotEvents []string
+		for ev := range got.Session.Events().All() {
+			gotEvents = append(gotEvents, ev.ID)
+		}
+		if diff := cmp.Diff(wantEvents, gotEvents); diff != "" {
+			t.Errorf("events mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(wantState, maps.Collect(got.Session.State().All())); diff != "" {
+			t.Errorf("state mismatch (-want +got):\n%s", diff)
+		}
+	}
+	checkSession([]string{"e1"}, map[string]any{"k": "v1"})
+
+	// The next append replaces the incomplete line.
+	if err := s.AppendEvent(ctx, created.Session, &session.Event{ID: "e2", Actions: session.EventActions{StateDelta: map[string]any{"k": "v2"}}}); err != nil {
+		t.Fatal(err)
+	}
+	checkSession([]string{"e1", "e2"}, map[string]any{"k": "v2"})
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "lost") {
+		t.Errorf("session file still contains the incomplete line:\n%s", data)
+	}
+}
+
+func TestCorruptFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "corrupt line", content: "{\"session\":{\"version\":1,\"id\":\"s1\"}}\nnot json\n{\"event\":{}}\n"},
+		{name: "missing header", content: "{\"event\":{}}\n"},
+		{name: "empty", content: ""},
+		{name: "unsupported version", content: "{\"session\":{\"version\":2,\"id\":\"s1\"}}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := emptyService(t)
+			file := s.sessionFile("app", "user", "s1")
+			if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err == nil {
+				t.Errorf("Get() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestConcurrentAppends(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	const numServices, numEvents = 4, 10
+
+	var services []session.Service
+	for range numServices {
+		s, err := NewSessionService(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		services = append(services, s)
+	}
+	if _, err := services[0].Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, numServices*numEvents)
+	for i, s := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range numEvents {
+				ev := &session.Event{
+					ID:      fmt.Sprintf("e%d-%d", i, j),
+					Actions: session.EventActions{StateDelta: map[string]any{fmt.Sprintf("app:k%d-%d", i, j): true}},
+				}
+				// The appends to a session loaded before the appends of the
+				// other services fail, the session is loaded again.
+				for {
+					resp, err := s.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+					if err != nil {
+						errs <- err
+						return
+					}
+					err = s.AppendEvent(ctx, resp.Session, ev)
+					if !errors.Is(err, session.ErrStaleSession) {
+						if err != nil {
+							errs <- err
+						}
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	got, err := services[0].Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := got.Session.Events().Len(); n != numServices*numEvents {
+		t.Errorf("got %d events, want %d", n, numServices*numEvents)
+	}
+	if n := len(maps.Collect(got.Session.State().All())); n != numServices*numEvents {
+		t.Errorf("got %d app state keys, want %d", n, numServices*numEvents)
+	}
+}
+
+func TestEscapedNames(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	s, err := NewSessionService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ids := range [][3]string{
+		{"app/with/slashes", "..", "."},
+		{"app/with/slashes", "user", "../../escape"},
+		{"app/with/slashes", "user", "with space"},
+	} {
+		if _, err := s.Create(ctx, &session.CreateRequest{AppName: ids[0], UserID: ids[1], SessionID: ids[2]}); err != nil {
+			t.Fatalf("Create(%q) failed: %v", ids, err)
+		}
+		if _, err := s.Get(ctx, &session.GetRequest{AppName: ids[0], UserID: ids[1], SessionID: ids[2]}); err != nil {
+			t.Errorf("Get(%q) failed: %v", ids, err)
+		}
+	}
+
+	resp, err := s.List(ctx, &session.ListRequest{AppName: "app/with/slashes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, sess := range resp.Sessions {
+		got = append(got, sess.UserID()+"|"+sess.ID())
+	}
+	want := []string{"..|.", "user|../../escape", "user|with space"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+
+	// All the files are in the directory of the app.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d entries in the service directory, want 1", len(entries))
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix && !windows
+
+package filestore
+
+import (
+	"os"
+	"sync"
+)
+
+// processLock serializes the accesses to the files in the process. The
+// files are not locked for the other processes on these systems, e.g. wasm
+// or plan9, so the directory must not be shared by several processes.
+var processLock sync.RWMutex
+
+// lockFile locks the files of the service in the process.
+func lockFile(f *os.File, exclusive bool) error {
+	if exclusive {
+		processLock.Lock()
+	} else {
+		processLock.RLock()
+	}
+	return nil
+}
+
+// unlockFile unlocks the files locked by lockFile.
+func unlockFile(f *os.File, exclusive bool) error {
+	if exclusive {
+		processLock.Unlock()
+	} else {
+		processLock.RUnlock()
+	}
+	return nil
+}
@@ -0,0 +1,458 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filestore provides a [session.Service] storing the sessions in
+// files, for the single binary deployments and the command line tools which
+// don't use a database.
+//
+// Each session is an append-only JSONL file: its first line holds the
+// session and the next lines its events, one per line. A rewind appends a
+// line removing the events from a given one. The app and user
+// states are JSON files, replaced atomically when they change. The files of
+// an app are locked while they are read or written, so several processes
+// can share the directory on unix and windows. On the other systems, the
+// files are only locked within the process.
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/internal/sessionutils"
+	"google.golang.org/adk/session"
+)
+
+// fileService is a file implementation of session.Service.
+type fileService struct {
+	dir string
+}
+
+// NewSessionService creates a new [session.Service] storing the sessions in
+// the directory, created if needed. It implements [session.RewindService].
+func NewSessionService(dir string) (session.Service, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating file session service: %w", err)
+	}
+	return &fileService{dir: dir}, nil
+}
+
+// Create creates the session file, implements session.Service.
+func (s *fileService) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	if req.AppName == "" || req.UserID == "" {
+		return nil, fmt.Errorf("app_name and user_id are required, got app_name: %q, user_id: %q", req.AppName, req.UserID)
+	}
+
+	sessionID := req.SessionID
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+
+	unlock, err := s.lock(req.AppName, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	appDelta, userDelta, sessionState := sessionutils.ExtractStateDeltas(req.State)
+	header := &sessionHeader{
+		Version:    formatVersion,
+		AppName:    req.AppName,
+		UserID:     req.UserID,
+		ID:         sessionID,
+		State:      sessionState,
+		CreateTime: time.Now(),
+	}
+	revision, err := createSessionFile(s.sessionFile(req.AppName, req.UserID, sessionID), header, nil)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("session %s already exists", sessionID)
+		}
+		return nil, fmt.Errorf("error creating session file: %w", err)
+	}
+	appState, err := updateState(filepath.Join(s.appDir(req.AppName), stateFileName), appDelta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save app state: %w", err)
+	}
+	userState, err := updateState(filepath.Join(s.userDir(req.AppName, req.UserID), stateFileName), userDelta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save user state: %w", err)
+	}
+
+	return &session.CreateResponse{
+		Session: &localSession{
+			appName:   req.AppName,
+			userID:    req.UserID,
+			sessionID: sessionID,
+			state:     sessionutils.MergeStates(appState, userState, sessionState),
+			updatedAt: header.CreateTime,
+			revision:  revision,
+		},
+	}, nil
+}
+
+// Get reads the session file, implements session.Service.
+func (s *fileService) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID)
+	}
+
+	unlock, err := s.lock(appName, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	_, sess, err := readSession(s.sessionFile(appName, userID, sessionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("session %+v not found", sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+	appState, userState, err := s.readStates(appName, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error on get session: %w", err)
+	}
+	sess.state = sessionutils.MergeStates(appState, userState, sess.state)
+
+	filteredEvents := sess.events
+	if req.NumRecentEvents > 0 {
+		start := max(len(filteredEvents)-req.NumRecentEvents, 0)
+		filteredEvents = filteredEvents[start:]
+	}
+	// apply timestamp filter, assuming list is sorted
+	if !req.After.IsZero() && len(filteredEvents) > 0 {
+		firstIndexToKeep := sort.Search(len(filteredEvents), func(i int) bool {
+			return !filteredEvents[i].Timestamp.Before(req.After)
+		})
+		filteredEvents = filteredEvents[firstIndexToKeep:]
+	}
+	sess.events = make([]*session.Event, 0, len(filteredEvents))
+	sess.events = append(sess.events, filteredEvents...)
+
+	return &session.GetResponse{
+		Session: sess,
+	}, nil
+}
+
+// List reads the session files of the app, or of the user if set,
+// implements session.Service. The sessions are returned without their
+// events.
+func (s *fileService) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	appName, userID := req.AppName, req.UserID
+	if appName == "" {
+		return nil, fmt.Errorf("app_name is required, got app_name: %q", appName)
+	}
+
+	unlock, err := s.lock(appName, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	userIDs := []string{userID}
+	if userID == "" {
+		userIDs, err = readNames(filepath.Join(s.appDir(appName), usersDirName), "")
+		if err != nil {
+			return nil, fmt.Errorf("error listing users: %w", err)
+		}
+	}
+
+	sessions := make([]session.Session, 0)
+	for _, userID := range userIDs {
+		sessionIDs, err := readNames(s.sessionsDir(appName, userID), sessionFileExt)
+		if err != nil {
+			return nil, fmt.Errorf("error listing sessions: %w", err)
+		}
+		if len(sessionIDs) == 0 {
+			continue
+		}
+		appState, userState, err := s.readStates(appName, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error on list sessions: %w", err)
+		}
+		for _, sessionID := range sessionIDs {
+			_, sess, err := readSession(s.sessionFile(appName, userID, sessionID))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error reading session %s: %w", sessionID, err)
+			}
+			sess.state = sessionutils.MergeStates(appState, userState, sess.state)
+			sess.events = nil
+			sessions = append(sessions, sess)
+		}
+	}
+	return &session.ListResponse{
+		Sessions: sessions,
+	}, nil
+}
+
+// Delete removes the session file, implements session.Service.
+func (s *fileService) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, sessionID)
+	}
+
+	unlock, err := s.lock(appName, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(s.sessionFile(appName, userID, sessionID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error deleting session: %w", err)
+	}
+	return nil
+}
+
+// AppendEvent appends the event to the session file and applies its state
+// delta, implements session.Service.
+//
+// The event is written before the app and user states, so a crash in between
+// loses the app and user state changes of the event.
+func (s *fileService) AppendEvent(ctx context.Context, curSession session.Session, event *session.Event) error {
+	if curSession == nil {
+		return fmt.Errorf("session is nil")
+	}
+	if event == nil {
+		return fmt.Errorf("event is nil")
+	}
+	// ignore partial events
+	if event.Partial {
+		return nil
+	}
+
+	// Trim temp state before persisting
+	event = trimTempDeltaState(event)
+
+	sess, ok := curSession.(*localSession)
+	if !ok {
+		return fmt.Errorf("unexpected session type %T", curSession)
+	}
+
+	unlock, err := s.lock(sess.AppName(), true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	revision, err := appendRecord(s.sessionFile(sess.AppName(), sess.UserID(), sess.ID()), &record{Event: event}, sess.revision)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("session not found, cannot apply event")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+	sess.revision = revision
+	appDelta, userDelta, _ := sessionutils.ExtractStateDeltas(event.Actions.StateDelta)
+	if _, err := updateState(filepath.Join(s.appDir(sess.AppName()), stateFileName), appDelta); err != nil {
+		return fmt.Errorf("failed to save app state: %w", err)
+	}
+	if _, err := updateState(filepath.Join(s.userDir(sess.AppName(), sess.UserID()), stateFileName), userDelta); err != nil {
+		return fmt.Errorf("failed to save user state: %w", err)
+	}
+
+	return sess.appendEvent(event)
+}
+
+// Rewind appends a rewind marker to the session file, implements
+// session.RewindService.
+func (s *fileService) Rewind(ctx context.Context, req *session.RewindRequest) (*session.RewindResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("%w: app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", session.ErrInvalidRequest, appName, userID, sessionID)
+	}
+	if req.EventID == "" && req.InvocationID == "" {
+		return nil, fmt.Errorf("%w: event_id or invocation_id is required", session.ErrInvalidRequest)
+	}
+
+	unlock, err := s.lock(appName, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file := s.sessionFile(appName, userID, sessionID)
+	_, sess, err := readSession(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("session %+v %w", sessionID, session.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+	i, err := sessionutils.RewindIndex(sess.events, eventIDs, req.EventID, req.InvocationID)
+	if err != nil {
+		return nil, err
+	}
+	removedEvents := sess.events[i:]
+
+	marker := &rewindMarker{EventID: sess.events[i].ID, Time: time.Now()}
+	if _, err := appendRecord(file, &record{Rewind: marker}, sess.revision); err != nil {
+		return nil, fmt.Errorf("failed to save rewind: %w", err)
+	}
+	_, sess, err = readSession(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+	appState, userState, err := s.readStates(appName, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error on rewind session: %w", err)
+	}
+	sess.state = sessionutils.MergeStates(appState, userState, sess.state)
+
+	return &session.RewindResponse{
+		Session:       sess,
+		RemovedEvents: removedEvents,
+	}, nil
+}
+
+// Fork creates a session file with the header and the events of the
+// session before the event of the request, implements session.RewindService.
+func (s *fileService) Fork(ctx context.Context, req *session.ForkRequest) (*session.ForkResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("%w: app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", session.ErrInvalidRequest, appName, userID, sessionID)
+	}
+
+	newSessionID := req.NewSessionID
+	if newSessionID == "" {
+		newSessionID = uuid.NewString()
+	}
+
+	unlock, err := s.lock(appName, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	header, sess, err := readSession(s.sessionFile(appName, userID, sessionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("session %+v %w", sessionID, session.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+	i, err := sessionutils.RewindIndex(sess.events, eventIDs, req.EventID, req.InvocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	file := s.sessionFile(appName, userID, newSessionID)
+	forkedHeader := &sessionHeader{
+		Version:    formatVersion,
+		AppName:    appName,
+		UserID:     userID,
+		ID:         newSessionID,
+		State:      header.State,
+		CreateTime: time.Now(),
+	}
+	if _, err := createSessionFile(file, forkedHeader, sess.events[:i]); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("session %s already exists", newSessionID)
+		}
+		return nil, fmt.Errorf("error creating session file: %w", err)
+	}
+	_, forked, err := readSession(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session: %w", err)
+	}
+	appState, userState, err := s.readStates(appName, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error on fork session: %w", err)
+	}
+	forked.state = sessionutils.MergeStates(appState, userState, forked.state)
+
+	return &session.ForkResponse{
+		Session: forked,
+	}, nil
+}
+
+// lock locks the files of the app, exclusively for the writes. It returns
+// the function unlocking them.
+func (s *fileService) lock(appName string, exclusive bool) (func(), error) {
+	dir := s.appDir(appName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating app directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error locking %s: %w", f.Name(), err)
+	}
+	return func() {
+		_ = unlockFile(f, exclusive)
+		f.Close()
+	}, nil
+}
+
+// readStates reads the app and user states.
+func (s *fileService) readStates(appName, userID string) (appState, userState map[string]any, err error) {
+	appState, err = readState(filepath.Join(s.appDir(appName), stateFileName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read app state: %w", err)
+	}
+	userState, err = readState(filepath.Join(s.userDir(appName, userID), stateFileName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read user state: %w", err)
+	}
+	return appState, userState, nil
+}
+
+// readNames returns the unescaped names of the entries of the directory
+// with the extension, without it. The names of the entries without the
+// extension are returned if it is empty. A missing directory has no entries.
+func readNames(dir, ext string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if ext != "" {
+			var ok bool
+			if name, ok = strings.CutSuffix(name, ext); !ok || entry.IsDir() {
+				continue
+			}
+		} else if !entry.IsDir() {
+			continue
+		}
+		unescaped, err := unescapeName(name)
+		if err != nil {
+			continue
+		}
+		names = append(names, unescaped)
+	}
+	return names, nil
+}
+
+var _ session.RewindService = (*fileService)(nil)
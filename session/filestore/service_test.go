@@ -0,0 +1,37 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestore
+
+import (
+	"testing"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/sessiontest"
+)
+
+func Test_fileService_Conformance(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T) session.Service {
+		return emptyService(t)
+	})
+}
+
+func emptyService(t *testing.T) *fileService {
+	t.Helper()
+	service, err := NewSessionService(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create session service: %v", err)
+	}
+	return service.(*fileService)
+}
@@ -64,13 +64,8 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 		return nil, fmt.Errorf("session %s already exists", req.SessionID)
 	}
 
-	state := req.State
-	if state == nil {
-		state = make(stateMap)
-	}
 	val := &session{
 		id:        key,
-		state:     state,
 		updatedAt: time.Now(),
 	}
 
@@ -78,10 +73,11 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 	defer s.mu.Unlock()
 
 	s.sessions.Set(encodedKey, val)
-	appDelta, userDelta, _ := sessionutils.ExtractStateDeltas(req.State)
+	appDelta, userDelta, sessionDelta := sessionutils.ExtractStateDeltas(req.State)
 	appState := s.updateAppState(appDelta, req.AppName)
 	userState := s.updateUserState(userDelta, req.AppName, req.UserID)
-	val.state = sessionutils.MergeStates(appState, userState, state)
+	val.state = sessionutils.MergeStates(appState, userState, sessionDelta)
+	val.initialState = sessionDelta
 
 	copiedSession := copySessionWithoutStateAndEvents(val)
 	copiedSession.state = maps.Clone(val.state)
@@ -217,6 +213,9 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 	if !ok {
 		return fmt.Errorf("session not found, cannot apply event")
 	}
+	if sess.revision != stored_session.revision {
+		return fmt.Errorf("%w: session %s is at revision %d, got revision %d", ErrStaleSession, sess.ID(), stored_session.revision, sess.revision)
+	}
 
 	// update the in-memory session
 	if err := sess.appendEvent(event); err != nil {
@@ -226,6 +225,7 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 	// update the in-memory session service
 	stored_session.events = append(stored_session.events, event)
 	stored_session.updatedAt = event.Timestamp
+	stored_session.revision++
 	if len(event.Actions.StateDelta) > 0 {
 		appDelta, userDelta, sessionDelta := sessionutils.ExtractStateDeltas(event.Actions.StateDelta)
 		s.updateAppState(appDelta, curSession.AppName())
@@ -235,6 +235,86 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 	return nil
 }
 
+func (s *inMemoryService) Rewind(ctx context.Context, req *RewindRequest) (*RewindResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("%w: app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", ErrInvalidRequest, appName, userID, sessionID)
+	}
+	if req.EventID == "" && req.InvocationID == "" {
+		return nil, fmt.Errorf("%w: event_id or invocation_id is required", ErrInvalidRequest)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	storedSession, ok := s.sessions.Get(id{appName: appName, userID: userID, sessionID: sessionID}.Encode())
+	if !ok {
+		return nil, fmt.Errorf("session %+v %w", sessionID, ErrNotFound)
+	}
+	i, err := sessionutils.RewindIndex(storedSession.events, eventIDs, req.EventID, req.InvocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	removedEvents := slices.Clone(storedSession.events[i:])
+	storedSession.events = slices.Clone(storedSession.events[:i])
+	storedSession.state = sessionutils.ReplayState(storedSession.initialState, storedSession.events, eventStateDelta)
+	storedSession.updatedAt = time.Now()
+	storedSession.revision++
+
+	copiedSession := copySessionWithoutStateAndEvents(storedSession)
+	copiedSession.state = s.mergeStates(storedSession.state, appName, userID)
+	copiedSession.events = slices.Clone(storedSession.events)
+	return &RewindResponse{
+		Session:       copiedSession,
+		RemovedEvents: removedEvents,
+	}, nil
+}
+
+func (s *inMemoryService) Fork(ctx context.Context, req *ForkRequest) (*ForkResponse, error) {
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || sessionID == "" {
+		return nil, fmt.Errorf("%w: app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", ErrInvalidRequest, appName, userID, sessionID)
+	}
+
+	newSessionID := req.NewSessionID
+	if newSessionID == "" {
+		newSessionID = uuid.NewString()
+	}
+	key := id{appName: appName, userID: userID, sessionID: newSessionID}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	storedSession, ok := s.sessions.Get(id{appName: appName, userID: userID, sessionID: sessionID}.Encode())
+	if !ok {
+		return nil, fmt.Errorf("session %+v %w", sessionID, ErrNotFound)
+	}
+	if _, ok := s.sessions.Get(key.Encode()); ok {
+		return nil, fmt.Errorf("session %s already exists", newSessionID)
+	}
+	i, err := sessionutils.RewindIndex(storedSession.events, eventIDs, req.EventID, req.InvocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	val := &session{
+		id:           key,
+		events:       slices.Clone(storedSession.events[:i]),
+		initialState: maps.Clone(storedSession.initialState),
+		updatedAt:    time.Now(),
+	}
+	val.state = sessionutils.ReplayState(val.initialState, val.events, eventStateDelta)
+	s.sessions.Set(key.Encode(), val)
+
+	copiedSession := copySessionWithoutStateAndEvents(val)
+	copiedSession.state = s.mergeStates(val.state, appName, userID)
+	copiedSession.events = slices.Clone(val.events)
+	return &ForkResponse{
+		Session: copiedSession,
+	}, nil
+}
+
 func (s *inMemoryService) updateAppState(appDelta stateMap, appName string) stateMap {
 	innerMap, ok := s.appState[appName]
 	if !ok {
@@ -292,6 +372,12 @@ type session struct {
 	events    []*Event
 	state     map[string]any
 	updatedAt time.Time
+	// revision is the number of changes of the stored session when the
+	// session was loaded, it detects the stale sessions.
+	revision int64
+	// initialState is the session scoped state of the stored session at
+	// creation, it is the base state of the rewinds and forks.
+	initialState map[string]any
 }
 
 func (s *session) ID() string {
@@ -336,6 +422,7 @@ func (s *session) appendEvent(event *Event) error {
 
 	s.events = append(s.events, event)
 	s.updatedAt = event.Timestamp
+	s.revision++
 	return nil
 }
 
@@ -455,7 +542,8 @@ func copySessionWithoutStateAndEvents(sess *session) *session {
 			sessionID: sess.id.sessionID,
 		},
 		updatedAt: sess.updatedAt,
+		revision:  sess.revision,
 	}
 }
 
-var _ Service = (*inMemoryService)(nil)
+var _ RewindService = (*inMemoryService)(nil)
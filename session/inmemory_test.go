@@ -351,7 +351,7 @@ func Test_databaseService_Get(t *testing.T) {
 				if diff := cmp.Diff(tt.wantResponse, got,
 					cmp.AllowUnexported(session{}),
 					cmp.AllowUnexported(id{}),
-					cmpopts.IgnoreFields(session{}, "mu", "updatedAt")); diff != "" {
+					cmpopts.IgnoreFields(session{}, "mu", "updatedAt", "revision")); diff != "" {
 					t.Errorf("Get session mismatch: (-want +got):\n%s", diff)
 				}
 			}
@@ -489,7 +489,7 @@ func Test_databaseService_List(t *testing.T) {
 				opts := []cmp.Option{
 					cmp.AllowUnexported(session{}),
 					cmp.AllowUnexported(id{}),
-					cmpopts.IgnoreFields(session{}, "mu", "updatedAt"),
+					cmpopts.IgnoreFields(session{}, "mu", "updatedAt", "revision"),
 					cmpopts.SortSlices(func(a, b Session) bool {
 						return a.ID() < b.ID()
 					}),
@@ -812,7 +812,7 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 			opts := []cmp.Option{
 				cmp.AllowUnexported(session{}),
 				cmp.AllowUnexported(id{}),
-				cmpopts.IgnoreFields(session{}, "mu", "updatedAt"),
+				cmpopts.IgnoreFields(session{}, "mu", "updatedAt", "revision"),
 				cmpopts.IgnoreFields(Event{}, "Timestamp"),
 				// Add sorters if event order is not guaranteed
 				cmpopts.SortSlices(func(a, b *Event) bool {
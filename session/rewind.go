@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+
+	"google.golang.org/adk/internal/sessionutils"
+)
+
+var (
+	// ErrNotFound is wrapped by the errors of [RewindService] when the
+	// session, the event or the invocation of the request doesn't exist.
+	ErrNotFound = sessionutils.ErrNotFound
+	// ErrInvalidRequest is wrapped by the errors of [RewindService] when the
+	// request is invalid, e.g. with both an event and an invocation.
+	ErrInvalidRequest = sessionutils.ErrInvalidRequest
+)
+
+// RewindService is a [Service] which can rewind and fork the sessions, e.g.
+// to retry the conversation from an earlier turn.
+type RewindService interface {
+	Service
+	// Rewind removes the events of the session from the event of the
+	// request, included. The session scoped state is reverted to its value
+	// before the event, while the app and user scoped states, shared with
+	// the other sessions, are kept. The sessions loaded before are stale,
+	// see [ErrStaleSession].
+	Rewind(context.Context, *RewindRequest) (*RewindResponse, error)
+	// Fork creates a new session with the events of the session before the
+	// event of the request, and the session scoped state before it.
+	Fork(context.Context, *ForkRequest) (*ForkResponse, error)
+}
+
+// RewindRequest represents a request to rewind a session.
+type RewindRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+
+	// EventID is the ID of the first event to remove.
+	EventID string
+	// InvocationID is the ID of the first invocation to remove: its events
+	// and the later ones are removed.
+	// Exactly one of EventID and InvocationID is required.
+	InvocationID string
+}
+
+// RewindResponse represents a response from [RewindService.Rewind].
+type RewindResponse struct {
+	// Session is the rewound session.
+	Session Session
+	// RemovedEvents are the events removed from the session, e.g. to revert
+	// the artifact versions of their ArtifactDelta.
+	RemovedEvents []*Event
+}
+
+// ForkRequest represents a request to fork a session.
+type ForkRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+
+	// EventID is the ID of the first event not copied to the new session.
+	EventID string
+	// InvocationID is the ID of the first invocation not copied to the new
+	// session.
+	// Optional: if EventID and InvocationID are not set, all the events are
+	// copied.
+	InvocationID string
+
+	// NewSessionID is the client-provided ID of the new session.
+	// Optional: if not set, it will be autogenerated.
+	NewSessionID string
+}
+
+// ForkResponse represents a response from [RewindService.Fork].
+type ForkResponse struct {
+	// Session is the new session.
+	Session Session
+}
+
+// eventIDs returns the ID and the invocation ID of the event, for
+// [sessionutils.RewindIndex].
+func eventIDs(event *Event) (string, string) {
+	return event.ID, event.InvocationID
+}
+
+// eventStateDelta returns the state delta of the event, for
+// [sessionutils.ReplayState].
+func eventStateDelta(event *Event) map[string]any {
+	return event.Actions.StateDelta
+}
@@ -16,6 +16,7 @@ package session
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -28,10 +29,21 @@ type Service interface {
 	List(context.Context, *ListRequest) (*ListResponse, error)
 	Delete(context.Context, *DeleteRequest) error
 	// AppendEvent is used to append an event to a session, and remove temporary state keys from the event.
+	//
+	// The session must be up to date: AppendEvent returns an error wrapping
+	// [ErrStaleSession] when an event was appended to the session since it
+	// was loaded, e.g. by a concurrent invocation. The session is updated
+	// with the event so that it can be used to append the next events.
 	AppendEvent(context.Context, Session, *Event) error
 }
 
+// ErrStaleSession is the error of [Service.AppendEvent] when the session was
+// updated since it was loaded. The session must be loaded again with
+// [Service.Get], and the agent run again with its current state and events.
+var ErrStaleSession = errors.New("stale session")
+
 // InMemoryService returns an in-memory implementation of the session service.
+// It implements [RewindService].
 func InMemoryService() Service {
 	return &inMemoryService{
 		appState:  make(map[string]stateMap),
@@ -20,7 +20,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/model"
+	"google.golang.org/genai"
 )
 
 // Session represents a series of interactions between a user and agents.
@@ -153,6 +155,25 @@ type EventActions struct {
 	TransferToAgent string
 	// The agent is escalating to a higher level agent.
 	Escalate bool
+	// Authentication configs requested by the tools, keyed by the function
+	// call ID. Only valid for function response event.
+	RequestedAuthConfigs map[string]*auth.Config
+	// AgentState is the checkpoint of the event author's progress in the
+	// invocation, e.g. the current sub-agent of a workflow agent. It is used
+	// to resume the invocation.
+	AgentState map[string]any
+	// Compaction is the summary of the earlier events of the session. It
+	// replaces them in the LLM requests.
+	Compaction *EventCompaction
+}
+
+// EventCompaction is the summary of the events of the session with
+// timestamps between StartTimestamp and EndTimestamp, included.
+type EventCompaction struct {
+	StartTimestamp time.Time
+	EndTimestamp   time.Time
+	// CompactedContent is the summary of the events.
+	CompactedContent *genai.Content
 }
 
 // Prefixes for defining session's state scopes
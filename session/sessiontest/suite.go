@@ -0,0 +1,807 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessiontest provides a conformance test suite for the
+// implementations of [session.Service].
+//
+// A session service runs the suite in its tests:
+//
+//	func TestConformance(t *testing.T) {
+//		sessiontest.Run(t, func(t *testing.T) session.Service {
+//			return newEmptyService(t)
+//		})
+//	}
+package sessiontest
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Run runs the conformance tests of a session service. newService returns
+// an empty service, it is called once per test. The tests of
+// [session.RewindService] are skipped if the service doesn't implement it.
+func Run(t *testing.T, newService func(t *testing.T) session.Service) {
+	t.Helper()
+	tests := []struct {
+		name string
+		test func(t *testing.T, s session.Service)
+	}{
+		{"Create", testCreate},
+		{"Get", testGet},
+		{"GetFilters", testGetFilters},
+		{"List", testList},
+		{"Delete", testDelete},
+		{"EventOrdering", testEventOrdering},
+		{"PartialEvents", testPartialEvents},
+		{"StatePrefixes", testStatePrefixes},
+		{"ConcurrentAppendEvent", testConcurrentAppendEvent},
+		{"StaleSession", testStaleSession},
+		{"EventRoundTrip", testEventRoundTrip},
+		{"Rewind", testRewind},
+		{"Fork", testFork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newService(t))
+		})
+	}
+}
+
+const appName = "sessiontest_app"
+
+// baseTime is the timestamp of the first events. It has a microsecond
+// precision, which all the storages keep.
+var baseTime = time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+func testCreate(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	state := map[string]any{"k": "v", "n": 1.5}
+	resp, err := s.Create(ctx, &session.CreateRequest{AppName: appName, UserID: "user", SessionID: "s1", State: state})
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	checkSession(t, resp.Session, "user", "s1")
+	if diff := cmp.Diff(state, maps.Collect(resp.Session.State().All())); diff != "" {
+		t.Errorf("Create() state mismatch (-want +got):\n%s", diff)
+	}
+	if n := resp.Session.Events().Len(); n != 0 {
+		t.Errorf("Create() returned %d events, want 0", n)
+	}
+
+	generated, err := s.Create(ctx, &session.CreateRequest{AppName: appName, UserID: "user"})
+	if err != nil {
+		t.Fatalf("Create() without session ID failed: %v", err)
+	}
+	if id := generated.Session.ID(); id == "" || id == "s1" {
+		t.Errorf("Create() without session ID generated ID %q, want a new ID", id)
+	}
+
+	if _, err := s.Create(ctx, &session.CreateRequest{AppName: appName, UserID: "user", SessionID: "s1"}); err == nil {
+		t.Errorf("Create() of an existing session succeeded, want error")
+	}
+	for _, req := range []*session.CreateRequest{
+		{UserID: "user", SessionID: "s2"},
+		{AppName: appName, SessionID: "s2"},
+	} {
+		if _, err := s.Create(ctx, req); err == nil {
+			t.Errorf("Create(%+v) succeeded, want error", req)
+		}
+	}
+}
+
+func testGet(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	createSession(t, s, "user1", "s1", map[string]any{"k": "v1"})
+	createSession(t, s, "user2", "s1", map[string]any{"k": "v2"})
+
+	resp, err := s.Get(ctx, &session.GetRequest{AppName: appName, UserID: "user2", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	checkSession(t, resp.Session, "user2", "s1")
+	if diff := cmp.Diff(map[string]any{"k": "v2"}, maps.Collect(resp.Session.State().All())); diff != "" {
+		t.Errorf("Get() state mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, req := range []*session.GetRequest{
+		{AppName: appName, UserID: "user1", SessionID: "unknown"},
+		{AppName: appName, UserID: "user3", SessionID: "s1"},
+		{AppName: "unknown_app", UserID: "user1", SessionID: "s1"},
+		{UserID: "user1", SessionID: "s1"},
+		{AppName: appName, SessionID: "s1"},
+		{AppName: appName, UserID: "user1"},
+	} {
+		if _, err := s.Get(ctx, req); err == nil {
+			t.Errorf("Get(%+v) succeeded, want error", req)
+		}
+	}
+}
+
+func testGetFilters(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	sess := createSession(t, s, "user", "s1", nil)
+	for i := range 5 {
+		appendEvent(t, s, sess, &session.Event{ID: fmt.Sprint(i), Author: "user", Timestamp: baseTime.Add(time.Duration(i) * time.Second)})
+	}
+
+	tests := []struct {
+		name            string
+		numRecentEvents int
+		after           time.Time
+		want            []string
+	}{
+		{name: "no filter", want: []string{"0", "1", "2", "3", "4"}},
+		{name: "num recent events", numRecentEvents: 3, want: []string{"2", "3", "4"}},
+		{name: "more recent events than events", numRecentEvents: 10, want: []string{"0", "1", "2", "3", "4"}},
+		{name: "after", after: baseTime.Add(3 * time.Second), want: []string{"3", "4"}},
+		{name: "after all events", after: baseTime.Add(time.Minute)},
+		{name: "combined", numRecentEvents: 3, after: baseTime.Add(3 * time.Second), want: []string{"3", "4"}},
+		{name: "combined with fewer recent events", numRecentEvents: 1, after: baseTime.Add(2 * time.Second), want: []string{"4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.Get(ctx, &session.GetRequest{AppName: appName, UserID: "user", SessionID: "s1", NumRecentEvents: tt.numRecentEvents, After: tt.after})
+			if err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, eventIDs(resp.Session)); diff != "" {
+				t.Errorf("Get() events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func testList(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	createSession(t, s, "user1", "s1", map[string]any{"k": "v1", "user:u": "u1"})
+	createSession(t, s, "user1", "s2", map[string]any{"k": "v2"})
+	createSession(t, s, "user2", "s1", map[string]any{"k": "v3", "app:a": "a"})
+	if _, err := s.Create(ctx, &session.CreateRequest{AppName: "other_app", UserID: "user1", SessionID: "s3"}); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	type listed struct {
+		UserID, ID string
+		State      map[string]any
+	}
+	tests := []struct {
+		name   string
+		userID string
+		want   []listed
+	}{
+		{
+			name:   "user",
+			userID: "user1",
+			want: []listed{
+				{"user1", "s1", map[string]any{"k": "v1", "user:u": "u1", "app:a": "a"}},
+				{"user1", "s2", map[string]any{"k": "v2", "user:u": "u1", "app:a": "a"}},
+			},
+		},
+		{
+			name:   "all users",
+			userID: "",
+			want: []listed{
+				{"user1", "s1", map[string]any{"k": "v1", "user:u": "u1", "app:a": "a"}},
+				{"user1", "s2", map[string]any{"k": "v2", "user:u": "u1", "app:a": "a"}},
+				{"user2", "s1", map[string]any{"k": "v3", "app:a": "a"}},
+			},
+		},
+		{
+			name:   "unknown user",
+			userID: "user3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.List(ctx, &session.ListRequest{AppName: appName, UserID: tt.userID})
+			if err != nil {
+				t.Fatalf("List() failed: %v", err)
+			}
+			var got []listed
+			for _, sess := range resp.Sessions {
+				if sess.AppName() != appName {
+					t.Errorf("List() returned session of app %q, want %q", sess.AppName(), appName)
+				}
+				got = append(got, listed{sess.UserID(), sess.ID(), maps.Collect(sess.State().All())})
+			}
+			slices.SortFunc(got, func(a, b listed) int {
+				if a.UserID != b.UserID {
+					return strings.Compare(a.UserID, b.UserID)
+				}
+				return strings.Compare(a.ID, b.ID)
+			})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("List() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := s.List(ctx, &session.ListRequest{UserID: "user1"}); err == nil {
+		t.Errorf("List() without app name succeeded, want error")
+	}
+}
+
+func testDelete(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	s1 := createSession(t, s, "user", "s1", map[string]any{"k": "v"})
+	appendEvent(t, s, s1, &session.Event{
+		ID:        "e1",
+		Timestamp: baseTime,
+		Actions:   session.EventActions{StateDelta: map[string]any{"app:a": "a", "user:u": "u", "k": "v2"}},
+	})
+	s2 := createSession(t, s, "user", "s2", nil)
+	appendEvent(t, s, s2, &session.Event{ID: "e2", Timestamp: baseTime})
+
+	if err := s.Delete(ctx, &session.DeleteRequest{AppName: appName, UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := s.Get(ctx, &session.GetRequest{AppName: appName, UserID: "user", SessionID: "s1"}); err == nil {
+		t.Errorf("Get() of the deleted session succeeded, want error")
+	}
+	resp, err := s.List(ctx, &session.ListRequest{AppName: appName, UserID: "user"})
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	var ids []string
+	for _, sess := range resp.Sessions {
+		ids = append(ids, sess.ID())
+	}
+	if diff := cmp.Diff([]string{"s2"}, ids); diff != "" {
+		t.Errorf("List() after Delete() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The other sessions keep their events, and the app and user states
+	// are not deleted with the session.
+	got := getSession(t, s, "user", "s2")
+	if diff := cmp.Diff([]string{"e2"}, eventIDs(got)); diff != "" {
+		t.Errorf("events of the other session mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]any{"app:a": "a", "user:u": "u"}, maps.Collect(got.State().All())); diff != "" {
+		t.Errorf("state of the other session mismatch (-want +got):\n%s", diff)
+	}
+
+	// The events and the state of the session are deleted with it.
+	recreated := createSession(t, s, "user", "s1", nil)
+	if n := recreated.Events().Len(); n != 0 {
+		t.Errorf("Create() of a deleted session returned %d events, want 0", n)
+	}
+	got = getSession(t, s, "user", "s1")
+	if n := got.Events().Len(); n != 0 {
+		t.Errorf("Get() of a recreated session returned %d events, want 0", n)
+	}
+	if _, err := got.State().Get("k"); err == nil {
+		t.Errorf("recreated session has the state of the deleted session")
+	}
+
+	if err := s.Delete(ctx, &session.DeleteRequest{AppName: appName, UserID: "user", SessionID: "unknown"}); err != nil {
+		t.Errorf("Delete() of an unknown session failed: %v", err)
+	}
+	if err := s.Delete(ctx, &session.DeleteRequest{AppName: appName, UserID: "user"}); err == nil {
+		t.Errorf("Delete() without session ID succeeded, want error")
+	}
+}
+
+func testEventOrdering(t *testing.T, s session.Service) {
+	sess := createSession(t, s, "user", "s1", nil)
+	var want []string
+	for i := range 10 {
+		id := fmt.Sprintf("event-%d", i)
+		appendEvent(t, s, sess, &session.Event{ID: id, Author: "agent", InvocationID: "inv", Timestamp: baseTime.Add(time.Duration(i) * time.Millisecond)})
+		want = append(want, id)
+	}
+
+	// The session passed to AppendEvent is updated.
+	if diff := cmp.Diff(want, eventIDs(sess)); diff != "" {
+		t.Errorf("events of the appended session mismatch (-want +got):\n%s", diff)
+	}
+	got := getSession(t, s, "user", "s1")
+	if diff := cmp.Diff(want, eventIDs(got)); diff != "" {
+		t.Errorf("Get() events mismatch (-want +got):\n%s", diff)
+	}
+	if last := baseTime.Add(9 * time.Millisecond); !got.LastUpdateTime().Equal(last) {
+		t.Errorf("LastUpdateTime() = %v, want the timestamp of the last event %v", got.LastUpdateTime(), last)
+	}
+	if ev := got.Events().At(3); ev == nil || ev.ID != "event-3" {
+		t.Errorf("Events().At(3) = %v, want event-3", ev)
+	}
+	if ev := got.Events().At(10); ev != nil {
+		t.Errorf("Events().At(10) = %v, want nil", ev)
+	}
+}
+
+func testPartialEvents(t *testing.T, s session.Service) {
+	sess := createSession(t, s, "user", "s1", nil)
+	appendEvent(t, s, sess, &session.Event{
+		ID:          "partial",
+		Timestamp:   baseTime,
+		LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("hel", genai.RoleModel), Partial: true},
+		Actions:     session.EventActions{StateDelta: map[string]any{"k": "v"}},
+	})
+	got := getSession(t, s, "user", "s1")
+	if n := got.Events().Len(); n != 0 {
+		t.Errorf("got %d events after appending a partial event, want 0", n)
+	}
+	if _, err := got.State().Get("k"); err == nil {
+		t.Errorf("the state delta of the partial event was applied")
+	}
+
+	ctx := t.Context()
+	if err := s.AppendEvent(ctx, nil, &session.Event{ID: "e"}); err == nil {
+		t.Errorf("AppendEvent() to a nil session succeeded, want error")
+	}
+	if err := s.AppendEvent(ctx, sess, nil); err == nil {
+		t.Errorf("AppendEvent() of a nil event succeeded, want error")
+	}
+}
+
+func testStatePrefixes(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	s1 := createSession(t, s, "user1", "s1", map[string]any{"app:a1": "x", "user:u1": "x", "k1": "x", "temp:t1": "x"})
+	appendEvent(t, s, s1, &session.Event{
+		ID:        "e1",
+		Timestamp: baseTime,
+		Actions: session.EventActions{StateDelta: map[string]any{
+			"app:a2":  "y",
+			"user:u2": "y",
+			"k2":      "y",
+			"temp:t2": "y",
+		}},
+	})
+
+	// The session passed to AppendEvent has the new state, without the
+	// temporary keys.
+	wantS1 := map[string]any{"app:a1": "x", "app:a2": "y", "user:u1": "x", "user:u2": "y", "k1": "x", "k2": "y"}
+	gotS1 := maps.Collect(s1.State().All())
+	delete(gotS1, "temp:t1")
+	if diff := cmp.Diff(wantS1, gotS1); diff != "" {
+		t.Errorf("appended session state mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantS1, maps.Collect(getSession(t, s, "user1", "s1").State().All())); diff != "" {
+		t.Errorf("stored session state mismatch (-want +got):\n%s", diff)
+	}
+
+	// The app state is shared by all the users, the user state by the
+	// sessions of the user, and the session state is not shared.
+	tests := []struct {
+		userID string
+		want   map[string]any
+	}{
+		{"user1", map[string]any{"app:a1": "x", "app:a2": "y", "user:u1": "x", "user:u2": "y"}},
+		{"user2", map[string]any{"app:a1": "x", "app:a2": "y"}},
+	}
+	for _, tt := range tests {
+		created := createSession(t, s, tt.userID, "s2", nil)
+		if diff := cmp.Diff(tt.want, maps.Collect(created.State().All())); diff != "" {
+			t.Errorf("Create() state of a new session of %s mismatch (-want +got):\n%s", tt.userID, diff)
+		}
+		if diff := cmp.Diff(tt.want, maps.Collect(getSession(t, s, tt.userID, "s2").State().All())); diff != "" {
+			t.Errorf("Get() state of a new session of %s mismatch (-want +got):\n%s", tt.userID, diff)
+		}
+	}
+
+	// The temporary keys are not stored in the events.
+	ev := getSession(t, s, "user1", "s1").Events().At(0)
+	if ev == nil {
+		t.Fatalf("stored session has no event")
+	}
+	wantDelta := map[string]any{"app:a2": "y", "user:u2": "y", "k2": "y"}
+	if diff := cmp.Diff(wantDelta, ev.Actions.StateDelta); diff != "" {
+		t.Errorf("stored event state delta mismatch (-want +got):\n%s", diff)
+	}
+
+	// Updates of the app and user states are seen by the existing sessions.
+	s3 := createSession(t, s, "user1", "s3", nil)
+	appendEvent(t, s, s3, &session.Event{ID: "e2", Timestamp: baseTime, Actions: session.EventActions{StateDelta: map[string]any{"app:a1": "z", "user:u1": "z"}}})
+	resp, err := s.Get(ctx, &session.GetRequest{AppName: appName, UserID: "user1", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	for key, want := range map[string]any{"app:a1": "z", "user:u1": "z", "k1": "x"} {
+		if got, err := resp.Session.State().Get(key); err != nil || got != want {
+			t.Errorf("State().Get(%q) = %v, %v, want %v", key, got, err, want)
+		}
+	}
+}
+
+func testConcurrentAppendEvent(t *testing.T, s session.Service) {
+	const numSessions, numEvents = 4, 10
+	var sessions []session.Session
+	for i := range numSessions {
+		sessions = append(sessions, createSession(t, s, "user", fmt.Sprintf("s%d", i), nil))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, numSessions*numEvents)
+	for i, sess := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range numEvents {
+				ev := &session.Event{
+					ID:        fmt.Sprintf("s%d-e%d", i, j),
+					Timestamp: baseTime.Add(time.Duration(j) * time.Second),
+					Actions: session.EventActions{StateDelta: map[string]any{
+						fmt.Sprintf("app:s%d-e%d", i, j):  "x",
+						fmt.Sprintf("user:s%d-e%d", i, j): "x",
+						"last":                            fmt.Sprint(j),
+					}},
+				}
+				if err := s.AppendEvent(t.Context(), sess, ev); err != nil {
+					errs <- fmt.Errorf("AppendEvent(%s) failed: %w", ev.ID, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i := range numSessions {
+		got := getSession(t, s, "user", fmt.Sprintf("s%d", i))
+		var want []string
+		for j := range numEvents {
+			want = append(want, fmt.Sprintf("s%d-e%d", i, j))
+		}
+		if diff := cmp.Diff(want, eventIDs(got)); diff != "" {
+			t.Errorf("events of session s%d mismatch (-want +got):\n%s", i, diff)
+		}
+		state := maps.Collect(got.State().All())
+		if n := len(state); n != 2*numSessions*numEvents+1 {
+			t.Errorf("state of session s%d has %d keys, want %d: all the app and user keys and its last key", i, n, 2*numSessions*numEvents+1)
+		}
+		if last := state["last"]; last != fmt.Sprint(numEvents-1) {
+			t.Errorf("state of session s%d has last = %v, want %d", i, last, numEvents-1)
+		}
+	}
+}
+
+func testStaleSession(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	createSession(t, s, "user", "s1", nil)
+	first := getSession(t, s, "user", "s1")
+	second := getSession(t, s, "user", "s1")
+
+	appendEvent(t, s, first, &session.Event{ID: "e1", Timestamp: baseTime})
+	err := s.AppendEvent(ctx, second, &session.Event{ID: "stale", Timestamp: baseTime.Add(time.Second)})
+	if !errors.Is(err, session.ErrStaleSession) {
+		t.Errorf("AppendEvent() to a stale session = %v, want %v", err, session.ErrStaleSession)
+	}
+	// The partial events are not stored, the session is not checked.
+	if err := s.AppendEvent(ctx, second, &session.Event{ID: "partial", LLMResponse: model.LLMResponse{Partial: true}}); err != nil {
+		t.Errorf("AppendEvent() of a partial event to a stale session failed: %v", err)
+	}
+	// The session passed to AppendEvent is up to date.
+	appendEvent(t, s, first, &session.Event{ID: "e2", Timestamp: baseTime.Add(2 * time.Second)})
+	// The session loaded again is up to date.
+	appendEvent(t, s, getSession(t, s, "user", "s1"), &session.Event{ID: "e3", Timestamp: baseTime.Add(3 * time.Second)})
+
+	if diff := cmp.Diff([]string{"e1", "e2", "e3"}, eventIDs(getSession(t, s, "user", "s1"))); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func testEventRoundTrip(t *testing.T, s session.Service) {
+	want := FullEvent()
+	sess := createSession(t, s, "user", "s1", nil)
+	// AppendEvent may modify the event.
+	appendEvent(t, s, sess, FullEvent())
+
+	got := getSession(t, s, "user", "s1")
+	if got.Events().Len() != 1 {
+		t.Fatalf("got %d events, want 1", got.Events().Len())
+	}
+	if diff := cmp.Diff(want, got.Events().At(0)); diff != "" {
+		t.Errorf("stored event mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func testRewind(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	rs := rewindService(t, s)
+	s1 := createSession(t, s, "user", "s1", map[string]any{"k0": "init", "app:a": "x"})
+	appendEvents(t, s, s1, []*session.Event{
+		{ID: "e1", InvocationID: "inv1", Actions: session.EventActions{StateDelta: map[string]any{"k1": "v1"}}},
+		{ID: "e2", InvocationID: "inv2", Actions: session.EventActions{StateDelta: map[string]any{"k2": "v2", "app:a": "y"}}},
+		{ID: "e3", InvocationID: "inv2", Actions: session.EventActions{StateDelta: map[string]any{"k1": "changed"}}},
+		{ID: "e4", InvocationID: "inv3"},
+	})
+	stale := getSession(t, s, "user", "s1")
+
+	resp, err := rs.Rewind(ctx, &session.RewindRequest{AppName: appName, UserID: "user", SessionID: "s1", InvocationID: "inv2"})
+	if err != nil {
+		t.Fatalf("Rewind() failed: %v", err)
+	}
+	var removedIDs []string
+	for _, ev := range resp.RemovedEvents {
+		removedIDs = append(removedIDs, ev.ID)
+	}
+	if diff := cmp.Diff([]string{"e2", "e3", "e4"}, removedIDs); diff != "" {
+		t.Errorf("Rewind() removed events mismatch (-want +got):\n%s", diff)
+	}
+	// The session state is reverted, the app state is kept.
+	wantState := map[string]any{"k0": "init", "k1": "v1", "app:a": "y"}
+	for name, sess := range map[string]session.Session{"Rewind()": resp.Session, "Get()": getSession(t, s, "user", "s1")} {
+		checkSession(t, sess, "user", "s1")
+		if diff := cmp.Diff([]string{"e1"}, eventIDs(sess)); diff != "" {
+			t.Errorf("%s events mismatch (-want +got):\n%s", name, diff)
+		}
+		if diff := cmp.Diff(wantState, maps.Collect(sess.State().All())); diff != "" {
+			t.Errorf("%s state mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+
+	// The sessions loaded before the rewind are stale.
+	err = s.AppendEvent(ctx, stale, &session.Event{ID: "stale", Timestamp: baseTime.Add(time.Hour)})
+	if !errors.Is(err, session.ErrStaleSession) {
+		t.Errorf("AppendEvent() to a session loaded before Rewind() = %v, want %v", err, session.ErrStaleSession)
+	}
+	appendEvent(t, s, resp.Session, &session.Event{ID: "e5", Timestamp: baseTime.Add(time.Hour)})
+	if _, err := rs.Rewind(ctx, &session.RewindRequest{AppName: appName, UserID: "user", SessionID: "s1", EventID: "e5"}); err != nil {
+		t.Fatalf("Rewind() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"e1"}, eventIDs(getSession(t, s, "user", "s1"))); diff != "" {
+		t.Errorf("events after the second Rewind() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, tt := range []struct {
+		req     *session.RewindRequest
+		wantErr error
+	}{
+		{&session.RewindRequest{AppName: appName, UserID: "user", SessionID: "s1"}, session.ErrInvalidRequest},
+		{&session.RewindRequest{AppName: appName, UserID: "user", SessionID: "s1", EventID: "e1", InvocationID: "inv1"}, session.ErrInvalidRequest},
+		{&session.RewindRequest{AppName: appName, UserID: "user", SessionID: "s1", EventID: "e2"}, session.ErrNotFound},
+		{&session.RewindRequest{AppName: appName, UserID: "user", SessionID: "s1", InvocationID: "inv2"}, session.ErrNotFound},
+		{&session.RewindRequest{AppName: appName, UserID: "user", SessionID: "unknown", EventID: "e1"}, session.ErrNotFound},
+	} {
+		if _, err := rs.Rewind(ctx, tt.req); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Rewind(%+v) error = %v, want %v", tt.req, err, tt.wantErr)
+		}
+	}
+}
+
+func testFork(t *testing.T, s session.Service) {
+	ctx := t.Context()
+	rs := rewindService(t, s)
+	s1 := createSession(t, s, "user", "s1", map[string]any{"k0": "init"})
+	appendEvents(t, s, s1, []*session.Event{
+		{ID: "e1", InvocationID: "inv1", Actions: session.EventActions{StateDelta: map[string]any{"k1": "v1"}}},
+		{ID: "e2", InvocationID: "inv2", Actions: session.EventActions{StateDelta: map[string]any{"k2": "v2"}}},
+		{ID: "e3", InvocationID: "inv3"},
+	})
+
+	resp, err := rs.Fork(ctx, &session.ForkRequest{AppName: appName, UserID: "user", SessionID: "s1", EventID: "e2", NewSessionID: "fork"})
+	if err != nil {
+		t.Fatalf("Fork() failed: %v", err)
+	}
+	wantState := map[string]any{"k0": "init", "k1": "v1"}
+	for name, sess := range map[string]session.Session{"Fork()": resp.Session, "Get()": getSession(t, s, "user", "fork")} {
+		checkSession(t, sess, "user", "fork")
+		if diff := cmp.Diff([]string{"e1"}, eventIDs(sess)); diff != "" {
+			t.Errorf("%s events mismatch (-want +got):\n%s", name, diff)
+		}
+		if diff := cmp.Diff(wantState, maps.Collect(sess.State().All())); diff != "" {
+			t.Errorf("%s state mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+
+	// The sessions are independent.
+	appendEvent(t, s, resp.Session, &session.Event{ID: "e4", Timestamp: baseTime.Add(time.Hour)})
+	if diff := cmp.Diff([]string{"e1", "e4"}, eventIDs(getSession(t, s, "user", "fork"))); diff != "" {
+		t.Errorf("forked session events mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"e1", "e2", "e3"}, eventIDs(getSession(t, s, "user", "s1"))); diff != "" {
+		t.Errorf("source session events mismatch (-want +got):\n%s", diff)
+	}
+
+	// All the events are copied without an event, the ID is generated
+	// without a new session ID.
+	resp, err = rs.Fork(ctx, &session.ForkRequest{AppName: appName, UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("Fork() failed: %v", err)
+	}
+	if resp.Session.ID() == "" || resp.Session.ID() == "s1" {
+		t.Errorf("Fork() without a new session ID returned session ID %q", resp.Session.ID())
+	}
+	if diff := cmp.Diff([]string{"e1", "e2", "e3"}, eventIDs(getSession(t, s, "user", resp.Session.ID()))); diff != "" {
+		t.Errorf("forked session events mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := rs.Fork(ctx, &session.ForkRequest{AppName: appName, UserID: "user", SessionID: "s1", EventID: "e1", NewSessionID: "fork"}); err == nil {
+		t.Errorf("Fork() to an existing session succeeded, want error")
+	}
+	for _, tt := range []struct {
+		req     *session.ForkRequest
+		wantErr error
+	}{
+		{&session.ForkRequest{AppName: appName, UserID: "user", SessionID: "s1", EventID: "e1", InvocationID: "inv1"}, session.ErrInvalidRequest},
+		{&session.ForkRequest{AppName: appName, UserID: "user", SessionID: "s1", EventID: "unknown"}, session.ErrNotFound},
+		{&session.ForkRequest{AppName: appName, UserID: "user", SessionID: "unknown"}, session.ErrNotFound},
+	} {
+		if _, err := rs.Fork(ctx, tt.req); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Fork(%+v) error = %v, want %v", tt.req, err, tt.wantErr)
+		}
+	}
+}
+
+// FullEvent returns an event with all its fields set, but Partial since the
+// partial events are not stored. The values of the maps are the ones of
+// their JSON encoding, e.g. float64 numbers.
+func FullEvent() *session.Event {
+	return &session.Event{
+		LLMResponse: model.LLMResponse{
+			Content: &genai.Content{
+				Role: genai.RoleModel,
+				Parts: []*genai.Part{
+					{Text: "thinking", Thought: true, ThoughtSignature: []byte("signature")},
+					genai.NewPartFromText("hello"),
+					genai.NewPartFromBytes([]byte("image"), "image/png"),
+					{FunctionCall: &genai.FunctionCall{ID: "call1", Name: "get_weather", Args: map[string]any{"city": "Paris", "days": 2.0}}},
+				},
+			},
+			CitationMetadata: &genai.CitationMetadata{
+				Citations: []*genai.Citation{{Title: "title", URI: "https://example.com", StartIndex: 1, EndIndex: 5}},
+			},
+			GroundingMetadata: &genai.GroundingMetadata{
+				WebSearchQueries: []string{"weather paris"},
+				SearchEntryPoint: &genai.SearchEntryPoint{RenderedContent: "<div/>", SDKBlob: []byte("blob")},
+			},
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+				PromptTokenCount:     10,
+				CandidatesTokenCount: 5,
+				TotalTokenCount:      15,
+			},
+			CustomMetadata: map[string]any{
+				"key":    "value",
+				"number": 1.5,
+				"nested": map[string]any{"list": []any{"a", true}},
+			},
+			LogprobsResult: &genai.LogprobsResult{
+				ChosenCandidates: []*genai.LogprobsResultCandidate{{Token: "hello", TokenID: 42, LogProbability: -0.25}},
+				TopCandidates: []*genai.LogprobsResultTopCandidates{{Candidates: []*genai.LogprobsResultCandidate{
+					{Token: "hello", TokenID: 42, LogProbability: -0.25},
+					{Token: "hi", TokenID: 43, LogProbability: -1.5},
+				}}},
+			},
+			TurnComplete: true,
+			Interrupted:  true,
+			ErrorCode:    "error_code",
+			ErrorMessage: "error message",
+			FinishReason: genai.FinishReasonMaxTokens,
+			AvgLogprobs:  -0.25,
+			Candidates: []*model.LLMResponse{{
+				Content:      genai.NewContentFromText("other candidate", genai.RoleModel),
+				FinishReason: genai.FinishReasonStop,
+				AvgLogprobs:  -1.5,
+			}},
+		},
+		ID:           "event_id",
+		Timestamp:    baseTime,
+		InvocationID: "invocation_id",
+		Branch:       "root.child",
+		Author:       "child",
+		Actions: session.EventActions{
+			StateDelta:        map[string]any{"k": "v", "app:a": 2.0, "user:u": map[string]any{"x": []any{1.0, "y"}}},
+			ArtifactDelta:     map[string]int64{"report.pdf": 3},
+			SkipSummarization: true,
+			TransferToAgent:   "other_agent",
+			Escalate:          true,
+			RequestedAuthConfigs: map[string]*auth.Config{
+				"call1": {
+					RawCredential: &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: &auth.APIKey{Key: "key", Name: "X-Key", In: "header"}},
+					CredentialKey: "credential_key",
+				},
+			},
+			AgentState: map[string]any{"current_sub_agent": "child", "times_looped": 2.0},
+			Compaction: &session.EventCompaction{
+				StartTimestamp:   baseTime.Add(-time.Hour),
+				EndTimestamp:     baseTime.Add(-time.Minute),
+				CompactedContent: genai.NewContentFromText("summary", genai.RoleModel),
+			},
+		},
+		LongRunningToolIDs: []string{"call1"},
+	}
+}
+
+func createSession(t *testing.T, s session.Service, userID, sessionID string, state map[string]any) session.Session {
+	t.Helper()
+	resp, err := s.Create(t.Context(), &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID, State: state})
+	if err != nil {
+		t.Fatalf("Create(%s, %s) failed: %v", userID, sessionID, err)
+	}
+	return resp.Session
+}
+
+func getSession(t *testing.T, s session.Service, userID, sessionID string) session.Session {
+	t.Helper()
+	resp, err := s.Get(t.Context(), &session.GetRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		t.Fatalf("Get(%s, %s) failed: %v", userID, sessionID, err)
+	}
+	return resp.Session
+}
+
+func appendEvent(t *testing.T, s session.Service, sess session.Session, ev *session.Event) {
+	t.Helper()
+	if err := s.AppendEvent(t.Context(), sess, ev); err != nil {
+		t.Fatalf("AppendEvent(%s) failed: %v", ev.ID, err)
+	}
+}
+
+// appendEvents appends the events, with increasing timestamps from baseTime.
+func appendEvents(t *testing.T, s session.Service, sess session.Session, events []*session.Event) {
+	t.Helper()
+	for i, ev := range events {
+		ev.Timestamp = baseTime.Add(time.Duration(i) * time.Second)
+		appendEvent(t, s, sess, ev)
+	}
+}
+
+// rewindService returns the service as a [session.RewindService], skipping
+// the test if it doesn't implement it.
+func rewindService(t *testing.T, s session.Service) session.RewindService {
+	t.Helper()
+	rs, ok := s.(session.RewindService)
+	if !ok {
+		t.Skipf("%T doesn't implement session.RewindService", s)
+	}
+	return rs
+}
+
+func checkSession(t *testing.T, sess session.Session, userID, sessionID string) {
+	t.Helper()
+	if sess.AppName() != appName || sess.UserID() != userID || sess.ID() != sessionID {
+		t.Errorf("got session %s/%s/%s, want %s/%s/%s", sess.AppName(), sess.UserID(), sess.ID(), appName, userID, sessionID)
+	}
+}
+
+func eventIDs(sess session.Session) []string {
+	var ids []string
+	for ev := range sess.Events().All() {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+// unsetFields returns the names of the fields of the struct which have their
+// zero value, with the fields of the embedded structs.
+func unsetFields(v reflect.Value) []string {
+	var unset []string
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Anonymous {
+			unset = append(unset, unsetFields(v.Field(i))...)
+			continue
+		}
+		if v.Field(i).IsZero() {
+			unset = append(unset, v.Type().Name()+"."+field.Name)
+		}
+	}
+	return unset
+}
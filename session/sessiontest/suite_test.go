@@ -0,0 +1,40 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessiontest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/session"
+)
+
+// TestFullEvent checks that FullEvent sets all the fields, so that the
+// round trip test covers the new fields.
+func TestFullEvent(t *testing.T) {
+	ev := FullEvent()
+	got := unsetFields(reflect.ValueOf(*ev))
+	got = append(got, unsetFields(reflect.ValueOf(ev.Actions))...)
+	if diff := cmp.Diff([]string{"LLMResponse.Partial"}, got); diff != "" {
+		t.Errorf("FullEvent() unset fields mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	Run(t, func(t *testing.T) session.Service {
+		return session.InMemoryService()
+	})
+}
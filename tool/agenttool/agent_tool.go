@@ -25,7 +25,6 @@ import (
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
 	"google.golang.org/adk/internal/llminternal"
-	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/runner"
@@ -87,20 +86,19 @@ func (t *agentTool) Declaration() *genai.FunctionDeclaration {
 		Description: t.Description(),
 	}
 
-	var agentInputSchema *genai.Schema
+	var agentState *llminternal.State
 	llmAgent, ok := t.agent.(llminternal.Agent)
 	if ok && llmAgent != nil {
 		// TODO - understand what build_function_declaration does in python and apply if needed.
-		internalLlmAgent, ok := t.agent.(llminternal.Agent)
-		if !ok {
-			return nil
-		}
-		agentInputSchema = llminternal.Reveal(internalLlmAgent).InputSchema
+		agentState = llminternal.Reveal(llmAgent)
 	}
 
-	if agentInputSchema != nil {
-		decl.Parameters = agentInputSchema
-	} else {
+	switch {
+	case agentState != nil && agentState.InputSchema != nil:
+		decl.Parameters = agentState.InputSchema
+	case agentState != nil && agentState.InputJSONSchema != nil:
+		decl.ParametersJsonSchema = agentState.InputJSONSchema.Schema()
+	default:
 		decl.Parameters = &genai.Schema{
 			Type: "OBJECT",
 			Properties: map[string]*genai.Schema{
@@ -128,21 +126,16 @@ func (t *agentTool) Run(toolCtx tool.Context, args any) (map[string]any, error)
 		}
 	}
 
-	var agentInputSchema *genai.Schema
+	var agentState *llminternal.State
 	llmAgent, ok := t.agent.(llminternal.Agent)
-	isLllmAgent := (ok && llmAgent != nil)
-	if isLllmAgent {
-		internalLlmAgent, ok := t.agent.(llminternal.Agent)
-		if !ok {
-			return nil, fmt.Errorf("internal error: failed to convert to llm agent")
-		}
-		agentInputSchema = llminternal.Reveal(internalLlmAgent).InputSchema
+	if ok && llmAgent != nil {
+		agentState = llminternal.Reveal(llmAgent)
 	}
 
 	var content *genai.Content
 	var err error
-	if agentInputSchema != nil {
-		if err = utils.ValidateMapOnSchema(margs, agentInputSchema, true); err != nil {
+	if agentState != nil && (agentState.InputSchema != nil || agentState.InputJSONSchema != nil) {
+		if err = agentState.ValidateInput(margs); err != nil {
 			return nil, fmt.Errorf("argument validation failed for agent %s: %w", t.agent.Name(), err)
 		}
 		jsonData, err := json.Marshal(margs)
@@ -227,20 +220,15 @@ func (t *agentTool) Run(toolCtx tool.Context, args any) (map[string]any, error)
 	if outputText == "" {
 		return map[string]any{}, nil
 	}
-	if isLllmAgent {
-		internalLlmAgent, ok := t.agent.(llminternal.Agent)
-		if !ok {
-			return nil, fmt.Errorf("internal error: failed to convert to llm agent")
+	if agentState != nil && agentState.HasOutputSchema() {
+		parsedOutput, err := agentState.ParseOutput(outputText)
+		if err != nil {
+			return nil, fmt.Errorf("output validation failed for sub-agent %s: %w", t.agent.Name(), err)
 		}
-		if agentOutputSchema := llminternal.Reveal(internalLlmAgent).OutputSchema; agentOutputSchema != nil {
-			// Assuming schemautils.ValidateOutputSchema parses the JSON string outputText
-			// and validates it against the agentOutputSchema, returning a map[string]any.
-			parsedOutput, err := utils.ValidateOutputSchema(outputText, agentOutputSchema)
-			if err != nil {
-				return nil, fmt.Errorf("output validation failed for sub-agent %s: %w", t.agent.Name(), err)
-			}
-			return parsedOutput, nil
+		if m, ok := parsedOutput.(map[string]any); ok {
+			return m, nil
 		}
+		return map[string]any{"result": parsedOutput}, nil
 	}
 
 	return map[string]any{"result": outputText}, nil
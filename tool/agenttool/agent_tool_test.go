@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	icontext "google.golang.org/adk/internal/context"
@@ -207,6 +208,59 @@ func TestAgentTool_Run_Successful(t *testing.T) {
 	}
 }
 
+func TestAgentTool_Run_JSONSchema(t *testing.T) {
+	type Input struct {
+		IsMagic bool `json:"is_magic"`
+	}
+	type Output struct {
+		Numbers []int `json:"numbers"`
+	}
+	inputSchema, err := jsonschema.For[Input](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputSchema, err := jsonschema.For[Output](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testLLM := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromText(`{"numbers": [3, 7]}`, genai.RoleModel),
+		},
+	}
+	agent, err := llmagent.New(llmagent.Config{
+		Name:             "math_agent",
+		Model:            testLLM,
+		Description:      "Solves math problems.",
+		InputJSONSchema:  inputSchema,
+		OutputJSONSchema: outputSchema,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+	agentTool := agenttool.New(agent, nil)
+	toolCtx := createToolContext(t, agent)
+	toolImpl, ok := agentTool.(toolinternal.FunctionTool)
+	if !ok {
+		t.Fatal("agentTool does not implement FunctionTool")
+	}
+
+	if decl := toolImpl.Declaration(); decl.ParametersJsonSchema == nil || decl.Parameters != nil {
+		t.Errorf("Declaration() = %+v, want parameters JSON schema", decl)
+	}
+	if _, err := toolImpl.Run(toolCtx, map[string]any{"is_magic": "yes"}); err == nil {
+		t.Errorf("Run() with invalid args succeeded unexpectedly, want error")
+	}
+	result, err := toolImpl.Run(toolCtx, map[string]any{"is_magic": true})
+	if err != nil {
+		t.Fatalf("Run() failed unexpectedly: %v", err)
+	}
+	want := map[string]any{"numbers": []any{3.0, 7.0}}
+	if diff := cmp.Diff(want, result); diff != "" {
+		t.Errorf("Run() result diff (-want +got):\n%s", diff)
+	}
+}
+
 func TestAgentTool_Run_WithoutSchema(t *testing.T) {
 	testLLM := &testutil.MockModel{
 		Responses: []*genai.Content{
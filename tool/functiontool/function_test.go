@@ -18,15 +18,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"iter"
-	"net/http"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/jsonschema-go/jsonschema"
-	"google.golang.org/adk/internal/httprr"
-	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/typeutil"
 	"google.golang.org/adk/model"
@@ -326,18 +323,12 @@ func TestFunctionTool_ReturnsBasicType(t *testing.T) {
 // newGeminiTestClientConfig returns the genai.ClientConfig configured for record and replay.
 func newGeminiTestClientConfig(t *testing.T, rrfile string) *genai.ClientConfig {
 	t.Helper()
-	rr, err := testutil.NewGeminiTransport(rrfile)
+	cfg, rr, err := gemini.NewRecordReplayClientConfig(rrfile, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	apiKey := ""
-	if recording, _ := httprr.Recording(rrfile); !recording {
-		apiKey = "fakekey"
-	}
-	return &genai.ClientConfig{
-		HTTPClient: &http.Client{Transport: rr},
-		APIKey:     apiKey,
-	}
+	t.Cleanup(func() { rr.Close() })
+	return cfg
 }
 
 func readFirstResponse[T any](s iter.Seq2[*model.LLMResponse, error]) (T, error) {
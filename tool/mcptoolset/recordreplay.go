@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcptoolset
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/adktest/httprr"
+)
+
+// NewRecordReplayTransport returns a streamable HTTP transport to the MCP
+// server at endpoint, whose traffic is recorded in rrfile or replayed from
+// there using the [httprr] package. It is meant for the tests of agents using
+// MCP tools served over HTTP; pass it as [Config.Transport].
+//
+// The Mcp-Session-Id header, which is random, is scrubbed from the requests
+// and their JSON bodies are compacted. The persistent SSE stream of server
+// notifications is not supported, since it never ends and so cannot be
+// recorded: the transport answers it with 405 Method Not Allowed, as servers
+// without the stream do.
+//
+// The caller should close the returned [httprr.RecordReplay] when done.
+func NewRecordReplayTransport(rrfile, endpoint string) (*mcp.StreamableClientTransport, *httprr.RecordReplay, error) {
+	rr, err := httprr.Open(rrfile, http.DefaultTransport)
+	if err != nil {
+		return nil, nil, fmt.Errorf("httprr.Open(%q) failed: %w", rrfile, err)
+	}
+	rr.ScrubReq(httprr.ScrubHeaders("Mcp-Session-Id", "User-Agent"), httprr.CompactJSON)
+	return &mcp.StreamableClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Transport: noStandaloneSSE{rr}},
+	}, rr, nil
+}
+
+// noStandaloneSSE rejects the GET requests opening the persistent SSE stream
+// and passes the others to the wrapped transport.
+type noStandaloneSSE struct {
+	base http.RoundTripper
+}
+
+func (t noStandaloneSSE) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return &http.Response{
+		Status:     "405 Method Not Allowed",
+		StatusCode: http.StatusMethodNotAllowed,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcptoolset_test
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/tool/mcptoolset"
+)
+
+func TestNewRecordReplayTransport(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "weather_server", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_weather", Description: "returns weather in the given city"}, weatherFunc)
+	httpServer := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	endpoint := httpServer.URL
+	rrfile := filepath.Join(t.TempDir(), "mcp.httprr")
+
+	callTool := func() any {
+		t.Helper()
+		transport, rr, err := mcptoolset.NewRecordReplayTransport(rrfile, endpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rr.Close()
+		client := mcp.NewClient(&mcp.Implementation{Name: "test_client", Version: "v1.0.0"}, nil)
+		session, err := client.Connect(t.Context(), transport, nil)
+		if err != nil {
+			t.Fatalf("client.Connect() error = %v", err)
+		}
+		defer session.Close()
+		res, err := session.CallTool(t.Context(), &mcp.CallToolParams{
+			Name:      "get_weather",
+			Arguments: map[string]any{"city": "london"},
+		})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return res.StructuredContent
+	}
+
+	// Record the session with the running server.
+	if err := flag.Set("httprecord", regexp.QuoteMeta(rrfile)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { flag.Set("httprecord", "") })
+	recorded := callTool()
+	if err := flag.Set("httprecord", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay it once the server is gone.
+	httpServer.Close()
+	replayed := callTool()
+
+	want := map[string]any{"weather_summary": `Today in "london" is sunny`}
+	if diff := cmp.Diff(want, recorded); diff != "" {
+		t.Errorf("recorded result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, replayed); diff != "" {
+		t.Errorf("replayed result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/internal/version"
 	"google.golang.org/adk/tool"
 )
@@ -57,6 +58,7 @@ func New(cfg Config) (tool.Toolset, error) {
 		client:     client,
 		transport:  cfg.Transport,
 		toolFilter: cfg.ToolFilter,
+		authConfig: cfg.AuthConfig,
 	}, nil
 }
 
@@ -70,12 +72,25 @@ type Config struct {
 	// If ToolFilter is nil, then all tools are returned.
 	// tool.StringPredicate can be convenient if there's a known fixed list of tool names.
 	ToolFilter tool.Predicate
+	// AuthConfig is an optional credential required to call the MCP tools.
+	//
+	// The credential is obtained before every tool call, requesting the user
+	// authorization if needed, and carried by the context of the call. To
+	// send it to the MCP server, the HTTP client of the transport must use
+	// auth.Transport, e.g.
+	//
+	//	&mcp.StreamableClientTransport{
+	//		Endpoint:   endpoint,
+	//		HTTPClient: &http.Client{Transport: &auth.Transport{}},
+	//	}
+	AuthConfig *auth.Config
 }
 
 type set struct {
 	client     *mcp.Client
 	transport  mcp.Transport
 	toolFilter tool.Predicate
+	authConfig *auth.Config
 
 	mu      sync.Mutex
 	session *mcp.ClientSession
@@ -112,7 +127,7 @@ func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
 		}
 
 		for _, mcpTool := range resp.Tools {
-			t, err := convertTool(mcpTool, s.getSession)
+			t, err := convertTool(mcpTool, s.getSession, s.authConfig)
 			if err != nil {
 				return nil, fmt.Errorf("failed to convert MCP tool %q to adk tool: %w", mcpTool.Name, err)
 			}
@@ -20,6 +20,7 @@ import (
 	"iter"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -29,8 +30,8 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/auth"
 	icontext "google.golang.org/adk/internal/context"
-	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
@@ -167,29 +168,15 @@ func TestMCPToolSet(t *testing.T) {
 	}
 }
 
-func newGeminiTestClientConfig(t *testing.T, rrfile string) (http.RoundTripper, bool) {
-	t.Helper()
-	rr, err := testutil.NewGeminiTransport(rrfile)
-	if err != nil {
-		t.Fatal(err)
-	}
-	recording, _ := httprr.Recording(rrfile)
-	return rr, recording
-}
-
 func newGeminiModel(t *testing.T, modelName string) model.LLM {
-	apiKey := "fakeKey"
 	trace := filepath.Join("testdata", strings.ReplaceAll(t.Name()+".httprr", "/", "_"))
-	recording := false
-	transport, recording := newGeminiTestClientConfig(t, trace)
-	if recording { // if we are recording httprr trace, don't use the fakeKey.
-		apiKey = ""
+	cfg, rr, err := gemini.NewRecordReplayClientConfig(trace, nil)
+	if err != nil {
+		t.Fatal(err)
 	}
+	t.Cleanup(func() { rr.Close() })
 
-	model, err := gemini.NewModel(t.Context(), modelName, &genai.ClientConfig{
-		HTTPClient: &http.Client{Transport: transport},
-		APIKey:     apiKey,
-	})
+	model, err := gemini.NewModel(t.Context(), modelName, cfg)
 	if err != nil {
 		t.Fatalf("failed to create model: %v", err)
 	}
@@ -306,3 +293,66 @@ func TestToolFilter(t *testing.T) {
 		t.Errorf("tools mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestMCPToolSet_Auth(t *testing.T) {
+	oauthServer := testutil.NewFakeOAuthServer(t)
+
+	// Run a streamable HTTP MCP server requiring the access token.
+	server := mcp.NewServer(&mcp.Implementation{Name: "weather_server", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_weather", Description: "returns weather in the given city"},
+		func(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+			token, ok := strings.CutPrefix(req.Extra.Header.Get("Authorization"), "Bearer ")
+			if !ok || !oauthServer.ValidAccessToken(token) {
+				return nil, Output{}, fmt.Errorf("unauthorized")
+			}
+			return weatherFunc(ctx, req, input)
+		})
+	httpServer := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	t.Cleanup(httpServer.Close)
+
+	ts, err := mcptoolset.New(mcptoolset.Config{
+		Transport: &mcp.StreamableClientTransport{
+			Endpoint:   httpServer.URL,
+			HTTPClient: &http.Client{Transport: &auth.Transport{}},
+		},
+		AuthConfig: &auth.Config{
+			RawCredential: &auth.Credential{
+				Type: auth.CredentialTypeClientCredentials,
+				OAuth2: &auth.OAuth2{
+					ClientID:     oauthServer.ClientID,
+					ClientSecret: oauthServer.ClientSecret,
+					TokenURL:     oauthServer.TokenURL(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create MCP tool set: %v", err)
+	}
+
+	agent, err := llmagent.New(llmagent.Config{
+		Name: "weather_agent",
+		Model: &testutil.MockModel{
+			Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "london"}, genai.RoleModel),
+				genai.NewContentFromText("It is sunny.", genai.RoleModel),
+			},
+		},
+		Toolsets: []tool.Toolset{ts},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create agent: %v", err)
+	}
+
+	events, err := testutil.CollectEvents(newTestAgentRunner(t, agent).Run(t, "session1", "what is the weather in london?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %v", len(events), events)
+	}
+	want := map[string]any{"output": map[string]any{"weather_summary": `Today in "london" is sunny`}}
+	if diff := cmp.Diff(want, events[1].LLMResponse.Content.Parts[0].FunctionResponse.Response); diff != "" {
+		t.Errorf("unexpected tool response (-want +got):\n%s", diff)
+	}
+}
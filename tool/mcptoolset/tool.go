@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/toolinternal/toolutils"
 	"google.golang.org/adk/model"
@@ -30,7 +31,7 @@ import (
 
 type getSessionFunc func(ctx context.Context) (*mcp.ClientSession, error)
 
-func convertTool(t *mcp.Tool, getSessionFunc getSessionFunc) (tool.Tool, error) {
+func convertTool(t *mcp.Tool, getSessionFunc getSessionFunc, authConfig *auth.Config) (tool.Tool, error) {
 	return &mcpTool{
 		name:        t.Name,
 		description: t.Description,
@@ -41,6 +42,7 @@ func convertTool(t *mcp.Tool, getSessionFunc getSessionFunc) (tool.Tool, error)
 			ResponseJsonSchema:   t.OutputSchema,
 		},
 		getSessionFunc: getSessionFunc,
+		authConfig:     authConfig,
 	}, nil
 }
 
@@ -50,6 +52,7 @@ type mcpTool struct {
 	funcDeclaration *genai.FunctionDeclaration
 
 	getSessionFunc getSessionFunc
+	authConfig     *auth.Config
 }
 
 // Name implements the tool.Tool.
@@ -81,8 +84,26 @@ func (t *mcpTool) Run(ctx tool.Context, args any) (map[string]any, error) {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
-	// TODO: add auth
-	res, err := session.CallTool(ctx, &mcp.CallToolParams{
+	callCtx := context.Context(ctx)
+	if t.authConfig != nil {
+		cred, err := tool.LoadCredential(ctx, t.authConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load credential: %w", err)
+		}
+		if cred == nil {
+			if err := tool.RequestCredential(ctx, t.authConfig); err != nil {
+				return nil, fmt.Errorf("failed to request credential: %w", err)
+			}
+			return map[string]any{
+				"pending": true,
+				"message": "Pending User Authorization.",
+			}, nil
+		}
+		// The credential is applied to the HTTP requests by auth.Transport.
+		callCtx = auth.NewContext(ctx, cred)
+	}
+
+	res, err := session.CallTool(callCtx, &mcp.CallToolParams{
 		Name:      t.name,
 		Arguments: args,
 	})
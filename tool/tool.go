@@ -19,8 +19,10 @@ package tool
 
 import (
 	"context"
+	"fmt"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/session"
 )
@@ -53,6 +55,37 @@ type Context interface {
 	SearchMemory(context.Context, string) (*memory.SearchResponse, error)
 }
 
+// credentialContext is the [Context] of the agents supporting the
+// credentials, see [LoadCredential] and [RequestCredential].
+type credentialContext interface {
+	LoadCredential(context.Context, *auth.Config) (*auth.Credential, error)
+	RequestCredential(*auth.Config) error
+}
+
+// LoadCredential returns the credential described by the auth config.
+// Credentials that don't require user authorization are obtained directly.
+// It returns nil if the user hasn't granted the access yet, in which case
+// the tool should call [RequestCredential].
+func LoadCredential(ctx Context, cfg *auth.Config) (*auth.Credential, error) {
+	c, ok := ctx.(credentialContext)
+	if !ok {
+		return nil, fmt.Errorf("tool context of type %T doesn't support credentials", ctx)
+	}
+	return c.LoadCredential(ctx, cfg)
+}
+
+// RequestCredential requests the client to obtain the user authorization for
+// the credential described by the auth config. After the tool returns, the
+// invocation is paused until the client sends the authorization response,
+// and then the tool is called again.
+func RequestCredential(ctx Context, cfg *auth.Config) error {
+	c, ok := ctx.(credentialContext)
+	if !ok {
+		return fmt.Errorf("tool context of type %T doesn't support credentials", ctx)
+	}
+	return c.RequestCredential(cfg)
+}
+
 // Toolset is an interface for a collection of tools. It allows grouping
 // related tools together and providing them to an agent.
 type Toolset interface {